@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	orderbook "profitLossAndTradeInfoToDB/orderbooks"
+	"profitLossAndTradeInfoToDB/pkg/broker"
+	"profitLossAndTradeInfoToDB/pkg/display"
+	"profitLossAndTradeInfoToDB/pkg/market"
+	"profitLossAndTradeInfoToDB/pkg/profitLossGraph"
+)
+
+// sourceFiles is the -source of imports reading CSV files rather than a broker API
+const sourceFiles = "files"
+
+// brokerDuplicates makes refetched fills be skipped: a broker returns the
+// day's orders so far on every call, so they are not new versions. An
+// explicit -duplicates or DUPLICATE_POLICY is kept.
+func brokerDuplicates(fs *flag.FlagSet, config *Config) {
+	given := os.Getenv("DUPLICATE_POLICY") != ""
+	fs.Visit(func(f *flag.Flag) {
+		given = given || f.Name == "duplicates"
+	})
+	if !given {
+		config.DuplicatePolicy = string(orderbook.DuplicateSkip)
+	}
+}
+
+// runBrokerImport fetches each day's orders and P&L from the broker chosen by
+// config.Source and imports them through the same pipeline as CSV files
+func runBrokerImport(ctx context.Context, config Config) error {
+	if config.Backend != "mongo" {
+		return fmt.Errorf("the %s backend only imports CSV files, not -source %s", config.Backend, config.Source)
+	}
+	var accounts map[string]broker.AccountConfig
+	if config.BrokerConfig != "" {
+		var err error
+		if accounts, err = broker.LoadConfig(config.BrokerConfig); err != nil {
+			return err
+		}
+	}
+	client, name, err := broker.ForAccount(config.Source, config.Account, accounts)
+	if err != nil {
+		return err
+	}
+	days, err := processDays(config)
+	if err != nil {
+		return err
+	}
+
+	return withImport(ctx, config, func(ob *orderbook.OrderBook, _ *profitLossGraph.Repository, plService *profitLossGraph.Service) error {
+		logFunds(ctx, client, name)
+		for _, day := range days {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if err := importBrokerDay(ctx, ob, plService, client, name, day); err != nil {
+				log.Printf("Failed to import %s for %s: %v", name, day.Format("2006-01-02"), err)
+			}
+		}
+		return nil
+	})
+}
+
+// logFunds logs the account's current funds when the broker reports them
+func logFunds(ctx context.Context, client broker.Broker, name string) {
+	reporter, ok := client.(broker.FundsReporter)
+	if !ok {
+		return
+	}
+	funds, err := reporter.Funds(ctx)
+	if err != nil {
+		log.Printf("Failed to read %s funds: %v", name, err)
+		return
+	}
+	log.Printf("%s funds: opening %s, available %s, utilised %s", name,
+		display.Money(funds.Opening), display.Money(funds.Available), display.Money(funds.Utilized))
+}
+
+// importBrokerDay stores a day's orders and its P&L, sampled now or at the
+// close for past days
+func importBrokerDay(ctx context.Context, ob *orderbook.OrderBook, plService *profitLossGraph.Service, client broker.Broker, name string, day time.Time) error {
+	location := name + ":" + day.Format("2006-01-02")
+
+	err := trackImport(ctx, ob, location, "orders", func() error {
+		orders, err := client.Orders(ctx, day)
+		if err != nil {
+			return err
+		}
+		log.Printf("Fetched %d orders from %s", len(orders), location)
+		if len(orders) == 0 {
+			return nil
+		}
+		return ob.ImportOrders(ctx, orders, location)
+	})
+	if err != nil {
+		return err
+	}
+
+	return trackImport(ctx, ob, location, "profitLoss", func() error {
+		pnl, err := client.ProfitLoss(ctx, day)
+		if errors.Is(err, broker.ErrNoProfitLoss) {
+			log.Printf("%s has no P&L for %s; import the day's P&L file instead", name, day.Format("2006-01-02"))
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		at := time.Now()
+		if closing := market.Close(day); at.After(closing) {
+			at = closing
+		}
+		return plService.ProcessEntries(ctx, []profitLossGraph.ProfitLossEntry{{Timestamp: at, Value: pnl}}, location)
+	})
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"time"
+
+	"profitLossAndTradeInfoToDB/constants"
+	orderbook "profitLossAndTradeInfoToDB/orderbooks"
+	"profitLossAndTradeInfoToDB/pkg/archive"
+	"profitLossAndTradeInfoToDB/pkg/market"
+)
+
+func init() {
+	registerCommand(Command{
+		Name:  "archive",
+		Usage: "Move orders and MTM samples older than a date to the archive database: -before YYYY-MM-DD",
+		Run:   runArchive,
+	})
+}
+
+func runArchive(ctx context.Context, args []string) error {
+	var config Config
+	fs := flag.NewFlagSet("archive", flag.ExitOnError)
+	connectionFlags(fs, &config)
+	before := fs.String("before", "", "Archive data older than this day (YYYY-MM-DD)")
+	fs.Parse(args)
+
+	if *before == "" {
+		return fmt.Errorf("-before is required")
+	}
+	cutoff, err := time.ParseInLocation("2006-01-02", *before, market.Location())
+	if err != nil {
+		return fmt.Errorf("invalid -before: %v", err)
+	}
+
+	return withOrderBook(ctx, config, func(ob *orderbook.OrderBook) error {
+		hot := ob.GetMongoClient().Database(constants.DB_NAME)
+		archiver, err := archive.NewArchiver(hot, ob.ArchiveDatabase(), config.Account)
+		if err != nil {
+			return err
+		}
+
+		moved, err := archiver.MoveBefore(ctx, cutoff)
+		for collection, count := range moved {
+			log.Printf("Archived %d documents from %s", count, collection)
+		}
+		return err
+	})
+}
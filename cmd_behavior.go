@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"time"
+
+	orderbook "profitLossAndTradeInfoToDB/orderbooks"
+	"profitLossAndTradeInfoToDB/pkg/behavior"
+	"profitLossAndTradeInfoToDB/pkg/display"
+	"profitLossAndTradeInfoToDB/pkg/execution"
+	"profitLossAndTradeInfoToDB/pkg/trades"
+)
+
+func init() {
+	registerCommand(Command{
+		Name:  "revenge",
+		Usage: "Flag entries placed right after sharp MTM drops: -from -to [-drop 2000] [-drop-window 5m] [-reaction 15m]",
+		Run:   runRevenge,
+	})
+	registerCommand(Command{
+		Name:  "latency",
+		Usage: "Placement-to-execution latency per symbol and time of day: -from -to [-bucket 15m]",
+		Run:   runLatency,
+	})
+}
+
+func runRevenge(ctx context.Context, args []string) error {
+	var config Config
+	fs := flag.NewFlagSet("revenge", flag.ExitOnError)
+	connectionFlags(fs, &config)
+	readOnlyFlag(fs, &config)
+	from := fs.String("from", time.Now().AddDate(0, -1, 0).Format("2006-01-02"), "First day (YYYY-MM-DD)")
+	to := fs.String("to", time.Now().Format("2006-01-02"), "Last day (YYYY-MM-DD)")
+	var cfg behavior.RevengeConfig
+	fs.Float64Var(&cfg.DropThreshold, "drop", 2000, "MTM fall from the recent peak that counts as a sharp drop")
+	fs.DurationVar(&cfg.DropWindow, "drop-window", 5*time.Minute, "How far back the recent peak is searched")
+	fs.DurationVar(&cfg.ReactionWindow, "reaction", 15*time.Minute, "Entries within this time after a drop are flagged")
+	fs.Parse(args)
+
+	start, end, err := parseDateRange(*from, *to)
+	if err != nil {
+		return err
+	}
+
+	return withOrderBook(ctx, config, func(ob *orderbook.OrderBook) error {
+		pl, err := profitLossRepository(ob, config)
+		if err != nil {
+			return err
+		}
+
+		// MTM resets every session, so drops and matching are evaluated day by day
+		var report behavior.RevengeReport
+		for day := start; day.Before(end); day = day.AddDate(0, 0, 1) {
+			next := day.AddDate(0, 0, 1)
+
+			samples, err := pl.GetProfitLossByDateRange(ctx, day, next)
+			if err != nil {
+				return err
+			}
+			orders, err := ob.GetOrdersByDateRange(ctx, day, next)
+			if err != nil {
+				return err
+			}
+			if len(samples) == 0 || len(orders) == 0 {
+				continue
+			}
+
+			roundTrips, _ := trades.MatchFIFO(orderbook.Fills(orders))
+			report.Merge(behavior.DetectRevenge(samples, roundTrips, cfg))
+		}
+
+		fmt.Printf("Sharp MTM drops:        %d\n", len(report.Drops))
+		fmt.Printf("Trades after a drop:    %d, P&L %s\n", len(report.Flagged), display.Amount(report.FlaggedPnL))
+		fmt.Printf("Other trades:           %d, P&L %s\n", report.OtherCount, display.Amount(report.OtherPnL))
+		for _, trip := range report.Flagged {
+			fmt.Printf("  %s %-24s %-5s qty %-6s P&L %14s\n",
+				display.Time(trip.EntryTime), trip.Symbol, trip.Side, display.Quantity(trip.Quantity), display.Amount(trip.RealizedPnL))
+		}
+		return nil
+	})
+}
+
+func runLatency(ctx context.Context, args []string) error {
+	var config Config
+	fs := flag.NewFlagSet("latency", flag.ExitOnError)
+	connectionFlags(fs, &config)
+	readOnlyFlag(fs, &config)
+	from := fs.String("from", time.Now().AddDate(0, -1, 0).Format("2006-01-02"), "First day (YYYY-MM-DD)")
+	to := fs.String("to", time.Now().Format("2006-01-02"), "Last day (YYYY-MM-DD)")
+	bucket := fs.Duration("bucket", 15*time.Minute, "Time-of-day bucket size")
+	fs.Parse(args)
+
+	start, end, err := parseDateRange(*from, *to)
+	if err != nil {
+		return err
+	}
+	if *bucket <= 0 {
+		return fmt.Errorf("-bucket must be positive")
+	}
+
+	return withOrderBook(ctx, config, func(ob *orderbook.OrderBook) error {
+		orders, err := ob.GetOrdersByDateRange(ctx, start, end)
+		if err != nil {
+			return err
+		}
+
+		report := execution.BuildLatencyReport(orders, *bucket)
+		if report.Overall.Count == 0 {
+			fmt.Println("No orders with an execution time in this range")
+			return nil
+		}
+
+		printDistributions := func(title string, rows []execution.Distribution) {
+			fmt.Printf("\n%s\n", title)
+			fmt.Printf("%-24s %6s %10s %10s %10s %10s %10s\n", "", "Count", "Min", "P50", "P90", "P99", "Max")
+			for _, d := range rows {
+				fmt.Printf("%-24s %6d %10s %10s %10s %10s %10s\n", d.Key, d.Count,
+					d.Min.Round(time.Millisecond), d.P50.Round(time.Millisecond), d.P90.Round(time.Millisecond),
+					d.P99.Round(time.Millisecond), d.Max.Round(time.Millisecond))
+			}
+		}
+		printDistributions("Overall", []execution.Distribution{report.Overall})
+		printDistributions("By time of day", report.ByTimeOfDay)
+		printDistributions("By symbol", report.BySymbol)
+		return nil
+	})
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	orderbook "profitLossAndTradeInfoToDB/orderbooks"
+	"profitLossAndTradeInfoToDB/pkg/display"
+	"profitLossAndTradeInfoToDB/pkg/market"
+)
+
+func init() {
+	registerCommand(Command{
+		Name:  "calendar",
+		Usage: "Net P&L of every day of a month as a calendar: [-month YYYY-MM] [-json]",
+		Run:   runCalendar,
+	})
+}
+
+// calendarCell is the width of a day in the printed calendar
+const calendarCell = 11
+
+func runCalendar(ctx context.Context, args []string) error {
+	var config Config
+	fs := flag.NewFlagSet("calendar", flag.ExitOnError)
+	connectionFlags(fs, &config)
+	readOnlyFlag(fs, &config)
+	month := fs.String("month", time.Now().In(market.Location()).Format("2006-01"), "Month to show (YYYY-MM)")
+	asJSON := fs.Bool("json", false, "Write the calendar as JSON")
+	fs.Parse(args)
+
+	first, err := time.ParseInLocation("2006-01", *month, market.Location())
+	if err != nil {
+		return fmt.Errorf("invalid month: %v", err)
+	}
+
+	return withOrderBook(ctx, config, func(ob *orderbook.OrderBook) error {
+		calendar, err := ob.Calendar(ctx, first)
+		if err != nil {
+			return err
+		}
+
+		if *asJSON {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			return encoder.Encode(calendar)
+		}
+
+		displayCalendar(calendar)
+		return nil
+	})
+}
+
+// displayCalendar prints the month as weeks of Monday to Sunday, each day
+// showing its date and net P&L; non-trading days are marked with a dot
+func displayCalendar(calendar orderbook.CalendarMonth) {
+	fmt.Printf("Net P&L calendar for %s\n\n", calendar.Month)
+	for _, name := range []string{"Mon", "Tue", "Wed", "Thu", "Fri", "Sat", "Sun"} {
+		fmt.Printf("%*s", calendarCell, name)
+	}
+	fmt.Println()
+
+	weeks := calendar.Days[len(calendar.Days)-1].Week + 1
+	dates := make([][]string, weeks)
+	pnls := make([][]string, weeks)
+	for week := range dates {
+		dates[week] = make([]string, 7)
+		pnls[week] = make([]string, 7)
+	}
+	for _, day := range calendar.Days {
+		dates[day.Week][day.Weekday] = strings.TrimLeft(day.Date[8:], "0")
+		switch {
+		case day.Trades > 0:
+			pnls[day.Week][day.Weekday] = display.Number(day.NetPnL.Rupees(), 0)
+		case day.TradingDay:
+			pnls[day.Week][day.Weekday] = "-"
+		default:
+			pnls[day.Week][day.Weekday] = "."
+		}
+	}
+	for week := range dates {
+		for _, rows := range [][]string{dates[week], pnls[week]} {
+			for _, cell := range rows {
+				fmt.Printf("%*s", calendarCell, cell)
+			}
+			fmt.Println()
+		}
+		fmt.Println()
+	}
+
+	fmt.Printf("Net P&L:      %s\n", display.Amount(calendar.NetPnL))
+	fmt.Printf("Trading days: %d (%d traded, %d green, %d red)\n",
+		calendar.TradingDays, calendar.ActiveDays, calendar.GreenDays, calendar.RedDays)
+	if calendar.BestDay != nil {
+		fmt.Printf("Best day:     %s %s\n", calendar.BestDay.Date, display.Amount(calendar.BestDay.NetPnL))
+		fmt.Printf("Worst day:    %s %s\n", calendar.WorstDay.Date, display.Amount(calendar.WorstDay.NetPnL))
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"time"
+
+	orderbook "profitLossAndTradeInfoToDB/orderbooks"
+	"profitLossAndTradeInfoToDB/pkg/charges"
+	"profitLossAndTradeInfoToDB/pkg/display"
+	"profitLossAndTradeInfoToDB/pkg/reconcile"
+)
+
+func init() {
+	registerCommand(Command{
+		Name:  "crosscheck",
+		Usage: "Compare matched P&L (and charges) with the broker MTM per day: -from -to [-tolerance 100]",
+		Run:   runCrossCheck,
+	})
+}
+
+func runCrossCheck(ctx context.Context, args []string) error {
+	var config Config
+	fs := flag.NewFlagSet("crosscheck", flag.ExitOnError)
+	connectionFlags(fs, &config)
+	readOnlyFlag(fs, &config)
+	from := fs.String("from", time.Now().AddDate(0, -1, 0).Format("2006-01-02"), "First day (YYYY-MM-DD)")
+	to := fs.String("to", time.Now().Format("2006-01-02"), "Last day (YYYY-MM-DD)")
+	var opts reconcile.Options
+	fs.Float64Var(&opts.Tolerance, "tolerance", 100, "Allowed absolute difference in rupees")
+	fs.BoolVar(&opts.BrokerNetOfCharges, "mtm-net-of-charges", false, "The broker MTM already has charges deducted")
+	fs.Parse(args)
+
+	schedule, err := charges.LoadSchedule(config.ChargesSchedule)
+	if err != nil {
+		return err
+	}
+	opts.Charges = schedule
+
+	start, end, err := parseDateRange(*from, *to)
+	if err != nil {
+		return err
+	}
+
+	return withOrderBook(ctx, config, func(ob *orderbook.OrderBook) error {
+		pl, err := profitLossRepository(ob, config)
+		if err != nil {
+			return err
+		}
+
+		checks, err := reconcile.CrossCheck(ctx, ob, pl, start, end, opts)
+		if err != nil {
+			return err
+		}
+
+		diverging := 0
+		fmt.Printf("%-12s %7s %14s %12s %14s %14s %14s\n", "Date", "Orders", "Realized", "Charges", "Computed", "Broker MTM", "Difference")
+		for _, check := range checks {
+			mtm := "-"
+			if check.BrokerMTM != nil {
+				mtm = display.Amount(*check.BrokerMTM)
+			}
+			flag := ""
+			if check.Diverges {
+				flag = "  <-- diverges"
+				diverging++
+			}
+			fmt.Printf("%-12s %7d %14s %12s %14s %14s %14s%s\n",
+				display.Day(check.Date), check.Orders, display.Amount(check.RealizedPnL), display.Amount(check.Charges.Total),
+				display.Amount(check.Computed), mtm, display.Amount(check.Difference), flag)
+		}
+		fmt.Printf("\n%d of %d days diverge beyond %s\n", diverging, len(checks), display.Money(opts.Tolerance))
+		return nil
+	})
+}
@@ -0,0 +1,428 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	orderbook "profitLossAndTradeInfoToDB/orderbooks"
+	"profitLossAndTradeInfoToDB/pkg/display"
+	"profitLossAndTradeInfoToDB/pkg/market"
+	"profitLossAndTradeInfoToDB/pkg/notify"
+	"profitLossAndTradeInfoToDB/pkg/profitLossGraph"
+	"profitLossAndTradeInfoToDB/pkg/retryqueue"
+	"profitLossAndTradeInfoToDB/pkg/source"
+)
+
+func init() {
+	registerCommand(Command{
+		Name:  "daemon",
+		Usage: "Watch the CSV directory, ingest new files and alert on missing P&L: [-csv-dir DIR] [-interval 1m] [-pl-deadline 16:30] [-retry-max-attempts 8]",
+		Run:   runDaemon,
+	})
+	registerCommand(Command{
+		Name:  "retry-queue",
+		Usage: "List files the daemon is retrying: [-csv-dir DIR] [-reset]",
+		Run:   runRetryQueue,
+	})
+}
+
+// daemonConfig holds the settings of the watch loop
+type daemonConfig struct {
+	Interval   time.Duration
+	PLDeadline time.Duration // after midnight, market time
+	Retry      retryqueue.Policy
+	RetryState string
+}
+
+// retryFlags registers the retry queue location shared by daemon and retry-queue
+func retryFlags(fs *flag.FlagSet, config *Config, dc *daemonConfig) {
+	fs.StringVar(&config.CSVDir, "csv-dir", ".", "Directory to watch for CSV files")
+	fs.StringVar(&dc.RetryState, "retry-state", os.Getenv("RETRY_STATE_FILE"),
+		"File the retry queue is kept in (default CSV_DIR/.import-retry.json)")
+}
+
+// retryStatePath returns the retry queue file, defaulting to one inside the CSV directory
+func (dc daemonConfig) retryStatePath(csvDir string) string {
+	if dc.RetryState != "" {
+		return dc.RetryState
+	}
+	return filepath.Join(csvDir, ".import-retry.json")
+}
+
+// seenStatePath is the file the files already ingested are kept in, so a
+// restarted daemon does not ingest them again
+func seenStatePath(csvDir string) string {
+	return filepath.Join(csvDir, ".daemon-seen.json")
+}
+
+func runDaemon(ctx context.Context, args []string) error {
+	var config Config
+	var dc daemonConfig
+	fs := flag.NewFlagSet("daemon", flag.ExitOnError)
+	connectionFlags(fs, &config)
+	retryFlags(fs, &config, &dc)
+	fs.DurationVar(&dc.Interval, "interval", time.Minute, "How often the directory is scanned")
+	deadline := fs.String("pl-deadline", envOrDefault("PL_DEADLINE", "16:30"), "Market time by which the day's P&L must be ingested (HH:MM)")
+	holidays := fs.String("holidays", os.Getenv("MARKET_HOLIDAYS"), "Comma separated exchange holidays (YYYY-MM-DD)")
+	fs.IntVar(&dc.Retry.MaxAttempts, "retry-max-attempts", envIntOrDefault("RETRY_MAX_ATTEMPTS", retryqueue.DefaultPolicy.MaxAttempts),
+		"Attempts at a failing file before giving up on it")
+	fs.DurationVar(&dc.Retry.BaseDelay, "retry-base-delay", retryqueue.DefaultPolicy.BaseDelay, "Delay before retrying a failed file; doubled after every failure")
+	fs.DurationVar(&dc.Retry.MaxDelay, "retry-max-delay", retryqueue.DefaultPolicy.MaxDelay, "Longest delay between retries")
+	fs.Parse(args)
+
+	parsed, err := time.Parse("15:04", *deadline)
+	if err != nil {
+		return fmt.Errorf("invalid -pl-deadline: %v", err)
+	}
+	dc.PLDeadline = time.Duration(parsed.Hour())*time.Hour + time.Duration(parsed.Minute())*time.Minute
+	if *holidays != "" {
+		market.SetHolidays(strings.Split(*holidays, ","))
+	}
+
+	notifier := notifiersFromEnv()
+
+	queue, err := retryqueue.Open(dc.retryStatePath(config.CSVDir), dc.Retry)
+	if err != nil {
+		return err
+	}
+
+	return withOrderBook(ctx, config, func(ob *orderbook.OrderBook) error {
+		ensureDerived(ctx, ob, config)
+
+		plRepo, err := profitLossRepository(ob, config)
+		if err != nil {
+			return err
+		}
+		if err := plRepo.EnsureIndexes(ctx); err != nil {
+			return err
+		}
+		plService := profitLossGraph.NewService(plRepo, ob.RunID())
+		plService.OnSaved(ob.RefreshDays)
+		plService.PublishTo(ob.Events())
+
+		seen, err := loadSeen(seenStatePath(config.CSVDir))
+		if err != nil {
+			return err
+		}
+
+		d := &daemon{
+			config:    config,
+			dc:        dc,
+			ob:        ob,
+			plRepo:    plRepo,
+			plService: plService,
+			notifier:  notifier,
+			opener:    source.NewOpener(nil),
+			retries:   queue,
+			seen:      seen,
+			alerted:   map[string]bool{},
+		}
+		return d.run(ctx)
+	})
+}
+
+// notifiersFromEnv builds the notification channels configured in the
+// environment. NOTIFY_CHANNELS names channels and NOTIFY_ROUTES sends message
+// kinds and severities to them, see notify.ParseChannels and notify.ParseRules;
+// messages no route matches go to the log and NOTIFY_WEBHOOK_URL.
+func notifiersFromEnv() notify.Notifier {
+	notifiers := notify.Multi{notify.LogNotifier{}}
+	if url := os.Getenv("NOTIFY_WEBHOOK_URL"); url != "" {
+		notifiers = append(notifiers, notify.NewWebhookNotifier(url))
+	}
+
+	channelSpec, routeSpec := os.Getenv("NOTIFY_CHANNELS"), os.Getenv("NOTIFY_ROUTES")
+	if channelSpec == "" && routeSpec == "" {
+		return notifiers
+	}
+
+	channels, err := notify.ParseChannels(channelSpec)
+	if err != nil {
+		log.Fatalf("Invalid NOTIFY_CHANNELS: %v", err)
+	}
+	channels["log"] = notify.LogNotifier{}
+	rules, err := notify.ParseRules(routeSpec)
+	if err != nil {
+		log.Fatalf("Invalid NOTIFY_ROUTES: %v", err)
+	}
+	router, err := notify.NewRouter(channels, rules, notifiers)
+	if err != nil {
+		log.Fatalf("Invalid NOTIFY_ROUTES: %v", err)
+	}
+	return router
+}
+
+// fileState tracks a file between scans so it is only ingested once it stops growing
+type fileState struct {
+	size      int64
+	modTime   time.Time
+	processed bool
+}
+
+// seenFile is a processed file as it is stored in the seen state file
+type seenFile struct {
+	Path    string    `json:"path"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mod_time"`
+}
+
+// loadSeen reads the files a previous daemon processed; a missing file is none
+func loadSeen(path string) (map[string]fileState, error) {
+	seen := map[string]fileState{}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return seen, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read daemon state: %v", err)
+	}
+
+	var files []seenFile
+	if err := json.Unmarshal(data, &files); err != nil {
+		return nil, fmt.Errorf("failed to decode daemon state %s: %v", path, err)
+	}
+	for _, file := range files {
+		seen[file.Path] = fileState{size: file.Size, modTime: file.ModTime, processed: true}
+	}
+	return seen, nil
+}
+
+// saveSeen replaces the state file with the processed files of seen
+func saveSeen(path string, seen map[string]fileState) error {
+	var files []seenFile
+	for name, state := range seen {
+		if state.processed {
+			files = append(files, seenFile{Path: name, Size: state.size, ModTime: state.modTime})
+		}
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+
+	data, err := json.MarshalIndent(files, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode daemon state: %v", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".daemon-seen-*")
+	if err != nil {
+		return fmt.Errorf("failed to write daemon state: %v", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write daemon state: %v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write daemon state: %v", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write daemon state: %v", err)
+	}
+	return nil
+}
+
+type daemon struct {
+	config    Config
+	dc        daemonConfig
+	ob        *orderbook.OrderBook
+	plRepo    *profitLossGraph.Repository
+	plService *profitLossGraph.Service
+	notifier  notify.Notifier
+	opener    *source.Opener
+	retries   *retryqueue.Queue
+	seen      map[string]fileState
+	alerted   map[string]bool // days already alerted for a missing P&L file
+}
+
+func (d *daemon) run(ctx context.Context) error {
+	ticker := time.NewTicker(d.dc.Interval)
+	defer ticker.Stop()
+
+	log.Printf("Watching %s every %s", d.config.CSVDir, d.dc.Interval)
+	for {
+		// The files of one scan recompute the days they touch once
+		d.ob.DeferRecompute()
+		d.scan(ctx)
+		d.retryDue(ctx, time.Now())
+		if err := d.ob.FlushRecompute(ctx); err != nil {
+			log.Printf("Failed to recompute imported days: %v", err)
+		}
+		d.checkMissingProfitLoss(ctx, time.Now())
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// scan ingests CSV files that appeared or changed and have been stable for one interval
+func (d *daemon) scan(ctx context.Context) {
+	matches, err := filepath.Glob(filepath.Join(d.config.CSVDir, "*.csv"))
+	if err != nil {
+		log.Printf("Failed to scan %s: %v", d.config.CSVDir, err)
+		return
+	}
+
+	for _, path := range matches {
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+
+		previous, known := d.seen[path]
+		current := fileState{size: info.Size(), modTime: info.ModTime()}
+		if !known || previous.size != current.size || !previous.modTime.Equal(current.modTime) {
+			// New or still being written; look again next scan
+			d.seen[path] = current
+			continue
+		}
+		if previous.processed {
+			continue
+		}
+
+		// Failures reach the notification channels through the event bus and
+		// are retried from the queue
+		d.ingest(ctx, path)
+		if ctx.Err() != nil {
+			return
+		}
+		current.processed = true
+		d.seen[path] = current
+		if err := saveSeen(seenStatePath(d.config.CSVDir), d.seen); err != nil {
+			log.Printf("Failed to record %s as processed: %v", path, err)
+		}
+	}
+}
+
+// retryDue retries the queued files whose backoff has elapsed
+func (d *daemon) retryDue(ctx context.Context, now time.Time) {
+	for _, entry := range d.retries.Due(now) {
+		if _, err := os.Stat(entry.Location); errors.Is(err, os.ErrNotExist) {
+			log.Printf("Dropping %s from the retry queue: file no longer exists", entry.Location)
+			if err := d.retries.Succeed(entry.Location); err != nil {
+				log.Printf("Failed to update retry queue: %v", err)
+			}
+			continue
+		}
+
+		log.Printf("Retrying %s (attempt %d of %d)", entry.Location, entry.Attempts+1, d.dc.Retry.MaxAttempts)
+		d.ingest(ctx, entry.Location)
+	}
+}
+
+// ingest imports one file and updates its retry queue entry with the outcome
+func (d *daemon) ingest(ctx context.Context, path string) {
+	// Every file is its own import run, so one bad file can be purged alone
+	runID := newRunID()
+	d.ob.SetRunID(runID)
+	d.plService.SetRunID(runID)
+
+	err := processInput(ctx, d.opener, d.ob, d.plService, path)
+	if err == nil {
+		if err := d.retries.Succeed(path); err != nil {
+			log.Printf("Failed to update retry queue: %v", err)
+		}
+		return
+	}
+	if ctx.Err() != nil {
+		// Interrupted by shutdown, not a failure of the file
+		return
+	}
+
+	entry, qerr := d.retries.Fail(path, err, time.Now())
+	if qerr != nil {
+		log.Printf("Failed to update retry queue: %v", qerr)
+	}
+	if entry.Exhausted {
+		d.notifier.Notify(ctx, notify.Message{
+			Kind:     notify.KindImportAbandoned,
+			Severity: notify.SeverityError,
+			Title:    "Import abandoned",
+			Text:     fmt.Sprintf("%s (account %s) failed %d times, last error: %s; run retry-queue -reset once fixed", path, d.config.Account, entry.Attempts, entry.LastError),
+			Time:     time.Now(),
+		})
+		return
+	}
+	log.Printf("Will retry %s at %s", path, display.Clock(entry.NextAttempt))
+}
+
+func runRetryQueue(ctx context.Context, args []string) error {
+	var config Config
+	var dc daemonConfig
+	fs := flag.NewFlagSet("retry-queue", flag.ExitOnError)
+	retryFlags(fs, &config, &dc)
+	reset := fs.Bool("reset", false, "Re-arm files that exhausted their attempts so the daemon retries them")
+	displayFlags(fs)
+	fs.Parse(args)
+
+	queue, err := retryqueue.Open(dc.retryStatePath(config.CSVDir), retryqueue.DefaultPolicy)
+	if err != nil {
+		return err
+	}
+
+	if *reset {
+		n, err := queue.Reset(time.Now())
+		if err != nil {
+			return err
+		}
+		log.Printf("Re-armed %d abandoned files", n)
+	}
+
+	entries := queue.Entries()
+	if len(entries) == 0 {
+		fmt.Println("Retry queue is empty")
+		return nil
+	}
+	for _, entry := range entries {
+		status := "next " + display.Time(entry.NextAttempt)
+		if entry.Exhausted {
+			status = "abandoned"
+		}
+		fmt.Printf("%s\n  attempts %d, %s, first failed %s\n  %s\n",
+			entry.Location, entry.Attempts, status, display.Time(entry.FirstFailed), entry.LastError)
+	}
+	return nil
+}
+
+// checkMissingProfitLoss alerts once per trading day when no P&L data has been
+// ingested for it by the configured deadline
+func (d *daemon) checkMissingProfitLoss(ctx context.Context, now time.Time) {
+	if !market.IsTradingDay(now) {
+		return
+	}
+
+	day := market.DayStart(now)
+	key := day.Format("2006-01-02")
+	if d.alerted[key] || now.Before(day.Add(d.dc.PLDeadline)) {
+		return
+	}
+
+	entries, err := d.plRepo.GetProfitLossByDateRange(ctx, day, market.Close(now))
+	if err != nil {
+		log.Printf("Failed to check P&L for %s: %v", key, err)
+		return
+	}
+	if len(entries) > 0 {
+		return
+	}
+
+	d.alerted[key] = true
+	d.notifier.Notify(ctx, notify.Message{
+		Kind:     notify.KindMissingPL,
+		Severity: notify.SeverityWarning,
+		Title:    "Missing P&L file",
+		Text:     fmt.Sprintf("No profit/loss data ingested for %s (account %s) by %s", display.Day(day), d.config.Account, display.Clock(now)),
+		Time:     now,
+	})
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"profitLossAndTradeInfoToDB/constants"
+	orderbook "profitLossAndTradeInfoToDB/orderbooks"
+	"profitLossAndTradeInfoToDB/pkg/doctor"
+	"profitLossAndTradeInfoToDB/pkg/market"
+)
+
+func init() {
+	registerCommand(Command{
+		Name:  "doctor",
+		Usage: "Check MongoDB access, collections, indexes, configuration and CSV file names: [-csv-dir DIR]",
+		Run:   runDoctor,
+	})
+}
+
+func runDoctor(ctx context.Context, args []string) error {
+	var config Config
+	fs := flag.NewFlagSet("doctor", flag.ExitOnError)
+	connectionFlags(fs, &config)
+	fs.StringVar(&config.CSVDir, "csv-dir", ".", "Directory containing CSV files")
+	fs.Parse(args)
+
+	// Diagnostics never write, whatever the environment says
+	config.ReadOnly = true
+
+	report := &doctor.Report{}
+	checkConfig(report, config)
+
+	if config.MongoURI != "" {
+		start := time.Now()
+		ob, err := openOrderBook(ctx, config)
+		if err != nil {
+			report.Add(doctor.Fail, "connectivity", err.Error(),
+				"check MONGODB_CONNECTION_URL, network access and credentials")
+		} else {
+			report.Add(doctor.OK, "connectivity", fmt.Sprintf("connected and pinged in %s", time.Since(start).Round(time.Millisecond)), "")
+
+			db := ob.GetMongoClient().Database(constants.DB_NAME)
+			doctor.CheckPermissions(ctx, report, db)
+			doctor.CheckCollections(ctx, report, db)
+			checkDerived(ctx, report, ob)
+			ob.Close(ctx)
+		}
+	}
+
+	doctor.CheckCSVDir(report, config.CSVDir)
+
+	report.Print(os.Stdout)
+	if report.Failed() {
+		return fmt.Errorf("doctor found problems")
+	}
+	return nil
+}
+
+// checkConfig validates settings that would otherwise only fail deep inside a run
+func checkConfig(report *doctor.Report, config Config) {
+	if config.MongoURI == "" {
+		report.Add(doctor.Fail, "config", "no MongoDB connection string",
+			"set MONGODB_CONNECTION_URL in profitLossAndTradeBookToDB.env or pass -mongo-uri")
+	} else if u, err := url.Parse(config.MongoURI); err != nil || (u.Scheme != "mongodb" && u.Scheme != "mongodb+srv") {
+		report.Add(doctor.Fail, "config", "MongoDB connection string must start with mongodb:// or mongodb+srv://", "")
+	} else {
+		report.Add(doctor.OK, "config", fmt.Sprintf("MongoDB host %s, account %q", u.Host, config.Account), "")
+	}
+
+	tuning := config.MongoTuning
+	if tuning.MaxPoolSize > 0 && tuning.MinPoolSize > tuning.MaxPoolSize {
+		report.Add(doctor.Fail, "config",
+			fmt.Sprintf("minimum pool size %d exceeds maximum %d", tuning.MinPoolSize, tuning.MaxPoolSize),
+			"lower MONGODB_MIN_POOL_SIZE or raise MONGODB_MAX_POOL_SIZE")
+	}
+
+	if market.Location().String() != constants.MARKET_TIMEZONE {
+		report.Add(doctor.Warn, "config",
+			fmt.Sprintf("tz database has no %s, using a fixed IST offset", constants.MARKET_TIMEZONE),
+			"install tzdata or build with -tags timetzdata")
+	}
+
+	if deadline := os.Getenv("PL_DEADLINE"); deadline != "" {
+		if _, err := time.Parse("15:04", deadline); err != nil {
+			report.Add(doctor.Fail, "config", fmt.Sprintf("PL_DEADLINE %q is not HH:MM", deadline), "")
+		}
+	}
+
+	if holidays := os.Getenv("MARKET_HOLIDAYS"); holidays != "" {
+		for _, day := range strings.Split(holidays, ",") {
+			if _, err := time.Parse("2006-01-02", strings.TrimSpace(day)); err != nil {
+				report.Add(doctor.Fail, "config", fmt.Sprintf("MARKET_HOLIDAYS entry %q is not YYYY-MM-DD", day), "")
+			}
+		}
+	}
+
+	if hook := os.Getenv("NOTIFY_WEBHOOK_URL"); hook != "" {
+		if u, err := url.Parse(hook); err != nil || u.Host == "" {
+			report.Add(doctor.Fail, "config", "NOTIFY_WEBHOOK_URL is not an absolute URL", "")
+		}
+	}
+}
+
+// checkDerived warns when summaries or rollups are empty although orders exist
+func checkDerived(ctx context.Context, report *doctor.Report, ob *orderbook.OrderBook) {
+	gap, err := ob.CheckDerived(ctx)
+	if err != nil {
+		report.Add(doctor.Warn, "derived", err.Error(), "")
+		return
+	}
+	if gap.Empty() {
+		report.Add(doctor.OK, "derived", "summaries and rollups cover the stored orders", "")
+		return
+	}
+	report.Add(doctor.Warn, "derived", fmt.Sprintf("orders exist but %s are empty", derivedMissing(gap)),
+		"run backfill-derived, or any import with -auto-backfill")
+}
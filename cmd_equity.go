@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	orderbook "profitLossAndTradeInfoToDB/orderbooks"
+	"profitLossAndTradeInfoToDB/pkg/display"
+)
+
+func init() {
+	registerCommand(Command{
+		Name:  "equity",
+		Usage: "Show the equity curve, the running total of each day's closing MTM: -from -to [-rebuild] [-json]",
+		Run:   runEquity,
+	})
+}
+
+func runEquity(ctx context.Context, args []string) error {
+	var config Config
+	fs := flag.NewFlagSet("equity", flag.ExitOnError)
+	connectionFlags(fs, &config)
+	readOnlyFlag(fs, &config)
+	from := fs.String("from", time.Now().AddDate(0, -1, 0).Format("2006-01-02"), "First day (YYYY-MM-DD)")
+	to := fs.String("to", time.Now().Format("2006-01-02"), "Last day (YYYY-MM-DD)")
+	rebuild := fs.Bool("rebuild", false, "Rebuild the whole stored curve from the profit/loss data first")
+	asJSON := fs.Bool("json", false, "Write the curve as JSON")
+	fs.Parse(args)
+
+	start, end, err := parseDateRange(*from, *to)
+	if err != nil {
+		return err
+	}
+
+	return withOrderBook(ctx, config, func(ob *orderbook.OrderBook) error {
+		pl, err := profitLossRepository(ob, config)
+		if err != nil {
+			return err
+		}
+		if *rebuild {
+			if err := pl.RefreshEquityCurve(ctx, time.Time{}); err != nil {
+				return err
+			}
+		}
+
+		points, err := pl.GetEquityCurve(ctx, start, end)
+		if err != nil {
+			return err
+		}
+
+		if *asJSON {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			return encoder.Encode(points)
+		}
+
+		if len(points) == 0 {
+			fmt.Printf("No equity curve between %s and %s; -rebuild builds it from stored profit/loss data\n", *from, *to)
+			return nil
+		}
+		fmt.Printf("%-10s  %14s %14s %14s %5s\n", "Date", "Day P&L", "Cumulative", "Drawdown", "Days")
+		for _, point := range points {
+			fmt.Printf("%-10s  %14s %14s %14s %5d\n", display.Day(point.Date),
+				display.Money(point.DailyPnL), display.Money(point.Cumulative), display.Money(point.Drawdown), point.DrawdownDays)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"profitLossAndTradeInfoToDB/pkg/sampledata"
+)
+
+func init() {
+	registerCommand(Command{
+		Name:  "gen-template",
+		Usage: "Write empty canonical CSV templates: [-dir DIR]",
+		Run:   runGenTemplate,
+	})
+	registerCommand(Command{
+		Name:  "gen-sample",
+		Usage: "Write synthetic orderbook/P&L files: [-days N] [-dir DIR] [-end YYYY-MM-DD] [-seed S]",
+		Run:   runGenSample,
+	})
+}
+
+func runGenTemplate(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("gen-template", flag.ExitOnError)
+	dir := fs.String("dir", ".", "Directory to write the templates to")
+	fs.Parse(args)
+
+	files, err := sampledata.NewGenerator(*dir, 0).WriteTemplates()
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		log.Printf("Wrote %s", file)
+	}
+
+	return nil
+}
+
+func runGenSample(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("gen-sample", flag.ExitOnError)
+	dir := fs.String("dir", ".", "Directory to write the sample files to")
+	days := fs.Int("days", 5, "Number of trading days to generate")
+	end := fs.String("end", time.Now().Format("2006-01-02"), "Last day to generate (YYYY-MM-DD)")
+	seed := fs.Int64("seed", time.Now().UnixNano(), "Random seed for reproducible output")
+	timezoneFlags(fs)
+	sessionFlags(fs)
+	fs.Parse(args)
+
+	endDate, err := time.Parse("2006-01-02", *end)
+	if err != nil {
+		return fmt.Errorf("invalid -end: %v", err)
+	}
+	if *days <= 0 {
+		return fmt.Errorf("-days must be positive")
+	}
+
+	if err := os.MkdirAll(*dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create %s: %v", *dir, err)
+	}
+
+	files, err := sampledata.NewGenerator(*dir, *seed).GenerateDays(endDate, *days)
+	if err != nil {
+		return err
+	}
+	log.Printf("Wrote %d files to %s", len(files), *dir)
+
+	return nil
+}
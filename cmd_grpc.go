@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"net"
+	"os"
+
+	orderbook "profitLossAndTradeInfoToDB/orderbooks"
+	"profitLossAndTradeInfoToDB/pkg/rpc"
+)
+
+func init() {
+	registerCommand(Command{
+		Name:  "grpc",
+		Usage: "Serve the OrderBookService and ProfitLossService over gRPC: [-addr 127.0.0.1:9090] [-token T]",
+		Run:   runGRPC,
+	})
+}
+
+func runGRPC(ctx context.Context, args []string) error {
+	var config Config
+	fs := flag.NewFlagSet("grpc", flag.ExitOnError)
+	connectionFlags(fs, &config)
+	addr := fs.String("addr", envOrDefault("GRPC_ADDR", "127.0.0.1:9090"), "Address to listen on (env GRPC_ADDR)")
+	token := fs.String("token", os.Getenv("API_TOKEN"), "Bearer token required on every call; required unless -addr is a loopback address (env API_TOKEN)")
+	fs.Parse(args)
+
+	if err := checkExposure(*addr, *token); err != nil {
+		return err
+	}
+
+	// The services only read, so they never need write access
+	config.ReadOnly = true
+
+	return withOrderBook(ctx, config, func(ob *orderbook.OrderBook) error {
+		plRepo, err := profitLossRepository(ob, config)
+		if err != nil {
+			return err
+		}
+
+		listener, err := net.Listen("tcp", *addr)
+		if err != nil {
+			return err
+		}
+
+		server := rpc.NewServer(ob, plRepo, *token)
+		go func() {
+			<-ctx.Done()
+			server.GracefulStop()
+		}()
+
+		log.Printf("Serving gRPC on %s", *addr)
+		return server.Serve(listener)
+	})
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	orderbook "profitLossAndTradeInfoToDB/orderbooks"
+	"profitLossAndTradeInfoToDB/pkg/broker"
+	"profitLossAndTradeInfoToDB/pkg/market"
+	"profitLossAndTradeInfoToDB/pkg/profitLossGraph"
+	"profitLossAndTradeInfoToDB/pkg/source"
+)
+
+func init() {
+	registerCommand(Command{
+		Name:  "ingest",
+		Usage: "Import a day's or range's orderbook and P&L files, or the files and URLs given (the default command): [-date YYYY-MM-DD | -from -to] [-source files|BROKER] [-csv-dir DIR] [-merge] [file or URL ...]",
+		Run:   runIngest,
+	})
+	registerCommand(Command{
+		Name:  "backfill",
+		Usage: "Import the files of every trading day in a range: -from YYYY-MM-DD -to YYYY-MM-DD [-csv-dir DIR]",
+		Run:   runBackfill,
+	})
+}
+
+// ingestFlags registers the flags of the date-based and explicit-input import
+func ingestFlags(fs *flag.FlagSet, config *Config, headers *string) {
+	connectionFlags(fs, config)
+	fs.StringVar(&config.CSVDir, "csv-dir", ".",
+		"Directory containing CSV files")
+
+	fs.BoolVar(&config.Merge, "merge", false,
+		"Merge overlapping orderbook exports instead of loading each file")
+	fs.Func("source-priority", "Comma separated source name fragments, most trusted first (env MERGE_SOURCE_PRIORITY)", func(v string) error {
+		config.SourcePriority = strings.Split(v, ",")
+		return nil
+	})
+	if priority := os.Getenv("MERGE_SOURCE_PRIORITY"); priority != "" {
+		config.SourcePriority = strings.Split(priority, ",")
+	}
+	fs.DurationVar(&config.MergeTolerance, "merge-tolerance", 2*time.Second,
+		"Maximum timestamp difference for rows from different exports to be the same fill")
+
+	fs.Func("holidays", "Comma separated exchange holidays (YYYY-MM-DD) skipped in date ranges (env MARKET_HOLIDAYS)", func(v string) error {
+		market.SetHolidays(strings.Split(v, ","))
+		return nil
+	})
+	if holidays := os.Getenv("MARKET_HOLIDAYS"); holidays != "" {
+		market.SetHolidays(strings.Split(holidays, ","))
+	}
+
+	*headers = os.Getenv("IMPORT_HTTP_HEADERS")
+	fs.Func("header", "HTTP header sent when fetching URL inputs, \"Name: value\" (repeatable; env IMPORT_HTTP_HEADERS)", func(v string) error {
+		*headers += ";" + v
+		return nil
+	})
+}
+
+func runIngest(ctx context.Context, args []string) error {
+	var config Config
+	var headers string
+	fs := flag.NewFlagSet("ingest", flag.ExitOnError)
+	ingestFlags(fs, &config, &headers)
+	fs.StringVar(&config.ProcessDate, "date", time.Now().Format("2006-01-02"),
+		"Date to process (YYYY-MM-DD)")
+	fs.StringVar(&config.ProcessDate, "from", time.Now().Format("2006-01-02"),
+		"First day of a range to process (YYYY-MM-DD), same as -date")
+	fs.StringVar(&config.ProcessTo, "to", "",
+		"Last day of a range to process (YYYY-MM-DD); every trading day from -from is imported")
+	fs.StringVar(&config.Source, "source", envOrDefault("IMPORT_SOURCE", sourceFiles),
+		"Where orders come from: files, a broker API ("+strings.Join(broker.Names(), ", ")+"), or broker for the one -broker-config sets for the account (env IMPORT_SOURCE)")
+	fs.StringVar(&config.BrokerConfig, "broker-config", os.Getenv("BROKER_CONFIG"),
+		"JSON file of each account's broker and credentials; env such as KITE_API_KEY overrides it (env BROKER_CONFIG)")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s [command] [flags] [file or URL ...]\n\nFlags of ingest, the default command:\n", os.Args[0])
+		fs.PrintDefaults()
+		printCommands()
+	}
+	fs.Parse(args)
+
+	parsed, err := source.ParseHeaders(headers)
+	if err != nil {
+		return fmt.Errorf("invalid HTTP header: %v", err)
+	}
+	config.HTTPHeaders = parsed
+	config.Inputs = fs.Args()
+
+	if config.Source != sourceFiles {
+		brokerDuplicates(fs, &config)
+		return runBrokerImport(ctx, config)
+	}
+	if config.Backend != "mongo" {
+		return runSQLImport(ctx, config)
+	}
+
+	return withImport(ctx, config, func(ob *orderbook.OrderBook, _ *profitLossGraph.Repository, plService *profitLossGraph.Service) error {
+		// Process files based on date
+		if err := processFiles(ctx, ob, plService, config); err != nil {
+			return fmt.Errorf("failed to process files: %v", err)
+		}
+		return nil
+	})
+}
+
+func runBackfill(ctx context.Context, args []string) error {
+	var config Config
+	var headers string
+	fs := flag.NewFlagSet("backfill", flag.ExitOnError)
+	ingestFlags(fs, &config, &headers)
+	fs.StringVar(&config.ProcessDate, "from", "", "First day to import (YYYY-MM-DD)")
+	fs.StringVar(&config.ProcessTo, "to", time.Now().Format("2006-01-02"), "Last day to import (YYYY-MM-DD)")
+	fs.Parse(args)
+
+	if config.ProcessDate == "" {
+		return fmt.Errorf("-from is required")
+	}
+	if _, _, err := parseDateRange(config.ProcessDate, config.ProcessTo); err != nil {
+		return err
+	}
+	if config.Backend != "mongo" {
+		return runSQLImport(ctx, config)
+	}
+
+	return withImport(ctx, config, func(ob *orderbook.OrderBook, _ *profitLossGraph.Repository, plService *profitLossGraph.Service) error {
+		return processFiles(ctx, ob, plService, config)
+	})
+}
+
+// withImport opens the OrderBook and profit/loss service an import writes
+// through, repairing days a previous run left dirty first and recomputing the
+// days the import touched after it
+func withImport(ctx context.Context, config Config, fn func(*orderbook.OrderBook, *profitLossGraph.Repository, *profitLossGraph.Service) error) error {
+	return withOrderBook(ctx, config, func(ob *orderbook.OrderBook) error {
+		// Repair days a previous run left dirty before importing more data
+		if repaired, err := ob.RecomputeDirty(ctx); err != nil {
+			log.Printf("Failed to recompute dirty summaries: %v", err)
+		} else if repaired > 0 {
+			log.Printf("Recomputed %d dirty days", repaired)
+		}
+		ensureDerived(ctx, ob, config)
+
+		// Initialize ProfitLoss repository and service on the OrderBook's connection
+		plRepo, err := profitLossRepository(ob, config)
+		if err != nil {
+			return err
+		}
+		if err := plRepo.EnsureIndexes(ctx); err != nil {
+			return fmt.Errorf("failed to initialize ProfitLoss indexes: %v", err)
+		}
+
+		plService := profitLossGraph.NewService(plRepo, ob.RunID())
+		plService.OnSaved(ob.RefreshDays)
+		plService.PublishTo(ob.Events())
+
+		// Each day the run touches, and the periods covering it, is recomputed once at the end
+		ob.DeferRecompute()
+		err = fn(ob, plRepo, plService)
+		if flushErr := ob.FlushRecompute(ctx); flushErr != nil && err == nil {
+			err = flushErr
+		}
+		return err
+	})
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+
+	"profitLossAndTradeInfoToDB/constants"
+	orderbook "profitLossAndTradeInfoToDB/orderbooks"
+	"profitLossAndTradeInfoToDB/pkg/instruments"
+	"profitLossAndTradeInfoToDB/pkg/source"
+)
+
+func init() {
+	registerCommand(Command{
+		Name:  "instruments",
+		Usage: "Load NSE/BSE contract files into the instrument master: [-exchange NSE] FILE|URL ...",
+		Run:   runInstruments,
+	})
+}
+
+func runInstruments(ctx context.Context, args []string) error {
+	var config Config
+	fs := flag.NewFlagSet("instruments", flag.ExitOnError)
+	connectionFlags(fs, &config)
+	exchange := fs.String("exchange", "NSE", "Exchange of rows in files without an exchange column")
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		return fmt.Errorf("at least one contract file or URL is required")
+	}
+	// The master is being (re)loaded, so there is nothing to validate against yet
+	config.ValidateSymbols = false
+
+	return withOrderBook(ctx, config, func(ob *orderbook.OrderBook) error {
+		repo, err := instruments.NewRepository(ob.GetMongoClient().Database(constants.DB_NAME))
+		if err != nil {
+			return err
+		}
+		if err := repo.EnsureIndexes(ctx); err != nil {
+			return err
+		}
+
+		opener := source.NewOpener(nil)
+		for _, location := range fs.Args() {
+			r, err := opener.Open(ctx, location)
+			if err != nil {
+				return err
+			}
+			list, err := instruments.Parse(r, *exchange)
+			r.Close()
+			if err != nil {
+				return fmt.Errorf("failed to parse %s: %v", location, err)
+			}
+
+			loaded, err := repo.Load(ctx, list)
+			if err != nil {
+				return err
+			}
+			log.Printf("Loaded %d instruments from %s (%d changed)", len(list), location, loaded)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	orderbook "profitLossAndTradeInfoToDB/orderbooks"
+	"profitLossAndTradeInfoToDB/pkg/display"
+	"profitLossAndTradeInfoToDB/pkg/report"
+)
+
+func init() {
+	registerCommand(Command{
+		Name:  "intraday",
+		Usage: "Trades, turnover and realized P&L by time of day over a range of days: -from -to [-bucket 15m] [-json]; per weekday as a heatmap: -heatmap [-bucket 30m] [-chart FILE.svg|png]",
+		Run:   runIntraday,
+	})
+}
+
+func runIntraday(ctx context.Context, args []string) error {
+	var config Config
+	fs := flag.NewFlagSet("intraday", flag.ExitOnError)
+	connectionFlags(fs, &config)
+	readOnlyFlag(fs, &config)
+	from := fs.String("from", time.Now().AddDate(0, -1, 0).Format("2006-01-02"), "First day (YYYY-MM-DD)")
+	to := fs.String("to", time.Now().Format("2006-01-02"), "Last day (YYYY-MM-DD)")
+	bucket := fs.Duration("bucket", time.Hour, "Size of the slices of the day, e.g. 15m or 1h")
+	asJSON := fs.Bool("json", false, "Write the buckets as JSON")
+	heatmap := fs.Bool("heatmap", false, "Break the buckets down by weekday, aligned to the session open")
+	chart := fs.String("chart", "", "With -heatmap, also draw it to this .svg or .png file")
+	width := fs.Int("width", 960, "Heatmap chart width in pixels")
+	height := fs.Int("height", 420, "Heatmap chart height in pixels")
+	fs.Parse(args)
+
+	start, end, err := parseDateRange(*from, *to)
+	if err != nil {
+		return err
+	}
+
+	return withOrderBook(ctx, config, func(ob *orderbook.OrderBook) error {
+		if *heatmap {
+			heatmap, err := ob.IntradayHeatmap(ctx, start, end, *bucket)
+			if err != nil {
+				return err
+			}
+
+			if *asJSON {
+				encoder := json.NewEncoder(os.Stdout)
+				encoder.SetIndent("", "  ")
+				return encoder.Encode(heatmap)
+			}
+			if len(heatmap.Rows) == 0 {
+				fmt.Printf("No orders between %s and %s\n", *from, *to)
+				return nil
+			}
+			displayIntradayHeatmap(heatmap)
+			if *chart == "" {
+				return nil
+			}
+			title := fmt.Sprintf("%s realized P&L by time of day, %s to %s", config.Account, *from, *to)
+			if err := writeIntradayHeatmap(*chart, title, heatmap, *width, *height); err != nil {
+				return err
+			}
+			log.Printf("Wrote %s", *chart)
+			return nil
+		}
+
+		buckets, err := ob.IntradaySummary(ctx, start, end, *bucket)
+		if err != nil {
+			return err
+		}
+
+		if *asJSON {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			return encoder.Encode(buckets)
+		}
+
+		if len(buckets) == 0 {
+			fmt.Printf("No orders between %s and %s\n", *from, *to)
+			return nil
+		}
+		fmt.Printf("%-11s  %6s %14s %11s %7s %14s\n", "Bucket", "Orders", "Turnover", "Round trips", "Winners", "Realized P&L")
+		for _, b := range buckets {
+			fmt.Printf("%-11s  %6d %14s %11d %7d %14s\n",
+				b.Label, b.Trades, display.Money(b.Turnover), b.RoundTrips, b.Winners, display.Money(b.RealizedPnL))
+		}
+		return nil
+	})
+}
+
+// displayIntradayHeatmap prints the buckets down the page and the weekdays
+// across, each cell the realized P&L; buckets where nothing happened are left out
+func displayIntradayHeatmap(heatmap orderbook.IntradayHeatmap) {
+	fmt.Printf("%-11s", "Bucket")
+	for _, row := range heatmap.Rows {
+		fmt.Printf(" %12s", row.Weekday[:3])
+	}
+	fmt.Printf(" %12s\n", "All days")
+	for i, label := range heatmap.Buckets {
+		if heatmap.Totals[i] == (orderbook.HeatmapCell{}) {
+			continue
+		}
+		fmt.Printf("%-11s", label)
+		for _, row := range heatmap.Rows {
+			fmt.Printf(" %12s", heatmapAmount(row.Cells[i]))
+		}
+		fmt.Printf(" %12s\n", heatmapAmount(heatmap.Totals[i]))
+	}
+}
+
+// writeIntradayHeatmap draws the weekdays and their total as rows of a heatmap chart
+func writeIntradayHeatmap(path, title string, heatmap orderbook.IntradayHeatmap, width, height int) error {
+	grid := report.Heatmap{Columns: heatmap.Buckets}
+	addRow := func(name string, cells []orderbook.HeatmapCell) {
+		values := make([]*float64, len(cells))
+		for i, cell := range cells {
+			if cell != (orderbook.HeatmapCell{}) {
+				pnl := cell.RealizedPnL
+				values[i] = &pnl
+			}
+		}
+		grid.Rows = append(grid.Rows, name)
+		grid.Cells = append(grid.Cells, values)
+	}
+	for _, row := range heatmap.Rows {
+		addRow(row.Weekday, row.Cells)
+	}
+	addRow("All days", heatmap.Totals)
+	return report.WriteHeatmap(path, title, grid, width, height)
+}
+
+// heatmapAmount is the realized P&L of a heatmap cell, "-" when nothing happened in it
+func heatmapAmount(cell orderbook.HeatmapCell) string {
+	if cell == (orderbook.HeatmapCell{}) {
+		return "-"
+	}
+	return display.Money(cell.RealizedPnL)
+}
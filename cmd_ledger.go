@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"time"
+
+	"profitLossAndTradeInfoToDB/constants"
+	orderbook "profitLossAndTradeInfoToDB/orderbooks"
+	"profitLossAndTradeInfoToDB/pkg/display"
+	"profitLossAndTradeInfoToDB/pkg/ledger"
+	"profitLossAndTradeInfoToDB/pkg/market"
+	"profitLossAndTradeInfoToDB/pkg/returns"
+)
+
+func init() {
+	registerCommand(Command{
+		Name:  "ledger-add",
+		Usage: "Record a deposit (positive) or withdrawal (negative): -amount A [-date YYYY-MM-DD] [-note TEXT]",
+		Run:   runLedgerAdd,
+	})
+	registerCommand(Command{
+		Name:  "xirr",
+		Usage: "Money-weighted return from the ledger and daily P&L: -from YYYY-MM-DD -to YYYY-MM-DD",
+		Run:   runXIRR,
+	})
+	registerCommand(Command{
+		Name:  "turnover",
+		Usage: "Turnover relative to capital per month and financial year: -from YYYY-MM-DD -to YYYY-MM-DD [-capital C]",
+		Run:   runTurnover,
+	})
+}
+
+// ledgerRepository returns the cash ledger sharing the OrderBook's connection
+func ledgerRepository(ob *orderbook.OrderBook, config Config) (*ledger.Repository, error) {
+	return ledger.NewRepository(ob.GetMongoClient().Database(constants.DB_NAME), config.Account)
+}
+
+func runLedgerAdd(ctx context.Context, args []string) error {
+	var config Config
+	fs := flag.NewFlagSet("ledger-add", flag.ExitOnError)
+	connectionFlags(fs, &config)
+	amount := fs.Float64("amount", 0, "Deposit (positive) or withdrawal (negative) amount")
+	date := fs.String("date", time.Now().Format("2006-01-02"), "Date of the cashflow (YYYY-MM-DD)")
+	note := fs.String("note", "", "Optional note")
+	fs.Parse(args)
+
+	day, err := time.ParseInLocation("2006-01-02", *date, market.Location())
+	if err != nil {
+		return fmt.Errorf("invalid -date: %v", err)
+	}
+
+	return withOrderBook(ctx, config, func(ob *orderbook.OrderBook) error {
+		repo, err := ledgerRepository(ob, config)
+		if err != nil {
+			return err
+		}
+		if err := repo.Add(ctx, ledger.Cashflow{Date: day, Amount: *amount, Note: *note}); err != nil {
+			return err
+		}
+		log.Printf("Recorded cashflow of %.2f on %s", *amount, *date)
+		return nil
+	})
+}
+
+func runXIRR(ctx context.Context, args []string) error {
+	var config Config
+	fs := flag.NewFlagSet("xirr", flag.ExitOnError)
+	connectionFlags(fs, &config)
+	readOnlyFlag(fs, &config)
+	from := fs.String("from", time.Now().AddDate(-1, 0, 0).Format("2006-01-02"), "First day of the period (YYYY-MM-DD)")
+	to := fs.String("to", time.Now().Format("2006-01-02"), "Last day of the period (YYYY-MM-DD)")
+	fs.Parse(args)
+
+	start, end, err := parseDateRange(*from, *to)
+	if err != nil {
+		return err
+	}
+
+	return withOrderBook(ctx, config, func(ob *orderbook.OrderBook) error {
+		cash, err := ledgerRepository(ob, config)
+		if err != nil {
+			return err
+		}
+		pl, err := profitLossRepository(ob, config)
+		if err != nil {
+			return err
+		}
+
+		period, err := returns.ComputePeriod(ctx, cash, pl, start, end)
+		if period != nil {
+			fmt.Printf("Period:          %s to %s\n", display.Day(start), display.Day(end.AddDate(0, 0, -1)))
+			fmt.Printf("Start equity:    %s\n", display.Money(period.StartEquity))
+			fmt.Printf("Net deposits:    %s\n", display.Money(period.NetDeposits))
+			fmt.Printf("P&L:             %s (%d trading days)\n", display.Money(period.ProfitLoss), period.ClosesCovered)
+			fmt.Printf("End equity:      %s\n", display.Money(period.EndEquity))
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Printf("XIRR:            %s%%\n", display.Number(period.XIRR*100, 2))
+		return nil
+	})
+}
+
+func runTurnover(ctx context.Context, args []string) error {
+	var config Config
+	fs := flag.NewFlagSet("turnover", flag.ExitOnError)
+	connectionFlags(fs, &config)
+	readOnlyFlag(fs, &config)
+	from := fs.String("from", time.Now().AddDate(-1, 0, 0).Format("2006-01-02"), "First day of the report (YYYY-MM-DD)")
+	to := fs.String("to", time.Now().Format("2006-01-02"), "Last day of the report (YYYY-MM-DD)")
+	capital := fs.Float64("capital", envFloatOrDefault("TRADING_CAPITAL", 0), "Capital used when the ledger is empty (env TRADING_CAPITAL)")
+	fs.Parse(args)
+
+	start, end, err := parseDateRange(*from, *to)
+	if err != nil {
+		return err
+	}
+
+	return withOrderBook(ctx, config, func(ob *orderbook.OrderBook) error {
+		cash, err := ledgerRepository(ob, config)
+		if err != nil {
+			return err
+		}
+
+		monthly, yearly, err := returns.ComputeTurnover(ctx, ob, cash, *capital, start, end)
+		if err != nil {
+			return err
+		}
+
+		printTurnover := func(title string, rows []returns.TurnoverRow) {
+			fmt.Printf("\n%s\n", title)
+			fmt.Printf("%-10s %20s %17s %8s\n", "Period", "Turnover", "Capital", "Ratio")
+			for _, row := range rows {
+				fmt.Printf("%-10s %20s %17s %7sx\n", row.Period, display.Amount(row.Turnover), display.Money(row.Capital), display.Number(row.Ratio, 2))
+			}
+		}
+		printTurnover("Monthly turnover", monthly)
+		printTurnover("Financial year turnover", yearly)
+		return nil
+	})
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	orderbook "profitLossAndTradeInfoToDB/orderbooks"
+	"profitLossAndTradeInfoToDB/pkg/display"
+	"profitLossAndTradeInfoToDB/pkg/live"
+	"profitLossAndTradeInfoToDB/pkg/market"
+	"profitLossAndTradeInfoToDB/pkg/notify"
+	"profitLossAndTradeInfoToDB/pkg/profitLossGraph"
+)
+
+func init() {
+	registerCommand(Command{
+		Name:  "live",
+		Usage: "Tail today's MTM file, ingest it and stream intraday stats on ws://ADDR/live: [-file F] [-addr 127.0.0.1:8090] [-token T] [-origins URL,...] [-drawdown-alert 5000,2%] [-capital C]",
+		Run:   runLive,
+	})
+}
+
+func runLive(ctx context.Context, args []string) error {
+	var config Config
+	fs := flag.NewFlagSet("live", flag.ExitOnError)
+	connectionFlags(fs, &config)
+	fs.StringVar(&config.CSVDir, "csv-dir", ".", "Directory containing the day's profitLoss file")
+	file := fs.String("file", "", "MTM file to tail (default: today's profitLoss file in -csv-dir)")
+	addr := fs.String("addr", envOrDefault("LIVE_ADDR", "127.0.0.1:8090"), "Address of the WebSocket endpoint (env LIVE_ADDR)")
+	token := fs.String("token", os.Getenv("API_TOKEN"),
+		"Token clients must send as a bearer token, the \"bearer\" subprotocol's token or ?token=; required unless -addr is a loopback address (env API_TOKEN)")
+	origins := fs.String("origins", os.Getenv("LIVE_ORIGINS"),
+		"Comma separated origins of other sites whose pages may connect, e.g. https://dash.example.com (env LIVE_ORIGINS)")
+	interval := fs.Duration("interval", 2*time.Second, "How often the file is polled")
+	alertAt := fs.String("drawdown-alert", os.Getenv("LIVE_DRAWDOWN_ALERT"),
+		"Comma separated levels, amounts or % of capital, at which a fall from the day's high is notified with rising severity, e.g. 5000,2%,5% (env LIVE_DRAWDOWN_ALERT)")
+	capital := fs.Float64("capital", envFloatOrDefault("TRADING_CAPITAL", 0), "Capital percentage drawdown levels are relative to (env TRADING_CAPITAL)")
+	afterClose := fs.Duration("after-close", 15*time.Minute, "Keep tailing this long after the market closes")
+	fs.Parse(args)
+
+	if err := checkExposure(*addr, *token); err != nil {
+		return err
+	}
+	thresholds, err := live.ParseThresholds(*alertAt)
+	if err != nil {
+		return err
+	}
+	guard, err := live.NewGuard(thresholds, *capital)
+	if err != nil {
+		return err
+	}
+
+	path := *file
+	if path == "" {
+		path = filepath.Join(config.CSVDir, profitLossGraph.GetFileNameForDate(time.Now().In(market.Location())))
+	}
+
+	notifier := notifiersFromEnv()
+
+	return withOrderBook(ctx, config, func(ob *orderbook.OrderBook) error {
+		plRepo, err := profitLossRepository(ob, config)
+		if err != nil {
+			return err
+		}
+		if err := plRepo.EnsureIndexes(ctx); err != nil {
+			return err
+		}
+		plService := profitLossGraph.NewService(plRepo, ob.RunID())
+		plService.PublishTo(ob.Events())
+
+		var allowed []string
+		for _, origin := range strings.Split(*origins, ",") {
+			if origin = strings.TrimSpace(origin); origin != "" {
+				allowed = append(allowed, origin)
+			}
+		}
+		hub := live.NewHub(*token, allowed)
+		defer hub.Close()
+
+		sessionCtx, cancel := context.WithDeadline(ctx, market.Close(time.Now()).Add(*afterClose))
+		defer cancel()
+
+		mux := http.NewServeMux()
+		mux.Handle("/live", hub)
+		serveErr := make(chan error, 1)
+		go func() { serveErr <- listenAndServe(sessionCtx, *addr, mux) }()
+
+		tailer := live.NewTailer(path)
+		var tracker live.Tracker
+		for _, level := range guard.Levels() {
+			log.Printf("Drawdown level %d: %s below the day's high", level.Rank, display.Money(level.Amount))
+		}
+
+		ticker := time.NewTicker(*interval)
+		defer ticker.Stop()
+
+		log.Printf("Tailing %s until %s", path, display.Time(market.Close(time.Now()).Add(*afterClose)))
+		for {
+			entries, truncated, err := tailer.Poll()
+			if err != nil {
+				log.Printf("Failed to read %s: %v", path, err)
+			}
+			if truncated {
+				tracker.Reset()
+				guard.Reset()
+			}
+
+			if len(entries) > 0 {
+				if err := plService.ProcessEntries(sessionCtx, entries, path); err != nil {
+					log.Printf("Failed to store MTM samples: %v", err)
+				}
+
+				// Every sample is checked, so a dip between polls is not missed
+				// and a new high re-arms the levels
+				var stats live.Stats
+				var breach live.Level
+				breached := false
+				for _, entry := range entries {
+					stats = tracker.Update(entry)
+					if level, ok := guard.Check(stats); ok {
+						breach, breached = level, true
+					}
+				}
+				hub.Broadcast(stats)
+
+				if breached {
+					notifier.Notify(sessionCtx, drawdownMessage(breach, stats, len(guard.Levels()), config.Account))
+				}
+			}
+
+			select {
+			case <-sessionCtx.Done():
+				stats := tracker.Stats()
+				if stats.Samples > 0 {
+					log.Printf("Session ended: MTM %s, high %s, max drawdown %s",
+						display.Money(stats.MTM), display.Money(stats.DayHigh), display.Money(stats.MaxDrawdown))
+
+					// Summaries are refreshed once at the end rather than on every poll
+					if ctx.Err() == nil {
+						if err := ob.RefreshDays(ctx, []time.Time{stats.Time}); err != nil {
+							log.Printf("Failed to refresh daily summary: %v", err)
+						}
+					}
+				}
+				return <-serveErr
+			case err := <-serveErr:
+				return err
+			case <-ticker.C:
+			}
+		}
+	})
+}
+
+// drawdownMessage describes a crossed drawdown level; the deepest level is an error
+func drawdownMessage(level live.Level, stats live.Stats, levels int, account string) notify.Message {
+	severity := notify.SeverityWarning
+	if level.Deepest {
+		severity = notify.SeverityError
+	}
+	return notify.Message{
+		Kind:     notify.KindDrawdown,
+		Severity: severity,
+		Title:    fmt.Sprintf("Intraday drawdown level %d of %d (%s)", level.Rank, levels, level.Threshold),
+		Text: fmt.Sprintf("MTM %s is %s below the day's high of %s at %s, past the %s limit (account %s)",
+			display.Money(stats.MTM), display.Money(stats.DistanceFromHigh), display.Money(stats.DayHigh),
+			display.Clock(stats.Time), display.Money(level.Amount), account),
+		Time: stats.Time,
+	}
+}
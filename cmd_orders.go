@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"time"
+
+	orderbook "profitLossAndTradeInfoToDB/orderbooks"
+	"profitLossAndTradeInfoToDB/pkg/source"
+)
+
+func init() {
+	registerCommand(Command{
+		Name:  "add-order",
+		Usage: "Manually add an order: -type B|S -symbol SYM -quantity N -price P [-time T]",
+		Run:   runAddOrder,
+	})
+	registerCommand(Command{
+		Name:  "void-order",
+		Usage: "Soft-delete an order: -id ID -reason TEXT",
+		Run:   runVoidOrder,
+	})
+	registerCommand(Command{
+		Name:  "correct-order",
+		Usage: "Correct an order: -id ID [-quantity N] [-price P] [-type B|S] -reason TEXT",
+		Run:   runCorrectOrder,
+	})
+	registerCommand(Command{
+		Name:  "enrich",
+		Usage: "Attach notes and tags to orders from a mapping CSV (order_id,notes,tags): FILE|URL",
+		Run:   runEnrich,
+	})
+}
+
+func runVoidOrder(ctx context.Context, args []string) error {
+	var config Config
+	fs := flag.NewFlagSet("void-order", flag.ExitOnError)
+	connectionFlags(fs, &config)
+	id := fs.String("id", "", "Order ID to void")
+	reason := fs.String("reason", "", "Why the order is voided")
+	fs.Parse(args)
+
+	if *id == "" || *reason == "" {
+		return fmt.Errorf("-id and -reason are required")
+	}
+
+	return withOrderBook(ctx, config, func(ob *orderbook.OrderBook) error {
+		if err := ob.VoidOrder(ctx, *id, *reason); err != nil {
+			return err
+		}
+		log.Printf("Voided order %s", *id)
+		return nil
+	})
+}
+
+func runCorrectOrder(ctx context.Context, args []string) error {
+	var config Config
+	fs := flag.NewFlagSet("correct-order", flag.ExitOnError)
+	connectionFlags(fs, &config)
+	id := fs.String("id", "", "Order ID to correct")
+	reason := fs.String("reason", "", "Why the order is corrected")
+	quantity := fs.Float64("quantity", 0, "Corrected quantity")
+	price := fs.Float64("price", 0, "Corrected average price")
+	transactionType := fs.String("type", "", "Corrected transaction type (B or S)")
+	fs.Parse(args)
+
+	if *id == "" || *reason == "" {
+		return fmt.Errorf("-id and -reason are required")
+	}
+
+	// Only flags given on the command line are applied
+	var correction orderbook.OrderCorrection
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "quantity":
+			correction.Quantity = quantity
+		case "price":
+			correction.AveragePrice = price
+		case "type":
+			correction.TransactionType = transactionType
+		}
+	})
+
+	return withOrderBook(ctx, config, func(ob *orderbook.OrderBook) error {
+		if err := ob.CorrectOrder(ctx, *id, correction, *reason); err != nil {
+			return err
+		}
+		log.Printf("Corrected order %s", *id)
+		return nil
+	})
+}
+
+func runAddOrder(ctx context.Context, args []string) error {
+	var config Config
+	fs := flag.NewFlagSet("add-order", flag.ExitOnError)
+	connectionFlags(fs, &config)
+	timestamp := fs.String("time", time.Now().Format(time.RFC3339), "Order time (RFC3339)")
+	transactionType := fs.String("type", "", "Transaction type (B or S)")
+	symbol := fs.String("symbol", "", "Trading symbol")
+	product := fs.String("product", "", "Product (e.g. MIS, NRML)")
+	quantity := fs.Float64("quantity", 0, "Quantity, fractional for crypto")
+	price := fs.Float64("price", 0, "Average price")
+	status := fs.String("status", "COMPLETE", "Order status")
+	source := fs.String("source", "manual", "Where the order came from")
+	fs.Parse(args)
+
+	parsedTime, err := time.Parse(time.RFC3339, *timestamp)
+	if err != nil {
+		return fmt.Errorf("invalid -time: %v", err)
+	}
+
+	order := orderbook.Order{
+		Timestamp:       parsedTime,
+		TransactionType: *transactionType,
+		Symbol:          *symbol,
+		Product:         *product,
+		Quantity:        *quantity,
+		AveragePrice:    *price,
+		OrderStatus:     *status,
+		Source:          *source,
+	}
+
+	return withOrderBook(ctx, config, func(ob *orderbook.OrderBook) error {
+		stored, err := ob.AddOrder(ctx, order)
+		if err != nil {
+			return err
+		}
+		log.Printf("Added order %s", stored.ID.Hex())
+		return nil
+	})
+}
+
+func runEnrich(ctx context.Context, args []string) error {
+	var config Config
+	fs := flag.NewFlagSet("enrich", flag.ExitOnError)
+	connectionFlags(fs, &config)
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		return fmt.Errorf("exactly one mapping file or URL is required")
+	}
+
+	r, err := source.NewOpener(nil).Open(ctx, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	annotations, err := orderbook.ReadAnnotations(r)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %v", fs.Arg(0), err)
+	}
+
+	return withOrderBook(ctx, config, func(ob *orderbook.OrderBook) error {
+		missing, err := ob.AnnotateOrders(ctx, annotations)
+		if err != nil {
+			return err
+		}
+		for _, id := range missing {
+			log.Printf("Order %s not found", id)
+		}
+		log.Printf("Enriched %d of %d orders", len(annotations)-len(missing), len(annotations))
+		return nil
+	})
+}
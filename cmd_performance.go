@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	orderbook "profitLossAndTradeInfoToDB/orderbooks"
+	"profitLossAndTradeInfoToDB/pkg/display"
+)
+
+func init() {
+	registerCommand(Command{
+		Name:  "performance",
+		Usage: "Win rate, average win/loss, profit factor, expectancy, largest win/loss and win/loss streaks of the closed trades per day, week or month, or per weekday and expiry day: -from -to [-period day|week|month | -weekdays] [-refresh] [-json]",
+		Run:   runPerformance,
+	})
+}
+
+func runPerformance(ctx context.Context, args []string) error {
+	var config Config
+	fs := flag.NewFlagSet("performance", flag.ExitOnError)
+	connectionFlags(fs, &config)
+	readOnlyFlag(fs, &config)
+	from := fs.String("from", time.Now().AddDate(0, -1, 0).Format("2006-01-02"), "First day (YYYY-MM-DD)")
+	to := fs.String("to", time.Now().Format("2006-01-02"), "Last day (YYYY-MM-DD)")
+	periodName := fs.String("period", "day", "Statistics per day, week or month")
+	weekdays := fs.Bool("weekdays", false, "Break the closed trades down by day of the week and expiry vs other days instead")
+	refresh := fs.Bool("refresh", false, "Recompute the stored statistics of the range from the closed trades first")
+	asJSON := fs.Bool("json", false, "Write the statistics as JSON")
+	fs.Parse(args)
+
+	period, err := orderbook.ParseSummaryPeriod(*periodName)
+	if err != nil {
+		return err
+	}
+	start, end, err := parseDateRange(*from, *to)
+	if err != nil {
+		return err
+	}
+
+	return withOrderBook(ctx, config, func(ob *orderbook.OrderBook) error {
+		if *weekdays {
+			breakdown, err := ob.DayBreakdown(ctx, start, end)
+			if err != nil {
+				return err
+			}
+			if *asJSON {
+				encoder := json.NewEncoder(os.Stdout)
+				encoder.SetIndent("", "  ")
+				return encoder.Encode(breakdown)
+			}
+			if breakdown.Total.Trades == 0 {
+				fmt.Printf("No closed trades between %s and %s\n", *from, *to)
+				return nil
+			}
+			displayDayBreakdown(breakdown)
+			return nil
+		}
+
+		if *refresh {
+			if err := ob.RefreshPerformanceStats(ctx, start, end); err != nil {
+				return err
+			}
+		}
+
+		stats, err := ob.GetPerformanceStats(ctx, period, start, end)
+		if err != nil {
+			return err
+		}
+
+		if *asJSON {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			return encoder.Encode(stats)
+		}
+
+		if len(stats) == 0 {
+			fmt.Printf("No closed trades between %s and %s\n", *from, *to)
+			return nil
+		}
+		fmt.Printf("%-11s  %6s %6s %12s %12s %6s %12s %12s %12s %14s\n",
+			"Period", "Trades", "Win %", "Avg win", "Avg loss", "PF", "Expectancy", "Largest win", "Largest loss", "Realized P&L")
+		for _, s := range stats {
+			displayPerformanceRow(performanceLabel(s), s)
+		}
+		total := orderbook.CombinePerformance(stats)
+		displayPerformanceRow("Total", total)
+
+		// Historical maxima run from the first day with closed trades
+		days, err := ob.GetPerformanceStats(ctx, orderbook.PeriodDay, time.Time{}, end)
+		if err != nil {
+			return err
+		}
+		history := orderbook.CombinePerformance(days)
+
+		fmt.Printf("\n%-20s %8s %8s %10s\n", "Streaks", "Winning", "Losing", "Current")
+		displayStreaks("Days, this range", total.DayStreaks)
+		displayStreaks("Trades, this range", total.TradeStreaks)
+		displayStreaks("Days, all time", history.DayStreaks)
+		displayStreaks("Trades, all time", history.TradeStreaks)
+		return nil
+	})
+}
+
+// displayDayBreakdown prints the weekday rows, with the expiry trades each
+// holds, and the expiry vs non-expiry comparison
+func displayDayBreakdown(b orderbook.DayBreakdown) {
+	fmt.Printf("%-11s  %6s %6s %12s %12s %6s %12s %12s %12s %14s %7s\n",
+		"Weekday", "Trades", "Win %", "Avg win", "Avg loss", "PF", "Expectancy", "Largest win", "Largest loss", "Realized P&L", "Expiry")
+	for _, w := range b.Weekdays {
+		fmt.Printf("%-11s  %6d %6.1f %12s %12s %6.2f %12s %12s %12s %14s %7d\n", w.Weekday, w.Trades, w.WinRate,
+			display.Amount(w.AvgWin), display.Amount(w.AvgLoss), w.ProfitFactor, display.Amount(w.Expectancy),
+			display.Amount(w.LargestWin), display.Amount(w.LargestLoss), display.Amount(w.RealizedPnL), w.ExpiryTrades)
+	}
+	displayPerformanceRow("Total", b.Total)
+
+	fmt.Printf("\n%-11s  %6s %6s %12s %12s %6s %12s %12s %12s %14s %5s\n",
+		"Trades on", "Trades", "Win %", "Avg win", "Avg loss", "PF", "Expectancy", "Largest win", "Largest loss", "Realized P&L", "Days")
+	for _, row := range []struct {
+		label string
+		stats orderbook.PerformanceStats
+	}{{"Expiry day", b.Expiry}, {"Other days", b.NonExpiry}} {
+		s := row.stats
+		fmt.Printf("%-11s  %6d %6.1f %12s %12s %6.2f %12s %12s %12s %14s %5d\n", row.label, s.Trades, s.WinRate,
+			display.Amount(s.AvgWin), display.Amount(s.AvgLoss), s.ProfitFactor, display.Amount(s.Expectancy),
+			display.Amount(s.LargestWin), display.Amount(s.LargestLoss), display.Amount(s.RealizedPnL), s.Days)
+	}
+}
+
+// displayStreaks prints the longest runs of s and the run it ends on
+func displayStreaks(label string, s orderbook.Streaks) {
+	current := "-"
+	switch {
+	case s.Closing > 0:
+		current = fmt.Sprintf("%d won", s.Closing)
+	case s.Closing < 0:
+		current = fmt.Sprintf("%d lost", -s.Closing)
+	}
+	fmt.Printf("%-20s %8d %8d %10s\n", label, s.LongestWin, s.LongestLoss, current)
+}
+
+// performanceLabel names the period of s as it is listed
+func performanceLabel(s orderbook.PerformanceStats) string {
+	if s.Period == orderbook.PeriodMonth {
+		return s.PeriodStart.Format("Jan 2006")
+	}
+	return display.Day(s.PeriodStart)
+}
+
+func displayPerformanceRow(label string, s orderbook.PerformanceStats) {
+	fmt.Printf("%-11s  %6d %6.1f %12s %12s %6.2f %12s %12s %12s %14s\n", label, s.Trades, s.WinRate,
+		display.Amount(s.AvgWin), display.Amount(s.AvgLoss), s.ProfitFactor, display.Amount(s.Expectancy),
+		display.Amount(s.LargestWin), display.Amount(s.LargestLoss), display.Amount(s.RealizedPnL))
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	orderbook "profitLossAndTradeInfoToDB/orderbooks"
+	"profitLossAndTradeInfoToDB/pkg/display"
+	"profitLossAndTradeInfoToDB/pkg/market"
+)
+
+func init() {
+	registerCommand(Command{
+		Name:  "positions",
+		Usage: "Show the open positions at the end of a day, rebuilt from the orders: [-date YYYY-MM-DD] [-rebuild] [-json]",
+		Run:   runPositions,
+	})
+}
+
+func runPositions(ctx context.Context, args []string) error {
+	var config Config
+	fs := flag.NewFlagSet("positions", flag.ExitOnError)
+	connectionFlags(fs, &config)
+	readOnlyFlag(fs, &config)
+	date := fs.String("date", time.Now().Format("2006-01-02"), "Day whose end-of-day positions to show (YYYY-MM-DD)")
+	rebuild := fs.Bool("rebuild", false, "Rebuild the positions from the orders even when stored")
+	asJSON := fs.Bool("json", false, "Write the positions as JSON")
+	fs.Parse(args)
+
+	day, err := time.ParseInLocation("2006-01-02", *date, market.Location())
+	if err != nil {
+		return fmt.Errorf("invalid date: %v", err)
+	}
+
+	return withOrderBook(ctx, config, func(ob *orderbook.OrderBook) error {
+		var positions []orderbook.Position
+		if *rebuild {
+			positions, err = ob.RebuildPositions(ctx, day)
+		} else {
+			positions, err = ob.Positions(ctx, day)
+		}
+		if err != nil {
+			return err
+		}
+
+		if *asJSON {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			return encoder.Encode(positions)
+		}
+
+		if len(positions) == 0 {
+			fmt.Printf("No open positions at the end of %s\n", *date)
+			return nil
+		}
+		for _, position := range positions {
+			fmt.Printf("%-24s %-5s %10s @ %10s  since %s\n", position.Symbol, position.Side,
+				display.Quantity(position.NetQuantity), display.Number(position.AveragePrice, 2), display.Time(position.OpenedAt))
+		}
+		fmt.Printf("%d open positions at the end of %s\n", len(positions), *date)
+		return nil
+	})
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+
+	orderbook "profitLossAndTradeInfoToDB/orderbooks"
+)
+
+func init() {
+	registerCommand(Command{
+		Name:  "purge-run",
+		Usage: "Roll back an import run, deleting its orders and P&L entries: [-dry-run] RUN_ID",
+		Run:   runPurgeRun,
+	})
+}
+
+func runPurgeRun(ctx context.Context, args []string) error {
+	var config Config
+	fs := flag.NewFlagSet("purge-run", flag.ExitOnError)
+	connectionFlags(fs, &config)
+	dryRun := fs.Bool("dry-run", false, "Only count what would be deleted")
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		return fmt.Errorf("exactly one run ID is required")
+	}
+	runID := fs.Arg(0)
+	if *dryRun {
+		config.ReadOnly = true
+	}
+
+	return withOrderBook(ctx, config, func(ob *orderbook.OrderBook) error {
+		plRepo, err := profitLossRepository(ob, config)
+		if err != nil {
+			return err
+		}
+
+		orders, _, err := ob.PurgeRun(ctx, runID, *dryRun)
+		if err != nil {
+			return err
+		}
+		entries, err := plRepo.PurgeRun(ctx, runID, *dryRun)
+		if err != nil {
+			return err
+		}
+
+		if *dryRun {
+			fmt.Printf("Run %s: %d orders and %d P&L entries would be deleted\n", runID, orders, len(entries))
+			return nil
+		}
+
+		// The broker MTM of the days whose samples were deleted changes too
+		if err := ob.RefreshDays(ctx, entries); err != nil {
+			return err
+		}
+		log.Printf("Purged run %s: %d orders and %d P&L entries deleted", runID, orders, len(entries))
+		return nil
+	})
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	orderbook "profitLossAndTradeInfoToDB/orderbooks"
+	"profitLossAndTradeInfoToDB/pkg/display"
+)
+
+func init() {
+	registerCommand(Command{
+		Name:  "quarantine",
+		Usage: "List rows that could not be parsed: [-source FILE] [-json] [-export fixes.csv]",
+		Run:   runQuarantine,
+	})
+	registerCommand(Command{
+		Name:  "quarantine-reprocess",
+		Usage: "Import quarantined rows after fixing them in the file written by quarantine -export: FILE",
+		Run:   runQuarantineReprocess,
+	})
+}
+
+func runQuarantine(ctx context.Context, args []string) error {
+	var config Config
+	fs := flag.NewFlagSet("quarantine", flag.ExitOnError)
+	connectionFlags(fs, &config)
+	readOnlyFlag(fs, &config)
+	sourceName := fs.String("source", "", "Only rows of this file or URL")
+	asJSON := fs.Bool("json", false, "Write the rows as JSON")
+	export := fs.String("export", "", "Write the rows to this CSV file for fixing, then run quarantine-reprocess on it")
+	fs.Parse(args)
+
+	return withOrderBook(ctx, config, func(ob *orderbook.OrderBook) error {
+		rows, err := ob.QuarantinedRows(ctx, *sourceName)
+		if err != nil {
+			return err
+		}
+
+		if *export != "" {
+			file, err := os.Create(*export)
+			if err != nil {
+				return fmt.Errorf("failed to create %s: %v", *export, err)
+			}
+			defer file.Close()
+			if err := orderbook.WriteQuarantineCSV(file, rows); err != nil {
+				return fmt.Errorf("failed to write %s: %v", *export, err)
+			}
+			log.Printf("Wrote %d quarantined rows to %s", len(rows), *export)
+			return nil
+		}
+
+		if *asJSON {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			return encoder.Encode(rows)
+		}
+
+		for _, row := range rows {
+			fmt.Printf("%s  %s:%d  %s\n    %s\n",
+				display.Time(row.QuarantinedAt), row.Source, row.Row, row.Error, strings.Join(row.Record, ","))
+		}
+		fmt.Printf("%d quarantined rows\n", len(rows))
+		return nil
+	})
+}
+
+func runQuarantineReprocess(ctx context.Context, args []string) error {
+	var config Config
+	fs := flag.NewFlagSet("quarantine-reprocess", flag.ExitOnError)
+	connectionFlags(fs, &config)
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: quarantine-reprocess FILE")
+	}
+	path := fs.Arg(0)
+
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %v", path, err)
+	}
+	defer file.Close()
+
+	return withOrderBook(ctx, config, func(ob *orderbook.OrderBook) error {
+		result, err := ob.ReprocessQuarantine(ctx, file)
+		if err != nil {
+			return err
+		}
+		log.Printf("Imported %d fixed rows; %d are still invalid and stay quarantined", result.Stored, result.Failed)
+		return nil
+	})
+}
@@ -0,0 +1,312 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	orderbook "profitLossAndTradeInfoToDB/orderbooks"
+	"profitLossAndTradeInfoToDB/pkg/display"
+	"profitLossAndTradeInfoToDB/pkg/money"
+)
+
+func init() {
+	registerCommand(Command{
+		Name:  "query",
+		Usage: "List orders in a date range, of contracts expiring on a day or of a broker order with its fills: -from -to [-all] | -expiry D | -order-id ID [-symbol S] [-side BUY|SELL] [-tag T] [-json]",
+		Run:   runQuery,
+	})
+	registerCommand(Command{
+		Name:  "summary",
+		Usage: "Print the daily, weekly or monthly summaries of a day or range, or those of each symbol: [-date YYYY-MM-DD | -from -to] [-period day|week|month] [-by-symbol] [-symbol S] [-detail]",
+		Run:   runSummary,
+	})
+}
+
+func runQuery(ctx context.Context, args []string) error {
+	var config Config
+	fs := flag.NewFlagSet("query", flag.ExitOnError)
+	connectionFlags(fs, &config)
+	readOnlyFlag(fs, &config)
+	from := fs.String("from", time.Now().Format("2006-01-02"), "First day (YYYY-MM-DD)")
+	to := fs.String("to", time.Now().Format("2006-01-02"), "Last day (YYYY-MM-DD)")
+	symbol := fs.String("symbol", "", "Only orders for symbols containing this text")
+	side := fs.String("side", "", "Only BUY or SELL orders")
+	tag := fs.String("tag", "", "Only orders carrying this tag")
+	expiry := fs.String("expiry", "", "Orders in options and futures expiring on this day (YYYY-MM-DD), instead of -from/-to")
+	all := fs.Bool("all", false, "Include rejected, cancelled and open orders, i.e. the whole order book")
+	orderID := fs.String("order-id", "", "The orders of this broker order ID and their fills from the trade book")
+	asJSON := fs.Bool("json", false, "Write the orders as JSON")
+	fs.Parse(args)
+
+	if *orderID != "" {
+		return withOrderBook(ctx, config, func(ob *orderbook.OrderBook) error {
+			return queryOrderID(ctx, ob, *orderID, *asJSON)
+		})
+	}
+
+	if *expiry != "" {
+		*from, *to = *expiry, *expiry
+	}
+	start, end, err := parseDateRange(*from, *to)
+	if err != nil {
+		return err
+	}
+
+	return withOrderBook(ctx, config, func(ob *orderbook.OrderBook) error {
+		var orders []orderbook.Order
+		switch {
+		case *expiry != "":
+			orders, err = ob.GetOrdersByExpiry(ctx, start)
+		case *all:
+			orders, err = ob.GetOrderBook(ctx, start, end)
+		default:
+			orders, err = ob.GetOrdersByDateRange(ctx, start, end)
+		}
+		if err != nil {
+			return err
+		}
+
+		matched := orders[:0]
+		for _, order := range orders {
+			if *symbol != "" && !strings.Contains(strings.ToUpper(order.Symbol), strings.ToUpper(*symbol)) {
+				continue
+			}
+			if *side != "" && !strings.EqualFold(order.TransactionType, *side) {
+				continue
+			}
+			if *tag != "" && !slices.Contains(order.Tags, *tag) {
+				continue
+			}
+			matched = append(matched, order)
+		}
+
+		if *asJSON {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			return encoder.Encode(matched)
+		}
+
+		for _, order := range matched {
+			printOrder(order)
+		}
+		fmt.Printf("%d orders\n", len(matched))
+		return nil
+	})
+}
+
+func printOrder(order orderbook.Order) {
+	fmt.Printf("%s  %-24s %-4s %6s @ %10s  %-10s %s\n",
+		display.Time(order.Timestamp), order.Symbol, order.TransactionType, display.Quantity(order.Quantity),
+		display.Number(order.AveragePrice, 2), order.OrderStatus, order.ID.Hex())
+}
+
+// queryOrderID prints the orders placed under a broker order ID and the fills
+// the trade book links to them
+func queryOrderID(ctx context.Context, ob *orderbook.OrderBook, orderID string, asJSON bool) error {
+	orders, err := ob.GetOrdersByOrderID(ctx, orderID)
+	if err != nil {
+		return err
+	}
+	fills, err := ob.GetTradesForOrder(ctx, orderID)
+	if err != nil {
+		return err
+	}
+	if len(orders) == 0 && len(fills) == 0 {
+		return fmt.Errorf("no orders or trades with order ID %s", orderID)
+	}
+
+	if asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(map[string]any{"orders": orders, "trades": fills})
+	}
+
+	for _, order := range orders {
+		printOrder(order)
+		for _, change := range order.StatusHistory {
+			fmt.Printf("  %-21s %s  %s filled\n", change.Status, display.Time(change.Timestamp), display.Quantity(change.Quantity))
+		}
+	}
+	var filled float64
+	for _, fill := range fills {
+		fmt.Printf("  fill %-16s %s  %-4s %6s @ %10s\n", fill.TradeID, display.Time(fill.Timestamp),
+			fill.TransactionType, display.Quantity(fill.Quantity), display.Number(fill.Price, 2))
+		filled += fill.Quantity
+	}
+	fmt.Printf("%d orders, %d fills for %s filled\n", len(orders), len(fills), display.Quantity(filled))
+	return nil
+}
+
+func runSummary(ctx context.Context, args []string) error {
+	var config Config
+	fs := flag.NewFlagSet("summary", flag.ExitOnError)
+	connectionFlags(fs, &config)
+	readOnlyFlag(fs, &config)
+	date := fs.String("date", time.Now().Format("2006-01-02"), "Day to summarize (YYYY-MM-DD)")
+	from := fs.String("from", "", "First day of a range (YYYY-MM-DD); overrides -date")
+	to := fs.String("to", time.Now().Format("2006-01-02"), "Last day of a range (YYYY-MM-DD)")
+	bySymbol := fs.Bool("by-symbol", false, "Print the summaries of each symbol traded instead")
+	symbol := fs.String("symbol", "", "Only the summaries of this symbol; implies -by-symbol")
+	detail := fs.Bool("detail", false, "Print the full report of each day of a range instead of one table")
+	periodName := fs.String("period", string(orderbook.PeriodDay), "Summaries of each day, week or month; the rollup command refreshes the weekly and monthly ones")
+	fs.Parse(args)
+
+	period, err := orderbook.ParseSummaryPeriod(*periodName)
+	if err != nil {
+		return err
+	}
+
+	if *from == "" {
+		*from, *to = *date, *date
+	}
+	start, end, err := parseDateRange(*from, *to)
+	if err != nil {
+		return err
+	}
+
+	return withOrderBook(ctx, config, func(ob *orderbook.OrderBook) error {
+		if *bySymbol || *symbol != "" {
+			summaries, err := ob.GetSymbolSummaries(ctx, start, end, *symbol)
+			if err != nil {
+				return err
+			}
+			if len(summaries) == 0 {
+				fmt.Printf("No symbol summaries between %s and %s\n", *from, *to)
+				return nil
+			}
+			displaySymbolSummaries(summaries)
+			return nil
+		}
+
+		if period != orderbook.PeriodDay {
+			summaries, err := ob.GetPeriodSummaries(ctx, period, start, end)
+			if err != nil {
+				return err
+			}
+			if len(summaries) == 0 {
+				fmt.Printf("No %s summaries between %s and %s\n", period, *from, *to)
+				return nil
+			}
+			for i := range summaries {
+				displayPeriodSummary(&summaries[i])
+			}
+			return nil
+		}
+
+		summaries, err := ob.GetDailySummaries(ctx, start, end)
+		if err != nil {
+			return err
+		}
+		if len(summaries) == 0 {
+			fmt.Printf("No daily summaries between %s and %s\n", *from, *to)
+			return nil
+		}
+		if *from != *to && !*detail {
+			displaySummaryTable(summaries)
+			return nil
+		}
+		for i := range summaries {
+			displaySummary(&summaries[i])
+		}
+		return nil
+	})
+}
+
+// displaySummaryTable prints one line per daily summary and their totals
+func displaySummaryTable(summaries []orderbook.DailySummary) {
+	const row = "%-10s  %6s %7s %10s %10s %14s %14s %12s %14s %14s\n"
+	fmt.Printf(row, "Date", "Trades", "Symbols", "Buy Qty", "Sell Qty", "Turnover", "Realized P&L", "Charges", "Net P&L", "Broker MTM")
+
+	var total orderbook.DailySummary
+	var turnover, realized, charged, net, mtm money.Paise
+	for _, summary := range summaries {
+		brokerMTM := "-"
+		if summary.BrokerMTM != nil {
+			brokerMTM = display.Amount(*summary.BrokerMTM)
+			mtm += *summary.BrokerMTM
+		}
+		dayTurnover := summary.BuyTurnover + summary.SellTurnover
+		fmt.Printf(row, display.Day(summary.Date), strconv.Itoa(int(summary.TotalTrades)), strconv.Itoa(int(summary.UniqueSymbols)),
+			display.Quantity(summary.TotalBuyQuantity), display.Quantity(summary.TotalSellQuantity), display.Amount(dayTurnover),
+			display.Amount(summary.RealizedPnL), display.Amount(summary.Charges), display.Amount(summary.NetPnL), brokerMTM)
+
+		total.TotalTrades += summary.TotalTrades
+		total.TotalBuyQuantity += summary.TotalBuyQuantity
+		total.TotalSellQuantity += summary.TotalSellQuantity
+		turnover += dayTurnover
+		realized += summary.RealizedPnL
+		charged += summary.Charges
+		net += summary.NetPnL
+	}
+	fmt.Printf(row, "Total", strconv.Itoa(int(total.TotalTrades)), "",
+		display.Quantity(total.TotalBuyQuantity), display.Quantity(total.TotalSellQuantity), display.Amount(turnover),
+		display.Amount(realized), display.Amount(charged), display.Amount(net), display.Amount(mtm))
+}
+
+func displaySymbolSummaries(summaries []orderbook.SymbolSummary) {
+	fmt.Printf("%-10s  %-24s %6s %10s %10s %14s %14s %14s\n",
+		"Date", "Symbol", "Trades", "Buy Qty", "Sell Qty", "Buy Turnover", "Sell Turnover", "Realized P&L")
+	for _, summary := range summaries {
+		fmt.Printf("%-10s  %-24s %6d %10s %10s %14s %14s %14s\n",
+			display.Day(summary.Date), summary.Symbol, summary.Trades,
+			display.Quantity(summary.BuyQuantity), display.Quantity(summary.SellQuantity),
+			display.Amount(summary.BuyTurnover), display.Amount(summary.SellTurnover), display.Amount(summary.RealizedPnL))
+	}
+}
+
+func displayPeriodSummary(summary *orderbook.PeriodSummary) {
+	fmt.Printf("\n%s of %s to %s\n", strings.ToUpper(string(summary.Period[:1]))+string(summary.Period[1:]),
+		display.Day(summary.PeriodStart), display.Day(summary.PeriodEnd.AddDate(0, 0, -1)))
+	fmt.Println("===================")
+	fmt.Printf("Trading Days: %d\n", summary.Days)
+	fmt.Printf("Total Trades: %d\n", summary.TotalTrades)
+	fmt.Printf("Total Buy Quantity: %s\n", display.Quantity(summary.TotalBuyQuantity))
+	fmt.Printf("Total Sell Quantity: %s\n", display.Quantity(summary.TotalSellQuantity))
+	fmt.Printf("Unique Symbols: %d\n", summary.UniqueSymbols)
+	fmt.Printf("Realized P&L (matched): %s\n", display.Amount(summary.RealizedPnL))
+	fmt.Printf("Charges: %s\n", display.Amount(summary.Charges))
+	fmt.Printf("Net P&L: %s\n", display.Amount(summary.NetPnL))
+	if summary.BrokerMTM != nil {
+		fmt.Printf("Broker MTM: %s\n", display.Amount(*summary.BrokerMTM))
+	}
+	fmt.Printf("Buy / Sell Turnover: %s / %s\n", display.Amount(summary.BuyTurnover), display.Amount(summary.SellTurnover))
+	fmt.Printf("Last Updated: %s\n", display.Time(summary.LastUpdated))
+}
+
+func displaySummary(summary *orderbook.DailySummary) {
+	// Display summary in a formatted table
+	fmt.Println("\nDaily Summary Report")
+	fmt.Println("===================")
+	fmt.Printf("Date: %s\n", display.Day(summary.Date))
+	fmt.Printf("Total Trades: %d\n", summary.TotalTrades)
+	fmt.Printf("Total Buy Quantity: %s\n", display.Quantity(summary.TotalBuyQuantity))
+	fmt.Printf("Total Sell Quantity: %s\n", display.Quantity(summary.TotalSellQuantity))
+	fmt.Printf("Unique Symbols: %d\n", summary.UniqueSymbols)
+	if len(summary.Symbols) > 0 {
+		symbols := make([]string, len(summary.Symbols))
+		for i, sym := range summary.Symbols {
+			symbols[i] = fmt.Sprintf("%s (%d)", sym.Symbol, sym.Trades)
+		}
+		fmt.Printf("Symbols: %s\n", strings.Join(symbols, ", "))
+	}
+	fmt.Printf("Realized P&L (matched): %s\n", display.Amount(summary.RealizedPnL))
+	fmt.Printf("Charges: %s\n", display.Amount(summary.Charges))
+	fmt.Printf("Net P&L: %s\n", display.Amount(summary.NetPnL))
+	if summary.BrokerMTM != nil {
+		fmt.Printf("Broker MTM: %s\n", display.Amount(*summary.BrokerMTM))
+	}
+	fmt.Printf("Buy / Sell Turnover: %s / %s\n", display.Amount(summary.BuyTurnover), display.Amount(summary.SellTurnover))
+	if summary.PremiumRetainedPct != nil {
+		fmt.Printf("Premium Collected: %s\n", display.Amount(summary.PremiumCollected))
+		fmt.Printf("Premium Bought Back: %s\n", display.Amount(summary.PremiumBoughtBack))
+		fmt.Printf("Net Premium: %s (%s%% retained)\n", display.Amount(summary.NetPremium), display.Number(*summary.PremiumRetainedPct, 1))
+	}
+	fmt.Printf("Last Updated: %s\n", display.Time(summary.LastUpdated))
+}
@@ -0,0 +1,403 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	orderbook "profitLossAndTradeInfoToDB/orderbooks"
+	"profitLossAndTradeInfoToDB/pkg/display"
+	"profitLossAndTradeInfoToDB/pkg/profitLossGraph"
+	"profitLossAndTradeInfoToDB/pkg/report"
+	"profitLossAndTradeInfoToDB/pkg/trades"
+)
+
+func init() {
+	registerCommand(Command{
+		Name:  "report",
+		Usage: "Render a report from a Go text or HTML template: -from -to [-template FILE] [-out FILE] [-param key=value]; or chart the day's P&L and equity curve to images: chart -date D [-days N] [-format png|svg] [-out-dir DIR]; or write a self-contained HTML or printable PDF report: html|pdf [-date D | -from -to] [-out FILE]; or show drawdowns of the equity curve: risk -from -to [-json]",
+		Run:   runReport,
+	})
+	registerCommand(Command{
+		Name:  "compare",
+		Usage: "Compare two accounts, e.g. live against paper, over the same period: -account A -against B -from -to [-days]",
+		Run:   runCompare,
+	})
+}
+
+func runReport(ctx context.Context, args []string) error {
+	if len(args) > 0 && args[0] == "chart" {
+		return runReportChart(ctx, args[1:])
+	}
+	if len(args) > 0 && (args[0] == "html" || args[0] == "pdf") {
+		return runReportDocument(ctx, args[0], args[1:])
+	}
+	if len(args) > 0 && args[0] == "risk" {
+		return runReportRisk(ctx, args[1:])
+	}
+
+	var config Config
+	fs := flag.NewFlagSet("report", flag.ExitOnError)
+	connectionFlags(fs, &config)
+	readOnlyFlag(fs, &config)
+	from := fs.String("from", time.Now().AddDate(0, 0, -6).Format("2006-01-02"), "First day (YYYY-MM-DD)")
+	to := fs.String("to", time.Now().Format("2006-01-02"), "Last day (YYYY-MM-DD)")
+	templatePath := fs.String("template", os.Getenv("REPORT_TEMPLATE"), "Template file; .html/.htm use html/template (env REPORT_TEMPLATE; default built-in text report)")
+	out := fs.String("out", "", "Write the report to this file instead of stdout")
+	params := map[string]string{}
+	fs.Func("param", "key=value made available to the template as .Parameters.key (repeatable)", func(v string) error {
+		key, value, ok := strings.Cut(v, "=")
+		if !ok {
+			return fmt.Errorf("expected key=value")
+		}
+		params[key] = value
+		return nil
+	})
+	fs.Parse(args)
+
+	start, end, err := parseDateRange(*from, *to)
+	if err != nil {
+		return err
+	}
+
+	return withOrderBook(ctx, config, func(ob *orderbook.OrderBook) error {
+		pl, err := profitLossRepository(ob, config)
+		if err != nil {
+			return err
+		}
+
+		data, err := report.Build(ctx, ob, pl, start, end)
+		if err != nil {
+			return err
+		}
+		for key, value := range params {
+			data.Parameters[key] = value
+		}
+
+		var w io.Writer = os.Stdout
+		if *out != "" {
+			file, err := os.Create(*out)
+			if err != nil {
+				return fmt.Errorf("failed to create %s: %v", *out, err)
+			}
+			defer file.Close()
+			w = file
+		}
+
+		return report.Render(w, *templatePath, data)
+	})
+}
+
+// runReportDocument writes the built-in HTML or PDF report of a day or range to a file
+func runReportDocument(ctx context.Context, format string, args []string) error {
+	var config Config
+	fs := flag.NewFlagSet("report "+format, flag.ExitOnError)
+	connectionFlags(fs, &config)
+	readOnlyFlag(fs, &config)
+	date := fs.String("date", time.Now().Format("2006-01-02"), "Day to report (YYYY-MM-DD)")
+	from := fs.String("from", "", "First day of a range (YYYY-MM-DD); overrides -date")
+	to := fs.String("to", time.Now().Format("2006-01-02"), "Last day of a range (YYYY-MM-DD)")
+	out := fs.String("out", "", "File to write; default report-ACCOUNT-FROM-to-TO."+format)
+	fs.Parse(args)
+
+	if *from == "" {
+		*from, *to = *date, *date
+	}
+	start, end, err := parseDateRange(*from, *to)
+	if err != nil {
+		return err
+	}
+	if *out == "" {
+		*out = fmt.Sprintf("report-%s-%s-to-%s.%s", config.Account, *from, *to, format)
+	}
+
+	return withOrderBook(ctx, config, func(ob *orderbook.OrderBook) error {
+		pl, err := profitLossRepository(ob, config)
+		if err != nil {
+			return err
+		}
+		data, err := report.Build(ctx, ob, pl, start, end)
+		if err != nil {
+			return err
+		}
+
+		file, err := os.Create(*out)
+		if err != nil {
+			return fmt.Errorf("failed to create %s: %v", *out, err)
+		}
+		defer file.Close()
+		render := report.RenderHTML
+		if format == "pdf" {
+			render = report.RenderPDF
+		}
+		if err := render(file, data); err != nil {
+			return err
+		}
+		if err := file.Close(); err != nil {
+			return fmt.Errorf("failed to write %s: %v", *out, err)
+		}
+		log.Printf("Wrote %s", *out)
+		return nil
+	})
+}
+
+// runReportChart renders the intraday MTM of a day and the equity curve of the
+// days up to it to image files
+func runReportChart(ctx context.Context, args []string) error {
+	var config Config
+	fs := flag.NewFlagSet("report chart", flag.ExitOnError)
+	connectionFlags(fs, &config)
+	readOnlyFlag(fs, &config)
+	date := fs.String("date", time.Now().Format("2006-01-02"), "Day whose P&L to chart, and last day of the equity curve (YYYY-MM-DD)")
+	days := fs.Int("days", 30, "Days of equity curve up to -date")
+	format := fs.String("format", "png", "Image format: png or svg")
+	outDir := fs.String("out-dir", ".", "Directory the charts are written to")
+	width := fs.Int("width", 960, "Chart width in pixels")
+	height := fs.Int("height", 480, "Chart height in pixels")
+	fs.Parse(args)
+
+	if *format != "png" && *format != "svg" {
+		return fmt.Errorf("unknown chart format %q, expected png or svg", *format)
+	}
+	if *days < 1 {
+		return fmt.Errorf("-days must be at least 1")
+	}
+	day, end, err := parseDateRange(*date, *date)
+	if err != nil {
+		return err
+	}
+	start := day.AddDate(0, 0, 1-*days)
+
+	return withOrderBook(ctx, config, func(ob *orderbook.OrderBook) error {
+		pl, err := profitLossRepository(ob, config)
+		if err != nil {
+			return err
+		}
+
+		entries, err := pl.GetProfitLossByDateRange(ctx, day, end)
+		if err != nil {
+			return err
+		}
+		intraday := make([]report.Point, len(entries))
+		for i, entry := range entries {
+			intraday[i] = report.Point{Date: entry.Timestamp, Value: entry.Value}
+		}
+
+		curve, err := loadEquityCurve(ctx, pl, config.Account, start, end)
+		if err != nil {
+			return err
+		}
+		equity := make([]report.Point, len(curve))
+		for i, point := range curve {
+			equity[i] = report.Point{Date: point.Date, Value: point.Cumulative}
+		}
+
+		charts := []struct {
+			name, title string
+			points      []report.Point
+		}{
+			{"pl-" + *date, fmt.Sprintf("%s intraday P&L, %s", config.Account, *date), intraday},
+			{"equity-" + start.Format("2006-01-02") + "-to-" + *date,
+				fmt.Sprintf("%s equity curve, %s to %s", config.Account, start.Format("2006-01-02"), *date), equity},
+		}
+		for _, chart := range charts {
+			if len(chart.points) == 0 {
+				log.Printf("Skipping %s: no profit/loss data", chart.title)
+				continue
+			}
+			path := filepath.Join(*outDir, chart.name+"."+*format)
+			if err := report.WriteChart(path, chart.title, chart.points, *width, *height); err != nil {
+				return err
+			}
+			log.Printf("Wrote %s", path)
+		}
+		return nil
+	})
+}
+
+// loadEquityCurve returns the stored equity curve of [from, to), which carries
+// the P&L of earlier days; without one the curve is built from the daily
+// closes, starting at zero
+func loadEquityCurve(ctx context.Context, pl *profitLossGraph.Repository, account string, from, to time.Time) ([]profitLossGraph.EquityPoint, error) {
+	curve, err := pl.GetEquityCurve(ctx, from, to)
+	if err != nil || len(curve) > 0 {
+		return curve, err
+	}
+	closes, err := pl.GetDailyCloses(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+	return profitLossGraph.BuildEquityCurve(account, profitLossGraph.EquityPoint{}, closes), nil
+}
+
+func runReportRisk(ctx context.Context, args []string) error {
+	var config Config
+	fs := flag.NewFlagSet("report risk", flag.ExitOnError)
+	connectionFlags(fs, &config)
+	readOnlyFlag(fs, &config)
+	from := fs.String("from", time.Now().AddDate(0, -3, 0).Format("2006-01-02"), "First day (YYYY-MM-DD)")
+	to := fs.String("to", time.Now().Format("2006-01-02"), "Last day (YYYY-MM-DD)")
+	asJSON := fs.Bool("json", false, "Write the drawdowns as JSON")
+	fs.Parse(args)
+
+	start, end, err := parseDateRange(*from, *to)
+	if err != nil {
+		return err
+	}
+
+	return withOrderBook(ctx, config, func(ob *orderbook.OrderBook) error {
+		pl, err := profitLossRepository(ob, config)
+		if err != nil {
+			return err
+		}
+		curve, err := loadEquityCurve(ctx, pl, config.Account, start, end)
+		if err != nil {
+			return err
+		}
+		analysis := profitLossGraph.AnalyzeDrawdowns(curve)
+
+		if *asJSON {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			return encoder.Encode(analysis)
+		}
+
+		if len(curve) == 0 {
+			fmt.Printf("No profit/loss data between %s and %s\n", *from, *to)
+			return nil
+		}
+		displayDrawdowns(config.Account, *from, *to, analysis)
+		return nil
+	})
+}
+
+// displayDrawdowns prints the drawdown figures of a range and its drawdown periods
+func displayDrawdowns(account, from, to string, analysis profitLossGraph.DrawdownAnalysis) {
+	fmt.Printf("Drawdowns of %s, %s to %s\n\n", account, from, to)
+	fmt.Printf("Max drawdown:     %s\n", display.Money(analysis.MaxDrawdown))
+	fmt.Printf("Longest drawdown: %d days\n", analysis.LongestDays)
+	fmt.Printf("Current drawdown: %s for %d days\n", display.Money(analysis.Current), analysis.CurrentDays)
+	fmt.Printf("Since the start:  max %s, longest %d days\n", display.Money(analysis.MaxToDate), analysis.LongestToDate)
+
+	if len(analysis.Periods) == 0 {
+		return
+	}
+	fmt.Printf("\n%-11s  %14s  %-11s  %-11s  %-11s  %14s %5s\n", "Peak date", "Peak", "Start", "Trough", "Recovered", "Depth", "Days")
+	for _, period := range analysis.Periods {
+		peakDate, recovered := "start", "-"
+		if !period.PeakDate.IsZero() {
+			peakDate = display.Day(period.PeakDate)
+		}
+		if period.Recovered != nil {
+			recovered = display.Day(*period.Recovered)
+		}
+		fmt.Printf("%-11s  %14s  %-11s  %-11s  %-11s  %14s %5d\n", peakDate, display.Money(period.Peak),
+			display.Day(period.Start), display.Day(period.Trough), recovered, display.Money(period.Depth), period.Days)
+	}
+}
+
+func runCompare(ctx context.Context, args []string) error {
+	var config Config
+	fs := flag.NewFlagSet("compare", flag.ExitOnError)
+	connectionFlags(fs, &config)
+	readOnlyFlag(fs, &config)
+	against := fs.String("against", "", "Account to compare -account against, e.g. its paper account")
+	from := fs.String("from", time.Now().AddDate(0, -1, 0).Format("2006-01-02"), "First day (YYYY-MM-DD)")
+	to := fs.String("to", time.Now().Format("2006-01-02"), "Last day (YYYY-MM-DD)")
+	listDays := fs.Bool("days", false, "List the daily P&L of both accounts")
+	fs.Parse(args)
+
+	if *against == "" || *against == config.Account {
+		return fmt.Errorf("-against must name a second account")
+	}
+	start, end, err := parseDateRange(*from, *to)
+	if err != nil {
+		return err
+	}
+
+	otherConfig := config
+	otherConfig.Account = *against
+
+	var sides [2]*report.Data
+	var strategies [2]func(trades.RoundTrip) string
+	for i, cfg := range []Config{config, otherConfig} {
+		err := withOrderBook(ctx, cfg, func(ob *orderbook.OrderBook) error {
+			pl, err := profitLossRepository(ob, cfg)
+			if err != nil {
+				return err
+			}
+			data, err := report.Build(ctx, ob, pl, start, end)
+			if err != nil {
+				return err
+			}
+			sides[i] = data
+			strategies[i], err = tradeStrategies(ctx, ob, cfg, data.Trades)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("account %s: %v", cfg.Account, err)
+		}
+	}
+
+	c := report.Compare(sides[0], sides[1], strategies[0], strategies[1])
+
+	fmt.Printf("%s vs %s, %s to %s\n\n", c.A.Account, c.B.Account, display.Day(c.From), display.Day(c.To))
+	fmt.Printf("%-16s %14s %14s\n", "", c.A.Account, c.B.Account)
+	fmt.Printf("%-16s %14d %14d\n", "Days", c.A.Stats.Days, c.B.Stats.Days)
+	fmt.Printf("%-16s %14d %14d\n", "Trades", c.A.Stats.Trades, c.B.Stats.Trades)
+	fmt.Printf("%-16s %14s %14s\n", "Win %", display.Number(c.A.Stats.WinRate, 1), display.Number(c.B.Stats.WinRate, 1))
+	fmt.Printf("%-16s %14s %14s\n", "Realized P&L", display.Amount(c.A.Stats.RealizedPnL), display.Amount(c.B.Stats.RealizedPnL))
+	fmt.Printf("%-16s %14s %14s\n", "Max drawdown", display.Money(c.A.MaxDrawdown), display.Money(c.B.MaxDrawdown))
+	fmt.Println()
+
+	correlation := "n/a"
+	if c.Correlation != nil {
+		correlation = display.Number(*c.Correlation, 2)
+	}
+	fmt.Printf("Daily P&L correlation: %s over %d common days\n", correlation, c.CommonDays)
+	fmt.Printf("Relative drawdown:     %s (%s behind %s at worst)\n", display.Money(c.RelativeDrawdown), c.A.Account, c.B.Account)
+
+	fmt.Printf("\n%-16s %7s %7s %14s %14s %14s\n", "Strategy", "Trades", "Trades", "P&L", "P&L", "Difference")
+	for _, d := range c.Strategies {
+		fmt.Printf("%-16s %7d %7d %14s %14s %14s\n", d.Strategy, d.TradesA, d.TradesB,
+			display.Amount(d.PnLA), display.Amount(d.PnLB), display.Amount(d.Diff))
+	}
+
+	if *listDays {
+		fmt.Printf("\n%-12s %14s %14s %14s\n", "Day", c.A.Account, c.B.Account, "Difference")
+		for _, d := range c.Days {
+			fmt.Printf("%-12s %14s %14s %14s\n", display.Day(d.Date), display.Money(d.A), display.Money(d.B), display.Money(d.Diff))
+		}
+	}
+	return nil
+}
+
+// tradeStrategies names the strategy of each closed trade from the risk plan of its entry order
+func tradeStrategies(ctx context.Context, ob *orderbook.OrderBook, config Config, closed []trades.RoundTrip) (func(trades.RoundTrip) string, error) {
+	repo, err := riskRepository(ob, config)
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []string
+	for _, trip := range closed {
+		if trip.EntryID != "" {
+			ids = append(ids, trip.EntryID)
+		}
+	}
+	plans, err := repo.ForOrders(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(trip trades.RoundTrip) string {
+		return plans[trip.EntryID].Strategy
+	}, nil
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"time"
+
+	"profitLossAndTradeInfoToDB/constants"
+	orderbook "profitLossAndTradeInfoToDB/orderbooks"
+	"profitLossAndTradeInfoToDB/pkg/display"
+	"profitLossAndTradeInfoToDB/pkg/risk"
+	"profitLossAndTradeInfoToDB/pkg/trades"
+)
+
+func init() {
+	registerCommand(Command{
+		Name:  "risk-set",
+		Usage: "Record the planned risk of an entry order: -order ID (-risk RUPEES | -stop DISTANCE) [-strategy S] [-note TEXT]",
+		Run:   runRiskSet,
+	})
+	registerCommand(Command{
+		Name:  "r-multiple",
+		Usage: "R-multiples and expectancy in R per strategy: -from -to [-trades]",
+		Run:   runRMultiple,
+	})
+}
+
+// riskRepository returns the risk plan repository sharing the OrderBook's connection
+func riskRepository(ob *orderbook.OrderBook, config Config) (*risk.Repository, error) {
+	repo, err := risk.NewRepository(ob.GetMongoClient().Database(constants.DB_NAME), config.Account)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize risk repository: %v", err)
+	}
+	return repo, nil
+}
+
+func runRiskSet(ctx context.Context, args []string) error {
+	var config Config
+	fs := flag.NewFlagSet("risk-set", flag.ExitOnError)
+	connectionFlags(fs, &config)
+	orderID := fs.String("order", "", "ID of the entry order")
+	amount := fs.Float64("risk", 0, "Rupees risked on the whole order")
+	stop := fs.Float64("stop", 0, "Stop distance in price per unit")
+	strategy := fs.String("strategy", "", "Strategy the trade belongs to")
+	note := fs.String("note", "", "Free-text note")
+	fs.Parse(args)
+
+	if *orderID == "" {
+		return fmt.Errorf("-order is required")
+	}
+
+	return withOrderBook(ctx, config, func(ob *orderbook.OrderBook) error {
+		order, err := ob.GetOrder(ctx, *orderID)
+		if err != nil {
+			return err
+		}
+
+		repo, err := riskRepository(ob, config)
+		if err != nil {
+			return err
+		}
+
+		err = repo.Record(ctx, risk.Plan{
+			OrderID:       *orderID,
+			Symbol:        order.Symbol,
+			EntryTime:     order.Timestamp,
+			OrderQuantity: order.Quantity,
+			RiskAmount:    *amount,
+			StopDistance:  *stop,
+			Strategy:      *strategy,
+			Note:          *note,
+		})
+		if err != nil {
+			return err
+		}
+		log.Printf("Recorded risk plan for order %s (%s)", *orderID, order.Symbol)
+		return nil
+	})
+}
+
+func runRMultiple(ctx context.Context, args []string) error {
+	var config Config
+	fs := flag.NewFlagSet("r-multiple", flag.ExitOnError)
+	connectionFlags(fs, &config)
+	readOnlyFlag(fs, &config)
+	from := fs.String("from", time.Now().AddDate(0, -1, 0).Format("2006-01-02"), "First day (YYYY-MM-DD)")
+	to := fs.String("to", time.Now().Format("2006-01-02"), "Last day (YYYY-MM-DD)")
+	listTrades := fs.Bool("trades", false, "List every trade with its R-multiple")
+	fs.Parse(args)
+
+	start, end, err := parseDateRange(*from, *to)
+	if err != nil {
+		return err
+	}
+
+	return withOrderBook(ctx, config, func(ob *orderbook.OrderBook) error {
+		repo, err := riskRepository(ob, config)
+		if err != nil {
+			return err
+		}
+
+		orders, err := ob.GetOrdersByDateRange(ctx, start, end)
+		if err != nil {
+			return err
+		}
+		roundTrips, _ := trades.MatchFIFO(orderbook.Fills(orders))
+
+		var ids []string
+		for _, trip := range roundTrips {
+			if trip.EntryID != "" {
+				ids = append(ids, trip.EntryID)
+			}
+		}
+		plans, err := repo.ForOrders(ctx, ids)
+		if err != nil {
+			return err
+		}
+
+		multiples, expectancies, unplanned := risk.Analyze(roundTrips, plans)
+		if *listTrades {
+			for _, m := range multiples {
+				fmt.Printf("%s %-24s %-5s qty %-6s risk %12s P&L %12s %7sR  %s\n",
+					display.Time(m.Trip.EntryTime), m.Trip.Symbol, m.Trip.Side, display.Quantity(m.Trip.Quantity),
+					display.Money(m.Risk), display.Amount(m.Trip.RealizedPnL), display.Number(m.R, 2), m.Strategy)
+			}
+			fmt.Println()
+		}
+
+		fmt.Printf("%-16s %7s %8s %9s %9s %9s %11s\n", "Strategy", "Trades", "Win %", "Avg win", "Avg loss", "Total R", "Expectancy")
+		for _, e := range expectancies {
+			fmt.Printf("%-16s %7d %8s %8sR %8sR %8sR %10sR\n", e.Strategy, e.Trades, display.Number(e.WinRate, 1),
+				display.Number(e.AvgWinR, 2), display.Number(e.AvgLossR, 2), display.Number(e.TotalR, 2), display.Number(e.Expectancy, 2))
+		}
+		if unplanned > 0 {
+			fmt.Printf("\n%d of %d closed trades have no risk plan and are excluded\n", unplanned, len(roundTrips))
+		}
+		return nil
+	})
+}
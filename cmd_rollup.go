@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"time"
+
+	orderbook "profitLossAndTradeInfoToDB/orderbooks"
+	"profitLossAndTradeInfoToDB/pkg/display"
+)
+
+func init() {
+	registerCommand(Command{
+		Name:  "rollup",
+		Usage: "Refresh materialized rollups and weekly and monthly summaries: [-from YYYY-MM-DD] [-to YYYY-MM-DD] [-every 15m -lookback-days N]",
+		Run:   runRollup,
+	})
+	registerCommand(Command{
+		Name:  "recompute-dirty",
+		Usage: "Recompute summaries and rollups left dirty by failed mutations",
+		Run:   runRecomputeDirty,
+	})
+	registerCommand(Command{
+		Name:  "range-summary",
+		Usage: "Trades, turnover, unique symbols and P&L over any date range: -from -to",
+		Run:   runRangeSummary,
+	})
+	registerCommand(Command{
+		Name:  "backfill-derived",
+		Usage: "Rebuild the daily summaries and rollups of every day with orders",
+		Run:   runBackfillDerived,
+	})
+}
+
+func runRollup(ctx context.Context, args []string) error {
+	var config Config
+	fs := flag.NewFlagSet("rollup", flag.ExitOnError)
+	connectionFlags(fs, &config)
+	from := fs.String("from", time.Now().Format("2006-01-02"), "First day to refresh (YYYY-MM-DD)")
+	to := fs.String("to", time.Now().Format("2006-01-02"), "Last day to refresh (YYYY-MM-DD)")
+	every := fs.Duration("every", 0, "Keep running and refresh on this interval (e.g. 15m)")
+	lookbackDays := fs.Int("lookback-days", 1, "With -every, days before today to refresh on each run")
+	fs.Parse(args)
+
+	return withOrderBook(ctx, config, func(ob *orderbook.OrderBook) error {
+		if *every <= 0 {
+			start, end, err := parseDateRange(*from, *to)
+			if err != nil {
+				return err
+			}
+			if err := ob.RefreshRollups(ctx, start, end); err != nil {
+				return err
+			}
+			log.Printf("Refreshed rollups and period summaries for %s to %s", *from, *to)
+			return nil
+		}
+
+		ticker := time.NewTicker(*every)
+		defer ticker.Stop()
+		for {
+			now := time.Now()
+			if err := ob.RefreshRollups(ctx, now.AddDate(0, 0, -*lookbackDays), now); err != nil {
+				log.Printf("Rollup refresh failed: %v", err)
+			} else {
+				log.Printf("Refreshed rollups")
+			}
+
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-ticker.C:
+			}
+		}
+	})
+}
+
+func runRecomputeDirty(ctx context.Context, args []string) error {
+	var config Config
+	fs := flag.NewFlagSet("recompute-dirty", flag.ExitOnError)
+	connectionFlags(fs, &config)
+	fs.Parse(args)
+
+	return withOrderBook(ctx, config, func(ob *orderbook.OrderBook) error {
+		repaired, err := ob.RecomputeDirty(ctx)
+		if err != nil {
+			return err
+		}
+		log.Printf("Recomputed %d dirty days", repaired)
+		return nil
+	})
+}
+
+func runRangeSummary(ctx context.Context, args []string) error {
+	var config Config
+	fs := flag.NewFlagSet("range-summary", flag.ExitOnError)
+	connectionFlags(fs, &config)
+	readOnlyFlag(fs, &config)
+	from := fs.String("from", time.Now().AddDate(0, -1, 0).Format("2006-01-02"), "First day (YYYY-MM-DD)")
+	to := fs.String("to", time.Now().Format("2006-01-02"), "Last day (YYYY-MM-DD)")
+	fs.Parse(args)
+
+	start, end, err := parseDateRange(*from, *to)
+	if err != nil {
+		return err
+	}
+
+	return withOrderBook(ctx, config, func(ob *orderbook.OrderBook) error {
+		summary, err := ob.GetRangeSummary(ctx, start, end)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Period:          %s to %s\n", display.Day(start), display.Day(end.AddDate(0, 0, -1)))
+		fmt.Printf("Trading days:    %d\n", summary.Days)
+		fmt.Printf("Trades:          %d\n", summary.Trades)
+		fmt.Printf("Buy quantity:    %s\n", display.Quantity(summary.BuyQuantity))
+		fmt.Printf("Sell quantity:   %s\n", display.Quantity(summary.SellQuantity))
+		fmt.Printf("Unique symbols:  %d\n", summary.UniqueSymbols)
+		fmt.Printf("Turnover:        %s\n", display.Amount(summary.Turnover))
+		fmt.Printf("Realized P&L:    %s\n", display.Amount(summary.RealizedPnL))
+		fmt.Printf("Broker MTM:      %s\n", display.Amount(summary.BrokerMTM))
+		fmt.Printf("Source:          %s\n", summary.Source)
+		return nil
+	})
+}
+
+func runBackfillDerived(ctx context.Context, args []string) error {
+	var config Config
+	fs := flag.NewFlagSet("backfill-derived", flag.ExitOnError)
+	connectionFlags(fs, &config)
+	fs.Parse(args)
+
+	return withOrderBook(ctx, config, func(ob *orderbook.OrderBook) error {
+		start := time.Now()
+		days, err := ob.BackfillDerived(ctx)
+		if err != nil {
+			return err
+		}
+		log.Printf("Rebuilt summaries and rollups of %d days in %s", days, time.Since(start).Round(time.Second))
+		return nil
+	})
+}
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	orderbook "profitLossAndTradeInfoToDB/orderbooks"
+	"profitLossAndTradeInfoToDB/pkg/display"
+	"profitLossAndTradeInfoToDB/pkg/market"
+	"profitLossAndTradeInfoToDB/pkg/notify"
+	"profitLossAndTradeInfoToDB/pkg/profitLossGraph"
+	"profitLossAndTradeInfoToDB/pkg/schedule"
+)
+
+func init() {
+	registerCommand(Command{
+		Name:  "scheduler",
+		Usage: "Ingest each trading day's files on a cron schedule, catching up on days missed while down: [-schedule \"0 18 * * 1-5\"] [-csv-dir DIR] [-catch-up-days 30]",
+		Run:   runScheduler,
+	})
+}
+
+func runScheduler(ctx context.Context, args []string) error {
+	var config Config
+	fs := flag.NewFlagSet("scheduler", flag.ExitOnError)
+	connectionFlags(fs, &config)
+	fs.StringVar(&config.CSVDir, "csv-dir", ".", "Directory containing CSV files")
+	spec := fs.String("schedule", envOrDefault("IMPORT_SCHEDULE", "0 18 * * 1-5"), "Cron expression in market time: minute hour day-of-month month day-of-week")
+	statePath := fs.String("state", os.Getenv("SCHEDULE_STATE_FILE"), "File the last completed run is kept in (default CSV_DIR/.schedule.json)")
+	catchUpDays := fs.Int("catch-up-days", 30, "Furthest back, in days, missed runs are backfilled on start")
+	holidays := fs.String("holidays", os.Getenv("MARKET_HOLIDAYS"), "Comma separated exchange holidays (YYYY-MM-DD)")
+	fs.Parse(args)
+
+	sched, err := schedule.Parse(*spec)
+	if err != nil {
+		return err
+	}
+	if *holidays != "" {
+		market.SetHolidays(strings.Split(*holidays, ","))
+	}
+	if *statePath == "" {
+		*statePath = filepath.Join(config.CSVDir, ".schedule.json")
+	}
+	checkpoint, err := schedule.OpenCheckpoint(*statePath)
+	if err != nil {
+		return err
+	}
+
+	return withOrderBook(ctx, config, func(ob *orderbook.OrderBook) error {
+		ensureDerived(ctx, ob, config)
+
+		plRepo, err := profitLossRepository(ob, config)
+		if err != nil {
+			return err
+		}
+		if err := plRepo.EnsureIndexes(ctx); err != nil {
+			return err
+		}
+		plService := profitLossGraph.NewService(plRepo, ob.RunID())
+		plService.OnSaved(ob.RefreshDays)
+		plService.PublishTo(ob.Events())
+
+		s := &scheduler{config: config, schedule: sched, checkpoint: checkpoint, ob: ob, plService: plService, notifier: notifiersFromEnv()}
+		return s.run(ctx, *catchUpDays)
+	})
+}
+
+// scheduler runs the date-based import on a cron schedule
+type scheduler struct {
+	config     Config
+	schedule   *schedule.Schedule
+	checkpoint *schedule.Checkpoint
+	ob         *orderbook.OrderBook
+	plService  *profitLossGraph.Service
+	notifier   notify.Notifier
+}
+
+func (s *scheduler) run(ctx context.Context, catchUpDays int) error {
+	now := time.Now()
+	s.catchUp(ctx, now, now.AddDate(0, 0, -catchUpDays))
+
+	log.Printf("Scheduled imports from %s at %q", s.config.CSVDir, s.schedule)
+	for {
+		next := s.schedule.Next(time.Now())
+		if next.IsZero() {
+			return fmt.Errorf("schedule %q never fires", s.schedule)
+		}
+		log.Printf("Next import at %s", display.Time(next))
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil
+		case <-timer.C:
+		}
+
+		// A failed run holds the checkpoint back, so it is retried before this one
+		runs := []time.Time{next}
+		if !s.checkpoint.LastRun.IsZero() {
+			runs = s.due(next, next.AddDate(0, 0, -catchUpDays))
+		}
+		if len(runs) > 1 {
+			log.Printf("Retrying %d earlier scheduled runs first", len(runs)-1)
+		}
+		s.fireAll(ctx, runs)
+	}
+}
+
+// catchUp backfills the runs missed since the last completed one, once per
+// trading day and no further back than limit. A first start has nothing to
+// catch up on.
+func (s *scheduler) catchUp(ctx context.Context, now, limit time.Time) {
+	if s.checkpoint.LastRun.IsZero() {
+		return
+	}
+
+	runs := s.due(now, limit)
+	if len(runs) > 0 {
+		log.Printf("Catching up on %d missed scheduled runs", len(runs))
+	}
+	s.fireAll(ctx, runs)
+}
+
+// due returns the runs scheduled after the last completed one up to until, no
+// further back than limit. Only the last run of each day is kept; they all
+// import the same files.
+func (s *scheduler) due(until, limit time.Time) []time.Time {
+	since := s.checkpoint.LastRun
+	if since.Before(limit) {
+		since = limit
+	}
+
+	var runs []time.Time
+	for _, at := range s.schedule.Missed(since, until) {
+		if n := len(runs); n > 0 && market.DayStart(runs[n-1]).Equal(market.DayStart(at)) {
+			runs[n-1] = at
+			continue
+		}
+		runs = append(runs, at)
+	}
+	return runs
+}
+
+// fireAll fires the runs oldest first and stops at the first that fails, so
+// the checkpoint never moves past a day that was not imported
+func (s *scheduler) fireAll(ctx context.Context, runs []time.Time) {
+	for _, at := range runs {
+		if ctx.Err() != nil || !s.fire(ctx, at) {
+			return
+		}
+	}
+}
+
+// fire imports the files of the day containing at, if it is a trading day,
+// and records the run as completed. It reports whether the run completed; a
+// failed import leaves the checkpoint where it was.
+func (s *scheduler) fire(ctx context.Context, at time.Time) bool {
+	if market.IsTradingDay(at) {
+		// Every scheduled run is its own import run, so one day can be purged alone
+		runID := newRunID()
+		s.ob.SetRunID(runID)
+		s.plService.SetRunID(runID)
+
+		config := s.config
+		config.ProcessDate = market.DayStart(at).Format("2006-01-02")
+		log.Printf("Importing %s (run %s)", config.ProcessDate, runID)
+		s.ob.DeferRecompute()
+		err := processFiles(ctx, s.ob, s.plService, config)
+		if flushErr := s.ob.FlushRecompute(ctx); flushErr != nil && err == nil {
+			err = flushErr
+		}
+		if err != nil {
+			log.Printf("Scheduled import of %s failed: %v", config.ProcessDate, err)
+			return false
+		}
+		s.report(ctx, market.DayStart(at))
+	}
+	if ctx.Err() != nil {
+		return false
+	}
+
+	if err := s.checkpoint.Advance(at); err != nil {
+		log.Printf("Failed to record scheduled run: %v", err)
+		return false
+	}
+	return true
+}
+
+// report sends the day's summary as the daily report notification
+func (s *scheduler) report(ctx context.Context, day time.Time) {
+	summary, err := s.ob.GetDailySummary(ctx, day)
+	if err != nil {
+		log.Printf("No daily report for %s: %v", display.Day(day), err)
+		return
+	}
+
+	text := fmt.Sprintf("%d trades in %d symbols, realized %s, turnover %s",
+		summary.TotalTrades, summary.UniqueSymbols, display.Amount(summary.RealizedPnL),
+		display.Amount(summary.BuyTurnover+summary.SellTurnover))
+	if summary.BrokerMTM != nil {
+		text += fmt.Sprintf(", broker MTM %s", display.Amount(*summary.BrokerMTM))
+	}
+	s.notifier.Notify(ctx, notify.Message{
+		Kind:     notify.KindDailyReport,
+		Severity: notify.SeverityInfo,
+		Title:    fmt.Sprintf("Daily report %s (account %s)", display.Day(day), s.config.Account),
+		Text:     text,
+		Time:     time.Now(),
+	})
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"net/http"
+	"os"
+
+	orderbook "profitLossAndTradeInfoToDB/orderbooks"
+	"profitLossAndTradeInfoToDB/pkg/api"
+)
+
+func init() {
+	registerCommand(Command{
+		Name:  "serve",
+		Usage: "Serve orders, daily summaries and P&L over a read-only REST API, with -ui a web dashboard at /: [-addr 127.0.0.1:8081] [-token T] [-ui]",
+		Run:   runServe,
+	})
+}
+
+func runServe(ctx context.Context, args []string) error {
+	var config Config
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	connectionFlags(fs, &config)
+	addr := fs.String("addr", envOrDefault("API_ADDR", "127.0.0.1:8081"), "Address to listen on (env API_ADDR)")
+	token := fs.String("token", os.Getenv("API_TOKEN"), "Bearer token required on every request; required unless -addr is a loopback address (env API_TOKEN)")
+	ui := fs.Bool("ui", envBoolOrDefault("API_UI", false), "Also serve the web dashboard (equity curve, P&L calendar, day drill-down) at /")
+	fs.Parse(args)
+
+	if err := checkExposure(*addr, *token); err != nil {
+		return err
+	}
+
+	// The API only reads, so it never needs write access
+	config.ReadOnly = true
+
+	return withOrderBook(ctx, config, func(ob *orderbook.OrderBook) error {
+		plRepo, err := profitLossRepository(ob, config)
+		if err != nil {
+			return err
+		}
+
+		server := api.NewServer(ob, plRepo, *token)
+		var handler http.Handler = server.Handler()
+		if *ui {
+			mux := http.NewServeMux()
+			mux.Handle("/api/", handler)
+			mux.Handle("/", server.UI())
+			handler = mux
+		}
+		return listenAndServe(ctx, *addr, handler)
+	})
+}
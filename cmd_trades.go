@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	orderbook "profitLossAndTradeInfoToDB/orderbooks"
+	"profitLossAndTradeInfoToDB/pkg/display"
+	"profitLossAndTradeInfoToDB/pkg/money"
+)
+
+func init() {
+	registerCommand(Command{
+		Name:  "trades",
+		Usage: "List the closed round-trip trades matched FIFO per day: -from -to [-symbol S] [-json]",
+		Run:   runTrades,
+	})
+}
+
+func runTrades(ctx context.Context, args []string) error {
+	var config Config
+	fs := flag.NewFlagSet("trades", flag.ExitOnError)
+	connectionFlags(fs, &config)
+	readOnlyFlag(fs, &config)
+	from := fs.String("from", time.Now().Format("2006-01-02"), "First day (YYYY-MM-DD)")
+	to := fs.String("to", time.Now().Format("2006-01-02"), "Last day (YYYY-MM-DD)")
+	symbol := fs.String("symbol", "", "Only trades for symbols containing this text")
+	asJSON := fs.Bool("json", false, "Write the trades as JSON")
+	fs.Parse(args)
+
+	start, end, err := parseDateRange(*from, *to)
+	if err != nil {
+		return err
+	}
+
+	return withOrderBook(ctx, config, func(ob *orderbook.OrderBook) error {
+		closed, err := ob.GetClosedTrades(ctx, start, end)
+		if err != nil {
+			return err
+		}
+
+		matched := closed[:0]
+		for _, trade := range closed {
+			if *symbol == "" || strings.Contains(strings.ToUpper(trade.Symbol), strings.ToUpper(*symbol)) {
+				matched = append(matched, trade)
+			}
+		}
+
+		if *asJSON {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			return encoder.Encode(matched)
+		}
+
+		var total money.Paise
+		for _, trade := range matched {
+			fmt.Printf("%s  %-24s %-5s %6s  %10s -> %10s  %8s  %s\n",
+				display.Time(trade.EntryTime), trade.Symbol, trade.Side, display.Quantity(trade.Quantity),
+				display.Number(trade.EntryPrice, 2), display.Number(trade.ExitPrice, 2),
+				trade.HoldingTime.Round(time.Second), display.Amount(trade.RealizedPnL))
+			total += trade.RealizedPnL
+		}
+		fmt.Printf("%d trades, realized %s\n", len(matched), display.Amount(total))
+		return nil
+	})
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	orderbook "profitLossAndTradeInfoToDB/orderbooks"
+	"profitLossAndTradeInfoToDB/pkg/api"
+)
+
+func init() {
+	registerCommand(Command{
+		Name:  "webhook",
+		Usage: "Receive order postbacks on POST /ingest/order: [-addr :8080] [-secret S]",
+		Run:   runWebhook,
+	})
+}
+
+func runWebhook(ctx context.Context, args []string) error {
+	var config Config
+	fs := flag.NewFlagSet("webhook", flag.ExitOnError)
+	connectionFlags(fs, &config)
+	addr := fs.String("addr", ":8080", "Address to listen on")
+	secret := fs.String("secret", os.Getenv("INGEST_WEBHOOK_SECRET"), "Shared secret expected in the X-Webhook-Secret header")
+	fs.Parse(args)
+
+	return withOrderBook(ctx, config, func(ob *orderbook.OrderBook) error {
+		webhook, err := api.NewOrderWebhook(ob, *secret)
+		if err != nil {
+			return err
+		}
+
+		mux := http.NewServeMux()
+		mux.Handle("/ingest/order", webhook)
+		mux.Handle("/debug/vars", expvar.Handler())
+
+		return listenAndServe(ctx, *addr, mux)
+	})
+}
+
+// checkExposure refuses to serve account data without a token on an address
+// reachable from other hosts; loopback addresses may go without one
+func checkExposure(addr, token string) error {
+	if token != "" {
+		return nil
+	}
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return fmt.Errorf("invalid address %q: %v", addr, err)
+	}
+	if host == "localhost" {
+		return nil
+	}
+	if ip := net.ParseIP(host); ip != nil && ip.IsLoopback() {
+		return nil
+	}
+	return fmt.Errorf("refusing to listen on %s without a token; set -token (env API_TOKEN) or bind to 127.0.0.1", addr)
+}
+
+// listenAndServe runs the server until ctx is cancelled, then shuts it down gracefully
+func listenAndServe(ctx context.Context, addr string, handler http.Handler) error {
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+
+	log.Printf("Listening on %s", addr)
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
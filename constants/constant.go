@@ -2,5 +2,29 @@ package constants
 
 var DB_NAME string = "AlgoTradingInfo"
 var ORDERBOOK_SCHEMA string = "dailyTradeInfo"
+var ORDERS_TIMESERIES_SCHEMA string = "orders"
+var TRADEBOOK_SCHEMA string = "tradeBook"
+var ROUND_TRIPS_SCHEMA string = "trades"
+var POSITIONS_SCHEMA string = "positions"
 var PROFITLOSS_SCHEMA string = "dailyProfitLossInfo"
+var EQUITY_CURVE_SCHEMA string = "equityCurve"
 var DAILY_SUMMARY_SCHEMA string = "dailySummary"
+var SYMBOL_SUMMARY_SCHEMA string = "symbol_summaries"
+var PERIOD_SUMMARY_SCHEMA string = "periodSummary"
+var PERFORMANCE_STATS_SCHEMA string = "performanceStats"
+var ORDER_AUDIT_SCHEMA string = "orderAuditLog"
+var IMPORT_EVENTS_SCHEMA string = "importEvents"
+var ARCHIVE_DB_NAME string = "AlgoTradingInfoArchive"
+var ARCHIVE_STATE_SCHEMA string = "archiveState"
+var SYMBOL_DAILY_ROLLUP_SCHEMA string = "symbolDailyRollup"
+var HOURLY_ROLLUP_SCHEMA string = "hourlyRollup"
+var MONTHLY_ROLLUP_SCHEMA string = "monthlyRollup"
+var LEDGER_SCHEMA string = "cashLedger"
+var TRADE_RISK_SCHEMA string = "tradeRisk"
+var INSTRUMENTS_SCHEMA string = "instruments"
+var PROCESSED_FILES_SCHEMA string = "processed_files"
+var IMPORT_CHECKPOINTS_SCHEMA string = "importCheckpoints"
+var QUARANTINE_SCHEMA string = "quarantine"
+var DATA_VERSION_SCHEMA string = "dataVersions"
+var MARKET_TIMEZONE string = "Asia/Kolkata"
+var DEFAULT_ACCOUNT string = "default"
@@ -1,19 +1,28 @@
 package main
 
 import (
+	"bytes"
 	"context"
-	"flag"
+	"crypto/rand"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
 	"log"
+	"net/http"
 	"os"
-	"os/signal"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
-	"profitLossAndTradeInfoToDB/constants"
 	orderbook "profitLossAndTradeInfoToDB/orderbooks"
+	"profitLossAndTradeInfoToDB/pkg/display"
+	"profitLossAndTradeInfoToDB/pkg/events"
+	"profitLossAndTradeInfoToDB/pkg/market"
 	"profitLossAndTradeInfoToDB/pkg/profitLossGraph"
+	"profitLossAndTradeInfoToDB/pkg/source"
 
 	"github.com/joho/godotenv"
 	"go.uber.org/zap"
@@ -21,105 +30,209 @@ import (
 
 // Config holds application configuration
 type Config struct {
+	// Backend is the database the default import run writes to: mongo, or a
+	// SQL dialect reached through DSN
+	Backend     string
+	DSN         string
 	MongoURI    string
-	CSVDir      string
-	ProcessDate string
+	Account     string
+	ReadOnly    bool
+	MongoTuning orderbook.ClientTuning
+	Writer      orderbook.WriterOptions
+	Retry       orderbook.RetryPolicy
+	ArchiveURI  string
+	ArchiveDB   string
+	// RollupProfile names the rollups maintained for the account, see orderbook.RollupProfiles
+	RollupProfile string
+	// ValidateSymbols checks imported symbols against the instrument master
+	ValidateSymbols bool
+	// DuplicatePolicy is skip, overwrite or version, see orderbook.DuplicatePolicy
+	DuplicatePolicy string
+	// Reimport is skip or warn, see orderbook.ReimportPolicy
+	Reimport string
+	// ParseMode is strict or lenient, see orderbook.ParseMode
+	ParseMode string
+	// Parser names the parser of orderbook files, empty to pick it by file name, see orderbook.ParserFor
+	Parser string
+	// Unfilled is tag or skip, see orderbook.UnfilledPolicy
+	Unfilled string
+	// ChargesSchedule is a JSON file of dated charge rates, empty for charges.DefaultSchedule
+	ChargesSchedule string
+	// Concurrency bounds the orderbook files of a day imported at once
+	Concurrency int
+	// AutoBackfill rebuilds summaries and rollups missing for existing orders before importing
+	AutoBackfill bool
+	// Source is "files" to import CSV files, or a broker to fetch from, see broker.Names
+	Source string
+	// BrokerConfig is a file of each account's broker and credentials, see broker.LoadConfig
+	BrokerConfig string
+	CSVDir       string
+	ProcessDate  string
+	// ProcessTo ends a range of days starting at ProcessDate; empty imports ProcessDate alone
+	ProcessTo string
+	// Inputs are explicit files or HTTP(S) URLs given as arguments; when set
+	// they replace the date-based lookup in CSVDir
+	Inputs      []string
+	HTTPHeaders http.Header
+	// Merge unions overlapping orderbook exports of a day instead of loading
+	// each one, resolving conflicts by SourcePriority
+	Merge          bool
+	SourcePriority []string
+	MergeTolerance time.Duration
 }
 
 func main() {
-	// Setup configuration
-	config := parseFlags()
-
-	// Create context with cancellation
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
-
-	// Handle graceful shutdown
-	shutdown := make(chan os.Signal, 1)
-	signal.Notify(shutdown, os.Interrupt)
-	go func() {
-		<-shutdown
-		log.Println("Shutting down gracefully...")
-		cancel()
-	}()
-
-	// Initialize OrderBook
-	ob, err := orderbook.NewOrderBook(ctx, config.MongoURI)
-	if err != nil {
-		log.Fatalf("Failed to initialize OrderBook: %v", err)
-	}
-	defer func() {
-		if err := ob.Close(ctx); err != nil {
-			log.Printf("Error closing MongoDB connection: %v", err)
+	args := os.Args[1:]
+	if len(args) > 0 {
+		if cmd, ok := commands[args[0]]; ok {
+			runCommand(cmd, args[1:])
+			return
 		}
-	}()
+	}
 
-	// Get MongoDB database instance from OrderBook
-	// Note: You'll need to expose the DB from OrderBook or create a new connection
-	mongoClient := ob.GetMongoClient()            // You'll need to add this method to OrderBook
-	db := mongoClient.Database(constants.DB_NAME) // Use the same database as OrderBook
+	// Without a command the arguments are ingest's, as before subcommands existed
+	runCommand(commands["ingest"], args)
+}
 
-	// Initialize ProfitLoss repository and service
-	plRepo, err := profitLossGraph.NewRepository(db)
-	if err != nil {
-		log.Fatalf("Failed to initialize ProfitLoss repository: %v", err)
+// envOrDefault returns the value of the environment variable or the fallback when unset
+func envOrDefault(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
 	}
+	return fallback
+}
 
-	prl, err := plRepo.GetProfitLossByDateRange(ctx, time.Now().AddDate(0, 0, -1), time.Now())
-	if err != nil {
-		log.Fatalf("Failed to get profit loss: %v", err)
+// envFloatOrDefault returns the environment variable parsed as a float, or the fallback when unset or invalid
+func envFloatOrDefault(key string, fallback float64) float64 {
+	if value, err := strconv.ParseFloat(os.Getenv(key), 64); err == nil {
+		return value
 	}
+	return fallback
+}
 
-	fmt.Println(prl)
-
-	plService := profitLossGraph.NewService(plRepo)
-
-	// Process files based on date
-	if err := processFiles(ctx, ob, plService, config); err != nil {
-		log.Fatalf("Failed to process files: %v", err)
+// envIntOrDefault returns the environment variable parsed as an int, or the fallback when unset or invalid
+func envIntOrDefault(key string, fallback int) int {
+	if value, err := strconv.Atoi(os.Getenv(key)); err == nil {
+		return value
 	}
-
-	// Get and display summary
-	// if err := displaySummary(ctx, ob, config); err != nil {
-	// 	log.Fatalf("Failed to display summary: %v", err)
-	// }
+	return fallback
 }
 
-func parseFlags() Config {
-	config := Config{}
+// envBoolOrDefault returns the environment variable parsed as a bool, or the fallback when unset or invalid
+func envBoolOrDefault(key string, fallback bool) bool {
+	if value, err := strconv.ParseBool(os.Getenv(key)); err == nil {
+		return value
+	}
+	return fallback
+}
 
-	flag.StringVar(&config.MongoURI, "mongo-uri", os.Getenv("MONGODB_CONNECTION_URL"),
-		"MongoDB connection string")
-	flag.StringVar(&config.CSVDir, "csv-dir", ".",
-		"Directory containing CSV files")
-	flag.StringVar(&config.ProcessDate, "date", time.Now().Format("2006-01-02"),
-		"Date to process (YYYY-MM-DD)")
+// newRunID returns a random identifier for this import run
+func newRunID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		log.Fatalf("Failed to generate import run ID: %v", err)
+	}
+	b[6] = (b[6] & 0x0f) | 0x40 // version 4
+	b[8] = (b[8] & 0x3f) | 0x80 // RFC 4122 variant
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
 
-	flag.Parse()
+// processDays returns the days an import covers: ProcessDate alone, or every
+// trading day from ProcessDate to ProcessTo inclusive
+func processDays(config Config) ([]time.Time, error) {
+	if config.ProcessTo == "" || config.ProcessTo == config.ProcessDate {
+		day, err := time.ParseInLocation("2006-01-02", config.ProcessDate, market.Location())
+		if err != nil {
+			return nil, fmt.Errorf("invalid date format: %v", err)
+		}
+		return []time.Time{day}, nil
+	}
 
-	return config
+	start, end, err := parseDateRange(config.ProcessDate, config.ProcessTo)
+	if err != nil {
+		return nil, err
+	}
+	var days []time.Time
+	for day := start; day.Before(end); day = day.AddDate(0, 0, 1) {
+		if market.IsTradingDay(day) {
+			days = append(days, day)
+		}
+	}
+	return days, nil
 }
 
 func processFiles(ctx context.Context, ob *orderbook.OrderBook, plService *profitLossGraph.Service, config Config) error {
-	// Parse the process date
-	processDate, err := time.Parse("2006-01-02", config.ProcessDate)
+	if len(config.Inputs) > 0 {
+		return processInputs(ctx, ob, plService, config)
+	}
+
+	days, err := processDays(config)
 	if err != nil {
-		return fmt.Errorf("invalid date format: %v", err)
+		return err
+	}
+	if len(days) > 1 {
+		log.Printf("Importing %d trading days from %s to %s", len(days), config.ProcessDate, config.ProcessTo)
+	}
+	var failedDays int
+	for _, day := range days {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err := processDay(ctx, ob, plService, config, day); err != nil {
+			log.Printf("%s: %v", day.Format("2006-01-02"), err)
+			failedDays++
+		}
 	}
 
+	if failedDays > 0 {
+		return fmt.Errorf("%d of %d days had failed inputs", failedDays, len(days))
+	}
+	return nil
+}
+
+// processDay imports the orderbook and profit/loss files of one day. A failed
+// file does not stop the day's other files; the failures are returned together.
+// A day without exports, such as one the account did not trade, imports nothing
+// and is not a failure.
+func processDay(ctx context.Context, ob *orderbook.OrderBook, plService *profitLossGraph.Service, config Config, processDate time.Time) error {
+	var failed []string
+
 	// Process orderbook files
 	if err := processOrderBookFiles(ctx, ob, config, processDate); err != nil {
-		fmt.Println("failed to process orderbook files: ", err)
+		failed = append(failed, fmt.Sprintf("orderbook files: %v", err))
+	}
+
+	// Process trade book files, which are optional
+	if err := processTradeBookFiles(ctx, ob, config, processDate); err != nil {
+		failed = append(failed, fmt.Sprintf("trade book files: %v", err))
 	}
 
 	// Process profit/loss file
-	if err := plService.ProcessDailyProfitLoss(ctx, processDate); err != nil {
-		fmt.Println("failed to process profit/loss file: ", err)
+	filename := profitLossFile(config, processDate)
+	if _, err := os.Stat(filename); errors.Is(err, fs.ErrNotExist) {
+		log.Printf("No profit/loss file for %s", processDate.Format("2006-01-02"))
+	} else {
+		err := trackImport(ctx, ob, filename, "profitLoss", func() error {
+			return importOnce(ctx, source.NewOpener(nil), ob, filename, "profitLoss", func(r io.Reader, _ string) error {
+				return plService.ProcessProfitLoss(ctx, r, filename)
+			})
+		})
+		if err != nil {
+			failed = append(failed, fmt.Sprintf("profit/loss file: %v", err))
+		}
 	}
 
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to process %s", strings.Join(failed, "; "))
+	}
 	return nil
 }
 
+// profitLossFile is the path of the day's profit/loss file in the CSV directory
+func profitLossFile(config Config, day time.Time) string {
+	return filepath.Join(config.CSVDir, profitLossGraph.GetFileNameForDate(day))
+}
+
 func processOrderBookFiles(ctx context.Context, ob *orderbook.OrderBook, config Config, processDate time.Time) error {
 	// Find CSV files for the specified date
 	pattern := fmt.Sprintf("orderbook_*%s*.csv", processDate.Format("02-01-2006"))
@@ -129,33 +242,49 @@ func processOrderBookFiles(ctx context.Context, ob *orderbook.OrderBook, config
 	}
 
 	if len(matches) == 0 {
-		return fmt.Errorf("no CSV files found for date %s", config.ProcessDate)
+		log.Printf("No orderbook files for %s", processDate.Format("2006-01-02"))
+		return nil
 	}
 
-	// Process each file
-	var wg sync.WaitGroup
+	opener := source.NewOpener(nil)
+	if config.Merge {
+		return loadMerged(ctx, opener, ob, config, matches)
+	}
+
+	// Process the files on a bounded pool of workers, each streaming its
+	// file through its own batch writer
+	files := make(chan string)
 	errorChan := make(chan error, len(matches))
 
+	var wg sync.WaitGroup
+	for i := 0; i < max(config.Concurrency, 1); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for filename := range files {
+				err := trackImport(ctx, ob, filename, "orders", func() error {
+					return importOnce(ctx, opener, ob, filename, "orders", func(r io.Reader, checksum string) error {
+						return loadOrders(ctx, ob, r, filename, checksum)
+					})
+				})
+				if err != nil {
+					errorChan <- fmt.Errorf("failed to process %s: %v", filename, err)
+				}
+			}
+		}()
+	}
+
 	for _, file := range matches {
 		// Skip profit/loss files
 		if filepath.Base(file)[:10] == "profitLoss" {
 			continue
 		}
-
-		wg.Add(1)
-		go func(filename string) {
-			defer wg.Done()
-
-			log.Printf("Processing orderbook file: %s", filename)
-			if err := ob.LoadCSVFile(ctx, filename); err != nil {
-				errorChan <- fmt.Errorf("failed to process %s: %v", filename, err)
-				return
-			}
-			log.Printf("Completed processing: %s", filename)
-		}(file)
+		files <- file
 	}
+	close(files)
 
-	// Wait for all goroutines to complete
+	// Wait for the workers to drain the files
 	wg.Wait()
 	close(errorChan)
 
@@ -169,30 +298,314 @@ func processOrderBookFiles(ctx context.Context, ob *orderbook.OrderBook, config
 	return nil
 }
 
-func displaySummary(ctx context.Context, ob *orderbook.OrderBook, config Config) error {
-	processDate, err := time.Parse("2006-01-02", config.ProcessDate)
+// processTradeBookFiles stores the day's trade book exports, the fills of its orders
+func processTradeBookFiles(ctx context.Context, ob *orderbook.OrderBook, config Config, processDate time.Time) error {
+	pattern := fmt.Sprintf("tradebook_*%s*.csv", processDate.Format("02-01-2006"))
+	matches, err := filepath.Glob(filepath.Join(config.CSVDir, pattern))
 	if err != nil {
-		return fmt.Errorf("invalid date format: %v", err)
+		return fmt.Errorf("failed to find CSV files: %v", err)
+	}
+
+	opener := source.NewOpener(nil)
+	for _, filename := range matches {
+		err := trackImport(ctx, ob, filename, "trades", func() error {
+			return importOnce(ctx, opener, ob, filename, "trades", func(r io.Reader, _ string) error {
+				return loadTradeBook(ctx, ob, r, filename)
+			})
+		})
+		if err != nil {
+			return fmt.Errorf("failed to process %s: %v", filename, err)
+		}
+	}
+	return nil
+}
+
+// inputKind classifies an input by its base name: "profitLoss" and
+// "tradebook" prefixes mark P&L data and trade books, anything else is orderbook data
+func inputKind(location string) string {
+	name := source.BaseName(location)
+	switch {
+	case strings.HasPrefix(name, "profitLoss"):
+		return "profitLoss"
+	case strings.HasPrefix(strings.ToLower(name), "tradebook"):
+		return "trades"
+	}
+	return "orders"
+}
+
+// processInputs ingests explicitly listed files and URLs, by their inputKind
+func processInputs(ctx context.Context, ob *orderbook.OrderBook, plService *profitLossGraph.Service, config Config) error {
+	opener := source.NewOpener(config.HTTPHeaders)
+
+	inputs := config.Inputs
+	if config.Merge {
+		var orderInputs, otherInputs []string
+		for _, location := range inputs {
+			if inputKind(location) == "orders" {
+				orderInputs = append(orderInputs, location)
+			} else {
+				otherInputs = append(otherInputs, location)
+			}
+		}
+		if err := loadMerged(ctx, opener, ob, config, orderInputs); err != nil {
+			return err
+		}
+		inputs = otherInputs
+	}
+
+	var failed int
+	for _, location := range inputs {
+		if err := processInput(ctx, opener, ob, plService, location); err != nil {
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d inputs failed", failed, len(inputs))
+	}
+	return nil
+}
+
+// loadMerged parses every orderbook export, unions them by source priority and
+// stores the merged set once, with each order's provenance recorded
+func loadMerged(ctx context.Context, opener *source.Opener, ob *orderbook.OrderBook, config Config, locations []string) error {
+	if len(locations) == 0 {
+		return nil
 	}
 
-	summary, err := ob.GetDailySummary(ctx, processDate)
+	bus := ob.Events()
+	var sets [][]orderbook.Order
+	var loaded []orderbook.ProcessedFile
+	for _, location := range locations {
+		bus.Publish(ctx, events.Event{Type: events.FileStarted, Account: ob.Account(), Source: location, Kind: "orders"})
+
+		data, err := readInput(ctx, opener, location)
+		if err != nil {
+			bus.Publish(ctx, events.Event{Type: events.ImportFailed, Account: ob.Account(), Source: location, Kind: "orders", Error: err.Error()})
+			return err
+		}
+		file := orderbook.ProcessedFile{Checksum: orderbook.Checksum(data), Source: location, Kind: "orders", Size: int64(len(data))}
+		if skip, err := alreadyImported(ctx, ob, file); err != nil {
+			return err
+		} else if skip {
+			continue
+		}
+
+		orders, err := ob.ParseCSV(ctx, bytes.NewReader(data), location)
+		if err != nil {
+			err = fmt.Errorf("failed to parse %s: %v", location, err)
+			bus.Publish(ctx, events.Event{Type: events.ImportFailed, Account: ob.Account(), Source: location, Kind: "orders", Error: err.Error()})
+			return err
+		}
+		log.Printf("Parsed %d orders from %s", len(orders), location)
+		sets = append(sets, orders)
+		loaded = append(loaded, file)
+	}
+	if len(sets) == 0 {
+		return nil
+	}
+
+	merged := orderbook.MergeOrders(sets, orderbook.MergeOptions{
+		Priority:      config.SourcePriority,
+		TimeTolerance: config.MergeTolerance,
+	})
+	log.Printf("Merged %d exports into %d orders", len(locations), len(merged))
+
+	if err := ob.InsertOrders(ctx, merged); err != nil {
+		for _, file := range loaded {
+			bus.Publish(ctx, events.Event{Type: events.ImportFailed, Account: ob.Account(), Source: file.Source, Kind: "orders", Error: err.Error()})
+		}
+		return err
+	}
+	for _, file := range loaded {
+		if err := ob.RecordProcessedFile(ctx, file); err != nil {
+			log.Printf("Failed to register %s as processed: %v", file.Source, err)
+		}
+		bus.Publish(ctx, events.Event{Type: events.FileCompleted, Account: ob.Account(), Source: file.Source, Kind: "orders"})
+	}
+	return nil
+}
+
+// readInput reads a file or URL whole, so its checksum is known before loading it
+func readInput(ctx context.Context, opener *source.Opener, location string) ([]byte, error) {
+	r, err := opener.Open(ctx, location)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", location, err)
+	}
+	return data, nil
+}
+
+// alreadyImported checks file against the processed files registry and
+// reports whether the reimport policy skips it
+func alreadyImported(ctx context.Context, ob *orderbook.OrderBook, file orderbook.ProcessedFile) (bool, error) {
+	seen, err := ob.ProcessedFile(ctx, file.Checksum)
+	if err != nil || seen == nil {
+		return false, err
+	}
+
+	if !ob.ReimportPolicy().Loads(seen) {
+		ob.Events().Publish(ctx, events.Event{
+			Type: events.FileSkipped, Account: ob.Account(), RunID: ob.RunID(), Source: file.Source, Kind: file.Kind, Checksum: file.Checksum,
+		})
+		return true, nil
+	}
+	log.Printf("Warning: %s has the same content as %s, imported %s (run %s); loading it again",
+		file.Source, seen.Source, display.Time(seen.ProcessedAt), seen.RunID)
+	return false, nil
+}
+
+// importOnce loads location through load unless its content was already
+// imported and the reimport policy skips it, then registers it as processed.
+// The input is read twice, once for its checksum and once to load it, so
+// large exports are streamed rather than held in memory; load gets the checksum.
+func importOnce(ctx context.Context, opener *source.Opener, ob *orderbook.OrderBook, location, kind string, load func(r io.Reader, checksum string) error) error {
+	input, err := openSeekable(ctx, opener, location)
+	if err != nil {
+		return err
+	}
+	defer input.Close()
+
+	checksum, size, err := orderbook.ChecksumReader(input)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %v", location, err)
+	}
+	file := orderbook.ProcessedFile{Checksum: checksum, Source: location, Kind: kind, Size: size}
+	if skip, err := alreadyImported(ctx, ob, file); err != nil || skip {
+		return err
+	}
+
+	if _, err := input.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to rewind %s: %v", location, err)
+	}
+	if err := load(input, checksum); err != nil {
+		return err
+	}
+	return ob.RecordProcessedFile(ctx, file)
+}
+
+// openSeekable opens location so it can be read more than once: local files
+// directly, downloads through a temporary copy removed on Close
+func openSeekable(ctx context.Context, opener *source.Opener, location string) (io.ReadSeekCloser, error) {
+	r, err := opener.Open(ctx, location)
+	if err != nil {
+		return nil, err
+	}
+	if file, ok := r.(*os.File); ok {
+		return file, nil
+	}
+	defer r.Close()
+
+	spool, err := os.CreateTemp("", "import-*.csv")
+	if err != nil {
+		return nil, fmt.Errorf("failed to buffer %s: %v", location, err)
+	}
+	spooled := spooledFile{spool}
+	if _, err := io.Copy(spool, r); err != nil {
+		spooled.Close()
+		return nil, fmt.Errorf("failed to read %s: %v", location, err)
+	}
+	return spooled, nil
+}
+
+// spooledFile is a temporary copy of a download, removed when closed
+type spooledFile struct {
+	*os.File
+}
+
+func (f spooledFile) Close() error {
+	err := f.File.Close()
+	os.Remove(f.Name())
+	return err
+}
+
+// loadOrders imports an orderbook file, resuming after the last row an
+// interrupted import of the same content committed
+func loadOrders(ctx context.Context, ob *orderbook.OrderBook, r io.Reader, location, checksum string) error {
+	checkpoint, err := ob.Checkpoint(ctx, checksum)
+	if err != nil {
+		return err
+	}
+	if checkpoint != nil {
+		log.Printf("Resuming %s after row %d, committed by run %s at %s",
+			location, checkpoint.Row, checkpoint.RunID, display.Time(checkpoint.UpdatedAt))
+	}
+	return ob.ResumeCSV(ctx, r, location, checksum)
+}
+
+// loadTradeBook stores a trade book export, logging the rows it skipped
+func loadTradeBook(ctx context.Context, ob *orderbook.OrderBook, r io.Reader, location string) error {
+	result, err := ob.LoadTradeBook(ctx, r, location)
 	if err != nil {
-		return fmt.Errorf("failed to get daily summary: %v", err)
+		return err
 	}
+	for _, skipped := range result.Skipped {
+		log.Printf("Skipped line %d of %s: %s", skipped.Row, location, skipped.Message)
+	}
+	log.Printf("Stored %d trades from %s", result.Stored, location)
+	return nil
+}
+
+func processInput(ctx context.Context, opener *source.Opener, ob *orderbook.OrderBook, plService *profitLossGraph.Service, location string) error {
+	kind := inputKind(location)
+	return trackImport(ctx, ob, location, kind, func() error {
+		return importOnce(ctx, opener, ob, location, kind, func(r io.Reader, checksum string) error {
+			switch kind {
+			case "profitLoss":
+				return plService.ProcessProfitLoss(ctx, r, location)
+			case "trades":
+				return loadTradeBook(ctx, ob, r, location)
+			}
+			return loadOrders(ctx, ob, r, location, checksum)
+		})
+	})
+}
+
+// trackImport runs fn, publishing the start and the completion or failure of importing location
+func trackImport(ctx context.Context, ob *orderbook.OrderBook, location, kind string, fn func() error) error {
+	bus := ob.Events()
+	event := events.Event{Account: ob.Account(), RunID: ob.RunID(), Source: location, Kind: kind}
 
-	// Display summary in a formatted table
-	fmt.Println("\nDaily Summary Report")
-	fmt.Println("===================")
-	fmt.Printf("Date: %s\n", summary.Date.Format("02-Jan-2006"))
-	fmt.Printf("Total Trades: %d\n", summary.TotalTrades)
-	fmt.Printf("Total Buy Quantity: %d\n", summary.TotalBuyQuantity)
-	fmt.Printf("Total Sell Quantity: %d\n", summary.TotalSellQuantity)
-	fmt.Printf("Unique Symbols: %d\n", summary.UniqueSymbols)
-	fmt.Printf("Last Updated: %s\n", summary.LastUpdated.Format("15:04:05"))
+	event.Type = events.FileStarted
+	bus.Publish(ctx, event)
 
+	if err := fn(); err != nil {
+		logRejected(err)
+		event.Type, event.Error = events.ImportFailed, err.Error()
+		bus.Publish(ctx, event)
+		return err
+	}
+
+	event.Type = events.FileCompleted
+	bus.Publish(ctx, event)
 	return nil
 }
 
+// maxRejectedLogged caps the rejected orders listed per file
+const maxRejectedLogged = 20
+
+// logRejected lists the orders the database rejected when err reports some
+func logRejected(err error) {
+	var partial *orderbook.PartialWriteError
+	if !errors.As(err, &partial) {
+		return
+	}
+
+	for i, failure := range partial.Failed {
+		if i == maxRejectedLogged {
+			log.Printf("  ... and %d more", len(partial.Failed)-i)
+			break
+		}
+		log.Printf("  row %d: %s %s rejected (code %d): %s",
+			failure.Row, failure.Symbol, display.Time(failure.Timestamp), failure.Code, failure.Message)
+	}
+}
+
 func init() {
 	// Load .env file
 	err := godotenv.Load("profitLossAndTradeBookToDB.env")
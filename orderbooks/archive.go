@@ -0,0 +1,166 @@
+package orderbook
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"profitLossAndTradeInfoToDB/constants"
+	"profitLossAndTradeInfoToDB/pkg/archive"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ArchiveOptions locates the cold-storage database. An empty MongoURI keeps
+// the archive on the hot cluster; an empty Database uses constants.ARCHIVE_DB_NAME.
+type ArchiveOptions struct {
+	MongoURI string
+	Database string
+}
+
+// openArchive connects the archive database and attaches its orders collection
+func (ob *OrderBook) openArchive(ctx context.Context, opts Options) error {
+	name := opts.Archive.Database
+	if name == "" {
+		name = constants.ARCHIVE_DB_NAME
+	}
+
+	client := ob.client
+	if opts.Archive.MongoURI != "" && opts.Archive.MongoURI != opts.MongoURI {
+		clientOpts, err := clientOptions(opts.Archive.MongoURI, opts.Client)
+		if err != nil {
+			return fmt.Errorf("invalid archive client options: %v", err)
+		}
+
+		client, err = mongo.Connect(ctx, clientOpts)
+		if err != nil {
+			return fmt.Errorf("failed to connect to archive MongoDB: %v", err)
+		}
+		if err := client.Ping(ctx, nil); err != nil {
+			client.Disconnect(ctx)
+			return fmt.Errorf("failed to ping archive database: %v", err)
+		}
+		ob.archiveClient = client
+	}
+
+	ob.archiveDB = client.Database(name)
+	ob.archiveOrders = ob.archiveDB.Collection(constants.ORDERBOOK_SCHEMA)
+	ob.archivePlan = archive.NewPlanner(ob.archiveDB, ob.account)
+
+	return nil
+}
+
+// ArchiveDatabase returns the cold-storage database
+func (ob *OrderBook) ArchiveDatabase() *mongo.Database {
+	return ob.archiveDB
+}
+
+// findOrders runs the filter against the hot collection and, when the range
+// starting at from crosses the archive boundary, against the archived part of
+// it too, returning the union sorted by timestamp
+func (ob *OrderBook) findOrders(ctx context.Context, filter bson.M, from time.Time) ([]Order, error) {
+	orders, err := ob.queryOrders(ctx, ob.ordersCollection, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	if ob.archiveOrders == nil {
+		return orders, nil
+	}
+	timestamp, _ := filter["timestamp"].(bson.M)
+	archived, ok, err := ob.archivePlan.ArchiveFilter(ctx, from, timestamp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to plan archive query: %v", err)
+	}
+	if !ok {
+		return orders, nil
+	}
+
+	archiveFilter := bson.M{}
+	for key, value := range filter {
+		archiveFilter[key] = value
+	}
+	archiveFilter["timestamp"] = archived
+
+	older, err := ob.queryOrders(ctx, ob.archiveOrders, archiveFilter)
+	if err != nil {
+		return nil, err
+	}
+
+	// An order copied to the archive but not yet deleted from the hot
+	// collection is found in both
+	seen := make(map[primitive.ObjectID]bool, len(orders))
+	for _, order := range orders {
+		seen[order.ID] = true
+	}
+	for _, order := range older {
+		if !seen[order.ID] {
+			orders = append(orders, order)
+		}
+	}
+
+	sort.SliceStable(orders, func(i, j int) bool {
+		return orders[i].Timestamp.Before(orders[j].Timestamp)
+	})
+	return orders, nil
+}
+
+// distinctSymbols adds the symbols of the non-voided, filled orders in
+// [from, to) to symbols, from the archive too when the range reaches into it
+func (ob *OrderBook) distinctSymbols(ctx context.Context, from, to time.Time, symbols map[string]bool) error {
+	filter := bson.M{
+		"account":      ob.account,
+		"voided":       bson.M{"$ne": true},
+		"order_status": filledStatus,
+		"timestamp":    bson.M{"$gte": from, "$lt": to},
+	}
+	collections := []*mongo.Collection{ob.ordersCollection}
+	filters := []bson.M{filter}
+
+	if ob.archiveOrders != nil {
+		archived, ok, err := ob.archivePlan.ArchiveFilter(ctx, from, filter["timestamp"].(bson.M))
+		if err != nil {
+			return fmt.Errorf("failed to plan archive query: %v", err)
+		}
+		if ok {
+			archiveFilter := bson.M{}
+			for key, value := range filter {
+				archiveFilter[key] = value
+			}
+			archiveFilter["timestamp"] = archived
+			collections = append(collections, ob.archiveOrders)
+			filters = append(filters, archiveFilter)
+		}
+	}
+
+	for i, collection := range collections {
+		distinct, err := collection.Distinct(ctx, "symbol", filters[i])
+		if err != nil {
+			return fmt.Errorf("failed to list symbols: %v", err)
+		}
+		for _, symbol := range distinct {
+			if s, ok := symbol.(string); ok {
+				symbols[s] = true
+			}
+		}
+	}
+	return nil
+}
+
+func (ob *OrderBook) queryOrders(ctx context.Context, collection *mongo.Collection, filter bson.M) ([]Order, error) {
+	cursor, err := collection.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "timestamp", Value: 1}}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query orders: %v", err)
+	}
+	defer cursor.Close(ctx)
+
+	var orders []Order
+	if err := cursor.All(ctx, &orders); err != nil {
+		return nil, fmt.Errorf("failed to decode orders: %v", err)
+	}
+	return orders, nil
+}
@@ -0,0 +1,130 @@
+package orderbook
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"profitLossAndTradeInfoToDB/pkg/market"
+	"profitLossAndTradeInfoToDB/pkg/money"
+)
+
+// calendarLevels is the number of shades on each side of zero, as on a
+// contribution graph
+const calendarLevels = 4
+
+// CalendarDay is one cell of a month's P&L calendar. Level shades the cell:
+// 1 to 4 for profit and -1 to -4 for loss, relative to the month's largest
+// move, and 0 for days without trades or that broke even.
+type CalendarDay struct {
+	Date       string      `json:"date"`    // YYYY-MM-DD, market time
+	Weekday    int         `json:"weekday"` // 0 is Monday
+	Week       int         `json:"week"`    // Row of the month grid, from 0
+	TradingDay bool        `json:"trading_day"`
+	Trades     int32       `json:"trades"`
+	NetPnL     money.Paise `json:"net_pnl"`
+	Level      int         `json:"level"`
+}
+
+// CalendarMonth is the net P&L of every day of a month
+type CalendarMonth struct {
+	Month       string        `json:"month"` // YYYY-MM
+	Days        []CalendarDay `json:"days"`
+	NetPnL      money.Paise   `json:"net_pnl"`
+	TradingDays int           `json:"trading_days"`
+	ActiveDays  int           `json:"active_days"` // Days with trades
+	GreenDays   int           `json:"green_days"`
+	RedDays     int           `json:"red_days"`
+	BestDay     *CalendarDay  `json:"best_day,omitempty"`
+	WorstDay    *CalendarDay  `json:"worst_day,omitempty"`
+}
+
+// BuildCalendar lays the summaries falling in the month containing month out
+// as a calendar; summaries outside it are ignored
+func BuildCalendar(month time.Time, summaries []DailySummary) CalendarMonth {
+	first := PeriodMonth.Start(month)
+	next := first.AddDate(0, 1, 0)
+
+	byDay := make(map[string]DailySummary, len(summaries))
+	for _, s := range summaries {
+		byDay[s.Date.In(market.Location()).Format("2006-01-02")] = s
+	}
+
+	calendar := CalendarMonth{Month: first.Format("2006-01")}
+	offset := mondayIndex(first)
+	var largest money.Paise
+	for day := first; day.Before(next); day = day.AddDate(0, 0, 1) {
+		cell := CalendarDay{
+			Date:       day.Format("2006-01-02"),
+			Weekday:    mondayIndex(day),
+			Week:       (offset + day.Day() - 1) / 7,
+			TradingDay: market.IsTradingDay(day),
+		}
+		if cell.TradingDay {
+			calendar.TradingDays++
+		}
+		if s, ok := byDay[cell.Date]; ok && s.TotalTrades > 0 {
+			cell.Trades = s.TotalTrades
+			cell.NetPnL = s.NetPnL
+			calendar.NetPnL += s.NetPnL
+			if abs := max(s.NetPnL, -s.NetPnL); abs > largest {
+				largest = abs
+			}
+		}
+		calendar.Days = append(calendar.Days, cell)
+	}
+
+	for i := range calendar.Days {
+		cell := &calendar.Days[i]
+		if cell.Trades == 0 {
+			continue
+		}
+		calendar.ActiveDays++
+		switch {
+		case cell.NetPnL > 0:
+			calendar.GreenDays++
+		case cell.NetPnL < 0:
+			calendar.RedDays++
+		}
+		cell.Level = calendarLevel(cell.NetPnL, largest)
+
+		if calendar.BestDay == nil || cell.NetPnL > calendar.BestDay.NetPnL {
+			calendar.BestDay = cell
+		}
+		if calendar.WorstDay == nil || cell.NetPnL < calendar.WorstDay.NetPnL {
+			calendar.WorstDay = cell
+		}
+	}
+	return calendar
+}
+
+// Calendar returns the P&L calendar of the month containing month
+func (ob *OrderBook) Calendar(ctx context.Context, month time.Time) (CalendarMonth, error) {
+	first := PeriodMonth.Start(month)
+	summaries, err := ob.GetDailySummaries(ctx, first, first.AddDate(0, 1, 0))
+	if err != nil {
+		return CalendarMonth{}, err
+	}
+	return BuildCalendar(first, summaries), nil
+}
+
+// calendarLevel shades pnl against the largest absolute P&L of the month;
+// every day that moved gets at least one shade
+func calendarLevel(pnl, largest money.Paise) int {
+	if pnl == 0 || largest == 0 {
+		return 0
+	}
+	level := int(math.Ceil(math.Abs(pnl.Rupees()) / largest.Rupees() * calendarLevels))
+	if level > calendarLevels {
+		level = calendarLevels
+	}
+	if pnl < 0 {
+		return -level
+	}
+	return level
+}
+
+// mondayIndex numbers the weekdays from Monday, 0, to Sunday, 6
+func mondayIndex(t time.Time) int {
+	return (int(t.Weekday()) + 6) % 7
+}
@@ -0,0 +1,149 @@
+package orderbook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Checkpoint is how far an interrupted import of a file got: every row up to
+// Row was written. It is removed once the file is fully imported.
+type Checkpoint struct {
+	Account   string    `bson:"account" json:"account"`
+	Checksum  string    `bson:"checksum" json:"checksum"`
+	Source    string    `bson:"source" json:"source"`
+	Row       int       `bson:"row" json:"row"` // Last CSV line committed, the header being line 1
+	RunID     string    `bson:"run_id,omitempty" json:"run_id,omitempty"`
+	UpdatedAt time.Time `bson:"updated_at" json:"updated_at"`
+}
+
+// Checkpoint returns the resume point of a file content, or nil when no import of it was interrupted
+func (ob *OrderBook) Checkpoint(ctx context.Context, checksum string) (*Checkpoint, error) {
+	var checkpoint Checkpoint
+	err := ob.checkpoints.FindOne(ctx, bson.M{"account": ob.account, "checksum": checksum}).Decode(&checkpoint)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up import checkpoint: %v", err)
+	}
+	return &checkpoint, nil
+}
+
+func (ob *OrderBook) saveCheckpoint(ctx context.Context, checkpoint Checkpoint) error {
+	checkpoint.Account = ob.account
+	checkpoint.RunID = ob.RunID()
+	checkpoint.UpdatedAt = time.Now()
+
+	_, err := ob.checkpoints.ReplaceOne(ctx,
+		bson.M{"account": ob.account, "checksum": checkpoint.Checksum},
+		checkpoint,
+		options.Replace().SetUpsert(true),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save import checkpoint: %v", err)
+	}
+	return nil
+}
+
+func (ob *OrderBook) clearCheckpoint(ctx context.Context, checksum string) error {
+	if _, err := ob.checkpoints.DeleteOne(ctx, bson.M{"account": ob.account, "checksum": checksum}); err != nil {
+		return fmt.Errorf("failed to clear import checkpoint: %v", err)
+	}
+	return nil
+}
+
+// ResumeCSV loads orders like LoadCSV, checkpointing each written batch under
+// the checksum of the data. When an earlier import of the same content was
+// interrupted, the rows it committed are skipped rather than stored again.
+func (ob *OrderBook) ResumeCSV(ctx context.Context, r io.Reader, source, checksum string) error {
+	if err := ob.checkWritable(); err != nil {
+		return err
+	}
+
+	checkpoint, err := ob.Checkpoint(ctx, checksum)
+	if err != nil {
+		return err
+	}
+	if checkpoint == nil {
+		checkpoint = &Checkpoint{Checksum: checksum}
+	}
+	checkpoint.Source = source
+
+	writer := ob.newBatchWriter(ctx, source)
+	writer.checkpoint = newCheckpointer(ob, *checkpoint)
+
+	// Days of skipped rows are still recomputed: the interrupted run may not have got that far
+	skip := checkpoint.Row
+	emit := func(order Order) error {
+		if order.row <= skip {
+			writer.touch(order.Timestamp)
+			return nil
+		}
+		return writer.Add(order)
+	}
+	reject := ob.rejecter(ctx, source)
+	if quarantine := reject; quarantine != nil {
+		reject = func(row int, header, record []string, err error) error {
+			if row <= skip {
+				return nil // quarantined by the interrupted run
+			}
+			return quarantine(row, header, record, err)
+		}
+	}
+	if err := ob.parseCSV(r, source, emit, reject); err != nil {
+		writer.Abort(ctx)
+		return err
+	}
+
+	if err := writer.Close(ctx); err != nil {
+		return err
+	}
+	return ob.clearCheckpoint(ctx, checksum)
+}
+
+// checkpointer advances a file's checkpoint as batches finish. Workers finish
+// batches out of order, so the checkpoint only moves past a batch once every
+// batch before it was written in full.
+type checkpointer struct {
+	ob *OrderBook
+
+	mu         sync.Mutex
+	checkpoint Checkpoint
+	next       int         // Sequence number of the oldest batch not yet written
+	finished   map[int]int // Last row of batches written ahead of next
+}
+
+func newCheckpointer(ob *OrderBook, checkpoint Checkpoint) *checkpointer {
+	return &checkpointer{ob: ob, checkpoint: checkpoint, finished: map[int]int{}}
+}
+
+// done records that batch seq, ending at lastRow, was written in full
+func (c *checkpointer) done(ctx context.Context, seq, lastRow int) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.finished[seq] = lastRow
+	advanced := false
+	for {
+		row, ok := c.finished[c.next]
+		if !ok {
+			break
+		}
+		delete(c.finished, c.next)
+		c.next++
+		c.checkpoint.Row = row
+		advanced = true
+	}
+	if !advanced {
+		return nil
+	}
+	return c.ob.saveCheckpoint(ctx, c.checkpoint)
+}
@@ -0,0 +1,76 @@
+package orderbook
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readconcern"
+	"go.mongodb.org/mongo-driver/mongo/writeconcern"
+)
+
+// ClientTuning holds optional MongoDB client settings; zero values keep the driver defaults
+type ClientTuning struct {
+	MaxPoolSize            uint64
+	MinPoolSize            uint64
+	RetryWrites            *bool
+	ServerSelectionTimeout time.Duration
+	Compressors            []string // snappy, zstd and/or zlib, in order of preference
+	ReadConcern            string   // local, available, majority, linearizable or snapshot
+	WriteConcern           string   // "majority" or a number of nodes
+}
+
+var validCompressors = map[string]bool{"snappy": true, "zstd": true, "zlib": true}
+
+var validReadConcerns = map[string]bool{
+	"local": true, "available": true, "majority": true, "linearizable": true, "snapshot": true,
+}
+
+// clientOptions builds the driver options for the URI with the tuning applied on top
+func clientOptions(mongoURI string, tuning ClientTuning) (*options.ClientOptions, error) {
+	opts := options.Client().ApplyURI(mongoURI)
+
+	if tuning.MaxPoolSize > 0 {
+		opts.SetMaxPoolSize(tuning.MaxPoolSize)
+	}
+	if tuning.MinPoolSize > 0 {
+		opts.SetMinPoolSize(tuning.MinPoolSize)
+	}
+	if tuning.RetryWrites != nil {
+		opts.SetRetryWrites(*tuning.RetryWrites)
+	}
+	if tuning.ServerSelectionTimeout > 0 {
+		opts.SetServerSelectionTimeout(tuning.ServerSelectionTimeout)
+	}
+
+	if len(tuning.Compressors) > 0 {
+		for _, compressor := range tuning.Compressors {
+			if !validCompressors[compressor] {
+				return nil, fmt.Errorf("unsupported compressor %q", compressor)
+			}
+		}
+		opts.SetCompressors(tuning.Compressors)
+	}
+
+	if tuning.ReadConcern != "" {
+		if !validReadConcerns[tuning.ReadConcern] {
+			return nil, fmt.Errorf("unsupported read concern %q", tuning.ReadConcern)
+		}
+		opts.SetReadConcern(&readconcern.ReadConcern{Level: tuning.ReadConcern})
+	}
+
+	if tuning.WriteConcern != "" {
+		wc := &writeconcern.WriteConcern{W: tuning.WriteConcern}
+		if tuning.WriteConcern != "majority" {
+			nodes, err := strconv.Atoi(tuning.WriteConcern)
+			if err != nil || nodes < 0 {
+				return nil, fmt.Errorf("write concern must be \"majority\" or a node count, got %q", tuning.WriteConcern)
+			}
+			wc.W = nodes
+		}
+		opts.SetWriteConcern(wc)
+	}
+
+	return opts, nil
+}
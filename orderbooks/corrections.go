@@ -0,0 +1,143 @@
+package orderbook
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"profitLossAndTradeInfoToDB/pkg/market"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// OrderCorrection holds the fields to overwrite on an order; nil fields are left unchanged
+type OrderCorrection struct {
+	Quantity        *float64
+	AveragePrice    *float64
+	TransactionType *string
+}
+
+// OrderAuditEntry records a single void or correction applied to an order
+type OrderAuditEntry struct {
+	OrderID primitive.ObjectID `bson:"order_id" json:"order_id"`
+	Account string             `bson:"account" json:"account"`
+	Action  string             `bson:"action" json:"action"`
+	Reason  string             `bson:"reason" json:"reason"`
+	Before  bson.M             `bson:"before" json:"before"`
+	After   bson.M             `bson:"after" json:"after"`
+	At      time.Time          `bson:"at" json:"at"`
+}
+
+// Audit actions
+const (
+	AuditActionVoid    = "void"
+	AuditActionCorrect = "correct"
+)
+
+// VoidOrder soft-deletes an order so it no longer counts towards summaries
+func (ob *OrderBook) VoidOrder(ctx context.Context, orderID string, reason string) error {
+	after := bson.M{"voided": true, "void_reason": reason}
+	return ob.amendOrder(ctx, orderID, AuditActionVoid, reason, after)
+}
+
+// CorrectOrder overwrites the given fields of an order, e.g. a wrong quantity in the broker file
+func (ob *OrderBook) CorrectOrder(ctx context.Context, orderID string, correction OrderCorrection, reason string) error {
+	after := bson.M{}
+	if correction.Quantity != nil {
+		after["quantity"] = *correction.Quantity
+	}
+	if correction.AveragePrice != nil {
+		after["average_price"] = *correction.AveragePrice
+	}
+	if correction.TransactionType != nil {
+		after["transaction_type"] = *correction.TransactionType
+	}
+
+	if len(after) == 0 {
+		return fmt.Errorf("correction for order %s changes nothing", orderID)
+	}
+
+	return ob.amendOrder(ctx, orderID, AuditActionCorrect, reason, after)
+}
+
+// amendOrder applies the update, writes the audit entry and recomputes the order's daily summary and rollups
+func (ob *OrderBook) amendOrder(ctx context.Context, orderID, action, reason string, after bson.M) error {
+	if err := ob.checkWritable(); err != nil {
+		return err
+	}
+
+	id, err := primitive.ObjectIDFromHex(orderID)
+	if err != nil {
+		return fmt.Errorf("invalid order ID %q: %v", orderID, err)
+	}
+
+	filter := bson.M{"_id": id, "account": ob.account}
+
+	var order bson.M
+	if err := ob.ordersCollection.FindOne(ctx, filter).Decode(&order); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return fmt.Errorf("order %s not found", orderID)
+		}
+		return fmt.Errorf("failed to load order %s: %v", orderID, err)
+	}
+
+	before := bson.M{}
+	for field := range after {
+		before[field] = order[field]
+	}
+
+	timestamp, ok := order["timestamp"].(primitive.DateTime)
+	if !ok {
+		return fmt.Errorf("order %s has no timestamp", orderID)
+	}
+	// The summary is keyed by the market day, whatever the host's zone
+	day := market.DayStart(timestamp.Time())
+
+	// The audit entry is written first, so an amended order always has one;
+	// it is withdrawn again if the order cannot be updated
+	entry := OrderAuditEntry{
+		OrderID: id,
+		Account: ob.account,
+		Action:  action,
+		Reason:  reason,
+		Before:  before,
+		After:   after,
+		At:      time.Now(),
+	}
+	result, err := ob.auditCollection.InsertOne(ctx, entry)
+	if err != nil {
+		return fmt.Errorf("failed to write audit entry for order %s: %v", orderID, err)
+	}
+
+	if _, err := ob.ordersCollection.UpdateOne(ctx, filter, bson.M{"$set": after}); err != nil {
+		if _, undoErr := ob.auditCollection.DeleteOne(ctx, bson.M{"_id": result.InsertedID}); undoErr != nil {
+			return fmt.Errorf("failed to update order %s: %v (and to withdraw its audit entry: %v)", orderID, err, undoErr)
+		}
+		return fmt.Errorf("failed to update order %s: %v", orderID, err)
+	}
+
+	return ob.invalidateDates(ctx, []time.Time{day})
+}
+
+// GetOrderAuditTrail returns the audit entries of an order, oldest first
+func (ob *OrderBook) GetOrderAuditTrail(ctx context.Context, orderID string) ([]OrderAuditEntry, error) {
+	id, err := primitive.ObjectIDFromHex(orderID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid order ID %q: %v", orderID, err)
+	}
+
+	cursor, err := ob.auditCollection.Find(ctx, bson.M{"order_id": id, "account": ob.account})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query audit trail: %v", err)
+	}
+	defer cursor.Close(ctx)
+
+	var entries []OrderAuditEntry
+	if err := cursor.All(ctx, &entries); err != nil {
+		return nil, fmt.Errorf("failed to decode audit trail: %v", err)
+	}
+
+	return entries, nil
+}
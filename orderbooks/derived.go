@@ -0,0 +1,152 @@
+package orderbook
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"profitLossAndTradeInfoToDB/pkg/market"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// DerivedGap describes derived collections that are empty although the
+// account has orders, e.g. after upgrading from a version that did not
+// maintain them
+type DerivedGap struct {
+	Summaries bool     // No daily summaries at all
+	Rollups   []string // Maintained rollups, period summaries or performance stats without any document
+}
+
+// Empty reports whether nothing is missing
+func (g DerivedGap) Empty() bool {
+	return !g.Summaries && len(g.Rollups) == 0
+}
+
+// CheckDerived finds derived collections left empty while orders exist
+func (ob *OrderBook) CheckDerived(ctx context.Context) (DerivedGap, error) {
+	var gap DerivedGap
+
+	hasOrders, err := ob.hasOrders(ctx)
+	if err != nil || !hasOrders {
+		return gap, err
+	}
+
+	account := bson.M{"account": ob.account}
+	if gap.Summaries, err = isEmpty(ctx, ob.summaryCollection, account); err != nil {
+		return gap, err
+	}
+
+	db := ob.ordersCollection.Database()
+	for _, kind := range ob.rollups {
+		empty, err := isEmpty(ctx, db.Collection(kind.Collection), account)
+		if err != nil {
+			return gap, err
+		}
+		if empty {
+			gap.Rollups = append(gap.Rollups, kind.Name)
+		}
+	}
+	empty, err := isEmpty(ctx, ob.periodSummaries, account)
+	if err != nil {
+		return gap, err
+	}
+	if empty {
+		gap.Rollups = append(gap.Rollups, "period summaries")
+	}
+
+	// Accounts without closed trades have no stats to build
+	noStats, err := isEmpty(ctx, ob.performance, account)
+	if err != nil {
+		return gap, err
+	}
+	noTrades, err := isEmpty(ctx, ob.roundTrips, account)
+	if err != nil {
+		return gap, err
+	}
+	if noStats && !noTrades {
+		gap.Rollups = append(gap.Rollups, "performance stats")
+	}
+	return gap, nil
+}
+
+// hasOrders reports whether the account has any order, hot or archived
+func (ob *OrderBook) hasOrders(ctx context.Context) (bool, error) {
+	for _, collection := range []*mongo.Collection{ob.ordersCollection, ob.archiveOrders} {
+		if collection == nil {
+			continue
+		}
+		empty, err := isEmpty(ctx, collection, bson.M{"account": ob.account})
+		if err != nil || !empty {
+			return !empty, err
+		}
+	}
+	return false, nil
+}
+
+func isEmpty(ctx context.Context, collection *mongo.Collection, filter bson.M) (bool, error) {
+	n, err := collection.CountDocuments(ctx, filter, options.Count().SetLimit(1))
+	if err != nil {
+		return false, fmt.Errorf("failed to count %s: %v", collection.Name(), err)
+	}
+	return n == 0, nil
+}
+
+// BackfillDerived recomputes the daily summary of every day with orders and
+// the rollups spanning them, and returns the number of days
+func (ob *OrderBook) BackfillDerived(ctx context.Context) (int, error) {
+	if err := ob.checkWritable(); err != nil {
+		return 0, err
+	}
+
+	var dates []time.Time
+	for _, collection := range []*mongo.Collection{ob.ordersCollection, ob.archiveOrders} {
+		if collection == nil {
+			continue
+		}
+		days, err := orderDays(ctx, collection, ob.account)
+		if err != nil {
+			return 0, err
+		}
+		dates = append(dates, days...)
+	}
+
+	days := distinctDays(dates)
+	if err := ob.recomputeDays(ctx, days); err != nil {
+		return 0, err
+	}
+	return len(days), nil
+}
+
+// orderDays returns the market days with at least one order in collection
+func orderDays(ctx context.Context, collection *mongo.Collection, account string) ([]time.Time, error) {
+	pipeline := bson.A{
+		bson.M{"$match": bson.M{"account": account}},
+		bson.M{"$group": bson.M{"_id": bson.M{"$dateTrunc": bson.M{
+			"date":     "$timestamp",
+			"unit":     "day",
+			"timezone": market.Timezone(),
+		}}}},
+	}
+
+	cursor, err := collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list order days: %v", err)
+	}
+	defer cursor.Close(ctx)
+
+	var rows []struct {
+		Day time.Time `bson:"_id"`
+	}
+	if err := cursor.All(ctx, &rows); err != nil {
+		return nil, fmt.Errorf("failed to decode order days: %v", err)
+	}
+
+	days := make([]time.Time, len(rows))
+	for i, row := range rows {
+		days[i] = row.Day.In(market.Location())
+	}
+	return days, nil
+}
@@ -0,0 +1,384 @@
+package orderbook
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"profitLossAndTradeInfoToDB/pkg/events"
+	"profitLossAndTradeInfoToDB/pkg/market"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// DuplicatePolicy decides what happens to an imported order whose dedup key is already stored
+type DuplicatePolicy string
+
+const (
+	DuplicateSkip      DuplicatePolicy = "skip"      // Keep the stored copy, drop the incoming one
+	DuplicateOverwrite DuplicatePolicy = "overwrite" // Replace the stored copy with the incoming one
+	// DuplicateVersion keeps both, numbering the incoming one as the next
+	// version. Summaries and reports count every stored version, so it suits
+	// auditing repeated exports rather than accounting.
+	DuplicateVersion DuplicatePolicy = "version"
+)
+
+// ParseDuplicatePolicy validates a policy name; empty selects DuplicateSkip
+func ParseDuplicatePolicy(name string) (DuplicatePolicy, error) {
+	switch policy := DuplicatePolicy(name); policy {
+	case "":
+		return DuplicateSkip, nil
+	case DuplicateSkip, DuplicateOverwrite, DuplicateVersion:
+		return policy, nil
+	}
+	return "", fmt.Errorf("unknown duplicate policy %q, expected skip, overwrite or version", name)
+}
+
+// Outcomes of the duplicate policy, counted per imported file
+const (
+	OutcomeInserted    = "inserted"
+	OutcomeSkipped     = "skipped"
+	OutcomeOverwritten = "overwritten"
+	OutcomeVersioned   = "versioned"
+	OutcomeUpdated     = "status-updated" // A stored order in progress took the incoming order's state
+	OutcomeUnfilled    = "unfilled"       // Dropped as it never traded, see UnfilledSkip
+)
+
+// dedupKey identifies the same fill across imports: account, time, symbol,
+// side, quantity and price. Orders with a broker or exchange order ID are
+// identified by it in place of time, symbol and side, which exports of the
+// same order may write differently.
+func dedupKey(order Order) string {
+	identity := []string{
+		strconv.FormatInt(order.Timestamp.UnixNano(), 10),
+		order.Symbol,
+		order.TransactionType,
+	}
+	if key := order.orderKey(); key != "" {
+		identity = []string{"order", key}
+	}
+
+	h := sha1.New()
+	for _, part := range append(append([]string{order.Account}, identity...),
+		strconv.FormatFloat(order.Quantity, 'f', -1, 64), // Whole quantities as before, e.g. 50
+		strconv.FormatFloat(order.AveragePrice, 'f', -1, 64),
+	) {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// storedCopy is the latest stored version of a dedup key and the run that
+// first stored it
+type storedCopy struct {
+	id      primitive.ObjectID
+	version int32
+	runID   string
+}
+
+// duplicateResolver applies the policy to the orders of one import. Keys seen
+// earlier in the same import count as stored, so repeated rows in a file are
+// treated like repeats across files. An incoming order whose stored copy is
+// still in progress, e.g. OPEN in an earlier export, updates that copy
+// instead, whatever the policy, so one document holds the order's history.
+// Orders that never traded are tagged or dropped by the UnfilledPolicy.
+type duplicateResolver struct {
+	ob       *OrderBook
+	policy   DuplicatePolicy
+	known    map[string]storedCopy
+	open     map[string]Order // Stored orders in progress, by orderKey
+	looked   map[string]bool  // Order keys already looked up
+	outcomes map[string]int
+}
+
+func (ob *OrderBook) newDuplicateResolver() *duplicateResolver {
+	return &duplicateResolver{
+		ob:       ob,
+		policy:   ob.duplicates,
+		known:    map[string]storedCopy{},
+		open:     map[string]Order{},
+		looked:   map[string]bool{},
+		outcomes: map[string]int{},
+	}
+}
+
+// resolve returns the writes for a batch and the orders they store. Only the
+// hot collection is checked; archived orders are not matched.
+func (r *duplicateResolver) resolve(ctx context.Context, batch []Order) ([]mongo.WriteModel, []Order, error) {
+	if err := r.lookup(ctx, batch); err != nil {
+		return nil, nil, err
+	}
+	models, written := r.apply(batch)
+	return models, written, nil
+}
+
+// apply decides the write of each order in batch against the stored copies
+// looked up so far, and counts them as stored for the rest of the import
+func (r *duplicateResolver) apply(batch []Order) ([]mongo.WriteModel, []Order) {
+	var models []mongo.WriteModel
+	var written []Order
+	for _, order := range batch {
+		stored, exists := r.known[order.DedupKey]
+		open, inProgress := r.open[order.orderKey()]
+		if !order.Filled() && r.ob.unfilled == UnfilledSkip && !inProgress {
+			r.outcomes[OutcomeUnfilled]++
+			continue
+		}
+		tagUnfilled(&order)
+
+		switch {
+		case !exists && inProgress:
+			order.ID = open.ID
+			order.Version = open.Version
+			updated := open
+			updateStatus(&updated, order)
+			// The order stays with the run that stored it, so purging a
+			// later run that only updated its status leaves it in place
+			updated.Source, updated.Account = order.Source, order.Account
+			tagUnfilled(&updated)
+			order = updated
+			models = append(models, mongo.NewReplaceOneModel().SetFilter(bson.M{"_id": open.ID}).SetReplacement(order))
+			r.outcomes[OutcomeUpdated]++
+		case !exists:
+			order.ID = primitive.NewObjectID()
+			order.Version = 1
+			models = append(models, insertModel(order))
+			r.outcomes[OutcomeInserted]++
+		case r.policy == DuplicateSkip:
+			r.outcomes[OutcomeSkipped]++
+			continue
+		case r.policy == DuplicateOverwrite:
+			order.ID = stored.id
+			order.Version = stored.version
+			if stored.runID != "" {
+				order.ImportRunID = stored.runID
+			}
+			models = append(models, mongo.NewReplaceOneModel().SetFilter(bson.M{"_id": stored.id}).SetReplacement(order))
+			r.outcomes[OutcomeOverwritten]++
+		default:
+			order.ID = primitive.NewObjectID()
+			order.Version = stored.version + 1
+			models = append(models, insertModel(order))
+			r.outcomes[OutcomeVersioned]++
+		}
+
+		r.known[order.DedupKey] = storedCopy{id: order.ID, version: order.Version, runID: order.ImportRunID}
+		if key := order.orderKey(); key != "" {
+			if order.InProgress() {
+				r.open[key] = order
+			} else {
+				delete(r.open, key)
+			}
+		}
+		written = append(written, order)
+	}
+
+	return models, written
+}
+
+// insertModel stores order unless its dedup key and version are already
+// stored, e.g. by a run that died halfway through the same file. The upsert
+// matches on the unique account_dedup_key_version index, so concurrent
+// imports cannot both insert the copy either.
+func insertModel(order Order) mongo.WriteModel {
+	return mongo.NewUpdateOneModel().
+		SetFilter(bson.M{"account": order.Account, "dedup_key": order.DedupKey, "version": order.Version}).
+		SetUpdate(bson.M{"$setOnInsert": order}).
+		SetUpsert(true)
+}
+
+// lookup loads the latest stored copy of every key in batch not seen yet, and
+// the stored orders in progress of the batch's order keys
+func (r *duplicateResolver) lookup(ctx context.Context, batch []Order) error {
+	if err := r.lookupOpen(ctx, batch); err != nil {
+		return err
+	}
+
+	var keys []string
+	for _, order := range batch {
+		if _, ok := r.known[order.DedupKey]; !ok {
+			keys = append(keys, order.DedupKey)
+		}
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+
+	var stored []struct {
+		ID       primitive.ObjectID `bson:"_id"`
+		DedupKey string             `bson:"dedup_key"`
+		Version  int32              `bson:"version"`
+		RunID    string             `bson:"import_run_id"`
+	}
+	err := r.ob.retry(ctx, "duplicate lookup", func() error {
+		cursor, err := r.ob.ordersCollection.Find(ctx,
+			bson.M{"account": r.ob.account, "dedup_key": bson.M{"$in": keys}},
+			options.Find().SetProjection(bson.M{"_id": 1, "dedup_key": 1, "version": 1, "import_run_id": 1}),
+		)
+		if err != nil {
+			return err
+		}
+		return cursor.All(ctx, &stored)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to look up duplicates: %v", err)
+	}
+
+	for _, doc := range stored {
+		if doc.Version == 0 {
+			doc.Version = 1 // Stored before versions were recorded
+		}
+		if known, ok := r.known[doc.DedupKey]; !ok || doc.Version > known.version {
+			r.known[doc.DedupKey] = storedCopy{id: doc.ID, version: doc.Version, runID: doc.RunID}
+		}
+	}
+	return nil
+}
+
+// lookupOpen loads the stored orders in progress of the order keys in batch
+// not looked up yet
+func (r *duplicateResolver) lookupOpen(ctx context.Context, batch []Order) error {
+	var orderIDs, exchangeIDs []string
+	for _, order := range batch {
+		key := order.orderKey()
+		if key == "" || r.looked[key] {
+			continue
+		}
+		r.looked[key] = true
+		if order.OrderID != "" {
+			orderIDs = append(orderIDs, order.OrderID)
+		} else {
+			exchangeIDs = append(exchangeIDs, order.ExchangeOrderID)
+		}
+	}
+	if len(orderIDs)+len(exchangeIDs) == 0 {
+		return nil
+	}
+
+	var stored []Order
+	err := r.ob.retry(ctx, "open order lookup", func() error {
+		cursor, err := r.ob.ordersCollection.Find(ctx, bson.M{
+			"account":      r.ob.account,
+			"voided":       bson.M{"$ne": true},
+			"order_status": bson.M{"$in": InProgressStatuses},
+			"$or": []bson.M{
+				{"order_id": bson.M{"$in": orderIDs}},
+				{"order_id": bson.M{"$exists": false}, "exchange_order_id": bson.M{"$in": exchangeIDs}},
+			},
+		}, options.Find().SetSort(bson.D{{Key: "version", Value: 1}}))
+		if err != nil {
+			return err
+		}
+		return cursor.All(ctx, &stored)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to look up orders in progress: %v", err)
+	}
+
+	for _, order := range stored {
+		r.open[order.orderKey()] = order // The latest version wins
+	}
+	return nil
+}
+
+// publish announces the outcome counts of the import of source
+func (r *duplicateResolver) publish(ctx context.Context, source string) {
+	if len(r.outcomes) == 0 {
+		return
+	}
+	r.ob.events.Publish(ctx, events.Event{
+		Type: events.DuplicatesResolved, Account: r.ob.account, RunID: r.ob.RunID(), Source: source, Kind: string(r.policy), Outcomes: r.outcomes,
+	})
+}
+
+// dedupIndex is the unique (account, dedup_key, version) index of the orders
+const dedupIndex = "account_dedup_key_version_unique"
+
+// migrateDedupIndex readies orders stored before the unique dedup index
+// existed: of the copies sharing an (account, dedup_key, version) only the
+// first stored is kept, and the summaries of their days are marked dirty, so
+// each account's next import recomputes them. It does nothing once the index
+// exists.
+func (ob *OrderBook) migrateDedupIndex(ctx context.Context) error {
+	specs, err := ob.ordersCollection.Indexes().ListSpecifications(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list order indexes: %v", err)
+	}
+	for _, spec := range specs {
+		if spec.Name == dedupIndex {
+			return nil
+		}
+	}
+
+	pipeline := bson.A{
+		bson.M{"$match": bson.M{"dedup_key": bson.M{"$exists": true}, "version": bson.M{"$exists": true}}},
+		bson.M{"$sort": bson.M{"_id": 1}},
+		bson.M{"$group": bson.M{
+			"_id":       bson.M{"account": "$account", "dedup_key": "$dedup_key", "version": "$version"},
+			"ids":       bson.M{"$push": "$_id"},
+			"timestamp": bson.M{"$first": "$timestamp"},
+			"count":     bson.M{"$sum": 1},
+		}},
+		bson.M{"$match": bson.M{"count": bson.M{"$gt": 1}}},
+	}
+	cursor, err := ob.ordersCollection.Aggregate(ctx, pipeline, options.Aggregate().SetAllowDiskUse(true))
+	if err != nil {
+		return fmt.Errorf("failed to find duplicate orders: %v", err)
+	}
+	var groups []struct {
+		ID struct {
+			Account string `bson:"account"`
+		} `bson:"_id"`
+		IDs       []primitive.ObjectID `bson:"ids"`
+		Timestamp time.Time            `bson:"timestamp"`
+	}
+	if err := cursor.All(ctx, &groups); err != nil {
+		return fmt.Errorf("failed to decode duplicate orders: %v", err)
+	}
+
+	var stale []primitive.ObjectID
+	for _, group := range groups {
+		stale = append(stale, group.IDs[1:]...)
+	}
+	for len(stale) > 0 {
+		batch := stale[:min(len(stale), 1000)]
+		if _, err := ob.ordersCollection.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": batch}}); err != nil {
+			return fmt.Errorf("failed to delete duplicate orders: %v", err)
+		}
+		stale = stale[len(batch):]
+	}
+
+	for _, group := range groups {
+		_, err := ob.summaryCollection.UpdateOne(ctx,
+			bson.M{"account": group.ID.Account, "date": market.DayStart(group.Timestamp)},
+			bson.M{"$set": bson.M{"dirty": true}},
+			options.Update().SetUpsert(true),
+		)
+		if err != nil {
+			return fmt.Errorf("failed to mark summaries of duplicate orders dirty: %v", err)
+		}
+	}
+	if len(groups) > 0 {
+		log.Printf("Removed duplicate copies of %d orders before creating the unique dedup index", len(groups))
+	}
+	return nil
+}
+
+// dropIndex drops the named index of collection if it exists
+func dropIndex(ctx context.Context, collection *mongo.Collection, name string) error {
+	_, err := collection.Indexes().DropOne(ctx, name)
+	var cmdErr mongo.CommandError
+	if err != nil && !(errors.As(err, &cmdErr) && cmdErr.Code == 27) { // IndexNotFound
+		return fmt.Errorf("failed to drop index %s: %v", name, err)
+	}
+	return nil
+}
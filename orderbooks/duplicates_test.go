@@ -0,0 +1,128 @@
+package orderbook
+
+import (
+	"testing"
+	"time"
+
+	"profitLossAndTradeInfoToDB/pkg/charges"
+)
+
+var tradingDay = time.Date(2024, 3, 14, 0, 0, 0, 0, time.UTC)
+
+// testOrder is a filled order of the test account keyed as imports key it
+func testOrder(minute int, side string, quantity, price float64) Order {
+	order := Order{
+		Account:         "acct",
+		Timestamp:       tradingDay.Add(9*time.Hour + time.Duration(minute)*time.Minute),
+		TransactionType: side,
+		Symbol:          "NIFTY24MAR22000CE",
+		Quantity:        quantity,
+		AveragePrice:    price,
+		OrderStatus:     "COMPLETE",
+	}
+	order.DedupKey = dedupKey(order)
+	return order
+}
+
+// testImport is one day's export: a round trip and a partly closed position
+func testImport() []Order {
+	return []Order{
+		testOrder(0, "B", 50, 120.5),
+		testOrder(5, "S", 50, 131.25),
+		testOrder(10, "B", 100, 98.05),
+		testOrder(20, "S", 50, 101.1),
+	}
+}
+
+// reimport applies the policy to the same export imported twice, the second
+// import seeing the first's orders as stored as its lookup would, and returns
+// the orders stored after each and the outcomes of the second
+func reimport(t *testing.T, policy DuplicatePolicy) (first, second []Order, outcomes map[string]int) {
+	t.Helper()
+	ob := &OrderBook{duplicates: policy, unfilled: UnfilledTag}
+
+	_, written := ob.newDuplicateResolver().apply(testImport())
+	stored := map[string]Order{}
+	for _, order := range written {
+		stored[order.ID.Hex()] = order
+		first = append(first, order)
+	}
+
+	r := ob.newDuplicateResolver()
+	for _, order := range first {
+		r.known[order.DedupKey] = storedCopy{id: order.ID, version: order.Version, runID: order.ImportRunID}
+	}
+	_, written = r.apply(testImport())
+	for _, order := range written {
+		stored[order.ID.Hex()] = order
+	}
+	for _, order := range stored {
+		second = append(second, order)
+	}
+	return first, second, r.outcomes
+}
+
+func TestDuplicateResolver(t *testing.T) {
+	tests := []struct {
+		policy   DuplicatePolicy
+		stored   int
+		outcome  string
+		versions int32 // Highest version stored
+	}{
+		{policy: DuplicateSkip, stored: 4, outcome: OutcomeSkipped, versions: 1},
+		{policy: DuplicateOverwrite, stored: 4, outcome: OutcomeOverwritten, versions: 1},
+		{policy: DuplicateVersion, stored: 8, outcome: OutcomeVersioned, versions: 2},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.policy), func(t *testing.T) {
+			_, stored, outcomes := reimport(t, tt.policy)
+			if len(stored) != tt.stored {
+				t.Errorf("stored %d orders, want %d", len(stored), tt.stored)
+			}
+			if outcomes[tt.outcome] != 4 || len(outcomes) != 1 {
+				t.Errorf("outcomes = %v, want 4 %s", outcomes, tt.outcome)
+			}
+			var versions int32
+			for _, order := range stored {
+				versions = max(versions, order.Version)
+			}
+			if versions != tt.versions {
+				t.Errorf("highest version = %d, want %d", versions, tt.versions)
+			}
+		})
+	}
+}
+
+func TestDuplicateResolverRepeatedRows(t *testing.T) {
+	// A row repeated within one file counts as stored by its first copy
+	ob := &OrderBook{duplicates: DuplicateSkip, unfilled: UnfilledTag}
+	r := ob.newDuplicateResolver()
+	order := testOrder(0, "B", 50, 120.5)
+	_, written := r.apply([]Order{order, order})
+	if len(written) != 1 || written[0].Version != 1 {
+		t.Fatalf("stored %v, want one copy at version 1", written)
+	}
+	if r.outcomes[OutcomeInserted] != 1 || r.outcomes[OutcomeSkipped] != 1 {
+		t.Errorf("outcomes = %v, want one inserted and one skipped", r.outcomes)
+	}
+}
+
+func TestReimportKeepsSummary(t *testing.T) {
+	policy, err := ParseDuplicatePolicy("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, policy := range []DuplicatePolicy{policy, DuplicateOverwrite} {
+		t.Run(string(policy), func(t *testing.T) {
+			first, second, _ := reimport(t, policy)
+			want := SummarizeDay("acct", tradingDay, first, charges.DefaultSchedule)
+			got := SummarizeDay("acct", tradingDay, second, charges.DefaultSchedule)
+			if got.TotalTrades != want.TotalTrades ||
+				got.TotalBuyQuantity != want.TotalBuyQuantity || got.TotalSellQuantity != want.TotalSellQuantity ||
+				got.BuyTurnover != want.BuyTurnover || got.SellTurnover != want.SellTurnover ||
+				got.RealizedPnL != want.RealizedPnL || got.Charges != want.Charges {
+				t.Errorf("summary after reimport = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package orderbook
+
+import (
+	"path"
+	"strings"
+	"time"
+)
+
+// CSVFormat describes a broker's orderbook export: header names that differ
+// from the generic aliases and how its values are written. The zero value is
+// the generic format.
+type CSVFormat struct {
+	Name string
+	// Patterns are file name globs of the format's exports, matched case-insensitively
+	Patterns []string
+	// Columns are header names per CSVHeader column, tried before csvColumnAliases
+	Columns map[string][]string
+	// DateColumns name a date column that precedes the time held by the
+	// timestamp column, for exports giving date and time separately
+	DateColumns []string
+	// Location of timestamps without an offset; nil for market time
+	Location *time.Location
+	// AssetSuffixes allows quantities and prices ending in their asset and
+	// grouped with commas, e.g. 0.00120000BTC or 1,234.5USDT
+	AssetSuffixes bool
+	// InstrumentType replaces the type parsed from the symbol, e.g. CRYPTO
+	InstrumentType string
+}
+
+// GenericFormat reads the canonical columns and the aliases brokers commonly use
+var GenericFormat = CSVFormat{Name: "generic"}
+
+func init() {
+	RegisterParser(FormatParser{Format: GenericFormat})
+}
+
+// FormatParser is the Parser of exports a CSVFormat describes
+type FormatParser struct {
+	Format CSVFormat
+}
+
+// Name is the format's name
+func (p FormatParser) Name() string {
+	return p.Format.Name
+}
+
+// Match reports whether filename matches one of the format's patterns
+func (p FormatParser) Match(filename string) bool {
+	for _, pattern := range p.Format.Patterns {
+		if ok, _ := path.Match(strings.ToLower(pattern), strings.ToLower(filename)); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Header locates the format's columns in header
+func (p FormatParser) Header(header []string) (RowDecoder, error) {
+	return newCSVColumns(header, p.Format)
+}
+
+// normalizeSide maps the side spellings of broker exports to B and S
+func normalizeSide(side string) string {
+	switch strings.ToUpper(strings.TrimSpace(side)) {
+	case "B", "BUY":
+		return "B"
+	case "S", "SELL":
+		return "S"
+	}
+	return side
+}
@@ -0,0 +1,236 @@
+package orderbook
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"profitLossAndTradeInfoToDB/pkg/market"
+	"profitLossAndTradeInfoToDB/pkg/money"
+	"profitLossAndTradeInfoToDB/pkg/trades"
+)
+
+// IntradayBucket aggregates the orders and closed trades falling in one slice
+// of the trading day, e.g. 09:15-09:30, over every day of a range. Closed
+// trades count in the bucket they were entered in, so the P&L shows which part
+// of the session the trades that made it were taken in.
+type IntradayBucket struct {
+	Start       time.Duration `json:"-"` // Offset of the bucket from midnight, market time
+	Label       string        `json:"bucket"`
+	Trades      int32         `json:"trades"`
+	Turnover    float64       `json:"turnover"`
+	RoundTrips  int32         `json:"round_trips"`
+	Winners     int32         `json:"winners"`
+	RealizedPnL float64       `json:"realized_pnl"`
+}
+
+// CheckIntradayBucket rejects bucket sizes that do not split a day evenly
+func CheckIntradayBucket(size time.Duration) error {
+	if size <= 0 || size > 24*time.Hour || (24*time.Hour)%size != 0 {
+		return fmt.Errorf("intraday bucket %s must divide a day evenly, e.g. 15m or 1h", size)
+	}
+	return nil
+}
+
+// SummarizeIntraday buckets orders by their time and round trips by their
+// entry time into slices of the day of the given size, aligned to midnight.
+// Only buckets holding something are returned, in time of day order.
+func SummarizeIntraday(orders []Order, roundTrips []trades.RoundTrip, size time.Duration) ([]IntradayBucket, error) {
+	if err := CheckIntradayBucket(size); err != nil {
+		return nil, err
+	}
+
+	type totals struct {
+		bucket        IntradayBucket
+		turnover, pnl money.Paise
+	}
+	buckets := make(map[time.Duration]*totals)
+	get := func(t time.Time) *totals {
+		start := t.Sub(market.DayStart(t)) / size * size
+		b, ok := buckets[start]
+		if !ok {
+			b = &totals{bucket: IntradayBucket{Start: start, Label: clockLabel(start) + "-" + clockLabel(start+size)}}
+			buckets[start] = b
+		}
+		return b
+	}
+
+	for _, order := range orders {
+		b := get(order.Timestamp)
+		b.bucket.Trades++
+		b.turnover += money.Value(order.AveragePrice, order.Quantity)
+	}
+	for _, trip := range roundTrips {
+		b := get(trip.EntryTime)
+		b.bucket.RoundTrips++
+		if trip.RealizedPnL > 0 {
+			b.bucket.Winners++
+		}
+		b.pnl += trip.RealizedPnL
+	}
+
+	result := make([]IntradayBucket, 0, len(buckets))
+	for _, b := range buckets {
+		b.bucket.Turnover, b.bucket.RealizedPnL = b.turnover.Rupees(), b.pnl.Rupees()
+		result = append(result, b.bucket)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Start < result[j].Start })
+	return result, nil
+}
+
+// IntradaySummary buckets the filled orders and closed trades of the days in
+// [from, to), see SummarizeIntraday
+func (ob *OrderBook) IntradaySummary(ctx context.Context, from, to time.Time, size time.Duration) ([]IntradayBucket, error) {
+	if err := CheckIntradayBucket(size); err != nil {
+		return nil, err
+	}
+	orders, err := ob.GetOrdersByDateRange(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+	closed, err := ob.GetClosedTrades(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	roundTrips := make([]trades.RoundTrip, len(closed))
+	for i, trade := range closed {
+		roundTrips[i] = trade.RoundTrip
+	}
+	return SummarizeIntraday(orders, roundTrips, size)
+}
+
+// clockLabel formats an offset from midnight as HH:MM
+func clockLabel(d time.Duration) string {
+	return fmt.Sprintf("%02d:%02d", int(d.Hours()), int(d.Minutes())%60)
+}
+
+// HeatmapCell is what happened in one slice of the day on one weekday
+type HeatmapCell struct {
+	Trades      int32   `json:"trades"`
+	RoundTrips  int32   `json:"round_trips"`
+	Winners     int32   `json:"winners"`
+	RealizedPnL float64 `json:"realized_pnl"`
+}
+
+// HeatmapRow is one weekday of an IntradayHeatmap, a cell per bucket
+type HeatmapRow struct {
+	Weekday string        `json:"weekday"`
+	Cells   []HeatmapCell `json:"cells"`
+}
+
+// IntradayHeatmap spreads orders and realized P&L over weekdays and slices of
+// the trading day. Buckets are aligned to the regular session's open, so 30
+// minute buckets run 09:15-09:45, 09:45-10:15 and so on; they span the
+// session and any trading outside it. Rows hold the weekdays with trades,
+// Monday first, and Totals adds them up per bucket.
+type IntradayHeatmap struct {
+	Buckets []string      `json:"buckets"`
+	Rows    []HeatmapRow  `json:"rows"`
+	Totals  []HeatmapCell `json:"totals"`
+}
+
+// BuildIntradayHeatmap buckets orders by their time and round trips by their
+// entry time, as SummarizeIntraday does, per weekday
+func BuildIntradayHeatmap(orders []Order, roundTrips []trades.RoundTrip, size time.Duration) (IntradayHeatmap, error) {
+	if err := CheckIntradayBucket(size); err != nil {
+		return IntradayHeatmap{}, err
+	}
+
+	// Columns are counted from the bucket opening the session and may be
+	// negative for trades before it
+	column := func(t time.Time) int {
+		offset := t.Sub(market.DayStart(t)) - market.Regular.Open
+		index := offset / size
+		if offset < 0 && offset%size != 0 {
+			index--
+		}
+		return int(index)
+	}
+	type key struct {
+		weekday int
+		column  int
+	}
+	cells := make(map[key]*HeatmapCell)
+	pnl := make(map[key]money.Paise)
+	first, last := 0, int((market.Regular.Close-market.Regular.Open-1)/size)
+	get := func(t time.Time) key {
+		k := key{weekday: (int(t.In(market.Location()).Weekday()) + 6) % 7, column: column(t)}
+		if _, ok := cells[k]; !ok {
+			cells[k] = &HeatmapCell{}
+		}
+		first, last = min(first, k.column), max(last, k.column)
+		return k
+	}
+
+	for _, order := range orders {
+		cells[get(order.Timestamp)].Trades++
+	}
+	for _, trip := range roundTrips {
+		k := get(trip.EntryTime)
+		cells[k].RoundTrips++
+		if trip.RealizedPnL > 0 {
+			cells[k].Winners++
+		}
+		pnl[k] += trip.RealizedPnL
+	}
+
+	var heatmap IntradayHeatmap
+	for c := first; c <= last; c++ {
+		// The buckets at the ends of the day are cut at midnight
+		start := market.Regular.Open + time.Duration(c)*size
+		heatmap.Buckets = append(heatmap.Buckets, clockLabel(max(start, 0))+"-"+clockLabel(min(start+size, 24*time.Hour)))
+	}
+	heatmap.Totals = make([]HeatmapCell, len(heatmap.Buckets))
+	totals := make([]money.Paise, len(heatmap.Buckets))
+	for weekday := 0; weekday < 7; weekday++ {
+		row := HeatmapRow{Weekday: time.Weekday((weekday + 1) % 7).String(), Cells: make([]HeatmapCell, len(heatmap.Buckets))}
+		found := false
+		for c := first; c <= last; c++ {
+			k := key{weekday: weekday, column: c}
+			cell, ok := cells[k]
+			if !ok {
+				continue
+			}
+			found = true
+			cell.RealizedPnL = pnl[k].Rupees()
+			row.Cells[c-first] = *cell
+
+			total := &heatmap.Totals[c-first]
+			total.Trades += cell.Trades
+			total.RoundTrips += cell.RoundTrips
+			total.Winners += cell.Winners
+			totals[c-first] += pnl[k]
+		}
+		if found {
+			heatmap.Rows = append(heatmap.Rows, row)
+		}
+	}
+	for i := range heatmap.Totals {
+		heatmap.Totals[i].RealizedPnL = totals[i].Rupees()
+	}
+	return heatmap, nil
+}
+
+// IntradayHeatmap builds the heatmap of the filled orders and closed trades
+// of the days in [from, to), see BuildIntradayHeatmap
+func (ob *OrderBook) IntradayHeatmap(ctx context.Context, from, to time.Time, size time.Duration) (IntradayHeatmap, error) {
+	if err := CheckIntradayBucket(size); err != nil {
+		return IntradayHeatmap{}, err
+	}
+	orders, err := ob.GetOrdersByDateRange(ctx, from, to)
+	if err != nil {
+		return IntradayHeatmap{}, err
+	}
+	closed, err := ob.GetClosedTrades(ctx, from, to)
+	if err != nil {
+		return IntradayHeatmap{}, err
+	}
+
+	roundTrips := make([]trades.RoundTrip, len(closed))
+	for i, trade := range closed {
+		roundTrips[i] = trade.RoundTrip
+	}
+	return BuildIntradayHeatmap(orders, roundTrips, size)
+}
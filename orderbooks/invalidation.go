@@ -0,0 +1,195 @@
+package orderbook
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"profitLossAndTradeInfoToDB/pkg/market"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// invalidateDates marks the summaries of the given days dirty, then recomputes
+// them and the rollups covering them, or leaves that to FlushRecompute while
+// deferring. A failed recompute leaves the dirty flag set, so RecomputeDirty
+// can repair the day later instead of it drifting silently.
+func (ob *OrderBook) invalidateDates(ctx context.Context, dates []time.Time) error {
+	days := distinctDays(dates)
+	if len(days) == 0 {
+		return nil
+	}
+
+	for _, day := range days {
+		if err := ob.markDirty(ctx, day); err != nil {
+			return err
+		}
+	}
+	if err := ob.dropPositions(ctx, days[0]); err != nil {
+		return err
+	}
+
+	ob.pendingMu.Lock()
+	if ob.deferring {
+		ob.pending = append(ob.pending, days...)
+		ob.pendingMu.Unlock()
+		return nil
+	}
+	ob.pendingMu.Unlock()
+
+	return ob.recomputeDays(ctx, days)
+}
+
+// DeferRecompute has mutations only mark their days dirty until
+// FlushRecompute, so an import run of many files recomputes each day, and the
+// periods covering it, once
+func (ob *OrderBook) DeferRecompute() {
+	ob.pendingMu.Lock()
+	defer ob.pendingMu.Unlock()
+	ob.deferring = true
+}
+
+// FlushRecompute recomputes the days marked dirty since DeferRecompute and
+// stops deferring
+func (ob *OrderBook) FlushRecompute(ctx context.Context) error {
+	ob.pendingMu.Lock()
+	days := distinctDays(ob.pending)
+	ob.deferring, ob.pending = false, nil
+	ob.pendingMu.Unlock()
+
+	return ob.recomputeDays(ctx, days)
+}
+
+// RecomputeDirty recomputes every summary left dirty by an interrupted or failed
+// mutation and returns how many days were repaired
+func (ob *OrderBook) RecomputeDirty(ctx context.Context) (int, error) {
+	if err := ob.checkWritable(); err != nil {
+		return 0, err
+	}
+
+	cursor, err := ob.summaryCollection.Find(ctx,
+		bson.M{"account": ob.account, "dirty": true},
+		options.Find().SetProjection(bson.M{"date": 1}))
+	if err != nil {
+		return 0, fmt.Errorf("failed to query dirty summaries: %v", err)
+	}
+	defer cursor.Close(ctx)
+
+	var dirty []DailySummary
+	if err := cursor.All(ctx, &dirty); err != nil {
+		return 0, fmt.Errorf("failed to decode dirty summaries: %v", err)
+	}
+
+	dates := make([]time.Time, len(dirty))
+	for i, summary := range dirty {
+		dates[i] = summary.Date
+	}
+
+	days := distinctDays(dates)
+	if err := ob.recomputeDays(ctx, days); err != nil {
+		return 0, err
+	}
+
+	return len(days), nil
+}
+
+// markDirty flags the day's summary as out of date, creating it if needed
+func (ob *OrderBook) markDirty(ctx context.Context, day time.Time) error {
+	if err := ob.checkWritable(); err != nil {
+		return err
+	}
+
+	_, err := ob.summaryCollection.UpdateOne(ctx,
+		bson.M{"account": ob.account, "date": day},
+		bson.M{"$set": bson.M{"dirty": true}},
+		options.Update().SetUpsert(true),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to mark summary for %s dirty: %v", day.Format("2006-01-02"), err)
+	}
+
+	return nil
+}
+
+// recomputeDays rebuilds the daily summaries of the sorted days (clearing
+// their dirty flag), then the rollups, period summaries and performance stats
+// of only the periods containing them, and bumps the data version
+func (ob *OrderBook) recomputeDays(ctx context.Context, days []time.Time) error {
+	if len(days) == 0 {
+		return nil
+	}
+
+	for _, day := range days {
+		if err := ob.updateDailySummary(ctx, day); err != nil {
+			return fmt.Errorf("failed to update daily summary: %v", err)
+		}
+	}
+
+	for _, kind := range ob.rollups {
+		period := PeriodDay
+		if kind.Unit == "month" {
+			period = PeriodMonth
+		}
+		for _, w := range periodWindows(period, days) {
+			if err := ob.refreshRollup(ctx, kind, w.start, w.end); err != nil {
+				return fmt.Errorf("failed to refresh %s rollup: %v", kind.Name, err)
+			}
+		}
+	}
+	for _, period := range SummaryPeriods {
+		for _, w := range periodWindows(period, days) {
+			if err := ob.refreshPeriodSummaries(ctx, period, w.start, w.end); err != nil {
+				return fmt.Errorf("failed to refresh %s summaries: %v", period, err)
+			}
+		}
+	}
+	for _, period := range performancePeriods {
+		for _, w := range periodWindows(period, days) {
+			if err := ob.refreshPerformanceStats(ctx, period, w.start, w.end); err != nil {
+				return fmt.Errorf("failed to refresh %s performance stats: %v", period, err)
+			}
+		}
+	}
+	return ob.version.Bump(ctx)
+}
+
+// window is a span of whole periods, [start, end)
+type window struct {
+	start, end time.Time
+}
+
+// periodWindows returns the periods containing the sorted days, adjacent
+// periods joined into one window
+func periodWindows(period SummaryPeriod, days []time.Time) []window {
+	var windows []window
+	for _, day := range days {
+		start := period.Start(day)
+		end := period.next(start)
+		if n := len(windows); n > 0 && !start.After(windows[n-1].end) {
+			if end.After(windows[n-1].end) {
+				windows[n-1].end = end
+			}
+			continue
+		}
+		windows = append(windows, window{start: start, end: end})
+	}
+	return windows
+}
+
+// distinctDays returns the sorted, de-duplicated market-time start of each date
+func distinctDays(dates []time.Time) []time.Time {
+	seen := make(map[time.Time]bool)
+	var days []time.Time
+	for _, date := range dates {
+		day := market.DayStart(date)
+		if !seen[day] {
+			seen[day] = true
+			days = append(days, day)
+		}
+	}
+
+	sort.Slice(days, func(i, j int) bool { return days[i].Before(days[j]) })
+	return days
+}
@@ -0,0 +1,90 @@
+package orderbook
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// MergeOptions controls how overlapping exports of the same day are unioned
+type MergeOptions struct {
+	// Priority lists source name fragments, most trusted first (e.g. "console",
+	// "api", "app"); a source matching none ranks after all listed ones
+	Priority []string
+	// TimeTolerance is how far apart two rows' timestamps may be and still be
+	// considered the same fill
+	TimeTolerance time.Duration
+}
+
+// MergeOrders unions orders parsed from several sources. Rows describing the
+// same fill (same symbol and side within TimeTolerance, one per source) are
+// collapsed into the copy from the highest-priority source, and every source
+// the fill appeared in is recorded in Provenance.
+func MergeOrders(sets [][]Order, opts MergeOptions) []Order {
+	var all []Order
+	for _, set := range sets {
+		all = append(all, set...)
+	}
+
+	sort.SliceStable(all, func(i, j int) bool {
+		if !all[i].Timestamp.Equal(all[j].Timestamp) {
+			return all[i].Timestamp.Before(all[j].Timestamp)
+		}
+		return sourceRank(all[i].Source, opts.Priority) < sourceRank(all[j].Source, opts.Priority)
+	})
+
+	type group struct {
+		chosen  Order
+		sources map[string]bool
+		order   []string
+	}
+	var groups []*group
+
+	for _, order := range all {
+		var match *group
+		for i := len(groups) - 1; i >= 0; i-- {
+			g := groups[i]
+			if order.Timestamp.Sub(g.chosen.Timestamp) > opts.TimeTolerance {
+				break
+			}
+			if g.chosen.Symbol == order.Symbol && g.chosen.TransactionType == order.TransactionType && !g.sources[order.Source] {
+				match = g
+				break
+			}
+		}
+
+		if match == nil {
+			groups = append(groups, &group{
+				chosen:  order,
+				sources: map[string]bool{order.Source: true},
+				order:   []string{order.Source},
+			})
+			continue
+		}
+
+		match.sources[order.Source] = true
+		match.order = append(match.order, order.Source)
+		if sourceRank(order.Source, opts.Priority) < sourceRank(match.chosen.Source, opts.Priority) {
+			match.chosen = order
+		}
+	}
+
+	merged := make([]Order, len(groups))
+	for i, g := range groups {
+		merged[i] = g.chosen
+		merged[i].Provenance = g.order
+	}
+
+	return merged
+}
+
+// sourceRank returns the index of the first priority fragment contained in source
+func sourceRank(source string, priority []string) int {
+	lower := strings.ToLower(source)
+	for i, fragment := range priority {
+		if fragment != "" && strings.Contains(lower, strings.ToLower(fragment)) {
+			return i
+		}
+	}
+	return len(priority)
+}
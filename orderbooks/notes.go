@@ -0,0 +1,160 @@
+package orderbook
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Annotation attaches a note and tags to an existing order
+type Annotation struct {
+	OrderID string
+	Notes   string // Replaces the order's notes when set
+	Tags    []string
+}
+
+// Mapping CSV columns; the order ID column is required
+var (
+	annotationIDColumns = []string{"order_id", "id", "_id"}
+)
+
+// splitTags splits a tags cell on ; or |, dropping empty tags
+func splitTags(cell string) []string {
+	var tags []string
+	for _, tag := range strings.FieldsFunc(cell, func(r rune) bool { return r == ';' || r == '|' }) {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// ReadAnnotations parses a mapping CSV with an order_id column and notes and/or tags columns
+func ReadAnnotations(r io.Reader) ([]Annotation, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read header: %v", err)
+	}
+	idCol := findColumn(header, annotationIDColumns)
+	notesCol := findColumn(header, notesColumns)
+	tagsCol := findColumn(header, tagsColumns)
+	if idCol < 0 {
+		return nil, fmt.Errorf("mapping has no order_id column")
+	}
+	if notesCol < 0 && tagsCol < 0 {
+		return nil, fmt.Errorf("mapping has neither a notes nor a tags column")
+	}
+
+	var annotations []Annotation
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+
+		cell := func(col int) string {
+			if col < 0 || col >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[col])
+		}
+
+		annotation := Annotation{OrderID: cell(idCol), Notes: cell(notesCol), Tags: splitTags(cell(tagsCol))}
+		if annotation.OrderID == "" {
+			return nil, fmt.Errorf("line %d: missing order ID", line)
+		}
+		if _, err := primitive.ObjectIDFromHex(annotation.OrderID); err != nil {
+			return nil, fmt.Errorf("line %d: invalid order ID %q", line, annotation.OrderID)
+		}
+		if annotation.Notes == "" && len(annotation.Tags) == 0 {
+			continue
+		}
+		annotations = append(annotations, annotation)
+	}
+
+	return annotations, nil
+}
+
+// AnnotateOrders sets the notes and adds the tags of each annotation on its
+// order, hot or archived. Notes and tags do not affect summaries, so nothing
+// is recomputed. It returns the IDs of orders that were not found.
+func (ob *OrderBook) AnnotateOrders(ctx context.Context, annotations []Annotation) ([]string, error) {
+	if err := ob.checkWritable(); err != nil {
+		return nil, err
+	}
+
+	pending := annotations
+	for _, collection := range []*mongo.Collection{ob.ordersCollection, ob.archiveOrders} {
+		if collection == nil || len(pending) == 0 {
+			continue
+		}
+
+		var err error
+		if pending, err = ob.annotate(ctx, collection, pending); err != nil {
+			return nil, err
+		}
+	}
+
+	missing := make([]string, len(pending))
+	for i, annotation := range pending {
+		missing[i] = annotation.OrderID
+	}
+	return missing, nil
+}
+
+// annotate updates the orders of collection and returns the annotations whose order it does not hold
+func (ob *OrderBook) annotate(ctx context.Context, collection *mongo.Collection, annotations []Annotation) ([]Annotation, error) {
+	ids := make([]primitive.ObjectID, len(annotations))
+	models := make([]mongo.WriteModel, len(annotations))
+	for i, annotation := range annotations {
+		ids[i], _ = primitive.ObjectIDFromHex(annotation.OrderID)
+
+		update := bson.M{}
+		if annotation.Notes != "" {
+			update["$set"] = bson.M{"notes": annotation.Notes}
+		}
+		if len(annotation.Tags) > 0 {
+			update["$addToSet"] = bson.M{"tags": bson.M{"$each": annotation.Tags}}
+		}
+		models[i] = mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"_id": ids[i], "account": ob.account}).
+			SetUpdate(update)
+	}
+
+	if _, err := collection.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false)); err != nil {
+		return nil, fmt.Errorf("failed to annotate orders: %v", err)
+	}
+
+	// Find which orders this collection holds
+	found, err := collection.Distinct(ctx, "_id", bson.M{"_id": bson.M{"$in": ids}, "account": ob.account})
+	if err != nil {
+		return nil, fmt.Errorf("failed to check annotated orders: %v", err)
+	}
+	held := make(map[primitive.ObjectID]bool, len(found))
+	for _, id := range found {
+		if oid, ok := id.(primitive.ObjectID); ok {
+			held[oid] = true
+		}
+	}
+
+	var rest []Annotation
+	for i, annotation := range annotations {
+		if !held[ids[i]] {
+			rest = append(rest, annotation)
+		}
+	}
+	return rest, nil
+}
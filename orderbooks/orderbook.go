@@ -3,55 +3,366 @@ package orderbook
 import (
 	"context"
 	"encoding/csv"
+	"errors"
 	"fmt"
+	"io"
+	"math"
 	"os"
 	constants "profitLossAndTradeInfoToDB/constants"
+	"profitLossAndTradeInfoToDB/pkg/archive"
+	"profitLossAndTradeInfoToDB/pkg/charges"
+	"profitLossAndTradeInfoToDB/pkg/dataversion"
+	"profitLossAndTradeInfoToDB/pkg/events"
+	"profitLossAndTradeInfoToDB/pkg/instruments"
+	"profitLossAndTradeInfoToDB/pkg/market"
+	"profitLossAndTradeInfoToDB/pkg/money"
+	"profitLossAndTradeInfoToDB/pkg/symbol"
+	"profitLossAndTradeInfoToDB/pkg/trades"
+	"regexp"
+	"slices"
+	"sort"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
+	"unicode"
 
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 // Order represents a single order entry
 type Order struct {
-	Timestamp       time.Time `bson:"timestamp" json:"timestamp"`
-	TransactionType string    `bson:"transaction_type" json:"transaction_type"`
-	Symbol          string    `bson:"symbol" json:"symbol"`
-	Product         string    `bson:"product" json:"product"`
-	Quantity        int32     `bson:"quantity" json:"quantity"`
-	AveragePrice    float64   `bson:"average_price" json:"average_price"`
-	OrderStatus     string    `bson:"order_status" json:"order_status"`
-	Timestamp3      int64     `bson:"timestamp3" json:"timestamp3"` // Unix timestamp field from the data
-
-	// Metadata fields for time series
+	ID              primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Account         string             `bson:"account" json:"account"`
+	Timestamp       time.Time          `bson:"timestamp" json:"timestamp"`
+	TransactionType string             `bson:"transaction_type" json:"transaction_type"`
+	Symbol          string             `bson:"symbol" json:"symbol"`
+	InstrumentType  string             `bson:"instrument_type,omitempty" json:"instrument_type,omitempty"` // CE, PE, FUT or EQ, parsed from the symbol
+	Product         string             `bson:"product" json:"product"`
+	Quantity        float64            `bson:"quantity" json:"quantity"` // Fractional for crypto
+	AveragePrice    float64            `bson:"average_price" json:"average_price"`
+	OrderStatus     string             `bson:"order_status" json:"order_status"`
+	OrderID         string             `bson:"order_id,omitempty" json:"order_id,omitempty"`                   // Broker's order ID, linking the order to its fills in the trade book
+	ExchangeOrderID string             `bson:"exchange_order_id,omitempty" json:"exchange_order_id,omitempty"` // Exchange's ID of the order, when the export has it
+	StatusHistory   []StatusChange     `bson:"status_history,omitempty" json:"status_history,omitempty"`       // Statuses the order went through, when the export had several rows of it
+	Provenance      []string           `bson:"provenance,omitempty" json:"provenance,omitempty"`               // Every source the order appeared in, after merging
+	ExecutionTime   *time.Time         `bson:"execution_time,omitempty" json:"execution_time,omitempty"`       // Exchange fill time, when the export has it
+	Timestamp3      int64              `bson:"timestamp3" json:"timestamp3"`                                   // Unix timestamp field from the data
+	Source          string             `bson:"source,omitempty" json:"source,omitempty"`
+	ImportRunID     string             `bson:"import_run_id,omitempty" json:"import_run_id,omitempty"` // Run that stored the order, see PurgeRun
+	Voided          bool               `bson:"voided,omitempty" json:"voided,omitempty"`
+	VoidReason      string             `bson:"void_reason,omitempty" json:"void_reason,omitempty"`
+	DedupKey        string             `bson:"dedup_key,omitempty" json:"dedup_key,omitempty"` // Same fill across imports, see DuplicatePolicy
+	Version         int32              `bson:"version,omitempty" json:"version,omitempty"`     // Copy number among orders with the same dedup key
+	Notes           string             `bson:"notes,omitempty" json:"notes,omitempty"`
+	Tags            []string           `bson:"tags,omitempty" json:"tags,omitempty"`
+	Instrument      *InstrumentInfo    `bson:"instrument,omitempty" json:"instrument,omitempty"` // Set when symbols are validated against the instrument master
+
+	row int // CSV line the order was read from, for reporting rejected orders
+
+	// Metadata fields for time series; strike and option type are only set for
+	// options, expiry for options and futures
 	MetaData struct {
-		StrikePrice int    `bson:"strike_price" json:"strike_price"`
-		OptionType  string `bson:"option_type" json:"option_type"`
+		StrikePrice float64    `bson:"strike_price,omitempty" json:"strike_price,omitempty"`
+		OptionType  string     `bson:"option_type,omitempty" json:"option_type,omitempty"`
+		Expiry      *time.Time `bson:"expiry,omitempty" json:"expiry,omitempty"` // Contract expiry day, midnight market time
 	} `bson:"metadata" json:"metadata"`
 }
 
+// UnfilledStatuses are the statuses of placed orders that never traded. The
+// order book keeps them, see GetOrderBook; P&L, summaries and the other
+// order queries leave them out.
+var UnfilledStatuses = []string{"REJECTED", "CANCELLED", "CANCELED", "OPEN", "PENDING", "TRIGGER PENDING", "EXPIRED"}
+
+// filledStatus matches the statuses of orders that traded. Imports store
+// statuses upper-cased, but orders stored before they did may differ in case
+// or carry spaces, so the statuses are matched as Filled compares them.
+var filledStatus = bson.M{"$nin": statusPatterns(UnfilledStatuses)}
+
+// statusPatterns returns case-insensitive patterns matching each status,
+// surrounded by any spaces
+func statusPatterns(statuses []string) bson.A {
+	patterns := make(bson.A, len(statuses))
+	for i, status := range statuses {
+		patterns[i] = primitive.Regex{Pattern: `^\s*` + regexp.QuoteMeta(status) + `\s*$`, Options: "i"}
+	}
+	return patterns
+}
+
+// Filled reports whether the order traded, going by its status
+func (o Order) Filled() bool {
+	return !slices.Contains(UnfilledStatuses, strings.ToUpper(strings.TrimSpace(o.OrderStatus)))
+}
+
+// InstrumentInfo is the instrument master data recorded on an order
+type InstrumentInfo struct {
+	Exchange string  `bson:"exchange" json:"exchange"`
+	Token    string  `bson:"token" json:"token"`
+	LotSize  int32   `bson:"lot_size" json:"lot_size"`
+	TickSize float64 `bson:"tick_size" json:"tick_size"`
+}
+
+// Fill converts the order into the matching engine's input
+func (o Order) Fill() trades.Fill {
+	fill := trades.Fill{
+		Symbol:          o.Symbol,
+		TransactionType: o.TransactionType,
+		Quantity:        o.Quantity,
+		Price:           o.AveragePrice,
+		Time:            o.Timestamp,
+	}
+	if !o.ID.IsZero() {
+		fill.ID = o.ID.Hex()
+	}
+	return fill
+}
+
+// Fills converts orders into the matching engine's input
+func Fills(orders []Order) []trades.Fill {
+	fills := make([]trades.Fill, len(orders))
+	for i, order := range orders {
+		fills[i] = order.Fill()
+	}
+	return fills
+}
+
+// CSVHeader is the canonical column layout of an orderbook CSV file
+var CSVHeader = []string{"timestamp", "transaction_type", "symbol", "product", "quantity", "average_price", "order_status"}
+
+// executionTimeColumns are the header names recognised as the execution time column
+var executionTimeColumns = []string{"execution_time", "exchange_time", "fill_time", "exec_time"}
+
+// Optional notes, tags and order ID columns; tags are separated by ; or |
+var (
+	notesColumns           = []string{"notes", "note", "comment", "remarks"}
+	tagsColumns            = []string{"tags", "tag"}
+	orderIDColumns         = []string{"order_id", "orderid", "order_no", "order_number", "broker_order_id"}
+	exchangeOrderIDColumns = []string{"exchange_order_id", "exch_order_id", "exchange_order_no", "exchorderid"}
+)
+
+// csvColumnAliases are the header names recognised for each CSVHeader column,
+// canonical name first, as broker exports name them
+var csvColumnAliases = [][]string{
+	{"timestamp", "time", "date", "order_time", "order_timestamp", "datetime", "date_time", "trade_time"},
+	{"transaction_type", "type", "side", "trade_type", "buy_sell"},
+	{"symbol", "trading_symbol", "tradingsymbol", "scrip"},
+	{"product", "product_type"},
+	{"quantity", "qty", "filled_qty", "filled_quantity", "traded_qty"},
+	{"average_price", "avg_price", "price", "average_traded_price", "avg_traded_price", "traded_price"},
+	{"order_status", "status"},
+}
+
+// optionalCSVColumns may be missing from a header; their fields are left empty
+var optionalCSVColumns = map[string]bool{"product": true, "order_status": true}
+
+// headerName normalises a header cell for matching: "Avg. Price" becomes avg_price,
+// "Buy/Sell" buy_sell and "Date(UTC)" date_utc
+var headerName = strings.NewReplacer(" ", "_", "-", "_", "/", "_", "(", "_", ".", "", ")", "")
+
+// findColumn returns the index of the first header matching one of the names
+// (case-insensitive, spaces and dashes matching underscores), or -1
+func findColumn(header []string, names []string) int {
+	for i, column := range header {
+		column = headerName.Replace(strings.ToLower(strings.TrimSpace(column)))
+		for _, name := range names {
+			if column == name {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// CSVTimestampLayout is the timestamp format written to orderbook CSV files;
+// imports also accept the layouts in timestampLayouts
+const CSVTimestampLayout = "2006-01-02T15:04:05-07:00"
+
 // DailySummary represents the daily trading summary
 type DailySummary struct {
+	Account           string    `bson:"account" json:"account"`
 	Date              time.Time `bson:"date" json:"date"`
 	TotalTrades       int32     `bson:"total_trades" json:"total_trades"`
-	TotalBuyQuantity  int32     `bson:"total_buy_quantity" json:"total_buy_quantity"`
-	TotalSellQuantity int32     `bson:"total_sell_quantity" json:"total_sell_quantity"`
+	TotalBuyQuantity  float64   `bson:"total_buy_quantity" json:"total_buy_quantity"`
+	TotalSellQuantity float64   `bson:"total_sell_quantity" json:"total_sell_quantity"`
 	UniqueSymbols     int32     `bson:"unique_symbols" json:"unique_symbols"`
-	LastUpdated       time.Time `bson:"last_updated" json:"last_updated"`
+	// Symbols lists the symbols traded, by name, with their number of trades
+	Symbols     []SymbolTrades `bson:"symbols,omitempty" json:"symbols,omitempty"`
+	LastUpdated time.Time      `bson:"last_updated" json:"last_updated"`
+	// RealizedPnL is computed by FIFO-matching the day's orders; BrokerMTM is the
+	// final MTM of the broker's profit/loss file. They are kept side by side so
+	// differences (charges, carried positions, missing orders) stay visible.
+	RealizedPnL money.Paise  `bson:"realized_pnl" json:"realized_pnl"`
+	BrokerMTM   *money.Paise `bson:"broker_mtm,omitempty" json:"broker_mtm,omitempty"`
+	// GrossPnL is the realized P&L before charges, Charges those of every fill
+	// of the day at the rates then in force, and NetPnL what is left of the first
+	GrossPnL     money.Paise `bson:"gross_pnl" json:"gross_pnl"`
+	Charges      money.Paise `bson:"charges" json:"charges"`
+	NetPnL       money.Paise `bson:"net_pnl" json:"net_pnl"`
+	BuyTurnover  money.Paise `bson:"buy_turnover" json:"buy_turnover"`
+	SellTurnover money.Paise `bson:"sell_turnover" json:"sell_turnover"`
+	// Option seller metrics: premium received on sold options, premium paid on
+	// bought options, their difference, and the share of collected premium kept
+	PremiumCollected   money.Paise `bson:"premium_collected" json:"premium_collected"`
+	PremiumBoughtBack  money.Paise `bson:"premium_bought_back" json:"premium_bought_back"`
+	NetPremium         money.Paise `bson:"net_premium" json:"net_premium"`
+	PremiumRetainedPct *float64    `bson:"premium_retained_pct,omitempty" json:"premium_retained_pct,omitempty"`
+	// Dirty is set while the summary is known to be out of date with the raw orders
+	Dirty bool `bson:"dirty" json:"dirty"`
+	// LastRunID is the import run that last recomputed the summary
+	LastRunID string `bson:"last_import_run_id,omitempty" json:"last_import_run_id,omitempty"`
+}
+
+// SymbolTrades is the number of trades of one symbol in a summary
+type SymbolTrades struct {
+	Symbol string `bson:"symbol" json:"symbol"`
+	Trades int32  `bson:"trades" json:"trades"`
+}
+
+// isOption reports whether the order is in an option contract. Orders stored
+// before instrument types were recorded are options when they have a strike.
+func isOption(order Order) bool {
+	if order.InstrumentType != "" {
+		return order.InstrumentType == string(symbol.Call) || order.InstrumentType == string(symbol.Put)
+	}
+	return order.MetaData.StrikePrice > 0
+}
+
+// addCharges sets the gross and net P&L from the realized P&L and the
+// charges of the day's orders
+func (summary *DailySummary) addCharges(orders []Order, schedule charges.Schedule) {
+	summary.GrossPnL = summary.RealizedPnL
+	summary.Charges = schedule.Compute(Fills(orders)).Total
+	summary.NetPnL = summary.GrossPnL - summary.Charges
+}
+
+// addTurnover fills in the buy/sell turnover and option premium metrics from the day's non-voided orders
+func (summary *DailySummary) addTurnover(orders []Order) {
+	var buy, sell, boughtBack, collected money.Paise
+	for _, order := range orders {
+		value := money.Value(order.AveragePrice, order.Quantity)
+		if order.TransactionType == "B" {
+			buy += value
+			if isOption(order) {
+				boughtBack += value
+			}
+		} else {
+			sell += value
+			if isOption(order) {
+				collected += value
+			}
+		}
+	}
+
+	summary.BuyTurnover, summary.SellTurnover = buy, sell
+	summary.PremiumBoughtBack, summary.PremiumCollected = boughtBack, collected
+	summary.NetPremium = collected - boughtBack
+	if summary.PremiumCollected > 0 {
+		retained := summary.NetPremium.Rupees() / summary.PremiumCollected.Rupees() * 100
+		summary.PremiumRetainedPct = &retained
+	}
+}
+
+// ErrReadOnly is returned by every write when the OrderBook was opened read-only
+var ErrReadOnly = errors.New("orderbook is in read-only mode")
+
+// Options configures an OrderBook
+type Options struct {
+	MongoURI string
+	Account  string
+	// ReadOnly refuses every write and skips collection and index setup,
+	// so the OrderBook can safely be pointed at a production database
+	ReadOnly bool
+	Client   ClientTuning
+	Archive  ArchiveOptions
+	// Rollups are the rollups maintained for the account; nil keeps all of them
+	Rollups []RollupKind
+	// Events receives batch and summary lifecycle events; may be nil
+	Events *events.Bus
+	Writer WriterOptions
+	// ValidateSymbols rejects orders whose symbol is not in the instrument
+	// master and records the contract's token, lot and tick size on the rest
+	ValidateSymbols bool
+	// Duplicates decides what happens to imported orders already stored; empty skips them
+	Duplicates DuplicatePolicy
+	// RunID is recorded on every order stored and summary recomputed, until SetRunID changes it
+	RunID string
+	// Reimport decides what happens to files whose content was already imported; empty skips them
+	Reimport ReimportPolicy
+	// Retry retries writes and aggregations failing with transient errors
+	Retry RetryPolicy
+	// ParseMode fails files on bad rows or skips and quarantines them
+	ParseMode ParseMode
+	// Parser reads every CSV file; nil picks each file's parser by its name, see ParserFor
+	Parser Parser
+	// Unfilled decides whether rejected, cancelled and open orders are stored; empty stores them tagged
+	Unfilled UnfilledPolicy
+	// Charges are the rates the net P&L of summaries is computed at; nil uses charges.DefaultSchedule
+	Charges charges.Schedule
 }
 
 // OrderBook handles MongoDB operations
 type OrderBook struct {
+	account           string
+	readOnly          bool
 	client            *mongo.Client
 	ordersCollection  *mongo.Collection
 	summaryCollection *mongo.Collection
+	symbolSummaries   *mongo.Collection // Daily summaries per symbol, see SymbolSummary
+	periodSummaries   *mongo.Collection // Weekly and monthly summaries, see PeriodSummary
+	performance       *mongo.Collection // Trade statistics per day, week and month, see PerformanceStats
+	auditCollection   *mongo.Collection
+	processedFiles    *mongo.Collection
+	checkpoints       *mongo.Collection
+	quarantine        *mongo.Collection // Rows that could not be parsed, see QuarantinedRow
+	tradeBook         *mongo.Collection // Fills, linked to ordersCollection by order ID, see Trade
+	roundTrips        *mongo.Collection // Closed trades matched FIFO per day, see ClosedTrade
+	positions         *mongo.Collection // End-of-day open positions, see Position
+	// Read-only view of the profit/loss samples, for the broker MTM in summaries
+	profitLossCollection *mongo.Collection
+	// Bumped after every recompute, see DataVersion
+	version *dataversion.Counter
+
+	// Cold storage for archived orders; archiveClient is nil when it shares client
+	archiveClient *mongo.Client
+	archiveDB     *mongo.Database
+	archiveOrders *mongo.Collection
+	archivePlan   *archive.Planner
+
+	rollups []RollupKind
+	events  *events.Bus
+	writer  WriterOptions
+
+	duplicates DuplicatePolicy
+	reimport   ReimportPolicy
+	retries    RetryPolicy
+	parseMode  ParseMode
+	parser     Parser
+	unfilled   UnfilledPolicy
+	charges    charges.Schedule
+
+	runMu sync.RWMutex
+	runID string
+
+	// Days awaiting their recompute while deferring, see DeferRecompute
+	pendingMu sync.Mutex
+	deferring bool
+	pending   []time.Time
+
+	// instruments is nil unless symbols are validated
+	instruments *instruments.Master
 }
 
-// NewOrderBook creates a new OrderBook instance
-func NewOrderBook(ctx context.Context, mongoURI string) (*OrderBook, error) {
-	client, err := mongo.Connect(ctx, options.Client().ApplyURI(mongoURI))
+// NewOrderBook creates a new OrderBook instance for the configured account
+func NewOrderBook(ctx context.Context, opts Options) (*OrderBook, error) {
+	clientOpts, err := clientOptions(opts.MongoURI, opts.Client)
+	if err != nil {
+		return nil, fmt.Errorf("invalid MongoDB client options: %v", err)
+	}
+
+	client, err := mongo.Connect(ctx, clientOpts)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to MongoDB: %v", err)
 	}
@@ -61,7 +372,74 @@ func NewOrderBook(ctx context.Context, mongoURI string) (*OrderBook, error) {
 		return nil, fmt.Errorf("failed to ping database: %v", err)
 	}
 
+	account := opts.Account
+	if account == "" {
+		account = constants.DEFAULT_ACCOUNT
+	}
+
 	db := client.Database(constants.DB_NAME)
+	ob := &OrderBook{
+		account:           account,
+		readOnly:          opts.ReadOnly,
+		client:            client,
+		ordersCollection:  db.Collection(constants.ORDERBOOK_SCHEMA),
+		summaryCollection: db.Collection(constants.DAILY_SUMMARY_SCHEMA),
+		auditCollection:   db.Collection(constants.ORDER_AUDIT_SCHEMA),
+		processedFiles:    db.Collection(constants.PROCESSED_FILES_SCHEMA),
+		checkpoints:       db.Collection(constants.IMPORT_CHECKPOINTS_SCHEMA),
+		quarantine:        db.Collection(constants.QUARANTINE_SCHEMA),
+		tradeBook:         db.Collection(constants.TRADEBOOK_SCHEMA),
+		roundTrips:        db.Collection(constants.ROUND_TRIPS_SCHEMA),
+		symbolSummaries:   db.Collection(constants.SYMBOL_SUMMARY_SCHEMA),
+		periodSummaries:   db.Collection(constants.PERIOD_SUMMARY_SCHEMA),
+		performance:       db.Collection(constants.PERFORMANCE_STATS_SCHEMA),
+		positions:         db.Collection(constants.POSITIONS_SCHEMA),
+
+		profitLossCollection: db.Collection(constants.PROFITLOSS_SCHEMA),
+		version:              dataversion.New(db, account),
+
+		rollups: opts.Rollups,
+		events:  opts.Events,
+		writer:  opts.Writer,
+
+		duplicates: opts.Duplicates,
+		reimport:   opts.Reimport,
+		retries:    opts.Retry,
+		parseMode:  opts.ParseMode,
+		parser:     opts.Parser,
+		unfilled:   opts.Unfilled,
+		charges:    opts.Charges,
+		runID:      opts.RunID,
+	}
+	if ob.duplicates == "" {
+		ob.duplicates = DuplicateSkip
+	}
+	if ob.reimport == "" {
+		ob.reimport = ReimportSkip
+	}
+	if ob.unfilled == "" {
+		ob.unfilled = UnfilledTag
+	}
+	if ob.charges == nil {
+		ob.charges = charges.DefaultSchedule
+	}
+	if ob.rollups == nil {
+		ob.rollups = RollupKinds
+	}
+
+	if err := ob.openArchive(ctx, opts); err != nil {
+		return nil, err
+	}
+
+	if opts.ValidateSymbols {
+		if err := ob.loadInstruments(ctx, db); err != nil {
+			return nil, err
+		}
+	}
+
+	if ob.readOnly {
+		return ob, nil
+	}
 
 	// Create time series collection for orders
 	timeSeriesOpts := options.CreateCollection().SetTimeSeriesOptions(
@@ -71,190 +449,833 @@ func NewOrderBook(ctx context.Context, mongoURI string) (*OrderBook, error) {
 			SetGranularity("minutes"),
 	)
 
-	if err := db.CreateCollection(ctx, "orders", timeSeriesOpts); err != nil {
+	if err := db.CreateCollection(ctx, constants.ORDERS_TIMESERIES_SCHEMA, timeSeriesOpts); err != nil {
 		// Ignore error if collection already exists
-		if !mongo.IsDuplicateKeyError(err) {
+		if !isNamespaceExists(err) {
 			return nil, fmt.Errorf("failed to create time series collection: %v", err)
 		}
 	}
 
-	return &OrderBook{
-		client:            client,
-		ordersCollection:  db.Collection(constants.ORDERBOOK_SCHEMA),
-		summaryCollection: db.Collection(constants.DAILY_SUMMARY_SCHEMA),
-	}, nil
+	if err := ob.ensureIndexes(ctx); err != nil {
+		return nil, err
+	}
+
+	return ob, nil
 }
 
-// extractMetadata extracts strike price and option type from symbol
-func extractMetadata(symbol string) (int, string) {
-	// Extract strike price - assuming it's the last numbers in the symbol
-	strikePrice := 0
-	for i := len(symbol) - 1; i >= 0; i-- {
-		if symbol[i] >= '0' && symbol[i] <= '9' {
-			continue
+// loadInstruments reads the instrument master symbols are validated against
+func (ob *OrderBook) loadInstruments(ctx context.Context, db *mongo.Database) error {
+	repo, err := instruments.NewRepository(db)
+	if err != nil {
+		return err
+	}
+	master, err := repo.Master(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load instrument master: %v", err)
+	}
+	if master.Len() == 0 {
+		return fmt.Errorf("symbol validation is enabled but the instrument master is empty; load it with the instruments command")
+	}
+	ob.instruments = master
+	return nil
+}
+
+// checkWritable returns ErrReadOnly when writes are disabled
+func (ob *OrderBook) checkWritable() error {
+	if ob.readOnly {
+		return ErrReadOnly
+	}
+	return nil
+}
+
+// Account returns the account the OrderBook is scoped to
+func (ob *OrderBook) Account() string {
+	return ob.account
+}
+
+// RunID returns the import run orders and summaries are currently recorded under
+func (ob *OrderBook) RunID() string {
+	ob.runMu.RLock()
+	defer ob.runMu.RUnlock()
+	return ob.runID
+}
+
+// SetRunID starts a new import run, e.g. for each file a long-running daemon ingests
+func (ob *OrderBook) SetRunID(runID string) {
+	ob.runMu.Lock()
+	defer ob.runMu.Unlock()
+	ob.runID = runID
+}
+
+// Events returns the bus lifecycle events are published on, possibly nil
+func (ob *OrderBook) Events() *events.Bus {
+	return ob.events
+}
+
+// IsReadOnly reports whether the OrderBook refuses writes
+func (ob *OrderBook) IsReadOnly() bool {
+	return ob.readOnly
+}
+
+// isNamespaceExists reports whether err is MongoDB's "collection already exists" error
+func isNamespaceExists(err error) bool {
+	var cmdErr mongo.CommandError
+	return errors.As(err, &cmdErr) && cmdErr.Code == 48
+}
+
+// ensureIndexes creates the indexes the OrderBook relies on
+func (ob *OrderBook) ensureIndexes(ctx context.Context) error {
+	// One summary document per account per day, even with concurrent imports
+	_, err := ob.summaryCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "account", Value: 1}, {Key: "date", Value: 1}},
+		Options: options.Index().SetUnique(true).SetName("account_date_unique"),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create daily summary index: %v", err)
+	}
+
+	// One copy of each fill per version, whatever the import does; imports also
+	// look up the stored copies of incoming orders through its prefix. Orders
+	// keyed by hand or stored before versions were recorded are left out.
+	if err := ob.migrateDedupIndex(ctx); err != nil {
+		return err
+	}
+	_, err = ob.ordersCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "account", Value: 1}, {Key: "dedup_key", Value: 1}, {Key: "version", Value: 1}},
+		Options: options.Index().SetUnique(true).SetName(dedupIndex).
+			SetPartialFilterExpression(bson.M{"dedup_key": bson.M{"$exists": true}, "version": bson.M{"$exists": true}}),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create dedup key index: %v", err)
+	}
+	// Superseded by the unique index's prefix
+	if err := dropIndex(ctx, ob.ordersCollection, "account_dedup_key"); err != nil {
+		return err
+	}
+
+	// Orders of the contracts expiring on a day, see GetOrdersByExpiry
+	_, err = ob.ordersCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "account", Value: 1}, {Key: "metadata.expiry", Value: 1}, {Key: "timestamp", Value: 1}},
+		Options: options.Index().SetName("account_expiry_timestamp").
+			SetPartialFilterExpression(bson.M{"metadata.expiry": bson.M{"$exists": true}}),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create expiry index: %v", err)
+	}
+
+	// The orders of a broker order ID, see GetOrdersByOrderID
+	_, err = ob.ordersCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "account", Value: 1}, {Key: "order_id", Value: 1}},
+		Options: options.Index().SetName("account_order_id").
+			SetPartialFilterExpression(bson.M{"order_id": bson.M{"$exists": true}}),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create order ID index: %v", err)
+	}
+
+	// One copy of each fill in the trade book, looked up by order
+	_, err = ob.tradeBook.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "account", Value: 1}, {Key: "order_id", Value: 1}, {Key: "trade_id", Value: 1}},
+		Options: options.Index().SetUnique(true).SetName("account_order_trade_unique"),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create trade book index: %v", err)
+	}
+
+	// The closed trades of a day, replaced when the day is recomputed
+	_, err = ob.roundTrips.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "account", Value: 1}, {Key: "date", Value: 1}, {Key: "exit_time", Value: 1}},
+		Options: options.Index().SetName("account_date_exit_time"),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create round trips index: %v", err)
+	}
+
+	// One summary per symbol per day
+	_, err = ob.symbolSummaries.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "account", Value: 1}, {Key: "date", Value: 1}, {Key: "symbol", Value: 1}},
+		Options: options.Index().SetUnique(true).SetName("account_date_symbol_unique"),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create symbol summaries index: %v", err)
+	}
+
+	// One summary per week or month
+	_, err = ob.periodSummaries.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "account", Value: 1}, {Key: "period", Value: 1}, {Key: "period_start", Value: 1}},
+		Options: options.Index().SetUnique(true).SetName("account_period_start_unique"),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create period summaries index: %v", err)
+	}
+
+	// One set of statistics per day, week or month
+	_, err = ob.performance.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "account", Value: 1}, {Key: "period", Value: 1}, {Key: "period_start", Value: 1}},
+		Options: options.Index().SetUnique(true).SetName("account_period_start_unique"),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create performance stats index: %v", err)
+	}
+
+	// The open positions of a day, by symbol
+	_, err = ob.positions.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "account", Value: 1}, {Key: "date", Value: 1}, {Key: "symbol", Value: 1}},
+		Options: options.Index().SetUnique(true).SetName("account_date_symbol_unique"),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create positions index: %v", err)
+	}
+
+	// One registry entry per account per file content
+	_, err = ob.processedFiles.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "account", Value: 1}, {Key: "checksum", Value: 1}},
+		Options: options.Index().SetUnique(true).SetName("account_checksum_unique"),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create processed files index: %v", err)
+	}
+
+	// One resume point per account per file content
+	_, err = ob.checkpoints.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "account", Value: 1}, {Key: "checksum", Value: 1}},
+		Options: options.Index().SetUnique(true).SetName("account_checksum_unique"),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create import checkpoints index: %v", err)
+	}
+
+	return nil
+}
+
+// setContract fills in the instrument type, expiry and, for options, the
+// strike and option type parsed from the order's symbol. A type the export
+// set, e.g. CRYPTO, is kept and the symbol is not parsed.
+func setContract(order *Order) {
+	if order.InstrumentType != "" {
+		return
+	}
+	contract := symbol.Parse(order.Symbol)
+	order.InstrumentType = string(contract.Type)
+	order.MetaData.Expiry = contract.Expiry
+	order.MetaData.StrikePrice, order.MetaData.OptionType = 0, ""
+	switch contract.Type {
+	case symbol.Call:
+		order.MetaData.StrikePrice, order.MetaData.OptionType = contract.Strike, "C"
+	case symbol.Put:
+		order.MetaData.StrikePrice, order.MetaData.OptionType = contract.Strike, "P"
+	}
+}
+
+// validateOrder checks the fields every stored order must have
+func validateOrder(order Order) error {
+	if order.Timestamp.IsZero() {
+		return fmt.Errorf("missing timestamp")
+	}
+	if order.Symbol == "" {
+		return fmt.Errorf("missing symbol")
+	}
+	if order.TransactionType != "B" && order.TransactionType != "S" {
+		return fmt.Errorf("transaction type must be B or S, got %q", order.TransactionType)
+	}
+	if !(order.Quantity > 0) || math.IsInf(order.Quantity, 1) {
+		return fmt.Errorf("quantity must be positive, got %v", order.Quantity)
+	}
+	if order.AveragePrice < 0 {
+		return fmt.Errorf("average price must not be negative, got %v", order.AveragePrice)
+	}
+	return nil
+}
+
+// prepareOrder validates an order and fills in the account, contract metadata and,
+// when symbols are validated, the instrument master data
+func (ob *OrderBook) prepareOrder(order *Order) error {
+	if err := validateOrder(*order); err != nil {
+		return err
+	}
+
+	var expiry *time.Time
+	if ob.instruments != nil {
+		instrument, ok := ob.instruments.Lookup(order.Symbol)
+		if !ok {
+			return fmt.Errorf("unknown symbol %q: not in the instrument master", order.Symbol)
 		}
-		if i+1 < len(symbol) {
-			strikePrice, _ = strconv.Atoi(symbol[i+1:])
+		order.Instrument = &InstrumentInfo{
+			Exchange: instrument.Exchange,
+			Token:    instrument.Token,
+			LotSize:  instrument.LotSize,
+			TickSize: instrument.TickSize,
+		}
+		if instrument.Expiry != nil {
+			// The master's dates carry no zone; take the day as it is written
+			y, m, d := instrument.Expiry.Date()
+			day := time.Date(y, m, d, 0, 0, 0, 0, market.Location())
+			expiry = &day
+		}
+	}
+
+	order.Account = ob.account
+	order.ImportRunID = ob.RunID()
+	order.OrderStatus = strings.ToUpper(strings.TrimSpace(order.OrderStatus))
+	setContract(order)
+	// The exchange's expiry wins over the one worked out from the symbol,
+	// which follows NSE's expiry day for every exchange
+	if expiry != nil {
+		order.MetaData.Expiry = expiry
+	}
+	order.DedupKey = dedupKey(*order)
+
+	return nil
+}
+
+// ImportOrders prepares and stores orders fetched from a source other than a
+// CSV file, e.g. a broker API, as one import of source
+func (ob *OrderBook) ImportOrders(ctx context.Context, orders []Order, source string) error {
+	for i := range orders {
+		orders[i].Source = source
+		if err := ob.prepareOrder(&orders[i]); err != nil {
+			return fmt.Errorf("invalid order %d of %s (%s): %v", i+1, source, orders[i].Symbol, err)
 		}
-		break
+	}
+	return ob.InsertOrders(ctx, orders)
+}
+
+// AddOrder stores a single manually keyed order, e.g. a fill missing from the
+// broker export or an off-platform trade, and updates that day's summary
+func (ob *OrderBook) AddOrder(ctx context.Context, order Order) (*Order, error) {
+	if err := ob.checkWritable(); err != nil {
+		return nil, err
 	}
 
-	// Determine option type
-	optionType := "C"
-	if symbol[len(symbol)-5] == 'P' {
-		optionType = "P"
+	if err := ob.prepareOrder(&order); err != nil {
+		return nil, fmt.Errorf("invalid order: %v", err)
 	}
+	tagUnfilled(&order)
 
-	return strikePrice, optionType
+	result, err := ob.ordersCollection.InsertOne(ctx, order)
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert order: %v", err)
+	}
+	if id, ok := result.InsertedID.(primitive.ObjectID); ok {
+		order.ID = id
+	}
+	ob.events.Publish(ctx, events.Event{
+		Type: events.BatchInserted, Account: ob.account, RunID: ob.RunID(), Source: order.Source, Kind: "orders", Count: 1,
+	})
+
+	if err := ob.invalidateDates(ctx, []time.Time{order.Timestamp}); err != nil {
+		return nil, err
+	}
+
+	return &order, nil
 }
 
 // LoadCSVFile loads orders from a CSV file
 func (ob *OrderBook) LoadCSVFile(ctx context.Context, filename string) error {
+	if err := ob.checkWritable(); err != nil {
+		return err
+	}
+
 	file, err := os.Open(filename)
 	if err != nil {
 		return fmt.Errorf("failed to open file: %v", err)
 	}
 	defer file.Close()
 
-	reader := csv.NewReader(file)
-	// Skip header
-	if _, err := reader.Read(); err != nil {
-		return fmt.Errorf("failed to read header: %v", err)
+	return ob.LoadCSV(ctx, file, filename)
+}
+
+// LoadCSV loads orders from CSV data; source names where the data came from
+// (a path or URL) and is recorded on every order. Rows are streamed to MongoDB
+// in batches. Rows that cannot be parsed fail the file in strict parse mode;
+// in lenient mode they are quarantined and the rest of the file is loaded.
+func (ob *OrderBook) LoadCSV(ctx context.Context, r io.Reader, source string) error {
+	if err := ob.checkWritable(); err != nil {
+		return err
 	}
 
-	var orders []interface{}
-	tradeDate := time.Time{}
+	writer := ob.newBatchWriter(ctx, source)
+	if err := ob.parseCSV(r, source, writer.Add, ob.rejecter(ctx, source)); err != nil {
+		writer.Abort(ctx)
+		return err
+	}
 
-	for {
-		record, err := reader.Read()
-		if err != nil {
-			break
+	return writer.Close(ctx)
+}
+
+// ParseCSV reads and validates orders from CSV data without storing them;
+// bad rows are treated as the parse mode says
+func (ob *OrderBook) ParseCSV(ctx context.Context, r io.Reader, source string) ([]Order, error) {
+	var orders []Order
+	err := ob.parseCSV(r, source, func(order Order) error {
+		orders = append(orders, order)
+		return nil
+	}, ob.rejecter(ctx, source))
+	if err != nil {
+		return nil, err
+	}
+	return orders, nil
+}
+
+// ParseOrders reads and validates orders for account from CSV data, for
+// storage backends other than MongoDB. Symbols are not validated against the
+// instrument master. In lenient mode bad rows are skipped and returned.
+func ParseOrders(r io.Reader, source, account string, mode ParseMode, parser Parser) ([]Order, []RowFailure, error) {
+	if account == "" {
+		account = constants.DEFAULT_ACCOUNT
+	}
+
+	var orders []Order
+	var skipped []RowFailure
+	var reject rejectFunc
+	if mode != ParseStrict {
+		reject = func(row int, header, record []string, err error) error {
+			skipped = append(skipped, RowFailure{Row: row, Symbol: cell(record, 2), Message: err.Error()})
+			return nil
 		}
+	}
 
-		fmt.Println("All record", record[0], record[1], record[2], record[3], record[4], record[5], record[6])
-		timestamp, err := time.Parse("2006-01-02T15:04:05-07:00", record[0])
-		if err != nil {
-			return fmt.Errorf("failed to parse timestamp: %v", err)
+	err := (&OrderBook{account: account, parser: parser}).parseCSV(r, source, func(order Order) error {
+		orders = append(orders, order)
+		return nil
+	}, reject)
+	if err != nil {
+		return nil, nil, err
+	}
+	return orders, skipped, nil
+}
+
+// SummarizeDay computes the summary of one market day from its orders, charged
+// at schedule; voided and unfilled orders are skipped. Storage backends
+// without an aggregation pipeline use it.
+func SummarizeDay(account string, day time.Time, orders []Order, schedule charges.Schedule) DailySummary {
+	summary := DailySummary{
+		Account:     account,
+		Date:        day,
+		LastUpdated: time.Now(),
+	}
+
+	var active []Order
+	symbols := map[string]int32{}
+	for _, order := range orders {
+		if order.Voided || !order.Filled() {
+			continue
 		}
+		active = append(active, order)
+		summary.TotalTrades++
+		if order.TransactionType == "B" {
+			summary.TotalBuyQuantity += order.Quantity
+		} else {
+			summary.TotalSellQuantity += order.Quantity
+		}
+		symbols[order.Symbol]++
+	}
+	summary.UniqueSymbols = int32(len(symbols))
+	for sym, n := range symbols {
+		summary.Symbols = append(summary.Symbols, SymbolTrades{Symbol: sym, Trades: n})
+	}
+	sort.Slice(summary.Symbols, func(i, j int) bool { return summary.Symbols[i].Symbol < summary.Symbols[j].Symbol })
+
+	roundTrips, _ := trades.MatchFIFO(Fills(active))
+	summary.RealizedPnL = trades.RealizedPnL(roundTrips)
+	summary.addTurnover(active)
+	summary.addCharges(active, schedule)
+
+	return summary
+}
+
+// csvColumns locates the columns of an orderbook CSV header; fields holds
+// the index of each CSVHeader column, -1 for a missing optional one
+type csvColumns struct {
+	fields                 []int
+	date                   int // Date of the timestamp column's time of day, -1 when it holds both
+	execution, notes, tags int
+	orderID, exchangeID    int
+	// Layouts of the timestamp and execution time columns, detected per file
+	timestamps, executions *timestampFormat
+	format                 CSVFormat
+}
 
-		quantity, _ := strconv.Atoi(record[4])
-		price, _ := strconv.ParseFloat(record[5], 64)
+// newCSVColumns finds the columns by header name, trying the format's names
+// before the generic aliases. A header naming none of the CSVHeader columns
+// is taken to be in the canonical order.
+func newCSVColumns(header []string, format CSVFormat) (csvColumns, error) {
+	columns := csvColumns{
+		fields: make([]int, len(CSVHeader)),
+		date:   -1,
+		// Exports that also carry the exchange execution time get it recorded
+		execution:  findColumn(header, executionTimeColumns),
+		notes:      findColumn(header, notesColumns),
+		tags:       findColumn(header, tagsColumns),
+		orderID:    findColumn(header, orderIDColumns),
+		exchangeID: findColumn(header, exchangeOrderIDColumns),
+		timestamps: &timestampFormat{location: format.Location},
+		executions: &timestampFormat{location: format.Location},
+		format:     format,
+	}
 
-		strikePrice, optionType := extractMetadata(record[2])
+	found := false
+	for i, aliases := range csvColumnAliases {
+		columns.fields[i] = findColumn(header, format.Columns[CSVHeader[i]])
+		if columns.fields[i] < 0 {
+			columns.fields[i] = findColumn(header, aliases)
+		}
+		found = found || columns.fields[i] >= 0
+	}
+	if !found {
+		for i := range columns.fields {
+			columns.fields[i] = i
+		}
+		return columns, nil
+	}
 
-		order := Order{
-			Timestamp:       timestamp,
-			TransactionType: record[1],
-			Symbol:          record[2],
-			Product:         record[3],
-			Quantity:        int32(quantity),
-			AveragePrice:    price,
-			OrderStatus:     record[6],
+	var missing []string
+	for i, name := range CSVHeader {
+		if columns.fields[i] < 0 && !optionalCSVColumns[name] {
+			missing = append(missing, name)
 		}
-		order.MetaData.StrikePrice = strikePrice
-		order.MetaData.OptionType = optionType
+	}
+	if len(missing) > 0 {
+		return columns, fmt.Errorf("header has no %s column", strings.Join(missing, ", "))
+	}
+	if len(format.DateColumns) > 0 {
+		if columns.date = findColumn(header, format.DateColumns); columns.date == columns.fields[0] {
+			columns.date = -1
+		}
+	}
+	return columns, nil
+}
 
-		orders = append(orders, order)
-		tradeDate = timestamp
+// field returns the record's value of CSVHeader column i
+func (c csvColumns) field(record []string, i int) string {
+	return cell(record, c.fields[i])
+}
+
+// timestamp returns the record's timestamp, prefixed with its date when the
+// format keeps the date in a column of its own
+func (c csvColumns) timestamp(record []string) string {
+	timestamp := strings.TrimSpace(c.field(record, 0))
+	if date := strings.TrimSpace(cell(record, c.date)); date != "" {
+		return date + " " + timestamp
+	}
+	return timestamp
+}
+
+// number parses a numeric column, dropping the asset suffix and digit
+// grouping of formats that have them
+func (c csvColumns) number(record []string, i int) (float64, error) {
+	value := strings.TrimSpace(c.field(record, i))
+	if c.format.AssetSuffixes {
+		value = strings.TrimRightFunc(strings.ReplaceAll(value, ",", ""), unicode.IsLetter)
+	}
+	return strconv.ParseFloat(value, 64)
+}
+
+// minFields is the number of cells a record needs to hold every required column
+func (c csvColumns) minFields() int {
+	n := 0
+	for i, index := range c.fields {
+		if !optionalCSVColumns[CSVHeader[i]] {
+			n = max(n, index+1)
+		}
 	}
+	return n
+}
+
+// rejectFunc receives a row that could not be parsed. Returning nil skips the
+// row; returning an error stops the import with it.
+type rejectFunc func(row int, header, record []string, err error) error
 
-	// Insert orders in bulk
-	if len(orders) > 0 {
-		_, err = ob.ordersCollection.InsertMany(ctx, orders)
+// parseCSV validates CSV rows one at a time and passes each order to emit.
+// Rows that fail to parse go to reject, or fail the import when it is nil.
+func (ob *OrderBook) parseCSV(r io.Reader, source string, emit func(Order) error, reject rejectFunc) error {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1 // Short rows are rejected one by one rather than ending the file
+	header, err := reader.Read()
+	if err != nil {
+		return fmt.Errorf("failed to read header: %v", err)
+	}
+	parser := ob.parserFor(source)
+	decoder, err := parser.Header(header)
+	if err != nil {
+		return fmt.Errorf("invalid %s header in %s: %v", parser.Name(), source, err)
+	}
+	joiner := &fillJoiner{emit: emit}
+
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return joiner.flush()
+		}
+
+		var order Order
+		if err == nil {
+			order, err = ob.parseRecord(record, decoder, source, line)
+		}
 		if err != nil {
-			return fmt.Errorf("failed to insert orders: %v", err)
+			if reject == nil {
+				return fmt.Errorf("invalid order in %s at line %d: %v", source, line, err)
+			}
+			if err := reject(line, header, record, err); err != nil {
+				return err
+			}
+			continue
 		}
 
-		// Update daily summary
-		if err := ob.updateDailySummary(ctx, tradeDate); err != nil {
-			return fmt.Errorf("failed to update daily summary: %v", err)
+		if err := joiner.add(order); err != nil {
+			return err
 		}
 	}
+}
+
+// parseRecord converts one CSV row into a prepared order
+func (ob *OrderBook) parseRecord(record []string, decoder RowDecoder, source string, line int) (Order, error) {
+	order, err := decoder.Decode(record)
+	if err != nil {
+		return Order{}, err
+	}
+	order.Source = source
+	order.row = line
+
+	if err := ob.prepareOrder(&order); err != nil {
+		return Order{}, err
+	}
+	return order, nil
+}
+
+// Decode converts a record into an order, before validation
+func (columns csvColumns) Decode(record []string) (Order, error) {
+	if need := columns.minFields(); len(record) < need {
+		return Order{}, fmt.Errorf("expected at least %d columns, got %d", need, len(record))
+	}
+
+	timestamp, err := columns.timestamps.parse(columns.timestamp(record))
+	if err != nil {
+		return Order{}, fmt.Errorf("failed to parse timestamp: %v", err)
+	}
+	quantity, err := columns.number(record, 4)
+	if err != nil {
+		return Order{}, fmt.Errorf("failed to parse quantity: %v", err)
+	}
+	price, err := columns.number(record, 5)
+	if err != nil {
+		return Order{}, fmt.Errorf("failed to parse price: %v", err)
+	}
+
+	order := Order{
+		Timestamp:       timestamp,
+		TransactionType: normalizeSide(columns.field(record, 1)),
+		Symbol:          columns.field(record, 2),
+		Product:         columns.field(record, 3),
+		Quantity:        quantity,
+		AveragePrice:    price,
+		OrderStatus:     columns.field(record, 6),
+		InstrumentType:  columns.format.InstrumentType,
+	}
+	if cell(record, columns.execution) != "" {
+		executed, err := columns.executions.parse(record[columns.execution])
+		if err != nil {
+			return Order{}, fmt.Errorf("failed to parse execution time: %v", err)
+		}
+		order.ExecutionTime = &executed
+	}
+	order.Notes = strings.TrimSpace(cell(record, columns.notes))
+	order.Tags = splitTags(cell(record, columns.tags))
+	order.OrderID = strings.TrimSpace(cell(record, columns.orderID))
+	order.ExchangeOrderID = strings.TrimSpace(cell(record, columns.exchangeID))
+	return order, nil
+}
+
+// Canonical returns the record's CSVHeader values, the timestamp joined with
+// its date, followed by the execution time, notes, tags and order IDs
+func (columns csvColumns) Canonical(record []string) []string {
+	values := []string{columns.timestamp(record)}
+	for i := 1; i < len(CSVHeader); i++ {
+		values = append(values, columns.field(record, i))
+	}
+	return append(values, cell(record, columns.execution), cell(record, columns.notes), cell(record, columns.tags),
+		cell(record, columns.orderID), cell(record, columns.exchangeID))
+}
+
+// cell returns column i of record, or "" when the row has no such column
+func cell(record []string, i int) string {
+	if i < 0 || i >= len(record) {
+		return ""
+	}
+	return record[i]
+}
+
+// InsertOrders stores prepared orders in bulk, applying the duplicate policy,
+// and recomputes the summaries and rollups of every day they touch. Orders the
+// database rejects are reported in a PartialWriteError after the rest are stored.
+func (ob *OrderBook) InsertOrders(ctx context.Context, orders []Order) error {
+	if err := ob.checkWritable(); err != nil {
+		return err
+	}
+	if len(orders) == 0 {
+		return nil
+	}
+
+	resolver := ob.newDuplicateResolver()
+	models, written, err := resolver.resolve(ctx, orders)
+	if err != nil {
+		return err
+	}
+	defer resolver.publish(ctx, orders[0].Source)
+	if len(models) == 0 {
+		return nil
+	}
 
+	stored, failed, err := ob.bulkWriteOrders(ctx, models, written)
+	if err != nil {
+		return fmt.Errorf("failed to insert orders: %v", err)
+	}
+	ob.events.Publish(ctx, events.Event{
+		Type: events.BatchInserted, Account: ob.account, RunID: ob.RunID(), Source: orders[0].Source, Kind: "orders", Count: stored,
+	})
+
+	tradeDates := make([]time.Time, len(written))
+	for i, order := range written {
+		tradeDates[i] = order.Timestamp
+	}
+	if err := ob.invalidateDates(ctx, tradeDates); err != nil {
+		return err
+	}
+	if len(failed) > 0 {
+		return &PartialWriteError{Source: orders[0].Source, Written: stored, Failed: failed}
+	}
 	return nil
 }
 
-// updateDailySummary updates the daily summary
+// updateDailySummary updates the daily summary of the market day containing
+// date. Orders and MTM samples are read through the archive planner, so an
+// archived day keeps its counts when it is recomputed.
 func (ob *OrderBook) updateDailySummary(ctx context.Context, date time.Time) error {
-	startOfDay := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
+	startOfDay := market.DayStart(date)
 	endOfDay := startOfDay.Add(24 * time.Hour)
 
-	pipeline := []bson.M{
-		{
-			"$match": bson.M{
-				"timestamp": bson.M{
-					"$gte": startOfDay,
-					"$lt":  endOfDay,
-				},
-			},
-		},
-		{
-			"$group": bson.M{
-				"_id":          nil,
-				"total_trades": bson.M{"$sum": 1},
-				"total_buy_quantity": bson.M{
-					"$sum": bson.M{
-						"$cond": []interface{}{
-							bson.M{"$eq": []interface{}{"$transaction_type", "B"}},
-							"$quantity",
-							0,
-						},
-					},
-				},
-				"total_sell_quantity": bson.M{
-					"$sum": bson.M{
-						"$cond": []interface{}{
-							bson.M{"$eq": []interface{}{"$transaction_type", "S"}},
-							"$quantity",
-							0,
-						},
-					},
-				},
-				"unique_symbols": bson.M{"$addToSet": "$symbol"},
-			},
-		},
+	orders, err := ob.GetOrdersByDateRange(ctx, startOfDay, endOfDay)
+	if err != nil {
+		return err
 	}
 
-	cursor, err := ob.ordersCollection.Aggregate(ctx, pipeline)
-	if err != nil {
-		return fmt.Errorf("failed to aggregate daily summary: %v", err)
+	// An empty day (e.g. every order of the day voided) still resets the summary
+	summary := SummarizeDay(ob.account, startOfDay, orders, ob.charges)
+	summary.LastRunID = ob.RunID()
+
+	roundTrips, _ := trades.MatchFIFO(Fills(orders))
+	if err := ob.storeRoundTrips(ctx, startOfDay, roundTrips, orders); err != nil {
+		return err
+	}
+	if err := ob.storeSymbolSummaries(ctx, startOfDay, SummarizeSymbols(ob.account, startOfDay, orders, roundTrips)); err != nil {
+		return err
 	}
 
-	var results []bson.M
-	if err = cursor.All(ctx, &results); err != nil {
-		return fmt.Errorf("failed to get aggregation results: %v", err)
+	if summary.BrokerMTM, err = ob.brokerMTM(ctx, startOfDay, endOfDay); err != nil {
+		return err
 	}
 
-	if len(results) > 0 {
-		summary := DailySummary{
-			Date:              startOfDay,
-			TotalTrades:       results[0]["total_trades"].(int32),
-			TotalBuyQuantity:  results[0]["total_buy_quantity"].(int32),
-			TotalSellQuantity: results[0]["total_sell_quantity"].(int32),
-			// UniqueSymbols:     len(results[0]["unique_symbols"].(bson.A)),
-			LastUpdated: time.Now(),
-		}
+	if err := ob.upsertDailySummary(ctx, summary); err != nil {
+		return err
+	}
+	ob.events.Publish(ctx, events.Event{Type: events.SummaryUpdated, Account: ob.account, RunID: ob.RunID(), Day: startOfDay})
+
+	return nil
+}
+
+// mtmSample is the last profit/loss sample of a day in one storage tier
+type mtmSample struct {
+	Timestamp time.Time   `bson:"timestamp"`
+	Value     money.Paise `bson:"value"`
+}
+
+// brokerMTM returns the last MTM sample of the broker's profit/loss data in
+// [start, end), hot or archived, or nil when no profit/loss file was ingested
+// for the day
+func (ob *OrderBook) brokerMTM(ctx context.Context, start, end time.Time) (*money.Paise, error) {
+	timestamp := bson.M{"$gte": start, "$lt": end}
+	last, err := ob.lastMTMSample(ctx, ob.profitLossCollection, timestamp)
+	if err != nil {
+		return nil, err
+	}
 
-		_, err = ob.summaryCollection.UpdateOne(
-			ctx,
-			bson.M{"date": startOfDay},
-			bson.M{"$set": summary},
-			options.Update().SetUpsert(true),
-		)
+	if ob.archiveDB != nil {
+		archived, ok, err := ob.archivePlan.ArchiveFilter(ctx, start, timestamp)
 		if err != nil {
-			return fmt.Errorf("failed to update daily summary document: %v", err)
+			return nil, fmt.Errorf("failed to plan archive query: %v", err)
+		}
+		if ok {
+			older, err := ob.lastMTMSample(ctx, ob.archiveDB.Collection(constants.PROFITLOSS_SCHEMA), archived)
+			if err != nil {
+				return nil, err
+			}
+			if last == nil || older != nil && older.Timestamp.After(last.Timestamp) {
+				last = older
+			}
+		}
+	}
+
+	if last == nil {
+		return nil, nil
+	}
+	return &last.Value, nil
+}
+
+// lastMTMSample returns the newest of the account's samples in collection
+// matching timestamp, or nil when there is none
+func (ob *OrderBook) lastMTMSample(ctx context.Context, collection *mongo.Collection, timestamp bson.M) (*mtmSample, error) {
+	var last mtmSample
+	err := collection.FindOne(ctx,
+		bson.M{"account": ob.account, "timestamp": timestamp},
+		options.FindOne().SetSort(bson.D{{Key: "timestamp", Value: -1}}),
+	).Decode(&last)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read broker MTM: %v", err)
+	}
+	return &last, nil
+}
+
+// RefreshDays recomputes the summaries and rollups of the days containing the
+// given times, e.g. after new profit/loss data arrived for them
+func (ob *OrderBook) RefreshDays(ctx context.Context, dates []time.Time) error {
+	return ob.invalidateDates(ctx, dates)
+}
+
+// upsertDailySummary writes the summary for its account and date.
+// Two concurrent upserts for the same day can both miss the filter and
+// race to insert; the unique index rejects the loser, which then retries
+// as a plain update against the winner's document.
+func (ob *OrderBook) upsertDailySummary(ctx context.Context, summary DailySummary) error {
+	if err := ob.checkWritable(); err != nil {
+		return err
+	}
+
+	filter := bson.M{"account": summary.Account, "date": summary.Date}
+	update := bson.M{"$set": summary}
+
+	err := ob.retry(ctx, "daily summary update", func() error {
+		_, err := ob.summaryCollection.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+		if mongo.IsDuplicateKeyError(err) {
+			_, err = ob.summaryCollection.UpdateOne(ctx, filter, update)
 		}
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update daily summary document: %v", err)
 	}
 
 	return nil
 }
 
-// GetDailySummary retrieves the summary for a specific date
+// GetDailySummary retrieves the summary of the market day containing date
 func (ob *OrderBook) GetDailySummary(ctx context.Context, date time.Time) (*DailySummary, error) {
-	startOfDay := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
+	startOfDay := market.DayStart(date)
 
 	var summary DailySummary
-	err := ob.summaryCollection.FindOne(ctx, bson.M{"date": startOfDay}).Decode(&summary)
+	err := ob.summaryCollection.FindOne(ctx, bson.M{"account": ob.account, "date": startOfDay}).Decode(&summary)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get daily summary: %v", err)
 	}
@@ -262,8 +1283,112 @@ func (ob *OrderBook) GetDailySummary(ctx context.Context, date time.Time) (*Dail
 	return &summary, nil
 }
 
+// GetOrder loads one of the account's orders by ID, looking in the archive when it is not hot
+func (ob *OrderBook) GetOrder(ctx context.Context, orderID string) (*Order, error) {
+	id, err := primitive.ObjectIDFromHex(orderID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid order ID %q: %v", orderID, err)
+	}
+
+	filter := bson.M{"_id": id, "account": ob.account}
+	for _, collection := range []*mongo.Collection{ob.ordersCollection, ob.archiveOrders} {
+		if collection == nil {
+			continue
+		}
+
+		var order Order
+		err := collection.FindOne(ctx, filter).Decode(&order)
+		if err == nil {
+			return &order, nil
+		}
+		if err != mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("failed to load order %s: %v", orderID, err)
+		}
+	}
+
+	return nil, fmt.Errorf("order %s not found", orderID)
+}
+
+// GetDailySummaries returns the account's daily summaries for days in [from, to), oldest first
+func (ob *OrderBook) GetDailySummaries(ctx context.Context, from, to time.Time) ([]DailySummary, error) {
+	cursor, err := ob.summaryCollection.Find(ctx,
+		bson.M{"account": ob.account, "date": bson.M{"$gte": from, "$lt": to}},
+		options.Find().SetSort(bson.D{{Key: "date", Value: 1}}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query daily summaries: %v", err)
+	}
+	defer cursor.Close(ctx)
+
+	var summaries []DailySummary
+	if err := cursor.All(ctx, &summaries); err != nil {
+		return nil, fmt.Errorf("failed to decode daily summaries: %v", err)
+	}
+	return summaries, nil
+}
+
+// DataVersion identifies the state of the account's data: a counter every
+// recompute of the account's summaries and every profit/loss write increments
+func (ob *OrderBook) DataVersion(ctx context.Context) (string, error) {
+	version, err := ob.version.Get(ctx)
+	if err != nil {
+		return "", err
+	}
+	return strconv.FormatInt(version, 10), nil
+}
+
+// GetOrdersByDateRange retrieves the account's non-voided, filled orders in
+// [from, to), oldest first, including orders moved to the archive
+func (ob *OrderBook) GetOrdersByDateRange(ctx context.Context, from, to time.Time) ([]Order, error) {
+	filter := bson.M{
+		"account":      ob.account,
+		"voided":       bson.M{"$ne": true},
+		"order_status": filledStatus,
+		"timestamp": bson.M{
+			"$gte": from,
+			"$lt":  to,
+		},
+	}
+
+	return ob.findOrders(ctx, filter, from)
+}
+
+// GetOrderBook retrieves every non-voided order placed in [from, to),
+// including rejected and cancelled ones, oldest first
+func (ob *OrderBook) GetOrderBook(ctx context.Context, from, to time.Time) ([]Order, error) {
+	filter := bson.M{
+		"account": ob.account,
+		"voided":  bson.M{"$ne": true},
+		"timestamp": bson.M{
+			"$gte": from,
+			"$lt":  to,
+		},
+	}
+
+	return ob.findOrders(ctx, filter, from)
+}
+
+// GetOrdersByExpiry retrieves the account's non-voided, filled orders in options and
+// futures expiring on the given day, oldest first, including archived orders
+func (ob *OrderBook) GetOrdersByExpiry(ctx context.Context, expiry time.Time) ([]Order, error) {
+	day := market.DayStart(expiry)
+	filter := bson.M{
+		"account":         ob.account,
+		"voided":          bson.M{"$ne": true},
+		"order_status":    filledStatus,
+		"metadata.expiry": day,
+		"timestamp":       bson.M{"$lt": day.AddDate(0, 0, 1)},
+	}
+
+	return ob.findOrders(ctx, filter, time.Time{})
+}
+
 // Close closes the MongoDB connection
 func (ob *OrderBook) Close(ctx context.Context) error {
+	if ob.archiveClient != nil {
+		if err := ob.archiveClient.Disconnect(ctx); err != nil {
+			return err
+		}
+	}
 	return ob.client.Disconnect(ctx)
 }
 
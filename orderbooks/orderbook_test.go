@@ -0,0 +1,23 @@
+package orderbook
+
+import "testing"
+
+func TestSetContract(t *testing.T) {
+	tests := []struct {
+		symbol     string
+		strike     float64
+		optionType string
+	}{
+		{"NIFTY24MAR22000CE", 22000, "C"},
+		{"BANKNIFTY28FEB24P45000.5", 45000.5, "P"},
+		{"NIFTY24MARFUT", 0, ""},
+		{"RELIANCE", 0, ""},
+	}
+	for _, tt := range tests {
+		order := Order{Symbol: tt.symbol}
+		setContract(&order)
+		if order.MetaData.StrikePrice != tt.strike || order.MetaData.OptionType != tt.optionType {
+			t.Errorf("%s: strike %v %q, want %v %q", tt.symbol, order.MetaData.StrikePrice, order.MetaData.OptionType, tt.strike, tt.optionType)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package orderbook
+
+import (
+	"time"
+
+	"profitLossAndTradeInfoToDB/pkg/symbol"
+)
+
+// cryptoFormat is the generic crypto schema: timestamp, side, symbol,
+// quantity and price columns, naive timestamps in UTC and quantities
+// fractional. Exchange formats start from it.
+func cryptoFormat(name string, patterns []string, columns map[string][]string) CSVFormat {
+	return CSVFormat{
+		Name:           name,
+		Patterns:       patterns,
+		Columns:        columns,
+		Location:       time.UTC,
+		AssetSuffixes:  true,
+		InstrumentType: string(symbol.Crypto),
+	}
+}
+
+func init() {
+	RegisterParser(FormatParser{Format: cryptoFormat("crypto", nil, map[string][]string{
+		"timestamp":        {"timestamp", "time", "date", "datetime", "date_utc", "time_utc"},
+		"transaction_type": {"side", "type", "transaction_type"},
+		"symbol":           {"symbol", "pair", "market", "instrument"},
+		"quantity":         {"quantity", "qty", "size", "filled", "executed"},
+		"average_price":    {"price", "average_price", "avg_price"},
+	})})
+
+	// Binance spot trade history: Date(UTC),Pair,Side,Price,Executed,Amount,Fee
+	// where Amount is the quote total, or in older exports
+	// Date(UTC),Market,Type,Price,Amount,Total,Fee,Fee Coin
+	RegisterParser(FormatParser{Format: cryptoFormat("binance", []string{"*binance*"}, map[string][]string{
+		"timestamp":        {"date_utc", "date", "time"},
+		"transaction_type": {"side", "type"},
+		"symbol":           {"pair", "market", "symbol"},
+		"quantity":         {"executed", "amount"},
+		"average_price":    {"price"},
+	})})
+}
@@ -0,0 +1,20 @@
+package orderbook
+
+// Upstox trade and order exports give the date and the time of day in
+// separate columns, e.g. 25-01-2024 and 10:00:00, and sides as Buy/Sell
+func init() {
+	RegisterParser(FormatParser{Format: CSVFormat{
+		Name:     "upstox",
+		Patterns: []string{"*upstox*"},
+		Columns: map[string][]string{
+			"timestamp":        {"trade_time", "order_time", "time"},
+			"transaction_type": {"side", "buy_sell", "transaction_type", "trade_type"},
+			"symbol":           {"symbol", "trading_symbol", "scrip_name", "instrument"},
+			"product":          {"product", "product_type"},
+			"quantity":         {"quantity", "qty", "traded_qty", "filled_qty"},
+			"average_price":    {"price", "trade_price", "avg_price", "average_price", "traded_price"},
+			"order_status":     {"status", "order_status"},
+		},
+		DateColumns: []string{"date", "trade_date", "order_date"},
+	}})
+}
@@ -0,0 +1,97 @@
+package orderbook
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Parser decodes one broker's orderbook export. The rows it decodes go
+// through the same pipeline whatever the parser: they are validated, then
+// stored or quarantined alike. Parsers register with RegisterParser and are
+// picked by name (-broker) or by the file name, see ParserFor.
+type Parser interface {
+	// Name selects the parser and is recorded on the rows it quarantines
+	Name() string
+	// Match reports whether a file, by its base name, is one of the parser's exports
+	Match(filename string) bool
+	// Header binds the parser to a file's header row. It must also accept
+	// the canonical columns quarantined rows are exported in, see WriteQuarantineCSV.
+	Header(header []string) (RowDecoder, error)
+}
+
+// RowDecoder decodes the records of one file
+type RowDecoder interface {
+	// Decode converts a record into an order; the pipeline then validates it
+	// and fills in the account, source and contract
+	Decode(record []string) (Order, error)
+	// Canonical returns the record's CSVHeader values followed by its
+	// execution time, notes, tags and order IDs, as quarantined rows are exported
+	Canonical(record []string) []string
+}
+
+var (
+	parsersMu sync.RWMutex
+	parsers   = map[string]Parser{}
+)
+
+// RegisterParser makes a parser available by its name and file names
+func RegisterParser(parser Parser) {
+	parsersMu.Lock()
+	defer parsersMu.Unlock()
+	parsers[parser.Name()] = parser
+}
+
+// ParserNames returns the registered parsers, sorted
+func ParserNames() []string {
+	parsersMu.RLock()
+	defer parsersMu.RUnlock()
+
+	names := make([]string, 0, len(parsers))
+	for name := range parsers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ParserByName looks up a parser; empty selects the generic one
+func ParserByName(name string) (Parser, error) {
+	if name == "" {
+		name = GenericFormat.Name
+	}
+
+	parsersMu.RLock()
+	parser, ok := parsers[strings.ToLower(name)]
+	parsersMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown orderbook parser %q, expected one of %s", name, strings.Join(ParserNames(), ", "))
+	}
+	return parser, nil
+}
+
+// ParserFor picks the parser of a file by its base name: the first, by name,
+// whose exports it matches, or the generic one
+func ParserFor(filename string) Parser {
+	filename = path.Base(filename)
+	for _, name := range ParserNames() {
+		parsersMu.RLock()
+		parser := parsers[name]
+		parsersMu.RUnlock()
+		if parser.Match(filename) {
+			return parser
+		}
+	}
+	parser, _ := ParserByName("")
+	return parser
+}
+
+// parserFor returns the parser of the configured -broker, or the one the file's name picks
+func (ob *OrderBook) parserFor(source string) Parser {
+	if ob.parser != nil {
+		return ob.parser
+	}
+	return ParserFor(source)
+}
@@ -0,0 +1,94 @@
+package orderbook
+
+import "slices"
+
+// orderKey identifies the order an export row belongs to: the broker's order
+// ID, else the exchange's, or "" when the export has neither
+func (o Order) orderKey() string {
+	if o.OrderID != "" {
+		return o.OrderID
+	}
+	if o.ExchangeOrderID != "" {
+		return "exchange:" + o.ExchangeOrderID
+	}
+	return ""
+}
+
+// fillJoiner joins the rows of an order into one logical order as rows are
+// parsed: partial fills are added up and status updates, see updateStatus,
+// replace the order's state. Exports list the rows of an order
+// consecutively, so an order is emitted as soon as a row of another follows it.
+type fillJoiner struct {
+	emit    func(Order) error
+	pending *Order
+}
+
+// add joins order into the pending one when both are rows of the same
+// order, otherwise emits the pending order and holds this one. Rows in the
+// same final status are fills; any other sequence is a status update.
+func (j *fillJoiner) add(order Order) error {
+	if j.pending != nil && sameOrder(*j.pending, order) {
+		if j.pending.InProgress() || j.pending.OrderStatus != order.OrderStatus {
+			updateStatus(j.pending, order)
+		} else {
+			joinFill(j.pending, order)
+		}
+		return nil
+	}
+	if err := j.flush(); err != nil {
+		return err
+	}
+	if order.orderKey() == "" {
+		return j.emit(order)
+	}
+	j.pending = &order
+	return nil
+}
+
+// flush emits the pending order, if any
+func (j *fillJoiner) flush() error {
+	if j.pending == nil {
+		return nil
+	}
+	order := *j.pending
+	j.pending = nil
+	return j.emit(order)
+}
+
+func sameOrder(a, b Order) bool {
+	return a.orderKey() == b.orderKey() && a.Symbol == b.Symbol && a.TransactionType == b.TransactionType
+}
+
+// joinFill adds fill to order: quantities sum at their volume-weighted average
+// price, the order keeps its first time and takes the fill's execution time,
+// status and row, so it is checkpointed after its last fill
+func joinFill(order *Order, fill Order) {
+	quantity := order.Quantity + fill.Quantity
+	order.AveragePrice = (order.AveragePrice*order.Quantity + fill.AveragePrice*fill.Quantity) / quantity
+	order.Quantity = quantity
+	if fill.Timestamp.Before(order.Timestamp) {
+		order.Timestamp = fill.Timestamp
+	}
+	if fill.ExecutionTime != nil && (order.ExecutionTime == nil || fill.ExecutionTime.After(*order.ExecutionTime)) {
+		order.ExecutionTime = fill.ExecutionTime
+	}
+	if fill.OrderStatus != "" {
+		order.OrderStatus = fill.OrderStatus
+	}
+	if order.OrderID == "" {
+		order.OrderID = fill.OrderID
+	}
+	if order.ExchangeOrderID == "" {
+		order.ExchangeOrderID = fill.ExchangeOrderID
+	}
+	for _, tag := range fill.Tags {
+		if !slices.Contains(order.Tags, tag) {
+			order.Tags = append(order.Tags, tag)
+		}
+	}
+	if n := len(order.StatusHistory); n > 0 {
+		order.StatusHistory[n-1].Quantity = order.Quantity
+	}
+	order.row = fill.row
+	order.DedupKey = dedupKey(*order)
+}
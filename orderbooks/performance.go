@@ -0,0 +1,284 @@
+package orderbook
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"profitLossAndTradeInfoToDB/pkg/market"
+	"profitLossAndTradeInfoToDB/pkg/money"
+	"profitLossAndTradeInfoToDB/pkg/trades"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// PerformanceStats are the statistics of the closed trades of a day, week or
+// month. Trades closing flat count as neither wins nor losses.
+type PerformanceStats struct {
+	Account      string        `bson:"account" json:"account"`
+	Period       SummaryPeriod `bson:"period" json:"period"`
+	PeriodStart  time.Time     `bson:"period_start" json:"period_start"`
+	PeriodEnd    time.Time     `bson:"period_end" json:"period_end"` // Start of the next period
+	Trades       int32         `bson:"trades" json:"trades"`
+	Wins         int32         `bson:"wins" json:"wins"`
+	Losses       int32         `bson:"losses" json:"losses"`
+	WinRate      float64       `bson:"win_rate" json:"win_rate"` // Percent of trades with a profit
+	GrossProfit  money.Paise   `bson:"gross_profit" json:"gross_profit"`
+	GrossLoss    money.Paise   `bson:"gross_loss" json:"gross_loss"` // Positive
+	RealizedPnL  money.Paise   `bson:"realized_pnl" json:"realized_pnl"`
+	AvgWin       money.Paise   `bson:"avg_win" json:"avg_win"`
+	AvgLoss      money.Paise   `bson:"avg_loss" json:"avg_loss"`           // Negative
+	ProfitFactor float64       `bson:"profit_factor" json:"profit_factor"` // Gross profit / gross loss; 0 without losses
+	Expectancy   money.Paise   `bson:"expectancy" json:"expectancy"`       // Mean P&L per trade
+	LargestWin   money.Paise   `bson:"largest_win" json:"largest_win"`
+	LargestLoss  money.Paise   `bson:"largest_loss" json:"largest_loss"` // Negative
+
+	// Runs of winning and losing trades by exit time, and of winning and
+	// losing days, see Streaks
+	TradeStreaks Streaks `bson:"trade_streaks" json:"trade_streaks"`
+	DayStreaks   Streaks `bson:"day_streaks" json:"day_streaks"`
+	Days         int32   `bson:"days" json:"days"` // Days with closed trades
+	WinningDays  int32   `bson:"winning_days" json:"winning_days"`
+	LosingDays   int32   `bson:"losing_days" json:"losing_days"`
+
+	LastUpdated time.Time `bson:"last_updated" json:"last_updated"`
+}
+
+// Streaks are the runs of consecutive wins and losses in a sequence of
+// outcomes; a flat outcome ends both. Opening and closing runs are signed,
+// positive for wins, so the streaks of adjacent periods can be joined: the
+// closing run of the whole history is the current streak.
+type Streaks struct {
+	Count       int32 `bson:"count" json:"count"` // Outcomes in the sequence
+	Opening     int32 `bson:"opening" json:"opening"`
+	Closing     int32 `bson:"closing" json:"closing"`
+	LongestWin  int32 `bson:"longest_win" json:"longest_win"`
+	LongestLoss int32 `bson:"longest_loss" json:"longest_loss"`
+}
+
+// streakOf is the streaks of a single outcome, by the sign of pnl
+func streakOf(pnl money.Paise) Streaks {
+	s := Streaks{Count: 1}
+	switch {
+	case pnl > 0:
+		s.Opening, s.Closing, s.LongestWin = 1, 1, 1
+	case pnl < 0:
+		s.Opening, s.Closing, s.LongestLoss = -1, -1, 1
+	}
+	return s
+}
+
+// Join returns the streaks of s followed by next
+func (s Streaks) Join(next Streaks) Streaks {
+	if s.Count == 0 {
+		return next
+	}
+	if next.Count == 0 {
+		return s
+	}
+
+	joined := Streaks{
+		Count:       s.Count + next.Count,
+		Opening:     s.Opening,
+		Closing:     next.Closing,
+		LongestWin:  max(s.LongestWin, next.LongestWin),
+		LongestLoss: max(s.LongestLoss, next.LongestLoss),
+	}
+	if s.Closing > 0 && next.Opening > 0 || s.Closing < 0 && next.Opening < 0 {
+		run := s.Closing + next.Opening
+		if run > 0 {
+			joined.LongestWin = max(joined.LongestWin, run)
+		} else {
+			joined.LongestLoss = max(joined.LongestLoss, -run)
+		}
+		// A sequence that is a single run extends into its neighbour
+		if s.Opening == s.Closing && abs32(s.Opening) == s.Count {
+			joined.Opening = run
+		}
+		if next.Opening == next.Closing && abs32(next.Closing) == next.Count {
+			joined.Closing = run
+		}
+	}
+	return joined
+}
+
+func abs32(v int32) int32 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+// ComputePerformance works out the statistics of a set of closed trades, in
+// exit order; the account, period and day fields are left for the caller
+func ComputePerformance(roundTrips []trades.RoundTrip) PerformanceStats {
+	stats := PerformanceStats{Trades: int32(len(roundTrips))}
+	for _, trip := range roundTrips {
+		stats.RealizedPnL += trip.RealizedPnL
+		stats.TradeStreaks = stats.TradeStreaks.Join(streakOf(trip.RealizedPnL))
+		switch {
+		case trip.RealizedPnL > 0:
+			stats.Wins++
+			stats.GrossProfit += trip.RealizedPnL
+			if trip.RealizedPnL > stats.LargestWin {
+				stats.LargestWin = trip.RealizedPnL
+			}
+		case trip.RealizedPnL < 0:
+			stats.Losses++
+			stats.GrossLoss -= trip.RealizedPnL
+			if trip.RealizedPnL < stats.LargestLoss {
+				stats.LargestLoss = trip.RealizedPnL
+			}
+		}
+	}
+	stats.derive()
+	return stats
+}
+
+// dayPerformance works out the statistics of the closed trades of one day
+func dayPerformance(roundTrips []trades.RoundTrip) PerformanceStats {
+	stats := ComputePerformance(roundTrips)
+	stats.Days = 1
+	stats.DayStreaks = streakOf(stats.RealizedPnL)
+	switch {
+	case stats.RealizedPnL > 0:
+		stats.WinningDays = 1
+	case stats.RealizedPnL < 0:
+		stats.LosingDays = 1
+	}
+	return stats
+}
+
+// CombinePerformance adds up the statistics of consecutive periods, oldest
+// first, into one, e.g. the days of a range; the account and period fields
+// are left unset
+func CombinePerformance(periods []PerformanceStats) PerformanceStats {
+	var stats PerformanceStats
+	for _, p := range periods {
+		stats.TradeStreaks = stats.TradeStreaks.Join(p.TradeStreaks)
+		stats.DayStreaks = stats.DayStreaks.Join(p.DayStreaks)
+		stats.Days += p.Days
+		stats.WinningDays += p.WinningDays
+		stats.LosingDays += p.LosingDays
+		stats.Trades += p.Trades
+		stats.Wins += p.Wins
+		stats.Losses += p.Losses
+		stats.GrossProfit += p.GrossProfit
+		stats.GrossLoss += p.GrossLoss
+		stats.RealizedPnL += p.RealizedPnL
+		stats.LargestWin = max(stats.LargestWin, p.LargestWin)
+		stats.LargestLoss = min(stats.LargestLoss, p.LargestLoss)
+	}
+	stats.derive()
+	return stats
+}
+
+// derive works out the rates and averages from the counts and totals
+func (s *PerformanceStats) derive() {
+	if s.Trades > 0 {
+		s.WinRate = float64(s.Wins) / float64(s.Trades) * 100
+		s.Expectancy = s.RealizedPnL.Div(int64(s.Trades))
+	}
+	if s.Wins > 0 {
+		s.AvgWin = s.GrossProfit.Div(int64(s.Wins))
+	}
+	if s.Losses > 0 {
+		s.AvgLoss = -s.GrossLoss.Div(int64(s.Losses))
+	}
+	if s.GrossLoss > 0 {
+		s.ProfitFactor = float64(s.GrossProfit) / float64(s.GrossLoss)
+	}
+}
+
+// performancePeriods are the periods performance statistics are stored for
+var performancePeriods = []SummaryPeriod{PeriodDay, PeriodWeek, PeriodMonth}
+
+// RefreshPerformanceStats recomputes the daily, weekly and monthly statistics
+// of the periods overlapping [from, to) from the stored closed trades
+func (ob *OrderBook) RefreshPerformanceStats(ctx context.Context, from, to time.Time) error {
+	if err := ob.checkWritable(); err != nil {
+		return err
+	}
+	for _, period := range performancePeriods {
+		if err := ob.refreshPerformanceStats(ctx, period, from, to); err != nil {
+			return fmt.Errorf("failed to refresh %s performance stats: %v", period, err)
+		}
+	}
+	return nil
+}
+
+func (ob *OrderBook) refreshPerformanceStats(ctx context.Context, period SummaryPeriod, from, to time.Time) error {
+	start := period.Start(from)
+	end := period.next(period.Start(to.Add(-time.Nanosecond)))
+
+	closed, err := ob.GetClosedTrades(ctx, start, end)
+	if err != nil {
+		return err
+	}
+	// Each period combines its days, which keeps the day streaks
+	byDay := make(map[int64][]trades.RoundTrip)
+	var days []time.Time
+	for _, trade := range closed {
+		day := market.DayStart(trade.Date)
+		if _, ok := byDay[day.Unix()]; !ok {
+			days = append(days, day)
+		}
+		byDay[day.Unix()] = append(byDay[day.Unix()], trade.RoundTrip)
+	}
+	sort.Slice(days, func(i, j int) bool { return days[i].Before(days[j]) })
+	byPeriod := make(map[int64][]PerformanceStats)
+	for _, day := range days {
+		key := period.Start(day).Unix()
+		byPeriod[key] = append(byPeriod[key], dayPerformance(byDay[day.Unix()]))
+	}
+
+	var docs []interface{}
+	for periodStart := start; periodStart.Before(end); periodStart = period.next(periodStart) {
+		dayStats := byPeriod[periodStart.Unix()]
+		if len(dayStats) == 0 {
+			continue
+		}
+		stats := CombinePerformance(dayStats)
+		stats.Account = ob.account
+		stats.Period = period
+		stats.PeriodStart = periodStart
+		stats.PeriodEnd = period.next(periodStart)
+		stats.LastUpdated = time.Now()
+		docs = append(docs, stats)
+	}
+
+	window := bson.M{"account": ob.account, "period": period, "period_start": bson.M{"$gte": start, "$lt": end}}
+	return ob.retry(ctx, string(period)+" performance stats update", func() error {
+		if _, err := ob.performance.DeleteMany(ctx, window); err != nil {
+			return err
+		}
+		if len(docs) == 0 {
+			return nil
+		}
+		_, err := ob.performance.InsertMany(ctx, docs)
+		return err
+	})
+}
+
+// GetPerformanceStats returns the stored statistics of the days, weeks or
+// months overlapping [from, to), oldest first
+func (ob *OrderBook) GetPerformanceStats(ctx context.Context, period SummaryPeriod, from, to time.Time) ([]PerformanceStats, error) {
+	filter := bson.M{"account": ob.account, "period": period, "period_start": bson.M{"$gte": period.Start(from), "$lt": to}}
+	cursor, err := ob.performance.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "period_start", Value: 1}}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query %s performance stats: %v", period, err)
+	}
+	defer cursor.Close(ctx)
+
+	var stats []PerformanceStats
+	if err := cursor.All(ctx, &stats); err != nil {
+		return nil, fmt.Errorf("failed to decode %s performance stats: %v", period, err)
+	}
+	for i := range stats {
+		stats[i].PeriodStart = stats[i].PeriodStart.In(market.Location())
+		stats[i].PeriodEnd = stats[i].PeriodEnd.In(market.Location())
+	}
+	return stats, nil
+}
@@ -0,0 +1,193 @@
+package orderbook
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"profitLossAndTradeInfoToDB/pkg/market"
+	"profitLossAndTradeInfoToDB/pkg/money"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// SummaryPeriod is the span daily summaries are aggregated over
+type SummaryPeriod string
+
+const (
+	PeriodDay   SummaryPeriod = "day"
+	PeriodWeek  SummaryPeriod = "week" // Monday to Sunday, market time
+	PeriodMonth SummaryPeriod = "month"
+)
+
+// SummaryPeriods are the periods with stored summaries, see RefreshPeriodSummaries
+var SummaryPeriods = []SummaryPeriod{PeriodWeek, PeriodMonth}
+
+// ParseSummaryPeriod validates a period name; empty selects PeriodDay
+func ParseSummaryPeriod(name string) (SummaryPeriod, error) {
+	switch SummaryPeriod(name) {
+	case "", PeriodDay:
+		return PeriodDay, nil
+	case PeriodWeek, PeriodMonth:
+		return SummaryPeriod(name), nil
+	}
+	return "", fmt.Errorf("unknown summary period %q, expected day, week or month", name)
+}
+
+// Start returns the start of the period containing t, in market time
+func (p SummaryPeriod) Start(t time.Time) time.Time {
+	day := market.DayStart(t)
+	switch p {
+	case PeriodWeek:
+		return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+	case PeriodMonth:
+		return time.Date(day.Year(), day.Month(), 1, 0, 0, 0, 0, day.Location())
+	}
+	return day
+}
+
+// next returns the start of the period after the one starting at start
+func (p SummaryPeriod) next(start time.Time) time.Time {
+	switch p {
+	case PeriodWeek:
+		return start.AddDate(0, 0, 7)
+	case PeriodMonth:
+		return start.AddDate(0, 1, 0)
+	}
+	return start.AddDate(0, 0, 1)
+}
+
+// PeriodSummary adds up the daily summaries of a week or month. Broker MTM is
+// the sum over the days that have one, and unique symbols are counted from
+// the symbol summaries.
+type PeriodSummary struct {
+	Account           string        `bson:"account" json:"account"`
+	Period            SummaryPeriod `bson:"period" json:"period"`
+	PeriodStart       time.Time     `bson:"period_start" json:"period_start"`
+	PeriodEnd         time.Time     `bson:"period_end" json:"period_end"` // Start of the next period
+	Days              int32         `bson:"days" json:"days"`             // Days with trades
+	TotalTrades       int32         `bson:"total_trades" json:"total_trades"`
+	TotalBuyQuantity  float64       `bson:"total_buy_quantity" json:"total_buy_quantity"`
+	TotalSellQuantity float64       `bson:"total_sell_quantity" json:"total_sell_quantity"`
+	UniqueSymbols     int32         `bson:"unique_symbols" json:"unique_symbols"`
+	RealizedPnL       money.Paise   `bson:"realized_pnl" json:"realized_pnl"`
+	BrokerMTM         *money.Paise  `bson:"broker_mtm,omitempty" json:"broker_mtm,omitempty"`
+	GrossPnL          money.Paise   `bson:"gross_pnl" json:"gross_pnl"`
+	Charges           money.Paise   `bson:"charges" json:"charges"`
+	NetPnL            money.Paise   `bson:"net_pnl" json:"net_pnl"`
+	BuyTurnover       money.Paise   `bson:"buy_turnover" json:"buy_turnover"`
+	SellTurnover      money.Paise   `bson:"sell_turnover" json:"sell_turnover"`
+	NetPremium        money.Paise   `bson:"net_premium" json:"net_premium"`
+	LastUpdated       time.Time     `bson:"last_updated" json:"last_updated"`
+}
+
+// add folds a daily summary into the period
+func (p *PeriodSummary) add(day DailySummary) {
+	if day.TotalTrades > 0 {
+		p.Days++
+	}
+	p.TotalTrades += day.TotalTrades
+	p.TotalBuyQuantity += day.TotalBuyQuantity
+	p.TotalSellQuantity += day.TotalSellQuantity
+	p.RealizedPnL += day.RealizedPnL
+	p.GrossPnL += day.GrossPnL
+	p.Charges += day.Charges
+	p.NetPnL += day.NetPnL
+	p.BuyTurnover += day.BuyTurnover
+	p.SellTurnover += day.SellTurnover
+	p.NetPremium += day.NetPremium
+	if day.BrokerMTM != nil {
+		var total money.Paise
+		if p.BrokerMTM != nil {
+			total = *p.BrokerMTM
+		}
+		total += *day.BrokerMTM
+		p.BrokerMTM = &total
+	}
+}
+
+// RefreshPeriodSummaries recomputes the weekly and monthly summaries of the
+// periods overlapping [from, to) from the daily summaries
+func (ob *OrderBook) RefreshPeriodSummaries(ctx context.Context, from, to time.Time) error {
+	if err := ob.checkWritable(); err != nil {
+		return err
+	}
+	for _, period := range SummaryPeriods {
+		if err := ob.refreshPeriodSummaries(ctx, period, from, to); err != nil {
+			return fmt.Errorf("failed to refresh %s summaries: %v", period, err)
+		}
+	}
+	return nil
+}
+
+func (ob *OrderBook) refreshPeriodSummaries(ctx context.Context, period SummaryPeriod, from, to time.Time) error {
+	start := period.Start(from)
+	end := period.next(period.Start(to.Add(-time.Nanosecond)))
+
+	days, err := ob.GetDailySummaries(ctx, start, end)
+	if err != nil {
+		return err
+	}
+
+	var docs []interface{}
+	for periodStart := start; periodStart.Before(end); periodStart = period.next(periodStart) {
+		summary := PeriodSummary{
+			Account:     ob.account,
+			Period:      period,
+			PeriodStart: periodStart,
+			PeriodEnd:   period.next(periodStart),
+			LastUpdated: time.Now(),
+		}
+		for _, day := range days {
+			if !day.Date.Before(summary.PeriodStart) && day.Date.Before(summary.PeriodEnd) {
+				summary.add(day)
+			}
+		}
+		if summary.Days == 0 {
+			continue
+		}
+
+		symbols, err := ob.symbolSummaries.Distinct(ctx, "symbol", bson.M{
+			"account": ob.account, "date": bson.M{"$gte": summary.PeriodStart, "$lt": summary.PeriodEnd},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to count symbols: %v", err)
+		}
+		summary.UniqueSymbols = int32(len(symbols))
+		docs = append(docs, summary)
+	}
+
+	window := bson.M{"account": ob.account, "period": period, "period_start": bson.M{"$gte": start, "$lt": end}}
+	return ob.retry(ctx, string(period)+" summary update", func() error {
+		if _, err := ob.periodSummaries.DeleteMany(ctx, window); err != nil {
+			return err
+		}
+		if len(docs) == 0 {
+			return nil
+		}
+		_, err := ob.periodSummaries.InsertMany(ctx, docs)
+		return err
+	})
+}
+
+// GetPeriodSummaries returns the weekly or monthly summaries of the periods
+// overlapping [from, to), oldest first
+func (ob *OrderBook) GetPeriodSummaries(ctx context.Context, period SummaryPeriod, from, to time.Time) ([]PeriodSummary, error) {
+	filter := bson.M{"account": ob.account, "period": period, "period_start": bson.M{"$gte": period.Start(from), "$lt": to}}
+	cursor, err := ob.periodSummaries.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "period_start", Value: 1}}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query %s summaries: %v", period, err)
+	}
+	defer cursor.Close(ctx)
+
+	var summaries []PeriodSummary
+	if err := cursor.All(ctx, &summaries); err != nil {
+		return nil, fmt.Errorf("failed to decode %s summaries: %v", period, err)
+	}
+	for i := range summaries {
+		summaries[i].PeriodStart = summaries[i].PeriodStart.In(market.Location())
+		summaries[i].PeriodEnd = summaries[i].PeriodEnd.In(market.Location())
+	}
+	return summaries, nil
+}
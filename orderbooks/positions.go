@@ -0,0 +1,154 @@
+package orderbook
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"profitLossAndTradeInfoToDB/pkg/market"
+	"profitLossAndTradeInfoToDB/pkg/trades"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Position is a symbol's open quantity at the end of a market day, rebuilt by
+// matching every order up to then FIFO, across days
+type Position struct {
+	ID           primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Account      string             `bson:"account" json:"account"`
+	Date         time.Time          `bson:"date" json:"date"` // Market day the position was open at the end of
+	Symbol       string             `bson:"symbol" json:"symbol"`
+	Side         string             `bson:"side" json:"side"`                 // trades.Long or trades.Short
+	NetQuantity  float64            `bson:"net_quantity" json:"net_quantity"` // Negative when short
+	AveragePrice float64            `bson:"average_price" json:"average_price"`
+	OpenedAt     time.Time          `bson:"opened_at" json:"opened_at"` // Time of the oldest open lot
+}
+
+// positionsSnapshot marks a day whose positions were rebuilt, so a day
+// without open positions is not rebuilt on every read
+const positionsSnapshot = "$snapshot"
+
+// Positions returns the open positions at the end of the market day containing
+// day, by symbol. They are rebuilt from the orders on first read and stored,
+// unless the OrderBook is read-only; changes to the orders of that day or an
+// earlier one drop the stored positions.
+func (ob *OrderBook) Positions(ctx context.Context, day time.Time) ([]Position, error) {
+	day = market.DayStart(day)
+	stored, found, err := ob.storedPositions(ctx, day)
+	if err != nil || found {
+		return stored, err
+	}
+	return ob.RebuildPositions(ctx, day)
+}
+
+// RebuildPositions rebuilds the open positions at the end of the market day
+// containing day and, unless the OrderBook is read-only, stores them
+func (ob *OrderBook) RebuildPositions(ctx context.Context, day time.Time) ([]Position, error) {
+	day = market.DayStart(day)
+	orders, err := ob.GetOrdersByDateRange(ctx, time.Time{}, day.Add(24*time.Hour))
+	if err != nil {
+		return nil, err
+	}
+	positions := OpenPositions(ob.account, day, orders)
+	if ob.readOnly {
+		return positions, nil
+	}
+
+	docs := []interface{}{Position{Account: ob.account, Date: day, Symbol: positionsSnapshot}}
+	for _, position := range positions {
+		docs = append(docs, position)
+	}
+	err = ob.retry(ctx, "position update", func() error {
+		if _, err := ob.positions.DeleteMany(ctx, bson.M{"account": ob.account, "date": day}); err != nil {
+			return err
+		}
+		_, err := ob.positions.InsertMany(ctx, docs)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to store positions of %s: %v", day.Format("2006-01-02"), err)
+	}
+	return positions, nil
+}
+
+// OpenPositions matches orders FIFO and returns the positions left open at the
+// end of day, sorted by symbol. Contracts that expired before day are left out.
+func OpenPositions(account string, day time.Time, orders []Order) []Position {
+	expiries := map[string]*time.Time{}
+	for _, order := range orders {
+		expiries[order.Symbol] = order.MetaData.Expiry
+	}
+
+	_, lots := trades.MatchFIFO(Fills(orders))
+	bySymbol := map[string]*Position{}
+	var symbols []string
+	for _, lot := range lots {
+		if expiry := expiries[lot.Symbol]; expiry != nil && expiry.Before(day) {
+			continue
+		}
+		position, ok := bySymbol[lot.Symbol]
+		if !ok {
+			position = &Position{Account: account, Date: day, Symbol: lot.Symbol, Side: lot.Side, OpenedAt: lot.Time}
+			bySymbol[lot.Symbol] = position
+			symbols = append(symbols, lot.Symbol)
+		}
+		// Open lots of a symbol are all on one side, the rest were matched
+		quantity := position.NetQuantity
+		if lot.Side == trades.Short {
+			quantity = -quantity
+		}
+		position.AveragePrice = (position.AveragePrice*quantity + lot.Price*lot.Quantity) / (quantity + lot.Quantity)
+		if lot.Side == trades.Short {
+			position.NetQuantity -= lot.Quantity
+		} else {
+			position.NetQuantity += lot.Quantity
+		}
+	}
+
+	sort.Strings(symbols)
+	positions := make([]Position, 0, len(symbols))
+	for _, symbol := range symbols {
+		positions = append(positions, *bySymbol[symbol])
+	}
+	return positions
+}
+
+// storedPositions loads the positions stored for day; found is false when
+// they were never rebuilt or were dropped since
+func (ob *OrderBook) storedPositions(ctx context.Context, day time.Time) ([]Position, bool, error) {
+	cursor, err := ob.positions.Find(ctx, bson.M{"account": ob.account, "date": day},
+		options.Find().SetSort(bson.D{{Key: "symbol", Value: 1}}))
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to query positions: %v", err)
+	}
+	defer cursor.Close(ctx)
+
+	var stored []Position
+	if err := cursor.All(ctx, &stored); err != nil {
+		return nil, false, fmt.Errorf("failed to decode positions: %v", err)
+	}
+
+	positions := make([]Position, 0, len(stored))
+	found := false
+	for _, position := range stored {
+		if position.Symbol == positionsSnapshot {
+			found = true
+			continue
+		}
+		positions = append(positions, position)
+	}
+	return positions, found, nil
+}
+
+// dropPositions deletes the positions stored for day and every later day,
+// which the orders of day feed into
+func (ob *OrderBook) dropPositions(ctx context.Context, day time.Time) error {
+	_, err := ob.positions.DeleteMany(ctx, bson.M{"account": ob.account, "date": bson.M{"$gte": day}})
+	if err != nil {
+		return fmt.Errorf("failed to drop positions from %s: %v", day.Format("2006-01-02"), err)
+	}
+	return nil
+}
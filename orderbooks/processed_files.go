@@ -0,0 +1,112 @@
+package orderbook
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ReimportPolicy decides what happens to a file whose content was already imported
+type ReimportPolicy string
+
+const (
+	ReimportSkip ReimportPolicy = "skip" // Do not load the file again
+	ReimportWarn ReimportPolicy = "warn" // Load it again, logging that it was seen before
+)
+
+// ParseReimportPolicy validates a policy name; empty selects ReimportSkip
+func ParseReimportPolicy(name string) (ReimportPolicy, error) {
+	switch policy := ReimportPolicy(name); policy {
+	case "":
+		return ReimportSkip, nil
+	case ReimportSkip, ReimportWarn:
+		return policy, nil
+	}
+	return "", fmt.Errorf("unknown reimport policy %q, expected skip or warn", name)
+}
+
+// Loads reports whether a file is loaded given its registry entry, nil when
+// its content was never imported
+func (p ReimportPolicy) Loads(seen *ProcessedFile) bool {
+	return seen == nil || p == ReimportWarn
+}
+
+// ProcessedFile records an imported file by the SHA-256 of its content, so
+// the same data is recognised under another name or path
+type ProcessedFile struct {
+	Account     string    `bson:"account" json:"account"`
+	Checksum    string    `bson:"checksum" json:"checksum"`
+	Source      string    `bson:"source" json:"source"`
+	Kind        string    `bson:"kind" json:"kind"`
+	Size        int64     `bson:"size" json:"size"`
+	RunID       string    `bson:"import_run_id,omitempty" json:"import_run_id,omitempty"`
+	ProcessedAt time.Time `bson:"processed_at" json:"processed_at"`
+}
+
+// Checksum returns the hex SHA-256 of a file's content
+func Checksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// ChecksumReader returns the SHA-256 checksum and size of the data read from r
+func ChecksumReader(r io.Reader) (string, int64, error) {
+	h := sha256.New()
+	size, err := io.Copy(h, r)
+	if err != nil {
+		return "", size, err
+	}
+	return hex.EncodeToString(h.Sum(nil)), size, nil
+}
+
+// ReimportPolicy returns what imports do with files already processed
+func (ob *OrderBook) ReimportPolicy() ReimportPolicy {
+	return ob.reimport
+}
+
+// ProcessedFile returns the registry entry of a checksum, or nil when the content was never imported
+func (ob *OrderBook) ProcessedFile(ctx context.Context, checksum string) (*ProcessedFile, error) {
+	var file ProcessedFile
+	err := ob.processedFiles.FindOne(ctx, bson.M{"account": ob.account, "checksum": checksum}).Decode(&file)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up processed file: %v", err)
+	}
+	return &file, nil
+}
+
+// RecordProcessedFile registers an imported file; importing the same content
+// again updates the entry to the latest import
+func (ob *OrderBook) RecordProcessedFile(ctx context.Context, file ProcessedFile) error {
+	if err := ob.checkWritable(); err != nil {
+		return err
+	}
+
+	file.Account = ob.account
+	if file.RunID == "" {
+		file.RunID = ob.RunID()
+	}
+	if file.ProcessedAt.IsZero() {
+		file.ProcessedAt = time.Now()
+	}
+
+	_, err := ob.processedFiles.ReplaceOne(ctx,
+		bson.M{"account": ob.account, "checksum": file.Checksum},
+		file,
+		options.Replace().SetUpsert(true),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record processed file: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package orderbook
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReimportPolicy(t *testing.T) {
+	seen := &ProcessedFile{Checksum: Checksum([]byte("orders")), Source: "orderbook_14-03-2024.csv"}
+	tests := []struct {
+		name     string
+		seen     *ProcessedFile
+		wantLoad bool
+	}{
+		{name: "", seen: nil, wantLoad: true},
+		{name: "", seen: seen, wantLoad: false},
+		{name: "skip", seen: nil, wantLoad: true},
+		{name: "skip", seen: seen, wantLoad: false},
+		{name: "warn", seen: nil, wantLoad: true},
+		{name: "warn", seen: seen, wantLoad: true},
+	}
+	for _, tt := range tests {
+		policy, err := ParseReimportPolicy(tt.name)
+		if err != nil {
+			t.Fatalf("ParseReimportPolicy(%q): %v", tt.name, err)
+		}
+		if got := policy.Loads(tt.seen); got != tt.wantLoad {
+			t.Errorf("%q loads a file seen as %v = %v, want %v", tt.name, tt.seen, got, tt.wantLoad)
+		}
+	}
+
+	if _, err := ParseReimportPolicy("always"); err == nil {
+		t.Error("ParseReimportPolicy accepted an unknown policy")
+	}
+}
+
+func TestChecksumReader(t *testing.T) {
+	// The same content is recognised whatever it is read from
+	content := "Date,Time,Symbol\n14-03-2024,09:15:00,NIFTY24MAR22000CE\n"
+	checksum, size, err := ChecksumReader(strings.NewReader(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if checksum != Checksum([]byte(content)) || size != int64(len(content)) {
+		t.Errorf("ChecksumReader = %s, %d, want %s, %d", checksum, size, Checksum([]byte(content)), len(content))
+	}
+	if other, _, _ := ChecksumReader(strings.NewReader(content + "\n")); other == checksum {
+		t.Error("different content has the same checksum")
+	}
+}
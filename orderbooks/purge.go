@@ -0,0 +1,82 @@
+package orderbook
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"profitLossAndTradeInfoToDB/pkg/events"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// PurgeRun deletes every order an import run stored, hot or archived, and
+// recomputes the summaries of their days. With dryRun nothing is deleted.
+// It returns the number of orders and the times they were placed at.
+func (ob *OrderBook) PurgeRun(ctx context.Context, runID string, dryRun bool) (int, []time.Time, error) {
+	if runID == "" {
+		return 0, nil, fmt.Errorf("run ID is required")
+	}
+	if !dryRun {
+		if err := ob.checkWritable(); err != nil {
+			return 0, nil, err
+		}
+	}
+
+	filter := bson.M{"account": ob.account, "import_run_id": runID}
+	collections := []*mongo.Collection{ob.ordersCollection}
+	if ob.archiveOrders != nil {
+		collections = append(collections, ob.archiveOrders)
+	}
+
+	var dates []time.Time
+	for _, collection := range collections {
+		times, err := orderTimes(ctx, collection, filter)
+		if err != nil {
+			return 0, nil, err
+		}
+		dates = append(dates, times...)
+
+		if dryRun || len(times) == 0 {
+			continue
+		}
+		if _, err := collection.DeleteMany(ctx, filter); err != nil {
+			return 0, nil, fmt.Errorf("failed to delete orders of run %s: %v", runID, err)
+		}
+	}
+	if dryRun {
+		return len(dates), dates, nil
+	}
+
+	ob.events.Publish(ctx, events.Event{
+		Type: events.RunPurged, Account: ob.account, RunID: runID, Kind: "orders", Count: len(dates),
+	})
+	if err := ob.invalidateDates(ctx, dates); err != nil {
+		return 0, nil, err
+	}
+	return len(dates), dates, nil
+}
+
+// orderTimes returns the timestamps of the orders matching filter
+func orderTimes(ctx context.Context, collection *mongo.Collection, filter bson.M) ([]time.Time, error) {
+	cursor, err := collection.Find(ctx, filter, options.Find().SetProjection(bson.M{"timestamp": 1}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find orders: %v", err)
+	}
+	defer cursor.Close(ctx)
+
+	var docs []struct {
+		Timestamp time.Time `bson:"timestamp"`
+	}
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, fmt.Errorf("failed to decode orders: %v", err)
+	}
+
+	times := make([]time.Time, len(docs))
+	for i, doc := range docs {
+		times[i] = doc.Timestamp
+	}
+	return times, nil
+}
@@ -0,0 +1,230 @@
+package orderbook
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"profitLossAndTradeInfoToDB/pkg/events"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ParseMode decides what happens to CSV rows that cannot be parsed
+type ParseMode string
+
+const (
+	ParseStrict  ParseMode = "strict"  // Fail the file on the first bad row
+	ParseLenient ParseMode = "lenient" // Skip bad rows, logging and quarantining them
+)
+
+// ParseParseMode validates a mode name; empty selects ParseLenient
+func ParseParseMode(name string) (ParseMode, error) {
+	switch mode := ParseMode(name); mode {
+	case "":
+		return ParseLenient, nil
+	case ParseStrict, ParseLenient:
+		return mode, nil
+	}
+	return "", fmt.Errorf("unknown parse mode %q, expected strict or lenient", name)
+}
+
+// rejecter returns how imports of source treat bad rows under the parse
+// mode: nil fails the file, otherwise rows are quarantined and skipped
+func (ob *OrderBook) rejecter(ctx context.Context, source string) rejectFunc {
+	if ob.parseMode == ParseStrict {
+		return nil
+	}
+	return ob.quarantineRow(ctx, source)
+}
+
+// QuarantinedRow is a CSV row that could not be parsed into an order, kept
+// with its file's header so it can be fixed and reprocessed
+type QuarantinedRow struct {
+	ID            primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Account       string             `bson:"account" json:"account"`
+	Source        string             `bson:"source" json:"source"`
+	Row           int                `bson:"row" json:"row"` // CSV line, the header being line 1
+	Header        []string           `bson:"header" json:"header"`
+	Record        []string           `bson:"record" json:"record"`
+	Format        string             `bson:"format,omitempty" json:"format,omitempty"` // Parser the row was read with, empty for generic
+	Error         string             `bson:"error" json:"error"`
+	RunID         string             `bson:"run_id,omitempty" json:"run_id,omitempty"`
+	QuarantinedAt time.Time          `bson:"quarantined_at" json:"quarantined_at"`
+}
+
+// quarantineRow is the rejectFunc of imports: it stores the row in the
+// quarantine collection and lets the import go on
+func (ob *OrderBook) quarantineRow(ctx context.Context, source string) rejectFunc {
+	return func(row int, header, record []string, cause error) error {
+		entry := QuarantinedRow{
+			Account:       ob.account,
+			Source:        source,
+			Row:           row,
+			Header:        header,
+			Record:        record,
+			Format:        ob.parserFor(source).Name(),
+			Error:         cause.Error(),
+			RunID:         ob.RunID(),
+			QuarantinedAt: time.Now(),
+		}
+		if _, err := ob.quarantine.InsertOne(ctx, entry); err != nil {
+			return fmt.Errorf("failed to quarantine line %d of %s (%v): %v", row, source, cause, err)
+		}
+
+		ob.events.Publish(ctx, events.Event{
+			Type: events.RowQuarantined, Account: ob.account, RunID: ob.RunID(), Source: source, Kind: "orders", Count: row, Error: cause.Error(),
+		})
+		return nil
+	}
+}
+
+// QuarantinedRows lists the account's quarantined rows, optionally of one source, oldest first
+func (ob *OrderBook) QuarantinedRows(ctx context.Context, source string) ([]QuarantinedRow, error) {
+	filter := bson.M{"account": ob.account}
+	if source != "" {
+		filter["source"] = source
+	}
+
+	cursor, err := ob.quarantine.Find(ctx, filter, options.Find().SetSort(bson.D{
+		{Key: "quarantined_at", Value: 1},
+		{Key: "row", Value: 1},
+	}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query quarantine: %v", err)
+	}
+	defer cursor.Close(ctx)
+
+	var rows []QuarantinedRow
+	if err := cursor.All(ctx, &rows); err != nil {
+		return nil, fmt.Errorf("failed to decode quarantined rows: %v", err)
+	}
+	return rows, nil
+}
+
+// quarantineIDColumn leads the columns of an exported quarantine
+const quarantineIDColumn = "quarantine_id"
+
+// WriteQuarantineCSV exports rows for fixing: the quarantine id followed by
+// the orderbook columns, including execution time, notes and tags, read by
+// each row's parser from its own header
+func WriteQuarantineCSV(w io.Writer, rows []QuarantinedRow) error {
+	writer := csv.NewWriter(w)
+	header := append([]string{quarantineIDColumn}, CSVHeader...)
+	header = append(header, executionTimeColumns[0], notesColumns[0], tagsColumns[0], orderIDColumns[0], exchangeOrderIDColumns[0])
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		decoder, err := quarantineDecoder(row.Format, row.Header)
+		if err != nil {
+			return fmt.Errorf("quarantined row %s: %v", row.ID.Hex(), err)
+		}
+		line := append([]string{row.ID.Hex()}, decoder.Canonical(row.Record)...)
+		if err := writer.Write(line); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// quarantineDecoder binds the parser a row was read with to header
+func quarantineDecoder(parserName string, header []string) (RowDecoder, error) {
+	parser, err := ParserByName(parserName)
+	if err != nil {
+		return nil, err
+	}
+	return parser.Header(header)
+}
+
+// ReprocessResult counts the outcome of reprocessing fixed quarantined rows
+type ReprocessResult struct {
+	Stored int // Rows imported and removed from the quarantine
+	Failed int // Rows still invalid; their quarantine entry records the new error
+}
+
+// ReprocessQuarantine imports fixed rows exported by WriteQuarantineCSV.
+// Rows that now parse are stored and leave the quarantine; the others stay,
+// updated with the edited record and its error.
+func (ob *OrderBook) ReprocessQuarantine(ctx context.Context, r io.Reader) (ReprocessResult, error) {
+	var result ReprocessResult
+	if err := ob.checkWritable(); err != nil {
+		return result, err
+	}
+
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if err != nil {
+		return result, fmt.Errorf("failed to read header: %v", err)
+	}
+	if len(header) == 0 || header[0] != quarantineIDColumn {
+		return result, fmt.Errorf("first column must be %s, as exported by the quarantine command", quarantineIDColumn)
+	}
+	header = header[1:]
+	if _, err := newCSVColumns(header, CSVFormat{}); err != nil {
+		return result, err
+	}
+
+	var orders []Order
+	var fixed []primitive.ObjectID
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return result, fmt.Errorf("failed to read line %d: %v", line, err)
+		}
+
+		id, err := primitive.ObjectIDFromHex(cell(record, 0))
+		if err != nil {
+			return result, fmt.Errorf("invalid %s on line %d: %q", quarantineIDColumn, line, cell(record, 0))
+		}
+		var entry QuarantinedRow
+		if err := ob.quarantine.FindOne(ctx, bson.M{"_id": id, "account": ob.account}).Decode(&entry); err != nil {
+			return result, fmt.Errorf("quarantined row %s not found: %v", id.Hex(), err)
+		}
+
+		// The export has the canonical columns, but its values are still
+		// read by the row's parser, e.g. crypto timestamps in UTC
+		decoder, err := quarantineDecoder(entry.Format, header)
+		if err != nil {
+			return result, fmt.Errorf("quarantined row %s: %v", id.Hex(), err)
+		}
+
+		record = record[1:]
+		order, err := ob.parseRecord(record, decoder, entry.Source, entry.Row)
+		if err != nil {
+			result.Failed++
+			_, updateErr := ob.quarantine.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{
+				"header": header, "record": record, "error": err.Error(),
+			}})
+			if updateErr != nil {
+				return result, fmt.Errorf("failed to update quarantined row %s: %v", id.Hex(), updateErr)
+			}
+			continue
+		}
+		orders = append(orders, order)
+		fixed = append(fixed, id)
+	}
+
+	if err := ob.InsertOrders(ctx, orders); err != nil {
+		return result, err
+	}
+	if len(fixed) > 0 {
+		if _, err := ob.quarantine.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": fixed}}); err != nil {
+			return result, fmt.Errorf("failed to release reprocessed rows: %v", err)
+		}
+	}
+	result.Stored = len(fixed)
+	return result, nil
+}
@@ -0,0 +1,212 @@
+package orderbook
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"profitLossAndTradeInfoToDB/pkg/market"
+	"profitLossAndTradeInfoToDB/pkg/money"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// RangeSummary aggregates an account's activity over an arbitrary span
+type RangeSummary struct {
+	Account       string      `json:"account"`
+	From          time.Time   `json:"from"`
+	To            time.Time   `json:"to"` // Exclusive
+	Trades        int64       `json:"trades"`
+	BuyQuantity   float64     `json:"buy_quantity"`
+	SellQuantity  float64     `json:"sell_quantity"`
+	Turnover      money.Paise `json:"turnover"`
+	UniqueSymbols int         `json:"unique_symbols"`
+	// RealizedPnL and BrokerMTM sum the daily summaries of the days in range
+	RealizedPnL money.Paise `json:"realized_pnl"`
+	BrokerMTM   money.Paise `json:"broker_mtm"`
+	Days        int         `json:"days"`
+	// Source tells which data answered the query: a rollup or the raw orders
+	Source string `json:"source"`
+}
+
+// GetRangeSummary aggregates trades, turnover, unique symbols and P&L over
+// [from, to). Day-aligned ranges are answered from the per-symbol daily rollup,
+// or from the monthly rollup plus raw orders for partial months, when the
+// account maintains them; anything else is aggregated from the raw orders.
+func (ob *OrderBook) GetRangeSummary(ctx context.Context, from, to time.Time) (*RangeSummary, error) {
+	if !from.Before(to) {
+		return nil, fmt.Errorf("empty range %s to %s", from, to)
+	}
+
+	summary := &RangeSummary{Account: ob.account, From: from, To: to}
+	dayAligned := market.DayStart(from).Equal(from) && market.DayStart(to).Equal(to)
+
+	var err error
+	switch {
+	case dayAligned && ob.MaintainsRollup(SymbolDailyRollup):
+		summary.Source = SymbolDailyRollup.Name + " rollup"
+		err = ob.rangeFromSymbolRollup(ctx, summary)
+	case dayAligned && ob.MaintainsRollup(MonthlyRollup):
+		summary.Source = MonthlyRollup.Name + " rollup + raw orders"
+		err = ob.rangeFromMonthlyRollup(ctx, summary)
+	default:
+		summary.Source = "raw orders"
+		var symbols map[string]bool
+		symbols, err = ob.addRawTotals(ctx, summary, from, to)
+		summary.UniqueSymbols = len(symbols)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	days, err := ob.GetDailySummaries(ctx, market.DayStart(from), to)
+	if err != nil {
+		return nil, err
+	}
+	for _, day := range days {
+		summary.RealizedPnL += day.RealizedPnL
+		if day.BrokerMTM != nil {
+			summary.BrokerMTM += *day.BrokerMTM
+		}
+		if day.TotalTrades > 0 {
+			summary.Days++
+		}
+	}
+
+	return summary, nil
+}
+
+// rangeFromSymbolRollup sums the per-symbol daily rollup grouped by symbol
+func (ob *OrderBook) rangeFromSymbolRollup(ctx context.Context, summary *RangeSummary) error {
+	collection := ob.ordersCollection.Database().Collection(SymbolDailyRollup.Collection)
+	pipeline := bson.A{
+		bson.M{"$match": bson.M{
+			"account":      ob.account,
+			"period_start": bson.M{"$gte": summary.From, "$lt": summary.To},
+		}},
+		bson.M{"$group": bson.M{
+			"_id":           "$symbol",
+			"trades":        bson.M{"$sum": "$trades"},
+			"buy_quantity":  bson.M{"$sum": "$buy_quantity"},
+			"sell_quantity": bson.M{"$sum": "$sell_quantity"},
+			"turnover":      bson.M{"$sum": storedPaise("$turnover")},
+		}},
+	}
+
+	cursor, err := collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return fmt.Errorf("failed to aggregate symbol rollups: %v", err)
+	}
+	defer cursor.Close(ctx)
+
+	var rows []struct {
+		Trades       int64       `bson:"trades"`
+		BuyQuantity  float64     `bson:"buy_quantity"`
+		SellQuantity float64     `bson:"sell_quantity"`
+		Turnover     money.Paise `bson:"turnover"`
+	}
+	if err := cursor.All(ctx, &rows); err != nil {
+		return fmt.Errorf("failed to decode symbol rollups: %v", err)
+	}
+
+	for _, row := range rows {
+		summary.Trades += row.Trades
+		summary.BuyQuantity += row.BuyQuantity
+		summary.SellQuantity += row.SellQuantity
+		summary.Turnover += row.Turnover
+	}
+	summary.UniqueSymbols = len(rows)
+	return nil
+}
+
+// storedPaise is the pipeline expression of a rollup amount in paise; rollups
+// refreshed before amounts were kept in paise hold rupees as doubles
+func storedPaise(field string) bson.M {
+	return bson.M{"$cond": bson.A{
+		bson.M{"$eq": bson.A{bson.M{"$type": field}, "double"}},
+		bson.M{"$toLong": bson.M{"$round": bson.A{bson.M{"$multiply": bson.A{field, 100}}, 0}}},
+		field,
+	}}
+}
+
+// rangeFromMonthlyRollup reads whole months from the monthly rollup and the
+// partial months at either end from the raw orders
+func (ob *OrderBook) rangeFromMonthlyRollup(ctx context.Context, summary *RangeSummary) error {
+	loc := market.Location()
+	from, to := summary.From.In(loc), summary.To.In(loc)
+
+	firstMonth := time.Date(from.Year(), from.Month(), 1, 0, 0, 0, 0, loc)
+	if firstMonth.Before(from) {
+		firstMonth = firstMonth.AddDate(0, 1, 0)
+	}
+	lastMonth := time.Date(to.Year(), to.Month(), 1, 0, 0, 0, 0, loc)
+
+	symbols := map[string]bool{}
+	addRaw := func(start, end time.Time) error {
+		if !start.Before(end) {
+			return nil
+		}
+		raw, err := ob.addRawTotals(ctx, summary, start, end)
+		for symbol := range raw {
+			symbols[symbol] = true
+		}
+		return err
+	}
+
+	if !firstMonth.Before(lastMonth) {
+		// No whole month inside the range
+		if err := addRaw(from, to); err != nil {
+			return err
+		}
+		summary.UniqueSymbols = len(symbols)
+		return nil
+	}
+
+	if err := addRaw(from, firstMonth); err != nil {
+		return err
+	}
+	if err := addRaw(lastMonth, to); err != nil {
+		return err
+	}
+
+	months, err := ob.GetRollups(ctx, MonthlyRollup, firstMonth, lastMonth)
+	if err != nil {
+		return err
+	}
+	for _, month := range months {
+		summary.Trades += int64(month.Trades)
+		summary.BuyQuantity += month.BuyQuantity
+		summary.SellQuantity += month.SellQuantity
+		summary.Turnover += month.Turnover
+	}
+
+	// The monthly rollup has no symbols, so they are collected from the orders
+	if err := ob.distinctSymbols(ctx, firstMonth, lastMonth, symbols); err != nil {
+		return err
+	}
+
+	summary.UniqueSymbols = len(symbols)
+	return nil
+}
+
+// addRawTotals adds the non-voided, filled orders in [from, to) to summary and returns their symbols
+func (ob *OrderBook) addRawTotals(ctx context.Context, summary *RangeSummary, from, to time.Time) (map[string]bool, error) {
+	orders, err := ob.GetOrdersByDateRange(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	symbols := map[string]bool{}
+	for _, order := range orders {
+		summary.Trades++
+		summary.Turnover += money.Value(order.AveragePrice, order.Quantity)
+		if order.TransactionType == "B" {
+			summary.BuyQuantity += order.Quantity
+		} else {
+			summary.SellQuantity += order.Quantity
+		}
+		symbols[order.Symbol] = true
+	}
+
+	return symbols, nil
+}
@@ -0,0 +1,105 @@
+package orderbook
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"time"
+
+	"profitLossAndTradeInfoToDB/pkg/events"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// RetryPolicy retries writes and aggregations failing with transient errors
+// such as a dropped connection or a primary stepping down
+type RetryPolicy struct {
+	Attempts  int           // Attempts including the first, default 4; 1 disables retries
+	BaseDelay time.Duration // Delay before the first retry, doubled after each; default 200ms
+	MaxDelay  time.Duration // Default 10s
+}
+
+func (p RetryPolicy) withDefaults() RetryPolicy {
+	if p.Attempts <= 0 {
+		p.Attempts = 4
+	}
+	if p.BaseDelay <= 0 {
+		p.BaseDelay = 200 * time.Millisecond
+	}
+	if p.MaxDelay < p.BaseDelay {
+		p.MaxDelay = max(10*time.Second, p.BaseDelay)
+	}
+	return p
+}
+
+// delay returns the backoff after the given number of failed attempts, with
+// jitter so concurrent writers do not retry in lockstep
+func (p RetryPolicy) delay(attempts int) time.Duration {
+	delay := p.BaseDelay
+	for i := 1; i < attempts && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	delay = min(delay, p.MaxDelay)
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
+
+// Server error codes of a node that is not, or no longer, the primary
+var notPrimaryCodes = []int{
+	91,    // ShutdownInProgress
+	189,   // PrimarySteppedDown
+	10107, // NotWritablePrimary
+	11600, // InterruptedAtShutdown
+	11602, // InterruptedDueToReplStateChange
+	13435, // NotPrimaryNoSecondaryOk
+	13436, // NotPrimaryOrSecondary
+}
+
+// isTransient reports whether err is worth retrying: network errors, timeouts
+// and a primary that is unavailable or changed
+func isTransient(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	if mongo.IsNetworkError(err) || mongo.IsTimeout(err) {
+		return true
+	}
+
+	var serverErr mongo.ServerError
+	if !errors.As(err, &serverErr) {
+		return false
+	}
+	if serverErr.HasErrorLabel("RetryableWriteError") || serverErr.HasErrorLabel("TransientTransactionError") {
+		return true
+	}
+	for _, code := range notPrimaryCodes {
+		if serverErr.HasErrorCode(code) {
+			return true
+		}
+	}
+	return false
+}
+
+// retry runs fn until it succeeds, fails with a permanent error or the
+// policy's attempts run out. fn must be safe to repeat, e.g. an upsert or a
+// $merge aggregation.
+func (ob *OrderBook) retry(ctx context.Context, op string, fn func() error) error {
+	policy := ob.retries.withDefaults()
+
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || attempt >= policy.Attempts || !isTransient(err) {
+			return err
+		}
+
+		writerMetrics.Add("retries", 1)
+		ob.events.Publish(ctx, events.Event{
+			Type: events.Retrying, Account: ob.account, RunID: ob.RunID(), Kind: op, Count: attempt, Error: err.Error(),
+		})
+
+		select {
+		case <-time.After(policy.delay(attempt)):
+		case <-ctx.Done():
+			return err
+		}
+	}
+}
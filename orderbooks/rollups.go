@@ -0,0 +1,374 @@
+package orderbook
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"profitLossAndTradeInfoToDB/constants"
+	"profitLossAndTradeInfoToDB/pkg/market"
+	"profitLossAndTradeInfoToDB/pkg/money"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// RollupKind describes one materialized rollup collection
+type RollupKind struct {
+	Name       string
+	Collection string
+	Unit       string // $dateTrunc unit the orders are bucketed by
+	BySymbol   bool
+}
+
+// Materialized rollups maintained by RefreshRollups
+var (
+	SymbolDailyRollup = RollupKind{Name: "symbol-daily", Collection: constants.SYMBOL_DAILY_ROLLUP_SCHEMA, Unit: "day", BySymbol: true}
+	HourlyRollup      = RollupKind{Name: "hourly", Collection: constants.HOURLY_ROLLUP_SCHEMA, Unit: "hour"}
+	MonthlyRollup     = RollupKind{Name: "monthly", Collection: constants.MONTHLY_ROLLUP_SCHEMA, Unit: "month"}
+)
+
+// RollupKinds lists every maintained rollup
+var RollupKinds = []RollupKind{SymbolDailyRollup, HourlyRollup, MonthlyRollup}
+
+// RollupProfiles are the named sets of rollups an account can maintain. The
+// monthly rollup is part of every profile because the turnover report reads it;
+// daily summaries are always kept.
+var RollupProfiles = map[string][]RollupKind{
+	"daily":        {MonthlyRollup},
+	"daily+hourly": {HourlyRollup, MonthlyRollup},
+	"daily+symbol": {SymbolDailyRollup, MonthlyRollup},
+	"full":         RollupKinds,
+}
+
+// ParseRollupProfile returns the rollups of a named profile
+func ParseRollupProfile(name string) ([]RollupKind, error) {
+	kinds, ok := RollupProfiles[name]
+	if !ok {
+		names := make([]string, 0, len(RollupProfiles))
+		for n := range RollupProfiles {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("unknown rollup profile %q, expected one of %s", name, strings.Join(names, ", "))
+	}
+	return kinds, nil
+}
+
+// MaintainsRollup reports whether the account's profile keeps the rollup up to date
+func (ob *OrderBook) MaintainsRollup(kind RollupKind) bool {
+	for _, k := range ob.rollups {
+		if k.Name == kind.Name {
+			return true
+		}
+	}
+	return false
+}
+
+// Rollup is a precomputed aggregate of orders over one period (and symbol)
+type Rollup struct {
+	Account      string      `bson:"account" json:"account"`
+	PeriodStart  time.Time   `bson:"period_start" json:"period_start"`
+	Symbol       string      `bson:"symbol,omitempty" json:"symbol,omitempty"`
+	Trades       int32       `bson:"trades" json:"trades"`
+	BuyQuantity  float64     `bson:"buy_quantity" json:"buy_quantity"`
+	SellQuantity float64     `bson:"sell_quantity" json:"sell_quantity"`
+	BuyValue     money.Paise `bson:"buy_value" json:"buy_value"`
+	SellValue    money.Paise `bson:"sell_value" json:"sell_value"`
+	Turnover     money.Paise `bson:"turnover" json:"turnover"`
+	RefreshedAt  time.Time   `bson:"refreshed_at" json:"refreshed_at"`
+}
+
+// RefreshRollups recomputes the account's rollups for the whole months covering [from, to),
+// and the weekly and monthly summaries and performance stats of those days.
+// Fresh rollups are merged in before the stale ones of that window are
+// removed, so readers never see the window empty and voided or corrected
+// orders never linger in the materialized collections.
+func (ob *OrderBook) RefreshRollups(ctx context.Context, from, to time.Time) error {
+	if err := ob.checkWritable(); err != nil {
+		return err
+	}
+
+	loc := market.Location()
+	from = from.In(loc)
+	to = to.In(loc)
+	start := time.Date(from.Year(), from.Month(), 1, 0, 0, 0, 0, loc)
+	end := time.Date(to.Year(), to.Month(), 1, 0, 0, 0, 0, loc).AddDate(0, 1, 0)
+
+	for _, kind := range ob.rollups {
+		if err := ob.refreshRollup(ctx, kind, start, end); err != nil {
+			return fmt.Errorf("failed to refresh %s rollup: %v", kind.Name, err)
+		}
+	}
+
+	if err := ob.RefreshPeriodSummaries(ctx, from, to); err != nil {
+		return err
+	}
+	if err := ob.RefreshPerformanceStats(ctx, from, to); err != nil {
+		return err
+	}
+	return ob.version.Bump(ctx)
+}
+
+func (ob *OrderBook) refreshRollup(ctx context.Context, kind RollupKind, start, end time.Time) error {
+	target := ob.ordersCollection.Database().Collection(kind.Collection)
+	// Every rollup of this run carries its time; Mongo dates keep milliseconds
+	refreshedAt := time.Now().Truncate(time.Millisecond)
+
+	// The pipeline only sees the hot collection, so a window reaching into
+	// the archive is aggregated from the orders of both tiers instead
+	archived := false
+	if ob.archiveOrders != nil {
+		var err error
+		if _, archived, err = ob.archivePlan.ArchiveFilter(ctx, start, bson.M{"$gte": start, "$lt": end}); err != nil {
+			return fmt.Errorf("failed to plan archive query: %v", err)
+		}
+	}
+	if archived {
+		if err := ob.upsertRollups(ctx, kind, start, end, refreshedAt); err != nil {
+			return err
+		}
+	} else if err := ob.mergeRollups(ctx, kind, start, end, refreshedAt); err != nil {
+		return err
+	}
+
+	// Periods without orders any more were not replaced by the refresh
+	stale := bson.M{
+		"account":      ob.account,
+		"period_start": bson.M{"$gte": start, "$lt": end},
+		"refreshed_at": bson.M{"$lt": refreshedAt},
+	}
+	if _, err := target.DeleteMany(ctx, stale); err != nil {
+		return fmt.Errorf("failed to clear stale rollups: %v", err)
+	}
+	return nil
+}
+
+// mergeRollups aggregates the rollups of [start, end) from the hot orders and
+// merges them into the rollup collection
+func (ob *OrderBook) mergeRollups(ctx context.Context, kind RollupKind, start, end, refreshedAt time.Time) error {
+	// A document, not a map, so the _id fields keep the order $merge matches on
+	groupID := bson.D{
+		{Key: "account", Value: "$account"},
+		{Key: "period", Value: bson.M{"$dateTrunc": bson.M{
+			"date":     "$timestamp",
+			"unit":     kind.Unit,
+			"timezone": market.Timezone(),
+		}}},
+	}
+	if kind.BySymbol {
+		groupID = append(groupID, bson.E{Key: "symbol", Value: "$symbol"})
+	}
+
+	value := orderPaise()
+	sideSum := func(side string, expr, zero interface{}) bson.M {
+		return bson.M{"$sum": bson.M{"$cond": bson.A{
+			bson.M{"$eq": bson.A{"$transaction_type", side}}, expr, zero,
+		}}}
+	}
+
+	project := bson.M{
+		"_id":           1,
+		"account":       "$_id.account",
+		"period_start":  "$_id.period",
+		"trades":        1,
+		"buy_quantity":  1,
+		"sell_quantity": 1,
+		"buy_value":     1,
+		"sell_value":    1,
+		"turnover":      bson.M{"$add": bson.A{"$buy_value", "$sell_value"}},
+		"refreshed_at":  refreshedAt,
+	}
+	if kind.BySymbol {
+		project["symbol"] = "$_id.symbol"
+	}
+
+	pipeline := bson.A{
+		bson.M{"$match": bson.M{
+			"account":      ob.account,
+			"voided":       bson.M{"$ne": true},
+			"order_status": filledStatus,
+			"timestamp":    bson.M{"$gte": start, "$lt": end},
+		}},
+		bson.M{"$group": bson.M{
+			"_id":           groupID,
+			"trades":        bson.M{"$sum": 1},
+			"buy_quantity":  sideSum("B", "$quantity", 0),
+			"sell_quantity": sideSum("S", "$quantity", 0),
+			"buy_value":     sideSum("B", value, int64(0)),
+			"sell_value":    sideSum("S", value, int64(0)),
+		}},
+		bson.M{"$project": project},
+		bson.M{"$merge": bson.M{
+			"into":           kind.Collection,
+			"on":             "_id",
+			"whenMatched":    "replace",
+			"whenNotMatched": "insert",
+		}},
+	}
+
+	err := ob.retry(ctx, kind.Name+" rollup", func() error {
+		cursor, err := ob.ordersCollection.Aggregate(ctx, pipeline)
+		if err != nil {
+			return err
+		}
+		return cursor.Close(ctx)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to run rollup pipeline: %v", err)
+	}
+	return nil
+}
+
+// orderPaise is the pipeline expression of an order's value in paise as a
+// long, rounded per order under the money rounding policy as money.Value
+// rounds it, so the rollups add up to the paisa like the daily summaries
+func orderPaise() bson.M {
+	// Snapped to a millionth of a paisa first, as money does, so float noise
+	// such as 0.49999999 does not decide the rounding
+	paise := bson.M{"$round": bson.A{bson.M{"$multiply": bson.A{"$average_price", "$quantity", 100}}, 6}}
+	var rounded bson.M
+	switch money.CurrentRounding() {
+	case money.HalfEven:
+		rounded = bson.M{"$round": bson.A{paise, 0}}
+	case money.Down:
+		rounded = bson.M{"$trunc": bson.A{paise, 0}}
+	default:
+		// Order values are never negative, so halves round up by flooring
+		rounded = bson.M{"$floor": bson.M{"$add": bson.A{paise, 0.5}}}
+	}
+	return bson.M{"$toLong": rounded}
+}
+
+// upsertRollups aggregates the rollups of [start, end) from the orders of
+// both storage tiers and upserts them under the _id the pipeline merges on
+func (ob *OrderBook) upsertRollups(ctx context.Context, kind RollupKind, start, end, refreshedAt time.Time) error {
+	orders, err := ob.GetOrdersByDateRange(ctx, start, end)
+	if err != nil {
+		return err
+	}
+
+	type key struct {
+		period int64
+		symbol string
+	}
+	type totals struct {
+		period                    time.Time
+		symbol                    string
+		trades                    int32
+		buyQuantity, sellQuantity float64
+		buyValue, sellValue       money.Paise
+	}
+	groups := make(map[key]*totals)
+	var keys []key
+	for _, order := range orders {
+		period := truncatePeriod(order.Timestamp, kind.Unit)
+		k := key{period: period.Unix()}
+		if kind.BySymbol {
+			k.symbol = order.Symbol
+		}
+		t, ok := groups[k]
+		if !ok {
+			t = &totals{period: period, symbol: k.symbol}
+			groups[k] = t
+			keys = append(keys, k)
+		}
+		t.trades++
+		value := money.Value(order.AveragePrice, order.Quantity)
+		if order.TransactionType == "B" {
+			t.buyQuantity += order.Quantity
+			t.buyValue += value
+		} else {
+			t.sellQuantity += order.Quantity
+			t.sellValue += value
+		}
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+
+	models := make([]mongo.WriteModel, 0, len(keys))
+	for _, k := range keys {
+		t := groups[k]
+		id := bson.D{{Key: "account", Value: ob.account}, {Key: "period", Value: t.period}}
+		if kind.BySymbol {
+			id = append(id, bson.E{Key: "symbol", Value: t.symbol})
+		}
+		rollup := bson.D{
+			{Key: "_id", Value: id},
+			{Key: "account", Value: ob.account},
+			{Key: "period_start", Value: t.period},
+			{Key: "trades", Value: t.trades},
+			{Key: "buy_quantity", Value: t.buyQuantity},
+			{Key: "sell_quantity", Value: t.sellQuantity},
+			{Key: "buy_value", Value: t.buyValue},
+			{Key: "sell_value", Value: t.sellValue},
+			{Key: "turnover", Value: t.buyValue + t.sellValue},
+			{Key: "refreshed_at", Value: refreshedAt},
+		}
+		if kind.BySymbol {
+			rollup = append(rollup, bson.E{Key: "symbol", Value: t.symbol})
+		}
+		models = append(models, mongo.NewReplaceOneModel().
+			SetFilter(bson.M{"_id": id}).
+			SetReplacement(rollup).
+			SetUpsert(true))
+	}
+
+	collection := ob.ordersCollection.Database().Collection(kind.Collection)
+	err = ob.retry(ctx, kind.Name+" rollup", func() error {
+		_, err := collection.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write rollups: %v", err)
+	}
+	return nil
+}
+
+// truncatePeriod returns the start, in market time, of the rollup period of
+// unit containing t, as $dateTrunc buckets it
+func truncatePeriod(t time.Time, unit string) time.Time {
+	loc := market.Location()
+	local := t.In(loc)
+	switch unit {
+	case "hour":
+		return time.Date(local.Year(), local.Month(), local.Day(), local.Hour(), 0, 0, 0, loc)
+	case "month":
+		return time.Date(local.Year(), local.Month(), 1, 0, 0, 0, 0, loc)
+	default:
+		return market.DayStart(t)
+	}
+}
+
+// GetRollups reads precomputed rollups whose period starts in [from, to)
+func (ob *OrderBook) GetRollups(ctx context.Context, kind RollupKind, from, to time.Time) ([]Rollup, error) {
+	if !ob.MaintainsRollup(kind) {
+		return nil, fmt.Errorf("%s rollup is not maintained for account %s", kind.Name, ob.account)
+	}
+
+	collection := ob.ordersCollection.Database().Collection(kind.Collection)
+	filter := bson.M{"account": ob.account, "period_start": bson.M{"$gte": from, "$lt": to}}
+
+	cursor, err := collection.Find(ctx, filter, options.Find().SetSort(bson.D{
+		{Key: "period_start", Value: 1},
+		{Key: "symbol", Value: 1},
+	}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query %s rollups: %v", kind.Name, err)
+	}
+	defer cursor.Close(ctx)
+
+	var rollups []Rollup
+	if err := cursor.All(ctx, &rollups); err != nil {
+		return nil, fmt.Errorf("failed to decode %s rollups: %v", kind.Name, err)
+	}
+	for i := range rollups {
+		rollups[i].PeriodStart = rollups[i].PeriodStart.In(market.Location())
+	}
+
+	return rollups, nil
+}
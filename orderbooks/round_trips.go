@@ -0,0 +1,74 @@
+package orderbook
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"profitLossAndTradeInfoToDB/pkg/trades"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ClosedTrade is a round trip of the trades collection: a day's fills matched
+// FIFO per symbol into entry and exit, as the daily summary's realized P&L is
+// computed. A day's closed trades are replaced whenever its summary is.
+type ClosedTrade struct {
+	ID               primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Account          string             `bson:"account" json:"account"`
+	Date             time.Time          `bson:"date" json:"date"` // Market day the trade was matched in
+	trades.RoundTrip `bson:",inline"`
+	Expiry           *time.Time `bson:"expiry,omitempty" json:"expiry,omitempty"` // Contract expiry day of the symbol's orders; nil for equity
+	ImportRunID      string     `bson:"import_run_id,omitempty" json:"import_run_id,omitempty"`
+}
+
+// storeRoundTrips replaces the closed trades of the market day starting at day,
+// taking each one's expiry from the stored metadata of the orders it was
+// matched from
+func (ob *OrderBook) storeRoundTrips(ctx context.Context, day time.Time, roundTrips []trades.RoundTrip, orders []Order) error {
+	expiries := make(map[string]*time.Time)
+	for _, order := range orders {
+		if order.MetaData.Expiry != nil {
+			expiries[order.Symbol] = order.MetaData.Expiry
+		}
+	}
+
+	docs := make([]interface{}, len(roundTrips))
+	for i, trip := range roundTrips {
+		docs[i] = ClosedTrade{Account: ob.account, Date: day, RoundTrip: trip, Expiry: expiries[trip.Symbol], ImportRunID: ob.RunID()}
+	}
+
+	err := ob.retry(ctx, "round trip update", func() error {
+		if _, err := ob.roundTrips.DeleteMany(ctx, bson.M{"account": ob.account, "date": day}); err != nil {
+			return err
+		}
+		if len(docs) == 0 {
+			return nil
+		}
+		_, err := ob.roundTrips.InsertMany(ctx, docs)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to store round trips of %s: %v", day.Format("2006-01-02"), err)
+	}
+	return nil
+}
+
+// GetClosedTrades returns the closed trades of the days in [from, to), by exit time
+func (ob *OrderBook) GetClosedTrades(ctx context.Context, from, to time.Time) ([]ClosedTrade, error) {
+	filter := bson.M{"account": ob.account, "date": bson.M{"$gte": from, "$lt": to}}
+	opts := options.Find().SetSort(bson.D{{Key: "exit_time", Value: 1}})
+	cursor, err := ob.roundTrips.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query closed trades: %v", err)
+	}
+	defer cursor.Close(ctx)
+
+	var closed []ClosedTrade
+	if err := cursor.All(ctx, &closed); err != nil {
+		return nil, fmt.Errorf("failed to decode closed trades: %v", err)
+	}
+	return closed, nil
+}
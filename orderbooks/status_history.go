@@ -0,0 +1,81 @@
+package orderbook
+
+import (
+	"slices"
+	"time"
+)
+
+// InProgressStatuses are the statuses of orders that may still change: a
+// later row or import of the same order updates it rather than adding an order
+var InProgressStatuses = []string{
+	"OPEN", "PENDING", "TRIGGER PENDING", "PARTIALLY FILLED", "PARTIAL", "TRANSIT", "MODIFIED",
+	"OPEN PENDING", "VALIDATION PENDING", "PUT ORDER REQ RECEIVED", "AMO REQ RECEIVED",
+}
+
+// StatusChange is one status an order went through
+type StatusChange struct {
+	Status    string    `bson:"status" json:"status"`
+	Timestamp time.Time `bson:"timestamp" json:"timestamp"` // Exchange time of the update when the export has it, else the row's time
+	Quantity  float64   `bson:"quantity" json:"quantity"`   // Filled so far
+}
+
+// InProgress reports whether the order's status may still change
+func (o Order) InProgress() bool {
+	return slices.Contains(InProgressStatuses, o.OrderStatus)
+}
+
+// statusHistory returns the order's history, or its current status as the
+// only entry when none was recorded
+func statusHistory(o Order) []StatusChange {
+	if len(o.StatusHistory) > 0 {
+		return o.StatusHistory
+	}
+	at := o.Timestamp
+	if o.ExecutionTime != nil {
+		at = *o.ExecutionTime
+	}
+	return []StatusChange{{Status: o.OrderStatus, Timestamp: at, Quantity: o.Quantity}}
+}
+
+// appendStatus adds the changes of later to history, dropping repeats of the
+// status history already ends in
+func appendStatus(history, later []StatusChange) []StatusChange {
+	history = slices.Clone(history)
+	for _, change := range later {
+		if n := len(history); n > 0 && history[n-1].Status == change.Status && history[n-1].Quantity == change.Quantity {
+			continue
+		}
+		history = append(history, change)
+	}
+	return history
+}
+
+// updateStatus replaces the order's state with that of update, a later
+// snapshot of the same order: quantity, price, status and execution time are
+// taken from it, the order keeps its first time and the status is recorded
+func updateStatus(order *Order, update Order) {
+	history := appendStatus(statusHistory(*order), statusHistory(update))
+	timestamp := order.Timestamp
+	if update.Timestamp.Before(timestamp) {
+		timestamp = update.Timestamp
+	}
+
+	order.Quantity = update.Quantity
+	order.AveragePrice = update.AveragePrice
+	order.OrderStatus = update.OrderStatus
+	if update.ExecutionTime != nil {
+		order.ExecutionTime = update.ExecutionTime
+	}
+	if update.Notes != "" {
+		order.Notes = update.Notes
+	}
+	for _, tag := range update.Tags {
+		if !slices.Contains(order.Tags, tag) {
+			order.Tags = append(order.Tags, tag)
+		}
+	}
+	order.Timestamp = timestamp
+	order.StatusHistory = history
+	order.row = update.row
+	order.DedupKey = dedupKey(*order)
+}
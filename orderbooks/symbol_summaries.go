@@ -0,0 +1,118 @@
+package orderbook
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"profitLossAndTradeInfoToDB/pkg/money"
+	"profitLossAndTradeInfoToDB/pkg/trades"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// SymbolSummary is the daily summary of one symbol. The symbol summaries of a
+// day are replaced together with its daily summary, whose totals they add up to.
+type SymbolSummary struct {
+	Account      string      `bson:"account" json:"account"`
+	Date         time.Time   `bson:"date" json:"date"`
+	Symbol       string      `bson:"symbol" json:"symbol"`
+	Trades       int32       `bson:"trades" json:"trades"`
+	BuyQuantity  float64     `bson:"buy_quantity" json:"buy_quantity"`
+	SellQuantity float64     `bson:"sell_quantity" json:"sell_quantity"`
+	BuyTurnover  money.Paise `bson:"buy_turnover" json:"buy_turnover"`
+	SellTurnover money.Paise `bson:"sell_turnover" json:"sell_turnover"`
+	RealizedPnL  money.Paise `bson:"realized_pnl" json:"realized_pnl"`
+	LastRunID    string      `bson:"last_import_run_id,omitempty" json:"last_import_run_id,omitempty"`
+}
+
+// SummarizeSymbols splits a day's filled orders and their round trips by
+// symbol, sorted by symbol
+func SummarizeSymbols(account string, day time.Time, orders []Order, roundTrips []trades.RoundTrip) []SymbolSummary {
+	type totals struct {
+		summary   SymbolSummary
+		buy, sell money.Paise
+		pnl       money.Paise
+	}
+	bySymbol := make(map[string]*totals)
+	get := func(sym string) *totals {
+		t, ok := bySymbol[sym]
+		if !ok {
+			t = &totals{summary: SymbolSummary{Account: account, Date: day, Symbol: sym}}
+			bySymbol[sym] = t
+		}
+		return t
+	}
+
+	for _, order := range orders {
+		t := get(order.Symbol)
+		t.summary.Trades++
+		value := money.Value(order.AveragePrice, order.Quantity)
+		if order.TransactionType == "B" {
+			t.summary.BuyQuantity += order.Quantity
+			t.buy += value
+		} else {
+			t.summary.SellQuantity += order.Quantity
+			t.sell += value
+		}
+	}
+	for _, trip := range roundTrips {
+		get(trip.Symbol).pnl += trip.RealizedPnL
+	}
+
+	summaries := make([]SymbolSummary, 0, len(bySymbol))
+	for _, t := range bySymbol {
+		t.summary.BuyTurnover, t.summary.SellTurnover = t.buy, t.sell
+		t.summary.RealizedPnL = t.pnl
+		summaries = append(summaries, t.summary)
+	}
+	sort.Slice(summaries, func(i, j int) bool { return summaries[i].Symbol < summaries[j].Symbol })
+	return summaries
+}
+
+// storeSymbolSummaries replaces the symbol summaries of the market day starting at day
+func (ob *OrderBook) storeSymbolSummaries(ctx context.Context, day time.Time, summaries []SymbolSummary) error {
+	docs := make([]interface{}, len(summaries))
+	for i, summary := range summaries {
+		summary.LastRunID = ob.RunID()
+		docs[i] = summary
+	}
+
+	err := ob.retry(ctx, "symbol summary update", func() error {
+		if _, err := ob.symbolSummaries.DeleteMany(ctx, bson.M{"account": ob.account, "date": day}); err != nil {
+			return err
+		}
+		if len(docs) == 0 {
+			return nil
+		}
+		_, err := ob.symbolSummaries.InsertMany(ctx, docs)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to store symbol summaries of %s: %v", day.Format("2006-01-02"), err)
+	}
+	return nil
+}
+
+// GetSymbolSummaries returns the symbol summaries of the days in [from, to) by
+// day and symbol; a non-empty symbol limits them to that symbol
+func (ob *OrderBook) GetSymbolSummaries(ctx context.Context, from, to time.Time, symbol string) ([]SymbolSummary, error) {
+	filter := bson.M{"account": ob.account, "date": bson.M{"$gte": from, "$lt": to}}
+	if symbol != "" {
+		filter["symbol"] = symbol
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "date", Value: 1}, {Key: "symbol", Value: 1}})
+	cursor, err := ob.symbolSummaries.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query symbol summaries: %v", err)
+	}
+	defer cursor.Close(ctx)
+
+	var summaries []SymbolSummary
+	if err := cursor.All(ctx, &summaries); err != nil {
+		return nil, fmt.Errorf("failed to decode symbol summaries: %v", err)
+	}
+	return summaries, nil
+}
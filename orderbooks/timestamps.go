@@ -0,0 +1,76 @@
+package orderbook
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"profitLossAndTradeInfoToDB/pkg/market"
+)
+
+// timestampLayout is one timestamp format seen in broker exports
+type timestampLayout struct {
+	name  string
+	parse func(value string, loc *time.Location) (time.Time, error)
+}
+
+// timestampLayouts are tried in order when detecting a file's layout.
+// Timestamps without an offset are read in the file's location.
+var timestampLayouts = []timestampLayout{
+	{name: "RFC3339", parse: func(value string, _ *time.Location) (time.Time, error) {
+		return time.Parse(time.RFC3339, value)
+	}},
+	{name: "2006-01-02 15:04:05", parse: naiveLayout("2006-01-02 15:04:05")},
+	{name: "2006-01-02T15:04:05", parse: naiveLayout("2006-01-02T15:04:05")},
+	{name: "02-01-2006 15:04:05", parse: naiveLayout("02-01-2006 15:04:05")},
+	{name: "epoch milliseconds", parse: epochMillis},
+}
+
+func naiveLayout(layout string) func(string, *time.Location) (time.Time, error) {
+	return func(value string, loc *time.Location) (time.Time, error) {
+		return time.ParseInLocation(layout, value, loc)
+	}
+}
+
+func epochMillis(value string, _ *time.Location) (time.Time, error) {
+	if len(value) < 12 {
+		return time.Time{}, fmt.Errorf("%q is too short for epoch milliseconds", value)
+	}
+	millis, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.UnixMilli(millis).In(market.Location()), nil
+}
+
+// timestampFormat parses the timestamps of one column. The layout is detected
+// from the first value and then required of every other value of the file,
+// so a day-first date cannot be read one way in one row and another in the next.
+type timestampFormat struct {
+	layout   *timestampLayout
+	location *time.Location // Of timestamps without an offset; nil for market time
+}
+
+func (f *timestampFormat) parse(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	loc := f.location
+	if loc == nil {
+		loc = market.Location()
+	}
+	if f.layout != nil {
+		t, err := f.layout.parse(value, loc)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("%q does not match the file's %s layout", value, f.layout.name)
+		}
+		return t, nil
+	}
+
+	for i := range timestampLayouts {
+		if t, err := timestampLayouts[i].parse(value, loc); err == nil {
+			f.layout = &timestampLayouts[i]
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("%q matches no known timestamp layout", value)
+}
@@ -0,0 +1,236 @@
+package orderbook
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"profitLossAndTradeInfoToDB/pkg/events"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Trade is one fill of the trade book. Where the order book holds every order
+// placed, rejected and cancelled ones included, the trade book holds what
+// actually executed; the two are linked by the broker's order ID.
+type Trade struct {
+	ID              primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Account         string             `bson:"account" json:"account"`
+	TradeID         string             `bson:"trade_id" json:"trade_id"`
+	OrderID         string             `bson:"order_id" json:"order_id"`
+	Timestamp       time.Time          `bson:"timestamp" json:"timestamp"`
+	TransactionType string             `bson:"transaction_type" json:"transaction_type"`
+	Symbol          string             `bson:"symbol" json:"symbol"`
+	Product         string             `bson:"product,omitempty" json:"product,omitempty"`
+	Quantity        float64            `bson:"quantity" json:"quantity"`
+	Price           float64            `bson:"price" json:"price"`
+	Source          string             `bson:"source" json:"source"`
+	ImportRunID     string             `bson:"import_run_id,omitempty" json:"import_run_id,omitempty"`
+}
+
+// TradeCSVHeader is the canonical column order of trade book CSV files
+var TradeCSVHeader = []string{"trade_id", "order_id", "timestamp", "transaction_type", "symbol", "product", "quantity", "price"}
+
+// tradeColumnAliases are the header names recognised for each TradeCSVHeader
+// column, canonical name first; Zerodha's tradebook export is covered
+var tradeColumnAliases = [][]string{
+	{"trade_id", "tradeid", "trade_no", "trade_number", "exchange_trade_id"},
+	orderIDColumns,
+	{"timestamp", "order_execution_time", "trade_time", "execution_time", "fill_time", "time", "datetime"},
+	{"transaction_type", "trade_type", "type", "side", "buy_sell"},
+	{"symbol", "tradingsymbol", "trading_symbol", "scrip"},
+	{"product", "product_type"},
+	{"quantity", "qty", "filled_qty", "traded_qty"},
+	{"price", "trade_price", "traded_price", "average_price"},
+}
+
+// TradeBookResult reports a trade book import
+type TradeBookResult struct {
+	Stored  int
+	Skipped []RowFailure // Rows that could not be parsed, lenient mode only
+}
+
+// LoadTradeBookFile loads a trade book CSV file, see LoadTradeBook
+func (ob *OrderBook) LoadTradeBookFile(ctx context.Context, filename string) (TradeBookResult, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return TradeBookResult{}, fmt.Errorf("failed to open file: %v", err)
+	}
+	defer file.Close()
+
+	return ob.LoadTradeBook(ctx, file, filename)
+}
+
+// LoadTradeBook stores the fills of a trade book CSV. Fills are keyed by
+// order and trade ID, so loading a file again replaces them rather than
+// adding copies. Bad rows fail the import in strict mode and are skipped
+// otherwise.
+func (ob *OrderBook) LoadTradeBook(ctx context.Context, r io.Reader, source string) (TradeBookResult, error) {
+	var result TradeBookResult
+	if err := ob.checkWritable(); err != nil {
+		return result, err
+	}
+
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if err != nil {
+		return result, fmt.Errorf("failed to read header: %v", err)
+	}
+	columns, err := newTradeColumns(header)
+	if err != nil {
+		return result, fmt.Errorf("invalid trade book header in %s: %v", source, err)
+	}
+
+	var models []mongo.WriteModel
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		var trade Trade
+		if err == nil {
+			trade, err = columns.decode(record)
+		}
+		if err != nil {
+			if ob.parseMode == ParseStrict {
+				return result, fmt.Errorf("invalid trade in %s at line %d: %v", source, line, err)
+			}
+			result.Skipped = append(result.Skipped, RowFailure{Row: line, Message: err.Error()})
+			continue
+		}
+
+		trade.Account = ob.account
+		trade.Source = source
+		trade.ImportRunID = ob.RunID()
+		filter := bson.M{"account": trade.Account, "order_id": trade.OrderID, "trade_id": trade.TradeID}
+		models = append(models, mongo.NewReplaceOneModel().SetFilter(filter).SetReplacement(trade).SetUpsert(true))
+	}
+	if len(models) == 0 {
+		return result, nil
+	}
+
+	var written *mongo.BulkWriteResult
+	err = ob.retry(ctx, "trade book bulk write", func() error {
+		var err error
+		written, err = ob.tradeBook.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+		return err
+	})
+	if err != nil {
+		return result, fmt.Errorf("failed to store trade book %s: %v", source, err)
+	}
+	result.Stored = int(written.UpsertedCount + written.MatchedCount)
+
+	ob.events.Publish(ctx, events.Event{
+		Type: events.BatchInserted, Account: ob.account, RunID: ob.RunID(), Source: source, Kind: "trades", Count: result.Stored,
+	})
+	return result, nil
+}
+
+// GetTradesForOrder returns the fills of a broker order, oldest first
+func (ob *OrderBook) GetTradesForOrder(ctx context.Context, orderID string) ([]Trade, error) {
+	return ob.findTrades(ctx, bson.M{"account": ob.account, "order_id": orderID})
+}
+
+// GetTradesByDateRange returns the account's fills in [from, to), oldest first
+func (ob *OrderBook) GetTradesByDateRange(ctx context.Context, from, to time.Time) ([]Trade, error) {
+	return ob.findTrades(ctx, bson.M{"account": ob.account, "timestamp": bson.M{"$gte": from, "$lt": to}})
+}
+
+// GetOrdersByOrderID returns the orders placed under a broker order ID,
+// usually one, including unfilled and archived ones
+func (ob *OrderBook) GetOrdersByOrderID(ctx context.Context, orderID string) ([]Order, error) {
+	filter := bson.M{"account": ob.account, "order_id": orderID, "voided": bson.M{"$ne": true}}
+	return ob.findOrders(ctx, filter, time.Time{})
+}
+
+func (ob *OrderBook) findTrades(ctx context.Context, filter bson.M) ([]Trade, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "timestamp", Value: 1}, {Key: "trade_id", Value: 1}})
+	cursor, err := ob.tradeBook.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query trade book: %v", err)
+	}
+	defer cursor.Close(ctx)
+
+	var trades []Trade
+	if err := cursor.All(ctx, &trades); err != nil {
+		return nil, fmt.Errorf("failed to decode trades: %v", err)
+	}
+	return trades, nil
+}
+
+// tradeColumns are the indexes of the TradeCSVHeader columns in a file
+type tradeColumns struct {
+	fields     []int
+	timestamps *timestampFormat
+}
+
+// newTradeColumns finds the columns by header name; product is optional
+func newTradeColumns(header []string) (tradeColumns, error) {
+	columns := tradeColumns{fields: make([]int, len(TradeCSVHeader)), timestamps: &timestampFormat{}}
+	var missing []string
+	for i, aliases := range tradeColumnAliases {
+		columns.fields[i] = findColumn(header, aliases)
+		if columns.fields[i] < 0 && TradeCSVHeader[i] != "product" {
+			missing = append(missing, TradeCSVHeader[i])
+		}
+	}
+	if len(missing) > 0 {
+		return columns, fmt.Errorf("header has no %s column", strings.Join(missing, ", "))
+	}
+	return columns, nil
+}
+
+func (c tradeColumns) field(record []string, i int) string {
+	return strings.TrimSpace(cell(record, c.fields[i]))
+}
+
+// decode converts a record into a trade, checking it is usable
+func (c tradeColumns) decode(record []string) (Trade, error) {
+	timestamp, err := c.timestamps.parse(c.field(record, 2))
+	if err != nil {
+		return Trade{}, fmt.Errorf("failed to parse timestamp: %v", err)
+	}
+	quantity, err := strconv.ParseFloat(c.field(record, 6), 64)
+	if err != nil {
+		return Trade{}, fmt.Errorf("failed to parse quantity: %v", err)
+	}
+	price, err := strconv.ParseFloat(c.field(record, 7), 64)
+	if err != nil {
+		return Trade{}, fmt.Errorf("failed to parse price: %v", err)
+	}
+
+	trade := Trade{
+		TradeID:         c.field(record, 0),
+		OrderID:         c.field(record, 1),
+		Timestamp:       timestamp,
+		TransactionType: normalizeSide(c.field(record, 3)),
+		Symbol:          c.field(record, 4),
+		Product:         c.field(record, 5),
+		Quantity:        quantity,
+		Price:           price,
+	}
+	switch {
+	case trade.TradeID == "" || trade.OrderID == "":
+		return Trade{}, fmt.Errorf("trade and order IDs are required")
+	case trade.Symbol == "":
+		return Trade{}, fmt.Errorf("symbol is required")
+	case trade.TransactionType != "B" && trade.TransactionType != "S":
+		return Trade{}, fmt.Errorf("invalid transaction type %q", trade.TransactionType)
+	case !(trade.Quantity > 0):
+		return Trade{}, fmt.Errorf("quantity must be positive, got %v", trade.Quantity)
+	case !(trade.Price >= 0):
+		return Trade{}, fmt.Errorf("price must not be negative, got %v", trade.Price)
+	}
+	return trade, nil
+}
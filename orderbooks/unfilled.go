@@ -0,0 +1,47 @@
+package orderbook
+
+import (
+	"fmt"
+	"slices"
+)
+
+// UnfilledPolicy decides what imports do with orders that never traded, see
+// UnfilledStatuses. Summaries, rollups and P&L leave them out either way.
+type UnfilledPolicy string
+
+const (
+	UnfilledTag  UnfilledPolicy = "tag"  // Store them tagged UnfilledTagName, for the order book
+	UnfilledSkip UnfilledPolicy = "skip" // Drop them
+)
+
+// UnfilledTagName tags the stored orders that never traded
+const UnfilledTagName = "unfilled"
+
+// ParseUnfilledPolicy validates a policy name; empty selects UnfilledTag
+func ParseUnfilledPolicy(name string) (UnfilledPolicy, error) {
+	switch policy := UnfilledPolicy(name); policy {
+	case "":
+		return UnfilledTag, nil
+	case UnfilledTag, UnfilledSkip:
+		return policy, nil
+	}
+	return "", fmt.Errorf("unknown unfilled order policy %q, expected tag or skip", name)
+}
+
+// DropUnfilled returns the orders that traded, for stores that import
+// without an OrderBook
+func DropUnfilled(orders []Order) []Order {
+	return slices.DeleteFunc(orders, func(order Order) bool { return !order.Filled() })
+}
+
+// tagUnfilled adds the unfilled tag to an order that never traded, or removes
+// it once a status update shows the order traded
+func tagUnfilled(order *Order) {
+	tagged := slices.Contains(order.Tags, UnfilledTagName)
+	switch {
+	case !order.Filled() && !tagged:
+		order.Tags = append(order.Tags, UnfilledTagName)
+	case order.Filled() && tagged:
+		order.Tags = slices.DeleteFunc(slices.Clone(order.Tags), func(tag string) bool { return tag == UnfilledTagName })
+	}
+}
@@ -0,0 +1,109 @@
+package orderbook
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"profitLossAndTradeInfoToDB/pkg/market"
+	"profitLossAndTradeInfoToDB/pkg/trades"
+)
+
+// WeekdayPerformance is the statistics of the closed trades of one day of the
+// week over a range, with how many of them were closed on their contract's
+// expiry day
+type WeekdayPerformance struct {
+	Weekday string `json:"weekday"`
+	PerformanceStats
+	ExpiryTrades int32 `json:"expiry_trades"`
+}
+
+// DayBreakdown splits the statistics of the closed trades of a range by day
+// of the week, Monday first, and by whether the trade was closed on the
+// expiry day of its option or future. Equity trades are never expiry trades.
+type DayBreakdown struct {
+	Weekdays  []WeekdayPerformance `json:"weekdays"` // Only days of the week with closed trades
+	Expiry    PerformanceStats     `json:"expiry"`
+	NonExpiry PerformanceStats     `json:"non_expiry"`
+	Total     PerformanceStats     `json:"total"`
+}
+
+// IsExpiryTrade reports whether the closed trade was matched on the expiry
+// day stored with its orders. Trades stored before expiries were kept with
+// them count as non-expiry until their day is recomputed.
+func IsExpiryTrade(trade ClosedTrade) bool {
+	return trade.Expiry != nil && trade.Expiry.Equal(market.DayStart(trade.Date))
+}
+
+// BuildDayBreakdown works out the breakdown of closed trades in exit order.
+// A day's trades are combined as a day first, so each group keeps its day
+// counts and streaks; a day with both kinds of trades counts on both sides.
+func BuildDayBreakdown(closed []ClosedTrade) DayBreakdown {
+	type day struct {
+		all, expiry, nonExpiry []trades.RoundTrip
+	}
+	byDay := make(map[int64]*day)
+	var days []time.Time
+	for _, trade := range closed {
+		start := market.DayStart(trade.Date)
+		d, ok := byDay[start.Unix()]
+		if !ok {
+			d = &day{}
+			byDay[start.Unix()] = d
+			days = append(days, start)
+		}
+		d.all = append(d.all, trade.RoundTrip)
+		if IsExpiryTrade(trade) {
+			d.expiry = append(d.expiry, trade.RoundTrip)
+		} else {
+			d.nonExpiry = append(d.nonExpiry, trade.RoundTrip)
+		}
+	}
+	sort.Slice(days, func(i, j int) bool { return days[i].Before(days[j]) })
+
+	var all, expiry, nonExpiry []PerformanceStats
+	weekdays := make([][]PerformanceStats, 7)
+	expiryTrades := make([]int32, 7)
+	for _, start := range days {
+		d := byDay[start.Unix()]
+		stats := dayPerformance(d.all)
+		all = append(all, stats)
+		index := mondayIndex(start)
+		weekdays[index] = append(weekdays[index], stats)
+		expiryTrades[index] += int32(len(d.expiry))
+		if len(d.expiry) > 0 {
+			expiry = append(expiry, dayPerformance(d.expiry))
+		}
+		if len(d.nonExpiry) > 0 {
+			nonExpiry = append(nonExpiry, dayPerformance(d.nonExpiry))
+		}
+	}
+
+	breakdown := DayBreakdown{
+		Weekdays:  []WeekdayPerformance{},
+		Expiry:    CombinePerformance(expiry),
+		NonExpiry: CombinePerformance(nonExpiry),
+		Total:     CombinePerformance(all),
+	}
+	for index, stats := range weekdays {
+		if len(stats) == 0 {
+			continue
+		}
+		breakdown.Weekdays = append(breakdown.Weekdays, WeekdayPerformance{
+			Weekday:          time.Weekday((index + 1) % 7).String(),
+			PerformanceStats: CombinePerformance(stats),
+			ExpiryTrades:     expiryTrades[index],
+		})
+	}
+	return breakdown
+}
+
+// DayBreakdown breaks down the closed trades of the days in [from, to) by day
+// of the week and expiry day
+func (ob *OrderBook) DayBreakdown(ctx context.Context, from, to time.Time) (DayBreakdown, error) {
+	closed, err := ob.GetClosedTrades(ctx, from, to)
+	if err != nil {
+		return DayBreakdown{}, err
+	}
+	return BuildDayBreakdown(closed), nil
+}
@@ -0,0 +1,69 @@
+package orderbook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// RowFailure is an order the database rejected
+type RowFailure struct {
+	Row       int // CSV line the order was read from, 0 when it was not read from CSV
+	Symbol    string
+	Timestamp time.Time
+	Code      int
+	Message   string
+}
+
+// PartialWriteError reports the orders of an import the database rejected;
+// every other order of the import was stored
+type PartialWriteError struct {
+	Source  string
+	Written int
+	Failed  []RowFailure
+}
+
+func (e *PartialWriteError) Error() string {
+	first := e.Failed[0]
+	return fmt.Sprintf("%d orders of %s were rejected and %d stored; row %d (%s): %s",
+		len(e.Failed), e.Source, e.Written, first.Row, first.Symbol, first.Message)
+}
+
+// bulkWriteOrders runs an unordered bulk write of models, which store orders
+// one to one, and reports the orders rejected instead of failing on them. err
+// is only set when the write failed as a whole, e.g. the connection dropped.
+func (ob *OrderBook) bulkWriteOrders(ctx context.Context, models []mongo.WriteModel, orders []Order) (int, []RowFailure, error) {
+	// Retrying is safe: inserts are upserts and replacements are idempotent
+	var result *mongo.BulkWriteResult
+	err := ob.retry(ctx, "order bulk write", func() error {
+		var err error
+		result, err = ob.ordersCollection.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+		return err
+	})
+
+	var bulkErr mongo.BulkWriteException
+	if err != nil && (!errors.As(err, &bulkErr) || bulkErr.WriteConcernError != nil) {
+		return 0, nil, err
+	}
+
+	stored := 0
+	if result != nil {
+		stored = int(result.UpsertedCount + result.ModifiedCount)
+	}
+
+	var failed []RowFailure
+	for _, writeErr := range bulkErr.WriteErrors {
+		if writeErr.Code == 11000 {
+			continue // a concurrent import stored the same copy first
+		}
+		order := orders[writeErr.Index]
+		failed = append(failed, RowFailure{
+			Row: order.row, Symbol: order.Symbol, Timestamp: order.Timestamp, Code: writeErr.Code, Message: writeErr.Message,
+		})
+	}
+	return stored, failed, nil
+}
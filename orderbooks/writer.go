@@ -0,0 +1,244 @@
+package orderbook
+
+import (
+	"context"
+	"expvar"
+	"fmt"
+	"sync"
+	"time"
+
+	"profitLossAndTradeInfoToDB/pkg/events"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// WriterOptions sizes the pipeline between CSV parsing and the bulk writes
+type WriterOptions struct {
+	BatchSize int // Orders per bulk write, default 1000
+	QueueSize int // Batches waiting for a worker before parsing blocks, default 4
+	Workers   int // Concurrent bulk writes, default 2
+}
+
+func (o WriterOptions) withDefaults() WriterOptions {
+	if o.BatchSize <= 0 {
+		o.BatchSize = 1000
+	}
+	if o.QueueSize <= 0 {
+		o.QueueSize = 4
+	}
+	if o.Workers <= 0 {
+		o.Workers = 2
+	}
+	return o
+}
+
+// Writer metrics, exported through expvar as order_writer
+var (
+	writerMetrics     = expvar.NewMap("order_writer")
+	writerQueueDepth  = new(expvar.Int) // Batches queued across all writers
+	writerFlushLastMS = new(expvar.Float)
+)
+
+func init() {
+	writerMetrics.Set("queue_depth", writerQueueDepth)
+	writerMetrics.Set("flush_last_ms", writerFlushLastMS)
+}
+
+// writeBatch is a batch of orders resolved against the duplicate policy
+type writeBatch struct {
+	models  []mongo.WriteModel
+	orders  []Order // Orders the models store
+	seq     int     // Position of the batch in the file
+	lastRow int     // CSV line of the batch's last order, skipped ones included
+}
+
+// batchWriter accumulates parsed orders into batches and hands them to a
+// bounded queue drained by worker goroutines. Add blocks while the queue is
+// full, so parsing slows to the pace MongoDB accepts writes. Duplicates are
+// resolved as batches are queued, one batch at a time, so repeats within a
+// file are seen in order. Orders the database rejects are collected into a
+// PartialWriteError while the rest of the file is still written.
+type batchWriter struct {
+	ob       *OrderBook
+	source   string
+	size     int
+	queue    chan writeBatch
+	batch    []Order
+	resolver *duplicateResolver
+	wg       sync.WaitGroup
+	seq      int
+
+	// checkpoint is nil unless the import is resumable, see ResumeCSV
+	checkpoint *checkpointer
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu      sync.Mutex
+	dates   []time.Time
+	written int
+	failed  []RowFailure
+	err     error
+}
+
+func (ob *OrderBook) newBatchWriter(ctx context.Context, source string) *batchWriter {
+	opts := ob.writer.withDefaults()
+	ctx, cancel := context.WithCancel(ctx)
+
+	w := &batchWriter{
+		ob:     ob,
+		source: source,
+		size:   opts.BatchSize,
+		queue:  make(chan writeBatch, opts.QueueSize),
+		ctx:    ctx,
+		cancel: cancel,
+
+		resolver: ob.newDuplicateResolver(),
+	}
+	for i := 0; i < opts.Workers; i++ {
+		w.wg.Add(1)
+		go w.work()
+	}
+	return w
+}
+
+// Add buffers an order, enqueueing a full batch
+func (w *batchWriter) Add(order Order) error {
+	w.batch = append(w.batch, order)
+	if len(w.batch) < w.size {
+		return nil
+	}
+	return w.enqueue()
+}
+
+func (w *batchWriter) enqueue() error {
+	seq, lastRow := w.seq, w.batch[len(w.batch)-1].row
+	w.seq++
+
+	models, orders, err := w.resolver.resolve(w.ctx, w.batch)
+	w.batch = nil
+	if err != nil {
+		w.cancel()
+		return err
+	}
+	if len(models) == 0 {
+		w.committed(seq, lastRow) // every order was a skipped duplicate
+		return w.failure()
+	}
+
+	select {
+	case w.queue <- writeBatch{models: models, orders: orders, seq: seq, lastRow: lastRow}:
+		writerQueueDepth.Add(1)
+		return nil
+	case <-w.ctx.Done():
+		if err := w.failure(); err != nil {
+			return err
+		}
+		return w.ctx.Err()
+	}
+}
+
+// Close flushes the last batch, waits for the workers and recomputes the
+// summaries of every day written, even when a batch failed
+func (w *batchWriter) Close(ctx context.Context) error {
+	var err error
+	if len(w.batch) > 0 {
+		err = w.enqueue()
+	}
+	close(w.queue)
+	w.wg.Wait()
+	w.cancel()
+
+	if failure := w.failure(); failure != nil {
+		err = failure
+	} else if err == nil && len(w.failed) > 0 {
+		err = &PartialWriteError{Source: w.source, Written: w.written, Failed: w.failed}
+	}
+
+	if invalidateErr := w.ob.invalidateDates(ctx, w.dates); invalidateErr != nil && err == nil {
+		err = invalidateErr
+	}
+	w.resolver.publish(ctx, w.source)
+	return err
+}
+
+// Abort stops the workers after a parse error, keeping what was already written
+func (w *batchWriter) Abort(ctx context.Context) {
+	w.batch = nil
+	w.cancel()
+	w.Close(ctx)
+}
+
+// touch has Close recompute the day of t although nothing of it is written
+func (w *batchWriter) touch(t time.Time) {
+	w.mu.Lock()
+	w.dates = append(w.dates, t)
+	w.mu.Unlock()
+}
+
+// committed advances the checkpoint past a batch written in full
+func (w *batchWriter) committed(seq, lastRow int) {
+	if w.checkpoint == nil {
+		return
+	}
+	if err := w.checkpoint.done(w.ctx, seq, lastRow); err != nil {
+		w.fail(err)
+	}
+}
+
+// fail records the first error of the import and stops the workers
+func (w *batchWriter) fail(err error) {
+	w.mu.Lock()
+	if w.err == nil {
+		w.err = err
+	}
+	w.mu.Unlock()
+	w.cancel()
+}
+
+func (w *batchWriter) failure() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.err
+}
+
+func (w *batchWriter) work() {
+	defer w.wg.Done()
+
+	for batch := range w.queue {
+		writerQueueDepth.Add(-1)
+		if w.ctx.Err() != nil {
+			continue // drain after a failure
+		}
+
+		start := time.Now()
+		stored, failed, err := w.ob.bulkWriteOrders(w.ctx, batch.models, batch.orders)
+		elapsed := time.Since(start)
+		writerFlushLastMS.Set(float64(elapsed.Microseconds()) / 1000)
+		writerMetrics.Add("flushes", 1)
+		writerMetrics.Add("flush_total_ms", elapsed.Milliseconds())
+
+		if err != nil {
+			w.fail(fmt.Errorf("failed to insert orders: %v", err))
+			continue
+		}
+
+		// Copies already stored are matched by their upsert and not counted
+		writerMetrics.Add("documents", int64(stored))
+		writerMetrics.Add("rejected", int64(len(failed)))
+		w.mu.Lock()
+		w.written += stored
+		w.failed = append(w.failed, failed...)
+		for _, order := range batch.orders {
+			w.dates = append(w.dates, order.Timestamp)
+		}
+		w.mu.Unlock()
+
+		w.ob.events.Publish(w.ctx, events.Event{
+			Type: events.BatchInserted, Account: w.ob.account, RunID: w.ob.RunID(), Source: w.source, Kind: "orders", Count: stored,
+		})
+		if len(failed) == 0 {
+			w.committed(batch.seq, batch.lastRow)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package api
+
+import (
+	"container/list"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// payloadCache keeps encoded responses keyed by endpoint, range, account and
+// data version, evicting the least recently used beyond its capacity. A key
+// never goes stale: new data changes the version and so the key.
+type payloadCache struct {
+	mu       sync.Mutex
+	capacity int
+	entries  map[string]*list.Element
+	order    *list.List // Most recently used first
+}
+
+type cachedPayload struct {
+	key  string
+	body []byte
+}
+
+func newPayloadCache(capacity int) *payloadCache {
+	return &payloadCache{
+		capacity: capacity,
+		entries:  map[string]*list.Element{},
+		order:    list.New(),
+	}
+}
+
+func (c *payloadCache) get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(element)
+	return element.Value.(*cachedPayload).body, true
+}
+
+func (c *payloadCache) put(key string, body []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, ok := c.entries[key]; ok {
+		element.Value.(*cachedPayload).body = body
+		c.order.MoveToFront(element)
+		return
+	}
+	c.entries[key] = c.order.PushFront(&cachedPayload{key: key, body: body})
+	for c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cachedPayload).key)
+	}
+}
+
+// etagFor derives the entity tag of a cache key
+func etagFor(key string) string {
+	sum := sha1.Sum([]byte(key))
+	return `"` + hex.EncodeToString(sum[:12]) + `"`
+}
+
+// matchesETag reports whether an If-None-Match header lists etag
+func matchesETag(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == etag || candidate == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// serveCached answers a conditional GET for an expensive payload. The key is
+// built from the request path and query, the account and the data version;
+// compute only runs when neither the client nor the cache has that version.
+func (s *Server) serveCached(w http.ResponseWriter, r *http.Request, compute func() (interface{}, error)) {
+	version, err := s.ob.DataVersion(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	key := strings.Join([]string{r.URL.Path, r.URL.Query().Encode(), s.ob.Account(), version}, "|")
+	etag := etagFor(key)
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "no-cache") // Cached copies must be revalidated
+
+	if matchesETag(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	body, ok := s.cache.get(key)
+	if !ok {
+		payload, err := compute()
+		if err == nil {
+			body, err = json.Marshal(payload)
+		}
+		if err != nil {
+			w.Header().Del("ETag")
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		s.cache.put(key, body)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(body)
+}
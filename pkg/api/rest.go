@@ -0,0 +1,423 @@
+package api
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	orderbook "profitLossAndTradeInfoToDB/orderbooks"
+	"profitLossAndTradeInfoToDB/pkg/market"
+	"profitLossAndTradeInfoToDB/pkg/profitLossGraph"
+	"profitLossAndTradeInfoToDB/pkg/report"
+)
+
+// dayLayout is the date format of the date, from and to query parameters
+const dayLayout = "2006-01-02"
+
+// Longest ranges the raw endpoints return in one response; orders and
+// minute-level P&L samples are read into memory, so larger ranges are paged
+// by the client
+const (
+	maxOrderDays      = 31
+	maxProfitLossDays = 7
+)
+
+// Server exposes read-only REST endpoints over the stored data:
+//
+//	GET /api/v1/orders?date=YYYY-MM-DD        orders of a day, or ?from=&to= for up to 31 days
+//	GET /api/v1/summaries?from=&to=           daily summaries
+//	GET /api/v1/summaries/symbols?from=&to=   daily summaries per symbol, &symbol= for one
+//	GET /api/v1/pl?from=&to=                  profit/loss samples of up to 7 days; dates or RFC3339 times
+//	GET /api/v1/pl/daily?from=&to=            last MTM sample of each day
+//	GET /api/v1/pl/equity?from=&to=           running total of the daily closes, with drawdown
+//	GET /api/v1/stats?from=&to=               trade statistics
+//	GET /api/v1/stats/intraday?from=&to=      orders and P&L by time of day, &bucket=15m (default 1h)
+//	GET /api/v1/stats/intraday/heatmap?from=&to=  the same per weekday, session aligned, &bucket=15m (default 30m)
+//	GET /api/v1/stats/performance?from=&to=   win rate, profit factor, expectancy per &period=day|week|month
+//	GET /api/v1/stats/weekday?from=&to=       the same per day of the week and expiry vs other days
+//	GET /api/v1/charts/equity?from=&to=       daily P&L, equity curve and broker MTM series
+//	GET /api/v1/calendar?month=YYYY-MM        net P&L of every day of a month (default this month)
+//	GET /api/v1/positions?date=YYYY-MM-DD     open positions at the end of a day
+//
+// from and to are inclusive days in market time. Summaries, stats and charts
+// carry an ETag tied to the account's data version and are cached, so polling
+// clients sending If-None-Match get 304 until new data arrives. When a token
+// is configured every request must send it as "Authorization: Bearer <token>".
+// UI serves a web dashboard built on these endpoints.
+type Server struct {
+	ob    *orderbook.OrderBook
+	pl    *profitLossGraph.Repository
+	token string
+	cache *payloadCache
+}
+
+// cacheCapacity is the number of computed payloads kept
+const cacheCapacity = 256
+
+// NewServer creates the REST API over an OrderBook and its profit/loss repository
+func NewServer(ob *orderbook.OrderBook, pl *profitLossGraph.Repository, token string) *Server {
+	return &Server{ob: ob, pl: pl, token: token, cache: newPayloadCache(cacheCapacity)}
+}
+
+// Handler returns the routes of the API
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v1/orders", s.orders)
+	mux.HandleFunc("GET /api/v1/summaries", s.summaries)
+	mux.HandleFunc("GET /api/v1/summaries/symbols", s.symbolSummaries)
+	mux.HandleFunc("GET /api/v1/pl", s.profitLoss)
+	mux.HandleFunc("GET /api/v1/pl/daily", s.dailyCloses)
+	mux.HandleFunc("GET /api/v1/pl/equity", s.equityCurve)
+	mux.HandleFunc("GET /api/v1/stats", s.stats)
+	mux.HandleFunc("GET /api/v1/stats/intraday", s.intraday)
+	mux.HandleFunc("GET /api/v1/stats/intraday/heatmap", s.intradayHeatmap)
+	mux.HandleFunc("GET /api/v1/stats/performance", s.performance)
+	mux.HandleFunc("GET /api/v1/stats/weekday", s.weekday)
+	mux.HandleFunc("GET /api/v1/charts/equity", s.equityChart)
+	mux.HandleFunc("GET /api/v1/calendar", s.calendar)
+	mux.HandleFunc("GET /api/v1/positions", s.positions)
+	return s.authenticate(mux)
+}
+
+// authenticate rejects requests without the bearer token, when one is configured
+func (s *Server) authenticate(next http.Handler) http.Handler {
+	return RequireToken(s.token, next)
+}
+
+// RequireToken rejects requests that do not send token as
+// "Authorization: Bearer <token>"; an empty token lets every request through
+func RequireToken(token string, next http.Handler) http.Handler {
+	if token == "" {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sent, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(sent), []byte(token)) != 1 {
+			writeError(w, http.StatusUnauthorized, "missing or invalid bearer token")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) orders(w http.ResponseWriter, r *http.Request) {
+	from, to, err := dayRange(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if from.AddDate(0, 0, maxOrderDays).Before(to) {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("orders are served for at most %d days at a time", maxOrderDays))
+		return
+	}
+
+	orders, err := s.ob.GetOrdersByDateRange(r.Context(), from, to)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if symbol := r.URL.Query().Get("symbol"); symbol != "" {
+		filtered := orders[:0]
+		for _, order := range orders {
+			if order.Symbol == symbol {
+				filtered = append(filtered, order)
+			}
+		}
+		orders = filtered
+	}
+	writeJSON(w, http.StatusOK, nonNil(orders))
+}
+
+func (s *Server) positions(w http.ResponseWriter, r *http.Request) {
+	date := r.URL.Query().Get("date")
+	if date == "" {
+		writeError(w, http.StatusBadRequest, "date is required")
+		return
+	}
+	day, err := time.ParseInLocation(dayLayout, date, market.Location())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid date: %v", err))
+		return
+	}
+
+	s.serveCached(w, r, func() (interface{}, error) {
+		return s.ob.Positions(r.Context(), day)
+	})
+}
+
+func (s *Server) symbolSummaries(w http.ResponseWriter, r *http.Request) {
+	from, to, err := dayRange(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	s.serveCached(w, r, func() (interface{}, error) {
+		summaries, err := s.ob.GetSymbolSummaries(r.Context(), from, to, r.URL.Query().Get("symbol"))
+		return nonNil(summaries), err
+	})
+}
+
+func (s *Server) intraday(w http.ResponseWriter, r *http.Request) {
+	from, to, err := dayRange(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	bucket := time.Hour
+	if value := r.URL.Query().Get("bucket"); value != "" {
+		if bucket, err = time.ParseDuration(value); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid bucket: %v", err))
+			return
+		}
+	}
+	if err := orderbook.CheckIntradayBucket(bucket); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	s.serveCached(w, r, func() (interface{}, error) {
+		buckets, err := s.ob.IntradaySummary(r.Context(), from, to, bucket)
+		return nonNil(buckets), err
+	})
+}
+
+func (s *Server) calendar(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	month := time.Now().In(market.Location())
+	if value := query.Get("month"); value != "" {
+		var err error
+		if month, err = time.ParseInLocation("2006-01", value, market.Location()); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid month: %v", err))
+			return
+		}
+	} else {
+		// Name the month in the query so the cache key changes with it
+		query.Set("month", month.Format("2006-01"))
+		r.URL.RawQuery = query.Encode()
+	}
+
+	s.serveCached(w, r, func() (interface{}, error) {
+		return s.ob.Calendar(r.Context(), month)
+	})
+}
+
+func (s *Server) intradayHeatmap(w http.ResponseWriter, r *http.Request) {
+	from, to, err := dayRange(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	bucket := 30 * time.Minute
+	if value := r.URL.Query().Get("bucket"); value != "" {
+		if bucket, err = time.ParseDuration(value); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid bucket: %v", err))
+			return
+		}
+	}
+	if err := orderbook.CheckIntradayBucket(bucket); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	s.serveCached(w, r, func() (interface{}, error) {
+		return s.ob.IntradayHeatmap(r.Context(), from, to, bucket)
+	})
+}
+
+func (s *Server) performance(w http.ResponseWriter, r *http.Request) {
+	from, to, err := dayRange(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	period, err := orderbook.ParseSummaryPeriod(r.URL.Query().Get("period"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	s.serveCached(w, r, func() (interface{}, error) {
+		stats, err := s.ob.GetPerformanceStats(r.Context(), period, from, to)
+		return nonNil(stats), err
+	})
+}
+
+func (s *Server) weekday(w http.ResponseWriter, r *http.Request) {
+	from, to, err := dayRange(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	s.serveCached(w, r, func() (interface{}, error) {
+		return s.ob.DayBreakdown(r.Context(), from, to)
+	})
+}
+
+func (s *Server) summaries(w http.ResponseWriter, r *http.Request) {
+	from, to, err := dayRange(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	s.serveCached(w, r, func() (interface{}, error) {
+		summaries, err := s.ob.GetDailySummaries(r.Context(), from, to)
+		return nonNil(summaries), err
+	})
+}
+
+func (s *Server) stats(w http.ResponseWriter, r *http.Request) {
+	from, to, err := dayRange(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	s.serveCached(w, r, func() (interface{}, error) {
+		data, err := report.Build(r.Context(), s.ob, s.pl, from, to)
+		if err != nil {
+			return nil, err
+		}
+		return data.Stats, nil
+	})
+}
+
+// EquityChart is the payload of the equity chart endpoint
+type EquityChart struct {
+	DailyPnL  []report.Point `json:"daily_pnl"`
+	Equity    []report.Point `json:"equity"`
+	BrokerMTM []report.Point `json:"broker_mtm"`
+}
+
+func (s *Server) equityChart(w http.ResponseWriter, r *http.Request) {
+	from, to, err := dayRange(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	s.serveCached(w, r, func() (interface{}, error) {
+		data, err := report.Build(r.Context(), s.ob, s.pl, from, to)
+		if err != nil {
+			return nil, err
+		}
+		return EquityChart{
+			DailyPnL:  nonNil(data.DailyPnL),
+			Equity:    nonNil(data.Equity),
+			BrokerMTM: nonNil(data.BrokerMTM),
+		}, nil
+	})
+}
+
+func (s *Server) equityCurve(w http.ResponseWriter, r *http.Request) {
+	from, to, err := dayRange(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	s.serveCached(w, r, func() (interface{}, error) {
+		points, err := s.pl.GetEquityCurve(r.Context(), from, to)
+		return nonNil(points), err
+	})
+}
+
+func (s *Server) profitLoss(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	from, err := parseInstant(query.Get("from"), false)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid from: %v", err))
+		return
+	}
+	to, err := parseInstant(query.Get("to"), true)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid to: %v", err))
+		return
+	}
+	if to.Before(from) {
+		writeError(w, http.StatusBadRequest, "to is before from")
+		return
+	}
+	if from.AddDate(0, 0, maxProfitLossDays).Before(to) {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("profit/loss samples are served for at most %d days at a time", maxProfitLossDays))
+		return
+	}
+
+	entries, err := s.pl.GetProfitLossByDateRange(r.Context(), from, to)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, nonNil(entries))
+}
+
+func (s *Server) dailyCloses(w http.ResponseWriter, r *http.Request) {
+	from, to, err := dayRange(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	closes, err := s.pl.GetDailyCloses(r.Context(), from, to)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, nonNil(closes))
+}
+
+// dayRange reads ?date= or ?from=&to= as the half-open range [from, to) of market days
+func dayRange(r *http.Request) (time.Time, time.Time, error) {
+	query := r.URL.Query()
+	first, last := query.Get("from"), query.Get("to")
+	if date := query.Get("date"); date != "" {
+		first, last = date, date
+	}
+	if first == "" || last == "" {
+		return time.Time{}, time.Time{}, fmt.Errorf("date, or from and to, are required (YYYY-MM-DD)")
+	}
+
+	from, err := time.ParseInLocation(dayLayout, first, market.Location())
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid from date: %v", err)
+	}
+	to, err := time.ParseInLocation(dayLayout, last, market.Location())
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid to date: %v", err)
+	}
+	if to.Before(from) {
+		return time.Time{}, time.Time{}, fmt.Errorf("to is before from")
+	}
+	return from, to.AddDate(0, 0, 1), nil
+}
+
+// parseInstant reads an RFC3339 time or a day; a day used as the end of a range covers all of it
+func parseInstant(value string, end bool) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, fmt.Errorf("required")
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	day, err := time.ParseInLocation(dayLayout, value, market.Location())
+	if err != nil {
+		return time.Time{}, fmt.Errorf("expected YYYY-MM-DD or RFC3339")
+	}
+	if end {
+		return day.AddDate(0, 0, 1).Add(-time.Nanosecond), nil
+	}
+	return day, nil
+}
+
+// nonNil makes empty results encode as [] rather than null
+func nonNil[T any](items []T) []T {
+	if items == nil {
+		return []T{}
+	}
+	return items
+}
@@ -0,0 +1,46 @@
+package api
+
+import (
+	_ "embed"
+	"html/template"
+	"net/http"
+	"time"
+
+	"profitLossAndTradeInfoToDB/pkg/market"
+)
+
+//go:embed ui.html.tmpl
+var uiTemplate string
+
+var uiPage = template.Must(template.New("ui").Parse(uiTemplate))
+
+// uiData is what the dashboard page is rendered with; everything else it
+// fetches from the REST API
+type uiData struct {
+	Today string
+	// Offset is the market's UTC offset in seconds, used to map the API's
+	// timestamps back to market days
+	Offset       int
+	AuthRequired bool
+}
+
+// UI returns the web dashboard: the equity curve, a calendar heatmap of daily
+// P&L and a drill-down into a day's orders. The page itself carries no data
+// and needs no token; it calls the REST API, sending the token the user
+// enters when one is configured. Mount it next to Handler, which serves /api/.
+func (s *Server) UI() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", func(w http.ResponseWriter, r *http.Request) {
+		now := time.Now().In(market.Location())
+		_, offset := now.Zone()
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if err := uiPage.Execute(w, uiData{
+			Today:        now.Format(dayLayout),
+			Offset:       offset,
+			AuthRequired: s.token != "",
+		}); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+	return mux
+}
@@ -0,0 +1,117 @@
+package api
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	orderbook "profitLossAndTradeInfoToDB/orderbooks"
+)
+
+// maxWebhookBody caps the size of a single postback
+const maxWebhookBody = 1 << 20
+
+// WebhookSecretHeader carries the shared secret on every postback
+const WebhookSecretHeader = "X-Webhook-Secret"
+
+// OrderPayload is the broker-agnostic JSON body accepted by POST /ingest/order:
+//
+//	{
+//	  "timestamp": "2024-01-10T09:20:49+05:30",  // RFC3339, required
+//	  "transaction_type": "B",                  // B or S, required
+//	  "symbol": "NIFTY11JAN24C22200",           // required
+//	  "product": "MIS",
+//	  "quantity": 50,                           // required, > 0
+//	  "average_price": 89.15,
+//	  "order_status": "COMPLETE",               // defaults to COMPLETE
+//	  "source": "my-algo"                       // defaults to "webhook"
+//	}
+//
+// The request must carry the shared secret in the X-Webhook-Secret header.
+type OrderPayload struct {
+	Timestamp       time.Time `json:"timestamp"`
+	TransactionType string    `json:"transaction_type"`
+	Symbol          string    `json:"symbol"`
+	Product         string    `json:"product"`
+	Quantity        float64   `json:"quantity"`
+	AveragePrice    float64   `json:"average_price"`
+	OrderStatus     string    `json:"order_status"`
+	Source          string    `json:"source"`
+	OrderID         string    `json:"order_id,omitempty"`
+	ExchangeOrderID string    `json:"exchange_order_id,omitempty"`
+}
+
+// OrderWebhook receives fills pushed by brokers or custom automation
+type OrderWebhook struct {
+	ob     *orderbook.OrderBook
+	secret string
+}
+
+// NewOrderWebhook creates the handler; an empty secret is rejected so the
+// endpoint is never exposed unauthenticated
+func NewOrderWebhook(ob *orderbook.OrderBook, secret string) (*OrderWebhook, error) {
+	if secret == "" {
+		return nil, fmt.Errorf("webhook secret is required")
+	}
+	return &OrderWebhook{ob: ob, secret: secret}, nil
+}
+
+func (h *OrderWebhook) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	if subtle.ConstantTimeCompare([]byte(r.Header.Get(WebhookSecretHeader)), []byte(h.secret)) != 1 {
+		writeError(w, http.StatusUnauthorized, "invalid webhook secret")
+		return
+	}
+
+	var payload OrderPayload
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxWebhookBody))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&payload); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid payload: %v", err))
+		return
+	}
+
+	order := orderbook.Order{
+		Timestamp:       payload.Timestamp,
+		TransactionType: payload.TransactionType,
+		Symbol:          payload.Symbol,
+		Product:         payload.Product,
+		Quantity:        payload.Quantity,
+		AveragePrice:    payload.AveragePrice,
+		OrderStatus:     payload.OrderStatus,
+		Source:          payload.Source,
+		OrderID:         payload.OrderID,
+		ExchangeOrderID: payload.ExchangeOrderID,
+	}
+	if order.OrderStatus == "" {
+		order.OrderStatus = "COMPLETE"
+	}
+	if order.Source == "" {
+		order.Source = "webhook"
+	}
+
+	stored, err := h.ob.AddOrder(r.Context(), order)
+	if err != nil {
+		writeError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, map[string]string{"id": stored.ID.Hex()})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
@@ -0,0 +1,167 @@
+package archive
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"profitLossAndTradeInfoToDB/constants"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const defaultBatchSize = 1000
+
+// State records how far an account's raw data has been archived
+type State struct {
+	Account        string    `bson:"account" json:"account"`
+	ArchivedBefore time.Time `bson:"archived_before" json:"archived_before"`
+	// PendingBefore is the cutoff of the latest run, recorded before it moves
+	// anything; ahead of ArchivedBefore while a run is in progress or was interrupted
+	PendingBefore time.Time `bson:"pending_before,omitempty" json:"pending_before,omitempty"`
+	UpdatedAt     time.Time `bson:"updated_at" json:"updated_at"`
+}
+
+// Bound returns the time before which data may be in the archive
+func (s *State) Bound() time.Time {
+	if s.PendingBefore.After(s.ArchivedBefore) {
+		return s.PendingBefore
+	}
+	return s.ArchivedBefore
+}
+
+// Archiver moves raw orders and MTM samples from the hot database to cold
+// storage; daily summaries and other rollups stay in the hot database
+type Archiver struct {
+	hot       *mongo.Database
+	cold      *mongo.Database
+	account   string
+	batchSize int
+}
+
+// NewArchiver creates an Archiver for one account
+func NewArchiver(hot, cold *mongo.Database, account string) (*Archiver, error) {
+	if hot == nil || cold == nil {
+		return nil, fmt.Errorf("hot and archive databases are required")
+	}
+	if hot.Client() == cold.Client() && hot.Name() == cold.Name() {
+		return nil, fmt.Errorf("archive database must differ from the hot database")
+	}
+
+	return &Archiver{
+		hot:       hot,
+		cold:      cold,
+		account:   account,
+		batchSize: defaultBatchSize,
+	}, nil
+}
+
+// archivedCollections are the raw collections moved by the archiver
+var archivedCollections = []string{
+	constants.ORDERBOOK_SCHEMA,
+	constants.PROFITLOSS_SCHEMA,
+}
+
+// MoveBefore archives every document older than before and returns the number moved per collection
+func (a *Archiver) MoveBefore(ctx context.Context, before time.Time) (map[string]int64, error) {
+	// Readers must look in the archive up to the cutoff as soon as the first batch moves
+	if err := a.updateState(ctx, "pending_before", before); err != nil {
+		return nil, err
+	}
+
+	moved := make(map[string]int64)
+	for _, name := range archivedCollections {
+		count, err := a.moveCollection(ctx, name, before)
+		moved[name] = count
+		if err != nil {
+			return moved, fmt.Errorf("failed to archive %s: %w", name, err)
+		}
+	}
+
+	if err := a.updateState(ctx, "archived_before", before); err != nil {
+		return moved, err
+	}
+
+	return moved, nil
+}
+
+// moveCollection copies documents in batches, then deletes them from the hot
+// collection. Copies are upserts by _id, so an interrupted run can be repeated.
+func (a *Archiver) moveCollection(ctx context.Context, name string, before time.Time) (int64, error) {
+	hot := a.hot.Collection(name)
+	cold := a.cold.Collection(name)
+	filter := bson.M{"account": a.account, "timestamp": bson.M{"$lt": before}}
+
+	var moved int64
+	for {
+		cursor, err := hot.Find(ctx, filter, options.Find().
+			SetSort(bson.D{{Key: "_id", Value: 1}}).
+			SetLimit(int64(a.batchSize)))
+		if err != nil {
+			return moved, fmt.Errorf("failed to read batch: %w", err)
+		}
+
+		var docs []bson.M
+		err = cursor.All(ctx, &docs)
+		cursor.Close(ctx)
+		if err != nil {
+			return moved, fmt.Errorf("failed to decode batch: %w", err)
+		}
+		if len(docs) == 0 {
+			return moved, nil
+		}
+
+		models := make([]mongo.WriteModel, len(docs))
+		ids := make([]interface{}, len(docs))
+		for i, doc := range docs {
+			ids[i] = doc["_id"]
+			models[i] = mongo.NewReplaceOneModel().
+				SetFilter(bson.M{"_id": doc["_id"]}).
+				SetReplacement(doc).
+				SetUpsert(true)
+		}
+
+		if _, err := cold.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false)); err != nil {
+			return moved, fmt.Errorf("failed to write batch to archive: %w", err)
+		}
+
+		result, err := hot.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": ids}})
+		if err != nil {
+			return moved, fmt.Errorf("failed to delete archived batch: %w", err)
+		}
+		moved += result.DeletedCount
+	}
+}
+
+// updateState advances one of the account's archive boundaries; they never move backwards
+func (a *Archiver) updateState(ctx context.Context, field string, before time.Time) error {
+	_, err := a.cold.Collection(constants.ARCHIVE_STATE_SCHEMA).UpdateOne(ctx,
+		bson.M{"account": a.account},
+		bson.M{
+			"$max": bson.M{field: before},
+			"$set": bson.M{"updated_at": time.Now()},
+		},
+		options.Update().SetUpsert(true),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record archive state: %w", err)
+	}
+
+	return nil
+}
+
+// GetState returns the account's archive boundary, or nil when nothing has been archived
+func GetState(ctx context.Context, cold *mongo.Database, account string) (*State, error) {
+	var state State
+	err := cold.Collection(constants.ARCHIVE_STATE_SCHEMA).FindOne(ctx, bson.M{"account": account}).Decode(&state)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read archive state: %w", err)
+	}
+
+	return &state, nil
+}
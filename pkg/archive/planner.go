@@ -0,0 +1,84 @@
+package archive
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// stateTTL is how long a Planner trusts the archive state it last read
+const stateTTL = time.Minute
+
+// Planner routes date-range queries to the storage tiers that can hold the
+// data. The hot collection is always queried, since late backfills of old days
+// land there; the archive only for the part of the range before its boundary.
+type Planner struct {
+	cold    *mongo.Database
+	account string
+
+	mu      sync.Mutex
+	bound   time.Time
+	fetched time.Time
+}
+
+// NewPlanner creates a planner for one account's archive
+func NewPlanner(cold *mongo.Database, account string) *Planner {
+	return &Planner{cold: cold, account: account}
+}
+
+// ArchiveFilter narrows a timestamp condition to the archived part of the range
+// starting at from. ok is false when the range lies entirely after the boundary,
+// so the archive need not be queried.
+func (p *Planner) ArchiveFilter(ctx context.Context, from time.Time, timestamp bson.M) (filter bson.M, ok bool, err error) {
+	bound, err := p.boundary(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+	if bound.IsZero() || !from.Before(bound) {
+		return nil, false, nil
+	}
+
+	filter = bson.M{}
+	for op, value := range timestamp {
+		filter[op] = value
+	}
+
+	// Tighten the upper bound to the archive boundary when it is earlier
+	for _, op := range []string{"$lt", "$lte"} {
+		if end, isTime := filter[op].(time.Time); isTime && !end.Before(bound) {
+			delete(filter, op)
+		}
+	}
+	_, hasLT := filter["$lt"]
+	_, hasLTE := filter["$lte"]
+	if !hasLT && !hasLTE {
+		filter["$lt"] = bound
+	}
+
+	return filter, true, nil
+}
+
+// boundary returns the cached archive bound, refreshing it after stateTTL
+func (p *Planner) boundary(ctx context.Context) (time.Time, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if !p.fetched.IsZero() && time.Since(p.fetched) < stateTTL {
+		return p.bound, nil
+	}
+
+	state, err := GetState(ctx, p.cold, p.account)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	p.bound = time.Time{}
+	if state != nil {
+		p.bound = state.Bound()
+	}
+	p.fetched = time.Now()
+	return p.bound, nil
+}
@@ -0,0 +1,100 @@
+package archive
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func day(d int) time.Time {
+	return time.Date(2024, 3, d, 0, 0, 0, 0, time.UTC)
+}
+
+// plannerAt is a planner that has just read bound as the archive boundary
+func plannerAt(bound time.Time) *Planner {
+	return &Planner{account: "acct", bound: bound, fetched: time.Now()}
+}
+
+func TestArchiveFilter(t *testing.T) {
+	tests := []struct {
+		name      string
+		bound     time.Time
+		from      time.Time
+		timestamp bson.M
+		want      bson.M // nil when the archive need not be queried
+	}{
+		{
+			name:      "nothing archived",
+			from:      day(1),
+			timestamp: bson.M{"$gte": day(1), "$lt": day(5)},
+		},
+		{
+			name:      "range after the boundary",
+			bound:     day(10),
+			from:      day(10),
+			timestamp: bson.M{"$gte": day(10), "$lt": day(15)},
+		},
+		{
+			name:      "range before the boundary",
+			bound:     day(10),
+			from:      day(1),
+			timestamp: bson.M{"$gte": day(1), "$lt": day(5)},
+			want:      bson.M{"$gte": day(1), "$lt": day(5)},
+		},
+		{
+			name:      "range across the boundary",
+			bound:     day(10),
+			from:      day(5),
+			timestamp: bson.M{"$gte": day(5), "$lt": day(15)},
+			want:      bson.M{"$gte": day(5), "$lt": day(10)},
+		},
+		{
+			name:      "inclusive end past the boundary",
+			bound:     day(10),
+			from:      day(5),
+			timestamp: bson.M{"$gte": day(5), "$lte": day(10)},
+			want:      bson.M{"$gte": day(5), "$lt": day(10)},
+		},
+		{
+			name:      "open ended",
+			bound:     day(10),
+			from:      day(5),
+			timestamp: bson.M{"$gte": day(5)},
+			want:      bson.M{"$gte": day(5), "$lt": day(10)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter, ok, err := plannerAt(tt.bound).ArchiveFilter(context.Background(), tt.from, tt.timestamp)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ok != (tt.want != nil) {
+				t.Fatalf("archive queried = %v, want %v", ok, tt.want != nil)
+			}
+			if ok && !reflect.DeepEqual(filter, tt.want) {
+				t.Errorf("filter = %v, want %v", filter, tt.want)
+			}
+		})
+	}
+}
+
+func TestStateBound(t *testing.T) {
+	// An interrupted run may have moved data up to its pending cutoff
+	tests := []struct {
+		state State
+		want  time.Time
+	}{
+		{state: State{ArchivedBefore: day(10)}, want: day(10)},
+		{state: State{ArchivedBefore: day(10), PendingBefore: day(20)}, want: day(20)},
+		{state: State{ArchivedBefore: day(10), PendingBefore: day(5)}, want: day(10)},
+	}
+	for _, tt := range tests {
+		if got := tt.state.Bound(); !got.Equal(tt.want) {
+			t.Errorf("%+v.Bound() = %v, want %v", tt.state, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package behavior
+
+import (
+	"time"
+
+	"profitLossAndTradeInfoToDB/pkg/money"
+	"profitLossAndTradeInfoToDB/pkg/profitLossGraph"
+	"profitLossAndTradeInfoToDB/pkg/trades"
+)
+
+// RevengeConfig defines what counts as a sharp MTM drop and a reactive entry
+type RevengeConfig struct {
+	DropThreshold  float64       // minimum fall from the recent peak, in rupees
+	DropWindow     time.Duration // how far back the recent peak is searched
+	ReactionWindow time.Duration // entries this soon after a drop are flagged
+}
+
+// Drop is a sharp fall of the MTM curve
+type Drop struct {
+	Time   time.Time `json:"time"`
+	Peak   float64   `json:"peak"`
+	Trough float64   `json:"trough"`
+	Amount float64   `json:"amount"`
+}
+
+// RevengeReport quantifies trades entered right after MTM drops
+type RevengeReport struct {
+	Drops      []Drop             `json:"drops"`
+	Flagged    []trades.RoundTrip `json:"flagged"`
+	FlaggedPnL money.Paise        `json:"flagged_pnl"`
+	OtherPnL   money.Paise        `json:"other_pnl"`
+	OtherCount int                `json:"other_count"`
+}
+
+// FindDrops returns the moments the MTM fell at least DropThreshold below its
+// peak of the preceding DropWindow. Samples must be in time order; a drop is
+// only reported again once the curve has recovered above the threshold.
+func FindDrops(samples []profitLossGraph.ProfitLossEntry, cfg RevengeConfig) []Drop {
+	var drops []Drop
+	inDrop := false
+	start := 0
+
+	for i, sample := range samples {
+		for start < i && sample.Timestamp.Sub(samples[start].Timestamp) > cfg.DropWindow {
+			start++
+		}
+
+		peak := sample.Value
+		for _, prior := range samples[start:i] {
+			if prior.Value > peak {
+				peak = prior.Value
+			}
+		}
+
+		fall := peak - sample.Value
+		if fall >= cfg.DropThreshold {
+			if !inDrop {
+				drops = append(drops, Drop{Time: sample.Timestamp, Peak: peak, Trough: sample.Value, Amount: fall})
+				inDrop = true
+			}
+		} else {
+			inDrop = false
+		}
+	}
+
+	return drops
+}
+
+// DetectRevenge flags round trips entered within ReactionWindow after a drop
+// and totals their P&L against the remaining trips
+func DetectRevenge(samples []profitLossGraph.ProfitLossEntry, roundTrips []trades.RoundTrip, cfg RevengeConfig) RevengeReport {
+	report := RevengeReport{Drops: FindDrops(samples, cfg)}
+
+	for _, trip := range roundTrips {
+		if enteredAfterDrop(trip.EntryTime, report.Drops, cfg.ReactionWindow) {
+			report.Flagged = append(report.Flagged, trip)
+			report.FlaggedPnL += trip.RealizedPnL
+			continue
+		}
+		report.OtherPnL += trip.RealizedPnL
+		report.OtherCount++
+	}
+
+	return report
+}
+
+// Merge adds another day's report to r
+func (r *RevengeReport) Merge(other RevengeReport) {
+	r.Drops = append(r.Drops, other.Drops...)
+	r.Flagged = append(r.Flagged, other.Flagged...)
+	r.FlaggedPnL += other.FlaggedPnL
+	r.OtherPnL += other.OtherPnL
+	r.OtherCount += other.OtherCount
+}
+
+func enteredAfterDrop(entry time.Time, drops []Drop, window time.Duration) bool {
+	for _, drop := range drops {
+		if entry.After(drop.Time) && entry.Sub(drop.Time) <= window {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,231 @@
+package broker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	orderbook "profitLossAndTradeInfoToDB/orderbooks"
+	"profitLossAndTradeInfoToDB/pkg/market"
+	"profitLossAndTradeInfoToDB/pkg/money"
+)
+
+// AngelBaseURL is the Angel One SmartAPI endpoint
+const AngelBaseURL = "https://apiconnect.angelone.in"
+
+// Angel reads the orderbook and positions of an Angel One account through
+// SmartAPI. It logs in with the client code, PIN and a TOTP generated from
+// the account's secret on first use. The API only has the current day's orders.
+type Angel struct {
+	BaseURL    string
+	APIKey     string
+	ClientCode string
+	PIN        string
+	TOTPSecret string
+	Client     *http.Client
+
+	mu  sync.Mutex
+	jwt string // Session token of the login
+}
+
+func init() {
+	Register("angel", Definition{
+		Keys: []string{"api_key", "client_code", "pin", "totp_secret"},
+		New: func(creds Credentials) (Broker, error) {
+			return &Angel{
+				BaseURL:    AngelBaseURL,
+				APIKey:     creds["api_key"],
+				ClientCode: creds["client_code"],
+				PIN:        creds["pin"],
+				TOTPSecret: creds["totp_secret"],
+				Client:     &http.Client{Timeout: 30 * time.Second},
+			}, nil
+		},
+	})
+}
+
+// angelResponse wraps every SmartAPI response
+type angelResponse struct {
+	Status    bool   `json:"status"`
+	Message   string `json:"message"`
+	ErrorCode string `json:"errorcode"`
+}
+
+func (r angelResponse) err() error {
+	if !r.Status {
+		return fmt.Errorf("angel: %s (%s)", r.Message, r.ErrorCode)
+	}
+	return nil
+}
+
+// headers are the ones SmartAPI requires of every request, with the session token once logged in
+func (a *Angel) headers(jwt string) http.Header {
+	headers := http.Header{}
+	headers.Set("X-PrivateKey", a.APIKey)
+	headers.Set("X-UserType", "USER")
+	headers.Set("X-SourceID", "WEB")
+	headers.Set("X-ClientLocalIP", "127.0.0.1")
+	headers.Set("X-ClientPublicIP", "127.0.0.1")
+	headers.Set("X-MACAddress", "00:00:00:00:00:00")
+	if jwt != "" {
+		headers.Set("Authorization", "Bearer "+jwt)
+	}
+	return headers
+}
+
+// session logs in once and returns the session token
+func (a *Angel) session(ctx context.Context) (string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.jwt != "" {
+		return a.jwt, nil
+	}
+
+	code, err := totp(a.TOTPSecret, time.Now())
+	if err != nil {
+		return "", err
+	}
+	var resp struct {
+		angelResponse
+		Data struct {
+			JWTToken string `json:"jwtToken"`
+		} `json:"data"`
+	}
+	login := map[string]string{"clientcode": a.ClientCode, "password": a.PIN, "totp": code}
+	url := a.BaseURL + "/rest/auth/angelbroking/user/v1/loginByPassword"
+	if err := doJSON(ctx, a.Client, http.MethodPost, url, a.headers(""), login, &resp); err != nil {
+		return "", fmt.Errorf("angel login: %w", err)
+	}
+	if err := resp.err(); err != nil {
+		return "", err
+	}
+	if resp.Data.JWTToken == "" {
+		return "", fmt.Errorf("angel login returned no session token")
+	}
+	a.jwt = resp.Data.JWTToken
+	return a.jwt, nil
+}
+
+// get fetches a SmartAPI resource after logging in
+func (a *Angel) get(ctx context.Context, path string, out interface{}) error {
+	jwt, err := a.session(ctx)
+	if err != nil {
+		return err
+	}
+	return getJSON(ctx, a.Client, a.BaseURL+path, a.headers(jwt), out)
+}
+
+// angelNumber decodes SmartAPI numbers, which are sent as strings or numbers
+type angelNumber float64
+
+func (n *angelNumber) UnmarshalJSON(data []byte) error {
+	value := strings.Trim(string(data), `"`)
+	if value == "" || value == "null" {
+		*n = 0
+		return nil
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return fmt.Errorf("invalid number %s", data)
+	}
+	*n = angelNumber(f)
+	return nil
+}
+
+type angelOrder struct {
+	OrderID         string      `json:"orderid"`
+	TradingSymbol   string      `json:"tradingsymbol"`
+	TransactionType string      `json:"transactiontype"`
+	ProductType     string      `json:"producttype"`
+	FilledShares    angelNumber `json:"filledshares"`
+	AveragePrice    angelNumber `json:"averageprice"`
+	OrderStatus     string      `json:"orderstatus"`
+	UpdateTime      string      `json:"updatetime"`
+	ExchangeTime    string      `json:"exchorderupdatetime"`
+}
+
+// SmartAPI times are in market time, e.g. 25-Jan-2024 10:00:00
+const angelTimeLayout = "02-Jan-2006 15:04:05"
+
+// Orders returns today's filled orders; day must be today
+func (a *Angel) Orders(ctx context.Context, day time.Time) ([]orderbook.Order, error) {
+	if err := checkToday("angel", day); err != nil {
+		return nil, err
+	}
+
+	var resp struct {
+		angelResponse
+		Data []angelOrder `json:"data"`
+	}
+	if err := a.get(ctx, "/rest/secure/angelbroking/order/v1/getOrderBook", &resp); err != nil {
+		return nil, fmt.Errorf("angel orders: %w", err)
+	}
+	if err := resp.err(); err != nil {
+		return nil, err
+	}
+
+	var orders []orderbook.Order
+	for _, o := range resp.Data {
+		if o.FilledShares <= 0 {
+			continue
+		}
+		timestamp, err := time.ParseInLocation(angelTimeLayout, o.UpdateTime, market.Location())
+		if err != nil {
+			return nil, fmt.Errorf("angel order %s: invalid updatetime %q", o.OrderID, o.UpdateTime)
+		}
+
+		order := orderbook.Order{
+			Timestamp:       timestamp,
+			TransactionType: side(o.TransactionType),
+			Symbol:          o.TradingSymbol,
+			Product:         o.ProductType,
+			Quantity:        float64(o.FilledShares),
+			AveragePrice:    float64(o.AveragePrice),
+			OrderStatus:     strings.ToUpper(o.OrderStatus),
+			OrderID:         o.OrderID,
+		}
+		if executed, err := time.ParseInLocation(angelTimeLayout, o.ExchangeTime, market.Location()); err == nil {
+			order.ExecutionTime = &executed
+		}
+		orders = append(orders, order)
+	}
+	return orders, nil
+}
+
+// ProfitLoss sums the P&L of today's positions; day must be today
+func (a *Angel) ProfitLoss(ctx context.Context, day time.Time) (float64, error) {
+	if err := checkToday("angel", day); err != nil {
+		return 0, err
+	}
+
+	var resp struct {
+		angelResponse
+		Data json.RawMessage `json:"data"` // null without positions
+	}
+	if err := a.get(ctx, "/rest/secure/angelbroking/order/v1/getPosition", &resp); err != nil {
+		return 0, fmt.Errorf("angel positions: %w", err)
+	}
+	if err := resp.err(); err != nil {
+		return 0, err
+	}
+
+	var positions []struct {
+		PnL angelNumber `json:"pnl"`
+	}
+	if len(resp.Data) > 0 && string(resp.Data) != "null" {
+		if err := json.Unmarshal(resp.Data, &positions); err != nil {
+			return 0, fmt.Errorf("failed to decode angel positions: %w", err)
+		}
+	}
+
+	var total money.Paise
+	for _, position := range positions {
+		total += money.FromRupees(float64(position.PnL))
+	}
+	return total.Rupees(), nil
+}
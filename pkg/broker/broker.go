@@ -0,0 +1,172 @@
+// Package broker fetches orders and P&L straight from broker APIs, so a day
+// can be imported without exporting CSV files first. Each broker registers a
+// Definition naming the credentials it needs.
+package broker
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	orderbook "profitLossAndTradeInfoToDB/orderbooks"
+)
+
+// Broker is one account at a broker
+type Broker interface {
+	// Orders returns the executed orders of the market day containing day
+	Orders(ctx context.Context, day time.Time) ([]orderbook.Order, error)
+	// ProfitLoss returns the account's mark-to-market P&L for the day
+	ProfitLoss(ctx context.Context, day time.Time) (float64, error)
+}
+
+// ErrNoProfitLoss is returned by ProfitLoss for days the broker has no P&L of
+var ErrNoProfitLoss = errors.New("broker has no P&L for the day")
+
+// Funds are an account's fund limits at the broker
+type Funds struct {
+	Opening   float64 // Limit at the start of the day
+	Available float64
+	Utilized  float64
+}
+
+// FundsReporter is implemented by brokers reporting the account's funds
+type FundsReporter interface {
+	Funds(ctx context.Context) (Funds, error)
+}
+
+// Credentials holds a broker's settings by key, e.g. api_key
+type Credentials map[string]string
+
+// Definition describes a broker: the credential keys it requires and how to
+// build a client from them
+type Definition struct {
+	Keys []string
+	New  func(creds Credentials) (Broker, error)
+}
+
+var (
+	definitionsMu sync.RWMutex
+	definitions   = map[string]Definition{}
+)
+
+// Register makes a broker available by name
+func Register(name string, definition Definition) {
+	definitionsMu.Lock()
+	defer definitionsMu.Unlock()
+	definitions[name] = definition
+}
+
+// Names returns the registered brokers, sorted
+func Names() []string {
+	definitionsMu.RLock()
+	defer definitionsMu.RUnlock()
+
+	names := make([]string, 0, len(definitions))
+	for name := range definitions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// New builds the named broker, checking every required credential is set
+func New(name string, creds Credentials) (Broker, error) {
+	definitionsMu.RLock()
+	definition, ok := definitions[name]
+	definitionsMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown broker %q, expected one of %s", name, strings.Join(Names(), ", "))
+	}
+
+	for _, key := range definition.Keys {
+		if creds[key] == "" {
+			return nil, fmt.Errorf("%s broker needs %s (env %s)", name, key, EnvName(name, key))
+		}
+	}
+	return definition.New(creds)
+}
+
+// EnvName is the environment variable holding a broker credential, e.g. KITE_API_KEY
+func EnvName(name, key string) string {
+	return strings.ToUpper(name + "_" + key)
+}
+
+// CredentialsFromEnv reads the named broker's credentials from the environment
+func CredentialsFromEnv(name string) Credentials {
+	definitionsMu.RLock()
+	definition := definitions[name]
+	definitionsMu.RUnlock()
+
+	creds := Credentials{}
+	for _, key := range definition.Keys {
+		if value := os.Getenv(EnvName(name, key)); value != "" {
+			creds[key] = value
+		}
+	}
+	return creds
+}
+
+// getJSON sends an authenticated GET request and decodes the JSON response into out
+func getJSON(ctx context.Context, client *http.Client, url string, headers http.Header, out interface{}) error {
+	return doJSON(ctx, client, http.MethodGet, url, headers, nil, out)
+}
+
+// doJSON sends a request with body, when not nil, encoded as JSON and
+// decodes the JSON response into out
+func doJSON(ctx context.Context, client *http.Client, method, url string, headers http.Header, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, reader)
+	if err != nil {
+		return fmt.Errorf("invalid request: %w", err)
+	}
+	for key, values := range headers {
+		req.Header[key] = values
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s returned %s: %s", req.URL.Path, resp.Status, strings.TrimSpace(string(body)))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode %s response: %w", req.URL.Path, err)
+	}
+	return nil
+}
+
+// side maps a broker's BUY/SELL to the orderbook's B/S
+func side(transactionType string) string {
+	switch strings.ToUpper(transactionType) {
+	case "BUY", "B":
+		return "B"
+	case "SELL", "S":
+		return "S"
+	}
+	return transactionType
+}
@@ -0,0 +1,60 @@
+package broker
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// Configured is the -source value selecting the broker an account is configured with
+const Configured = "broker"
+
+// AccountConfig is the broker an account's orders are fetched from
+type AccountConfig struct {
+	Broker      string      `json:"broker"`
+	Credentials Credentials `json:"credentials"`
+}
+
+// LoadConfig reads a broker config file, a JSON object mapping each account
+// to its broker and credentials:
+//
+//	{"main": {"broker": "fyers", "credentials": {"app_id": "...", "access_token": "..."}}}
+func LoadConfig(path string) (map[string]AccountConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read broker config: %w", err)
+	}
+
+	var accounts map[string]AccountConfig
+	if err := json.Unmarshal(data, &accounts); err != nil {
+		return nil, fmt.Errorf("failed to decode broker config %s: %w", path, err)
+	}
+	return accounts, nil
+}
+
+// ForAccount builds the broker of an account: the named one, or with
+// Configured the one the account's config entry names. Credentials come from
+// the entry when it is for the same broker, environment variables taking
+// precedence. It returns the broker's name with it.
+func ForAccount(name, account string, accounts map[string]AccountConfig) (Broker, string, error) {
+	entry, ok := accounts[account]
+	if name == Configured {
+		if !ok || entry.Broker == "" {
+			return nil, "", fmt.Errorf("no broker configured for account %s", account)
+		}
+		name = entry.Broker
+	}
+
+	creds := Credentials{}
+	if ok && entry.Broker == name {
+		for key, value := range entry.Credentials {
+			creds[key] = value
+		}
+	}
+	for key, value := range CredentialsFromEnv(name) {
+		creds[key] = value
+	}
+
+	b, err := New(name, creds)
+	return b, name, err
+}
@@ -0,0 +1,228 @@
+package broker
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	orderbook "profitLossAndTradeInfoToDB/orderbooks"
+	"profitLossAndTradeInfoToDB/pkg/market"
+	"profitLossAndTradeInfoToDB/pkg/money"
+)
+
+// DhanBaseURL is the Dhan API v2 endpoint
+const DhanBaseURL = "https://api.dhan.co/v2"
+
+// Dhan reads orders, trades, positions and funds of a Dhan account. Today's
+// orders come from the orderbook; past days are rebuilt from the trade history.
+type Dhan struct {
+	BaseURL     string
+	ClientID    string
+	AccessToken string
+	Client      *http.Client
+}
+
+func init() {
+	Register("dhan", Definition{
+		Keys: []string{"client_id", "access_token"},
+		New: func(creds Credentials) (Broker, error) {
+			return &Dhan{
+				BaseURL:     DhanBaseURL,
+				ClientID:    creds["client_id"],
+				AccessToken: creds["access_token"],
+				Client:      &http.Client{Timeout: 30 * time.Second},
+			}, nil
+		},
+	})
+}
+
+func (d *Dhan) headers() http.Header {
+	return http.Header{
+		"Access-Token": []string{d.AccessToken},
+		"Client-Id":    []string{d.ClientID},
+	}
+}
+
+type dhanOrder struct {
+	OrderID            string  `json:"orderId"`
+	ExchangeOrderID    string  `json:"exchangeOrderId"`
+	OrderStatus        string  `json:"orderStatus"`
+	TransactionType    string  `json:"transactionType"`
+	ProductType        string  `json:"productType"`
+	TradingSymbol      string  `json:"tradingSymbol"`
+	FilledQty          float64 `json:"filledQty"`
+	AverageTradedPrice float64 `json:"averageTradedPrice"`
+	CreateTime         string  `json:"createTime"`
+}
+
+type dhanTrade struct {
+	OrderID         string  `json:"orderId"`
+	ExchangeOrderID string  `json:"exchangeOrderId"`
+	TransactionType string  `json:"transactionType"`
+	ProductType     string  `json:"productType"`
+	TradingSymbol   string  `json:"tradingSymbol"`
+	TradedQuantity  float64 `json:"tradedQuantity"`
+	TradedPrice     float64 `json:"tradedPrice"`
+	CreateTime      string  `json:"createTime"`
+	ExchangeTime    string  `json:"exchangeTime"`
+}
+
+// Dhan times are in market time without an offset
+const dhanTimeLayout = "2006-01-02 15:04:05"
+
+func dhanTime(value string) (time.Time, error) {
+	return time.ParseInLocation(dhanTimeLayout, value, market.Location())
+}
+
+// Orders returns the day's filled orders: today's from the orderbook with
+// their last exchange fill time, earlier days' rebuilt from the trade history
+func (d *Dhan) Orders(ctx context.Context, day time.Time) ([]orderbook.Order, error) {
+	if checkToday("dhan", day) != nil {
+		return d.historicalOrders(ctx, day)
+	}
+
+	var list []dhanOrder
+	if err := getJSON(ctx, d.Client, d.BaseURL+"/orders", d.headers(), &list); err != nil {
+		return nil, fmt.Errorf("dhan orders: %w", err)
+	}
+	var trades []dhanTrade
+	if err := getJSON(ctx, d.Client, d.BaseURL+"/trades", d.headers(), &trades); err != nil {
+		return nil, fmt.Errorf("dhan trades: %w", err)
+	}
+	lastFill := map[string]time.Time{}
+	for _, trade := range trades {
+		if executed, err := dhanTime(trade.ExchangeTime); err == nil && executed.After(lastFill[trade.OrderID]) {
+			lastFill[trade.OrderID] = executed
+		}
+	}
+
+	var orders []orderbook.Order
+	for _, o := range list {
+		if o.FilledQty <= 0 {
+			continue
+		}
+		timestamp, err := dhanTime(o.CreateTime)
+		if err != nil {
+			return nil, fmt.Errorf("dhan order %s: invalid createTime %q", o.OrderID, o.CreateTime)
+		}
+		order := orderbook.Order{
+			Timestamp:       timestamp,
+			TransactionType: side(o.TransactionType),
+			Symbol:          o.TradingSymbol,
+			Product:         o.ProductType,
+			Quantity:        o.FilledQty,
+			AveragePrice:    o.AverageTradedPrice,
+			OrderStatus:     o.OrderStatus,
+			OrderID:         o.OrderID,
+			ExchangeOrderID: o.ExchangeOrderID,
+		}
+		if executed, ok := lastFill[o.OrderID]; ok {
+			order.ExecutionTime = &executed
+		}
+		orders = append(orders, order)
+	}
+	return orders, nil
+}
+
+// historicalOrders pages through the day's trade history and merges the
+// trades of each order into one order at their average price
+func (d *Dhan) historicalOrders(ctx context.Context, day time.Time) ([]orderbook.Order, error) {
+	date := day.In(market.Location()).Format("2006-01-02")
+
+	var trades []dhanTrade
+	for page := 0; ; page++ {
+		var batch []dhanTrade
+		url := fmt.Sprintf("%s/trades/%s/%s/%d", d.BaseURL, date, date, page)
+		if err := getJSON(ctx, d.Client, url, d.headers(), &batch); err != nil {
+			return nil, fmt.Errorf("dhan trade history: %w", err)
+		}
+		if len(batch) == 0 {
+			break
+		}
+		trades = append(trades, batch...)
+	}
+
+	type merged struct {
+		order orderbook.Order
+		value money.Paise
+	}
+	byOrder := map[string]*merged{}
+	for _, trade := range trades {
+		created, err := dhanTime(trade.CreateTime)
+		if err != nil {
+			return nil, fmt.Errorf("dhan trade of order %s: invalid createTime %q", trade.OrderID, trade.CreateTime)
+		}
+
+		m, ok := byOrder[trade.OrderID]
+		if !ok {
+			m = &merged{order: orderbook.Order{
+				Timestamp:       created,
+				TransactionType: side(trade.TransactionType),
+				Symbol:          trade.TradingSymbol,
+				Product:         trade.ProductType,
+				OrderStatus:     "TRADED",
+				OrderID:         trade.OrderID,
+				ExchangeOrderID: trade.ExchangeOrderID,
+			}}
+			byOrder[trade.OrderID] = m
+		}
+		if created.Before(m.order.Timestamp) {
+			m.order.Timestamp = created
+		}
+		m.order.Quantity += trade.TradedQuantity
+		m.value += money.Value(trade.TradedPrice, trade.TradedQuantity)
+		if executed, err := dhanTime(trade.ExchangeTime); err == nil &&
+			(m.order.ExecutionTime == nil || executed.After(*m.order.ExecutionTime)) {
+			m.order.ExecutionTime = &executed
+		}
+	}
+
+	orders := make([]orderbook.Order, 0, len(byOrder))
+	for _, m := range byOrder {
+		if m.order.Quantity > 0 {
+			m.order.AveragePrice = m.value.Rupees() / m.order.Quantity
+		}
+		orders = append(orders, m.order)
+	}
+	sort.Slice(orders, func(i, j int) bool {
+		return orders[i].Timestamp.Before(orders[j].Timestamp)
+	})
+	return orders, nil
+}
+
+// ProfitLoss sums the realized and unrealized P&L of today's positions; Dhan
+// keeps no P&L history, so other days return ErrNoProfitLoss
+func (d *Dhan) ProfitLoss(ctx context.Context, day time.Time) (float64, error) {
+	if checkToday("dhan", day) != nil {
+		return 0, ErrNoProfitLoss
+	}
+
+	var positions []struct {
+		RealizedProfit   float64 `json:"realizedProfit"`
+		UnrealizedProfit float64 `json:"unrealizedProfit"`
+	}
+	if err := getJSON(ctx, d.Client, d.BaseURL+"/positions", d.headers(), &positions); err != nil {
+		return 0, fmt.Errorf("dhan positions: %w", err)
+	}
+
+	var total money.Paise
+	for _, position := range positions {
+		total += money.FromRupees(position.RealizedProfit) + money.FromRupees(position.UnrealizedProfit)
+	}
+	return total.Rupees(), nil
+}
+
+// Funds returns the account's current fund limits
+func (d *Dhan) Funds(ctx context.Context) (Funds, error) {
+	var resp struct {
+		Available float64 `json:"availabelBalance"` // sic
+		Opening   float64 `json:"sodLimit"`
+		Utilized  float64 `json:"utilizedAmount"`
+	}
+	if err := getJSON(ctx, d.Client, d.BaseURL+"/fundlimit", d.headers(), &resp); err != nil {
+		return Funds{}, fmt.Errorf("dhan funds: %w", err)
+	}
+	return Funds{Opening: resp.Opening, Available: resp.Available, Utilized: resp.Utilized}, nil
+}
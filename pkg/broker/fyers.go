@@ -0,0 +1,144 @@
+package broker
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	orderbook "profitLossAndTradeInfoToDB/orderbooks"
+	"profitLossAndTradeInfoToDB/pkg/market"
+)
+
+// FyersBaseURL is the Fyers API v3 endpoint
+const FyersBaseURL = "https://api-t1.fyers.in/api/v3"
+
+// Fyers reads the orderbook and net P&L of a Fyers account. Like Kite, the
+// API only has the current day's orders.
+type Fyers struct {
+	BaseURL     string
+	AppID       string
+	AccessToken string
+	Client      *http.Client
+}
+
+func init() {
+	Register("fyers", Definition{
+		Keys: []string{"app_id", "access_token"},
+		New: func(creds Credentials) (Broker, error) {
+			return &Fyers{
+				BaseURL:     FyersBaseURL,
+				AppID:       creds["app_id"],
+				AccessToken: creds["access_token"],
+				Client:      &http.Client{Timeout: 30 * time.Second},
+			}, nil
+		},
+	})
+}
+
+func (f *Fyers) headers() http.Header {
+	return http.Header{"Authorization": []string{f.AppID + ":" + f.AccessToken}}
+}
+
+// fyersResponse carries the status every Fyers response starts with
+type fyersResponse struct {
+	S       string `json:"s"`
+	Message string `json:"message"`
+}
+
+func (r fyersResponse) err() error {
+	if r.S != "ok" {
+		return fmt.Errorf("fyers: %s", r.Message)
+	}
+	return nil
+}
+
+type fyersOrder struct {
+	ID            string  `json:"id"`
+	ExchangeID    string  `json:"exchOrdId"`
+	Symbol        string  `json:"symbol"` // Exchange prefixed, e.g. NSE:NIFTY24JAN21000CE
+	Side          int     `json:"side"`   // 1 buy, -1 sell
+	ProductType   string  `json:"productType"`
+	FilledQty     float64 `json:"filledQty"`
+	TradedPrice   float64 `json:"tradedPrice"`
+	Status        int     `json:"status"`
+	OrderDateTime string  `json:"orderDateTime"`
+}
+
+// Fyers order times are in market time, e.g. 25-Jan-2024 10:00:00
+const fyersTimeLayout = "02-Jan-2006 15:04:05"
+
+// fyersStatuses names the order statuses stored on orders
+var fyersStatuses = map[int]string{1: "CANCELLED", 2: "COMPLETE", 4: "TRANSIT", 5: "REJECTED", 6: "OPEN"}
+
+// Orders returns today's filled orders; day must be today
+func (f *Fyers) Orders(ctx context.Context, day time.Time) ([]orderbook.Order, error) {
+	if err := checkToday("fyers", day); err != nil {
+		return nil, err
+	}
+
+	var resp struct {
+		fyersResponse
+		OrderBook []fyersOrder `json:"orderBook"`
+	}
+	if err := getJSON(ctx, f.Client, f.BaseURL+"/orders", f.headers(), &resp); err != nil {
+		return nil, fmt.Errorf("fyers orders: %w", err)
+	}
+	if err := resp.err(); err != nil {
+		return nil, err
+	}
+
+	var orders []orderbook.Order
+	for _, o := range resp.OrderBook {
+		if o.FilledQty <= 0 {
+			continue
+		}
+		timestamp, err := time.ParseInLocation(fyersTimeLayout, o.OrderDateTime, market.Location())
+		if err != nil {
+			return nil, fmt.Errorf("fyers order %s: invalid orderDateTime %q", o.ID, o.OrderDateTime)
+		}
+		transactionType := "B"
+		if o.Side < 0 {
+			transactionType = "S"
+		}
+		_, symbol, _ := strings.Cut(o.Symbol, ":")
+		if symbol == "" {
+			symbol = o.Symbol
+		}
+
+		orders = append(orders, orderbook.Order{
+			Timestamp:       timestamp,
+			TransactionType: transactionType,
+			Symbol:          symbol,
+			Product:         o.ProductType,
+			Quantity:        o.FilledQty,
+			AveragePrice:    o.TradedPrice,
+			OrderStatus:     fyersStatuses[o.Status],
+			OrderID:         o.ID,
+			ExchangeOrderID: o.ExchangeID,
+		})
+	}
+	return orders, nil
+}
+
+// ProfitLoss returns the total P&L of today's positions; day must be today
+func (f *Fyers) ProfitLoss(ctx context.Context, day time.Time) (float64, error) {
+	if err := checkToday("fyers", day); err != nil {
+		return 0, err
+	}
+
+	var resp struct {
+		fyersResponse
+		Overall struct {
+			Total float64 `json:"pl_total"`
+		} `json:"overall"`
+	}
+	if err := getJSON(ctx, f.Client, f.BaseURL+"/positions", f.headers(), &resp); err != nil {
+		return 0, fmt.Errorf("fyers positions: %w", err)
+	}
+	if err := resp.err(); err != nil {
+		return 0, err
+	}
+	return resp.Overall.Total, nil
+}
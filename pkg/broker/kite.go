@@ -0,0 +1,135 @@
+package broker
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	orderbook "profitLossAndTradeInfoToDB/orderbooks"
+	"profitLossAndTradeInfoToDB/pkg/market"
+	"profitLossAndTradeInfoToDB/pkg/money"
+)
+
+// KiteBaseURL is the Kite Connect API endpoint
+const KiteBaseURL = "https://api.kite.trade"
+
+// Kite reads the orderbook and positions of a Zerodha account through Kite
+// Connect. The API only has the current day's orders.
+type Kite struct {
+	BaseURL     string
+	APIKey      string
+	AccessToken string
+	Client      *http.Client
+}
+
+func init() {
+	Register("kite", Definition{
+		Keys: []string{"api_key", "access_token"},
+		New: func(creds Credentials) (Broker, error) {
+			return &Kite{
+				BaseURL:     KiteBaseURL,
+				APIKey:      creds["api_key"],
+				AccessToken: creds["access_token"],
+				Client:      &http.Client{Timeout: 30 * time.Second},
+			}, nil
+		},
+	})
+}
+
+func (k *Kite) headers() http.Header {
+	return http.Header{
+		"X-Kite-Version": []string{"3"},
+		"Authorization":  []string{"token " + k.APIKey + ":" + k.AccessToken},
+	}
+}
+
+// checkToday fails for days other than today, which Kite has no data for
+func checkToday(broker string, day time.Time) error {
+	if !market.DayStart(day).Equal(market.DayStart(time.Now())) {
+		return fmt.Errorf("%s only has the current day's data, not %s", broker, day.Format("2006-01-02"))
+	}
+	return nil
+}
+
+type kiteOrder struct {
+	OrderID           string  `json:"order_id"`
+	ExchangeOrderID   string  `json:"exchange_order_id"`
+	Status            string  `json:"status"`
+	TradingSymbol     string  `json:"tradingsymbol"`
+	TransactionType   string  `json:"transaction_type"`
+	Product           string  `json:"product"`
+	FilledQuantity    float64 `json:"filled_quantity"`
+	AveragePrice      float64 `json:"average_price"`
+	OrderTimestamp    string  `json:"order_timestamp"`
+	ExchangeTimestamp string  `json:"exchange_timestamp"`
+}
+
+// Kite timestamps are in market time without an offset
+const kiteTimeLayout = "2006-01-02 15:04:05"
+
+// Orders returns today's filled orders; day must be today
+func (k *Kite) Orders(ctx context.Context, day time.Time) ([]orderbook.Order, error) {
+	if err := checkToday("kite", day); err != nil {
+		return nil, err
+	}
+
+	var resp struct {
+		Data []kiteOrder `json:"data"`
+	}
+	if err := getJSON(ctx, k.Client, k.BaseURL+"/orders", k.headers(), &resp); err != nil {
+		return nil, fmt.Errorf("kite orders: %w", err)
+	}
+
+	var orders []orderbook.Order
+	for _, o := range resp.Data {
+		if o.FilledQuantity <= 0 {
+			continue // rejected, cancelled or still open without fills
+		}
+		timestamp, err := time.ParseInLocation(kiteTimeLayout, o.OrderTimestamp, market.Location())
+		if err != nil {
+			return nil, fmt.Errorf("kite order %s: invalid order_timestamp %q", o.OrderID, o.OrderTimestamp)
+		}
+
+		order := orderbook.Order{
+			Timestamp:       timestamp,
+			TransactionType: side(o.TransactionType),
+			Symbol:          o.TradingSymbol,
+			Product:         o.Product,
+			Quantity:        o.FilledQuantity,
+			AveragePrice:    o.AveragePrice,
+			OrderStatus:     o.Status,
+			OrderID:         o.OrderID,
+			ExchangeOrderID: o.ExchangeOrderID,
+		}
+		if executed, err := time.ParseInLocation(kiteTimeLayout, o.ExchangeTimestamp, market.Location()); err == nil {
+			order.ExecutionTime = &executed
+		}
+		orders = append(orders, order)
+	}
+	return orders, nil
+}
+
+// ProfitLoss sums the mark-to-market of today's positions; day must be today
+func (k *Kite) ProfitLoss(ctx context.Context, day time.Time) (float64, error) {
+	if err := checkToday("kite", day); err != nil {
+		return 0, err
+	}
+
+	var resp struct {
+		Data struct {
+			Day []struct {
+				M2M float64 `json:"m2m"`
+			} `json:"day"`
+		} `json:"data"`
+	}
+	if err := getJSON(ctx, k.Client, k.BaseURL+"/portfolio/positions", k.headers(), &resp); err != nil {
+		return 0, fmt.Errorf("kite positions: %w", err)
+	}
+
+	var total money.Paise
+	for _, position := range resp.Data.Day {
+		total += money.FromRupees(position.M2M)
+	}
+	return total.Rupees(), nil
+}
@@ -0,0 +1,42 @@
+package broker
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTOTP(t *testing.T) {
+	// The SHA-1 vectors of RFC 6238, appendix B, cut to 6 digits; the secret
+	// is the ASCII "12345678901234567890" in base32
+	const secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+	tests := []struct {
+		name    string
+		secret  string
+		unix    int64
+		want    string
+		wantErr bool
+	}{
+		{"rfc 59", secret, 59, "287082", false},
+		{"rfc 1111111109", secret, 1111111109, "081804", false},
+		{"rfc 1111111111", secret, 1111111111, "050471", false},
+		{"rfc 1234567890", secret, 1234567890, "005924", false},
+		{"rfc 2000000000", secret, 2000000000, "279037", false},
+		{"rfc 20000000000", secret, 20000000000, "353130", false},
+		{"same 30 second step", secret, 30, "287082", false},
+		{"lower case with spaces", "gezd gnbv gy3t qojq gezd gnbv gy3t qojq", 59, "287082", false},
+		{"padded", " GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ==== ", 59, "287082", false},
+		{"invalid secret", "not base32!", 59, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := totp(tt.secret, time.Unix(tt.unix, 0))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("totp error = %v, want error %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("totp = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package charges
+
+import (
+	"profitLossAndTradeInfoToDB/pkg/money"
+	"profitLossAndTradeInfoToDB/pkg/trades"
+)
+
+// Rates are the brokerage and statutory charges applied to option orders.
+// Percentages are fractions of premium turnover (0.001 = 0.1%).
+type Rates struct {
+	BrokeragePerOrder float64 `json:"brokerage_per_order"`
+	STTSellRate       float64 `json:"stt_sell_rate"`       // securities transaction tax, sell side
+	ExchangeTxnRate   float64 `json:"exchange_txn_rate"`   // exchange transaction charges
+	SEBIRate          float64 `json:"sebi_rate"`           // SEBI turnover fee
+	StampDutyBuyRate  float64 `json:"stamp_duty_buy_rate"` // stamp duty, buy side
+	GSTRate           float64 `json:"gst_rate"`            // on brokerage, exchange and SEBI charges
+}
+
+// DefaultRates approximate a discount broker's NSE index options charges
+var DefaultRates = Rates{
+	BrokeragePerOrder: 20,
+	STTSellRate:       0.001,
+	ExchangeTxnRate:   0.0003503,
+	SEBIRate:          0.000001,
+	StampDutyBuyRate:  0.00003,
+	GSTRate:           0.18,
+}
+
+// Breakdown itemises the charges of one or more orders
+type Breakdown struct {
+	Brokerage   money.Paise `json:"brokerage"`
+	STT         money.Paise `json:"stt"`
+	ExchangeTxn money.Paise `json:"exchange_txn"`
+	SEBI        money.Paise `json:"sebi"`
+	StampDuty   money.Paise `json:"stamp_duty"`
+	GST         money.Paise `json:"gst"`
+	Total       money.Paise `json:"total"`
+}
+
+// Add accumulates another breakdown into b
+func (b *Breakdown) Add(other Breakdown) {
+	b.Brokerage += other.Brokerage
+	b.STT += other.STT
+	b.ExchangeTxn += other.ExchangeTxn
+	b.SEBI += other.SEBI
+	b.StampDuty += other.StampDuty
+	b.GST += other.GST
+	b.Total += other.Total
+}
+
+// ForFill returns the charges of a single executed order, each rounded to
+// the paisa under the money rounding policy and STT and stamp duty to the rupee
+func (r Rates) ForFill(fill trades.Fill) Breakdown {
+	value := money.Value(fill.Price, fill.Quantity).Rupees()
+
+	b := Breakdown{
+		Brokerage:   money.FromRupees(r.BrokeragePerOrder),
+		ExchangeTxn: money.FromRupees(value * r.ExchangeTxnRate),
+		SEBI:        money.FromRupees(value * r.SEBIRate),
+	}
+	if fill.TransactionType == "S" {
+		b.STT = money.FromRupees(money.RoundRupee(value * r.STTSellRate))
+	} else {
+		b.StampDuty = money.FromRupees(money.RoundRupee(value * r.StampDutyBuyRate))
+	}
+	b.GST = money.FromRupees((b.Brokerage + b.ExchangeTxn + b.SEBI).Rupees() * r.GSTRate)
+
+	b.Total = b.Brokerage + b.STT + b.ExchangeTxn + b.SEBI + b.StampDuty + b.GST
+
+	return b
+}
+
+// Compute totals the charges of every fill
+func (r Rates) Compute(fills []trades.Fill) Breakdown {
+	var total Breakdown
+	for _, fill := range fills {
+		total.Add(r.ForFill(fill))
+	}
+	return total
+}
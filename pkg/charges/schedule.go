@@ -0,0 +1,110 @@
+package charges
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"time"
+
+	"profitLossAndTradeInfoToDB/pkg/market"
+	"profitLossAndTradeInfoToDB/pkg/trades"
+)
+
+// Period is a set of rates in force from a date until the next period starts
+type Period struct {
+	From  time.Time
+	Rates Rates
+}
+
+// Schedule is the history of rates, oldest period first. Charges of a fill
+// use the rates in force on the day it executed, so recomputing old trades
+// does not apply today's rates.
+type Schedule []Period
+
+// DefaultSchedule approximates how NSE index options charges changed: STT on
+// option sales rose from 0.05% to 0.0625% in April 2023 and to 0.1% in
+// October 2024, when exchange charges moved to a flat 0.03503%
+var DefaultSchedule = Schedule{
+	{From: time.Date(2020, 7, 1, 0, 0, 0, 0, market.Location()), Rates: Rates{
+		BrokeragePerOrder: 20, STTSellRate: 0.0005, ExchangeTxnRate: 0.00053, SEBIRate: 0.000001, StampDutyBuyRate: 0.00003, GSTRate: 0.18,
+	}},
+	{From: time.Date(2023, 4, 1, 0, 0, 0, 0, market.Location()), Rates: Rates{
+		BrokeragePerOrder: 20, STTSellRate: 0.000625, ExchangeTxnRate: 0.0005, SEBIRate: 0.000001, StampDutyBuyRate: 0.00003, GSTRate: 0.18,
+	}},
+	{From: time.Date(2024, 10, 1, 0, 0, 0, 0, market.Location()), Rates: DefaultRates},
+}
+
+// RatesAt returns the rates in force at t; times before the first period use its rates
+func (s Schedule) RatesAt(t time.Time) Rates {
+	if len(s) == 0 {
+		return DefaultRates
+	}
+	i := sort.Search(len(s), func(i int) bool { return s[i].From.After(t) })
+	if i == 0 {
+		return s[0].Rates
+	}
+	return s[i-1].Rates
+}
+
+// ForFill returns the charges of a fill at the rates of its execution day
+func (s Schedule) ForFill(fill trades.Fill) Breakdown {
+	return s.RatesAt(fill.Time).ForFill(fill)
+}
+
+// Compute totals the charges of every fill, each at the rates of its day
+func (s Schedule) Compute(fills []trades.Fill) Breakdown {
+	var total Breakdown
+	for _, fill := range fills {
+		total.Add(s.ForFill(fill))
+	}
+	return total
+}
+
+// ReadSchedule parses a JSON array of periods, each the Rates fields plus an
+// "effective_from" date (YYYY-MM-DD, market time), in any order
+func ReadSchedule(r io.Reader) (Schedule, error) {
+	var entries []struct {
+		EffectiveFrom string `json:"effective_from"`
+		Rates
+	}
+	if err := json.NewDecoder(r).Decode(&entries); err != nil {
+		return nil, fmt.Errorf("failed to decode charge schedule: %w", err)
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("charge schedule has no periods")
+	}
+
+	schedule := make(Schedule, len(entries))
+	for i, entry := range entries {
+		from, err := time.ParseInLocation("2006-01-02", entry.EffectiveFrom, market.Location())
+		if err != nil {
+			return nil, fmt.Errorf("period %d: invalid effective_from %q", i+1, entry.EffectiveFrom)
+		}
+		schedule[i] = Period{From: from, Rates: entry.Rates}
+	}
+
+	sort.Slice(schedule, func(i, j int) bool { return schedule[i].From.Before(schedule[j].From) })
+	for i := 1; i < len(schedule); i++ {
+		if schedule[i].From.Equal(schedule[i-1].From) {
+			return nil, fmt.Errorf("two periods take effect on %s", schedule[i].From.Format("2006-01-02"))
+		}
+	}
+	return schedule, nil
+}
+
+// LoadSchedule reads a schedule file, or returns DefaultSchedule when path is empty
+func LoadSchedule(path string) (Schedule, error) {
+	if path == "" {
+		return DefaultSchedule, nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open charge schedule: %w", err)
+	}
+	defer file.Close()
+
+	return ReadSchedule(file)
+}
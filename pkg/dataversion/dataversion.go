@@ -0,0 +1,56 @@
+// Package dataversion keeps a counter per account that every write to the
+// account's orders, summaries and profit/loss samples increments, so cached
+// reads can tell whether anything changed since they were computed.
+package dataversion
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"profitLossAndTradeInfoToDB/constants"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Counter is the data version of one account
+type Counter struct {
+	collection *mongo.Collection
+	account    string
+}
+
+// New creates the counter of account in db
+func New(db *mongo.Database, account string) *Counter {
+	return &Counter{collection: db.Collection(constants.DATA_VERSION_SCHEMA), account: account}
+}
+
+// Bump increments the version; call it after a write has completed, so a
+// read computed during the write is never cached under the new version
+func (c *Counter) Bump(ctx context.Context) error {
+	_, err := c.collection.UpdateOne(ctx,
+		bson.M{"_id": c.account},
+		bson.M{"$inc": bson.M{"version": int64(1)}},
+		options.Update().SetUpsert(true),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to bump data version: %w", err)
+	}
+	return nil
+}
+
+// Get returns the version, 0 before the account's first write
+func (c *Counter) Get(ctx context.Context) (int64, error) {
+	var doc struct {
+		Version int64 `bson:"version"`
+	}
+	err := c.collection.FindOne(ctx, bson.M{"_id": c.account}).Decode(&doc)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read data version: %w", err)
+	}
+	return doc.Version, nil
+}
@@ -0,0 +1,154 @@
+package display
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"profitLossAndTradeInfoToDB/pkg/market"
+	"profitLossAndTradeInfoToDB/pkg/money"
+)
+
+// Locale describes how dates and numbers are written
+type Locale struct {
+	Name       string
+	DateLayout string
+	TimeLayout string
+	Decimal    string
+	Group      string
+	// IndianGrouping groups digits as 1,23,45,678 instead of 12,345,678
+	IndianGrouping bool
+}
+
+// Locales are the supported locales by name
+var Locales = map[string]Locale{
+	"en-IN": {Name: "en-IN", DateLayout: "02-Jan-2006", TimeLayout: "15:04:05", Decimal: ".", Group: ",", IndianGrouping: true},
+	"en-GB": {Name: "en-GB", DateLayout: "02 Jan 2006", TimeLayout: "15:04:05", Decimal: ".", Group: ","},
+	"en-US": {Name: "en-US", DateLayout: "Jan 02, 2006", TimeLayout: "03:04:05 PM", Decimal: ".", Group: ","},
+	"de-DE": {Name: "de-DE", DateLayout: "02.01.2006", TimeLayout: "15:04:05", Decimal: ",", Group: "."},
+	"fr-FR": {Name: "fr-FR", DateLayout: "02/01/2006", TimeLayout: "15:04:05", Decimal: ",", Group: " "},
+}
+
+var (
+	mu       sync.RWMutex
+	location *time.Location
+	locale   = Locales["en-IN"]
+)
+
+// SetTimezone sets the zone times are shown in; "" restores the market timezone
+func SetTimezone(name string) error {
+	var loc *time.Location
+	if name != "" {
+		var err error
+		if loc, err = time.LoadLocation(name); err != nil {
+			return fmt.Errorf("unknown timezone %q: %w", name, err)
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	location = loc
+	return nil
+}
+
+// SetLocale selects one of Locales
+func SetLocale(name string) error {
+	l, ok := Locales[name]
+	if !ok {
+		names := make([]string, 0, len(Locales))
+		for n := range Locales {
+			names = append(names, n)
+		}
+		return fmt.Errorf("unknown locale %q, supported: %s", name, strings.Join(names, ", "))
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	locale = l
+	return nil
+}
+
+// Location returns the display timezone, the market timezone unless configured
+func Location() *time.Location {
+	mu.RLock()
+	defer mu.RUnlock()
+	if location == nil {
+		return market.Location()
+	}
+	return location
+}
+
+func current() Locale {
+	mu.RLock()
+	defer mu.RUnlock()
+	return locale
+}
+
+// Time formats an instant as date and time in the display timezone
+func Time(t time.Time) string {
+	l := current()
+	return t.In(Location()).Format(l.DateLayout + " " + l.TimeLayout + " MST")
+}
+
+// Clock formats only the time of day of an instant in the display timezone
+func Clock(t time.Time) string {
+	return t.In(Location()).Format(current().TimeLayout)
+}
+
+// Day formats a trading day. Days are exchange calendar days, so they are
+// labelled in the market timezone whatever the display timezone is.
+func Day(t time.Time) string {
+	return t.In(market.Location()).Format(current().DateLayout)
+}
+
+// Number formats v with the given decimals and the locale's separators
+func Number(v float64, decimals int) string {
+	l := current()
+
+	s := fmt.Sprintf("%.*f", decimals, math.Abs(v))
+	intPart, frac, _ := strings.Cut(s, ".")
+
+	var groups []string
+	if l.IndianGrouping && len(intPart) > 3 {
+		groups = append(groups, intPart[len(intPart)-3:])
+		intPart = intPart[:len(intPart)-3]
+		for len(intPart) > 2 {
+			groups = append([]string{intPart[len(intPart)-2:]}, groups...)
+			intPart = intPart[:len(intPart)-2]
+		}
+	} else {
+		for len(intPart) > 3 {
+			groups = append([]string{intPart[len(intPart)-3:]}, groups...)
+			intPart = intPart[:len(intPart)-3]
+		}
+	}
+	groups = append([]string{intPart}, groups...)
+
+	out := strings.Join(groups, l.Group)
+	if frac != "" {
+		out += l.Decimal + frac
+	}
+	if v < 0 && strings.Trim(out, "0"+l.Group+l.Decimal) != "" {
+		out = "-" + out
+	}
+	return out
+}
+
+// Quantity formats a quantity as a plain number: whole quantities without
+// decimals, fractional (crypto) ones with up to 8
+func Quantity(v float64) string {
+	return strconv.FormatFloat(math.Round(v*1e8)/1e8, 'f', -1, 64)
+}
+
+// Money formats an amount with two decimals
+func Money(v float64) string {
+	return Number(v, 2)
+}
+
+// Amount formats an amount kept in paise
+func Amount(p money.Paise) string {
+	return Money(p.Rupees())
+}
@@ -0,0 +1,374 @@
+package doctor
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"profitLossAndTradeInfoToDB/constants"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Levels of a finding, from harmless to blocking
+const (
+	OK   = "OK"
+	Warn = "WARN"
+	Fail = "FAIL"
+)
+
+// Finding is the outcome of a single check with an optional remedy
+type Finding struct {
+	Level   string
+	Check   string
+	Message string
+	Fix     string
+}
+
+// Report collects findings in the order the checks ran
+type Report struct {
+	Findings []Finding
+}
+
+// Add records a finding
+func (r *Report) Add(level, check, message, fix string) {
+	r.Findings = append(r.Findings, Finding{Level: level, Check: check, Message: message, Fix: fix})
+}
+
+// Failed reports whether any check failed
+func (r *Report) Failed() bool {
+	for _, f := range r.Findings {
+		if f.Level == Fail {
+			return true
+		}
+	}
+	return false
+}
+
+// Print writes the findings, one per line with the fix indented below
+func (r *Report) Print(w io.Writer) {
+	var warnings, failures int
+	for _, f := range r.Findings {
+		fmt.Fprintf(w, "[%-4s] %-12s %s\n", f.Level, f.Check, f.Message)
+		if f.Fix != "" && f.Level != OK {
+			fmt.Fprintf(w, "       %-12s -> %s\n", "", f.Fix)
+		}
+		switch f.Level {
+		case Warn:
+			warnings++
+		case Fail:
+			failures++
+		}
+	}
+	fmt.Fprintf(w, "\n%d checks, %d warnings, %d failures\n", len(r.Findings), warnings, failures)
+}
+
+// requiredActions are the privileges imports need on the main database
+var requiredActions = []string{"find", "insert", "update", "remove", "createCollection", "createIndex"}
+
+// CheckPermissions verifies the connected user may read and write the database
+func CheckPermissions(ctx context.Context, r *Report, db *mongo.Database) {
+	var status struct {
+		AuthInfo struct {
+			AuthenticatedUsers []struct {
+				User string `bson:"user"`
+				DB   string `bson:"db"`
+			} `bson:"authenticatedUsers"`
+			Privileges []struct {
+				Resource struct {
+					DB         *string `bson:"db"`
+					Collection *string `bson:"collection"`
+				} `bson:"resource"`
+				Actions []string `bson:"actions"`
+			} `bson:"authenticatedUserPrivileges"`
+		} `bson:"authInfo"`
+	}
+	cmd := bson.D{{Key: "connectionStatus", Value: 1}, {Key: "showPrivileges", Value: true}}
+	if err := db.RunCommand(ctx, cmd).Decode(&status); err != nil {
+		r.Add(Warn, "permissions", fmt.Sprintf("could not read connection status: %v", err), "")
+		return
+	}
+
+	if len(status.AuthInfo.AuthenticatedUsers) == 0 {
+		r.Add(Warn, "permissions", "connected without authentication",
+			"enable access control and use a user limited to "+db.Name())
+		return
+	}
+
+	granted := map[string]bool{}
+	for _, p := range status.AuthInfo.Privileges {
+		if p.Resource.DB == nil || (*p.Resource.DB != "" && *p.Resource.DB != db.Name()) {
+			continue
+		}
+		if p.Resource.Collection != nil && *p.Resource.Collection != "" {
+			continue
+		}
+		for _, action := range p.Actions {
+			granted[action] = true
+		}
+	}
+
+	var missing []string
+	for _, action := range requiredActions {
+		if !granted[action] {
+			missing = append(missing, action)
+		}
+	}
+
+	user := status.AuthInfo.AuthenticatedUsers[0]
+	if len(missing) > 0 {
+		r.Add(Fail, "permissions",
+			fmt.Sprintf("user %s@%s lacks %s on %s", user.User, user.DB, strings.Join(missing, ", "), db.Name()),
+			"grant the readWrite role on "+db.Name())
+		return
+	}
+	r.Add(OK, "permissions", fmt.Sprintf("user %s@%s can read and write %s", user.User, user.DB, db.Name()), "")
+}
+
+// expectedIndex is an index a collection must carry for upserts to be correct
+type expectedIndex struct {
+	collection string
+	keys       bson.D
+	unique     bool
+	fix        string
+}
+
+var expectedIndexes = []expectedIndex{
+	{
+		collection: constants.DAILY_SUMMARY_SCHEMA,
+		keys:       bson.D{{Key: "account", Value: 1}, {Key: "date", Value: 1}},
+		unique:     true,
+		fix:        "run any import without --read-only to create it",
+	},
+	{
+		collection: constants.PROFITLOSS_SCHEMA,
+		keys:       bson.D{{Key: "account", Value: 1}, {Key: "timestamp", Value: 1}},
+		unique:     true,
+		fix:        "run an import; it removes duplicate (account, timestamp) samples and creates it",
+	},
+	{
+		collection: constants.ORDERBOOK_SCHEMA,
+		keys:       bson.D{{Key: "account", Value: 1}, {Key: "dedup_key", Value: 1}, {Key: "version", Value: 1}},
+		unique:     true,
+		fix:        "run an import; it removes duplicate (account, dedup_key, version) orders and creates it",
+	},
+	{
+		collection: constants.ORDERBOOK_SCHEMA,
+		keys:       bson.D{{Key: "account", Value: 1}, {Key: "metadata.expiry", Value: 1}, {Key: "timestamp", Value: 1}},
+		fix:        "run any import without --read-only to create it",
+	},
+	{
+		collection: constants.PROCESSED_FILES_SCHEMA,
+		keys:       bson.D{{Key: "account", Value: 1}, {Key: "checksum", Value: 1}},
+		unique:     true,
+		fix:        "remove duplicate (account, checksum) entries, then run an import to create it",
+	},
+	{
+		collection: constants.IMPORT_CHECKPOINTS_SCHEMA,
+		keys:       bson.D{{Key: "account", Value: 1}, {Key: "checksum", Value: 1}},
+		unique:     true,
+		fix:        "remove duplicate (account, checksum) checkpoints, then run an import to create it",
+	},
+}
+
+// CheckCollections verifies the collections, their indexes and the orders time series options
+func CheckCollections(ctx context.Context, r *Report, db *mongo.Database) {
+	specs, err := db.ListCollectionSpecifications(ctx, bson.D{})
+	if err != nil {
+		r.Add(Fail, "collections", fmt.Sprintf("could not list collections of %s: %v", db.Name(), err),
+			"grant the listCollections privilege")
+		return
+	}
+
+	byName := map[string]*mongo.CollectionSpecification{}
+	for _, spec := range specs {
+		byName[spec.Name] = spec
+	}
+
+	for _, name := range []string{constants.ORDERBOOK_SCHEMA, constants.PROFITLOSS_SCHEMA, constants.DAILY_SUMMARY_SCHEMA} {
+		if byName[name] == nil {
+			r.Add(Warn, "collections", fmt.Sprintf("%s.%s does not exist yet", db.Name(), name),
+				"it is created by the first import")
+		} else {
+			r.Add(OK, "collections", fmt.Sprintf("%s.%s exists", db.Name(), name), "")
+		}
+	}
+
+	checkTimeSeries(r, byName[constants.ORDERS_TIMESERIES_SCHEMA])
+
+	for _, idx := range expectedIndexes {
+		if byName[idx.collection] == nil {
+			continue
+		}
+		checkIndex(ctx, r, db.Collection(idx.collection), idx)
+	}
+}
+
+// checkTimeSeries verifies the orders collection was created with the expected time series options
+func checkTimeSeries(r *Report, spec *mongo.CollectionSpecification) {
+	name := constants.ORDERS_TIMESERIES_SCHEMA
+	if spec == nil {
+		r.Add(Warn, "timeseries", fmt.Sprintf("%s collection does not exist", name),
+			"run any import without --read-only to create it")
+		return
+	}
+
+	var opts struct {
+		TimeSeries *struct {
+			TimeField   string `bson:"timeField"`
+			MetaField   string `bson:"metaField"`
+			Granularity string `bson:"granularity"`
+		} `bson:"timeseries"`
+	}
+	if len(spec.Options) > 0 {
+		if err := bson.Unmarshal(spec.Options, &opts); err != nil {
+			r.Add(Warn, "timeseries", fmt.Sprintf("could not decode %s options: %v", name, err), "")
+			return
+		}
+	}
+
+	ts := opts.TimeSeries
+	switch {
+	case ts == nil:
+		r.Add(Fail, "timeseries", fmt.Sprintf("%s is a regular collection, not a time series", name),
+			fmt.Sprintf("drop %s (after exporting it) so the next import recreates it", name))
+	case ts.TimeField != "timestamp" || ts.MetaField != "metadata":
+		r.Add(Fail, "timeseries",
+			fmt.Sprintf("%s uses timeField=%q metaField=%q, expected timestamp/metadata", name, ts.TimeField, ts.MetaField),
+			fmt.Sprintf("drop %s (after exporting it) so the next import recreates it", name))
+	case ts.Granularity != "minutes":
+		r.Add(Warn, "timeseries", fmt.Sprintf("%s granularity is %q, expected minutes", name, ts.Granularity),
+			fmt.Sprintf("run collMod on %s with timeseries.granularity=minutes", name))
+	default:
+		r.Add(OK, "timeseries", fmt.Sprintf("%s is a time series on timestamp/metadata", name), "")
+	}
+}
+
+// checkIndex looks for an index with the expected keys and uniqueness
+func checkIndex(ctx context.Context, r *Report, coll *mongo.Collection, want expectedIndex) {
+	specs, err := coll.Indexes().ListSpecifications(ctx)
+	if err != nil {
+		r.Add(Warn, "indexes", fmt.Sprintf("could not list indexes of %s: %v", coll.Name(), err), "")
+		return
+	}
+
+	wantKeys, err := bson.Marshal(want.keys)
+	if err != nil {
+		r.Add(Warn, "indexes", fmt.Sprintf("invalid expected index on %s: %v", coll.Name(), err), "")
+		return
+	}
+
+	for _, spec := range specs {
+		if !sameKeys(spec.KeysDocument, wantKeys) {
+			continue
+		}
+		if want.unique && (spec.Unique == nil || !*spec.Unique) {
+			r.Add(Fail, "indexes", fmt.Sprintf("%s index %s is not unique", coll.Name(), spec.Name), want.fix)
+			return
+		}
+		r.Add(OK, "indexes", fmt.Sprintf("%s has index %s", coll.Name(), spec.Name), "")
+		return
+	}
+
+	r.Add(Fail, "indexes", fmt.Sprintf("%s is missing the unique %s index", coll.Name(), keyNames(want.keys)), want.fix)
+}
+
+// sameKeys compares index key documents by field order and direction
+func sameKeys(got bson.Raw, want []byte) bool {
+	gotElems, err := got.Elements()
+	if err != nil {
+		return false
+	}
+	wantElems, err := bson.Raw(want).Elements()
+	if err != nil || len(gotElems) != len(wantElems) {
+		return false
+	}
+	for i := range gotElems {
+		if gotElems[i].Key() != wantElems[i].Key() {
+			return false
+		}
+		g, gOK := gotElems[i].Value().AsInt64OK()
+		w, wOK := wantElems[i].Value().AsInt64OK()
+		if !gOK || !wOK || g != w {
+			return false
+		}
+	}
+	return true
+}
+
+func keyNames(keys bson.D) string {
+	names := make([]string, len(keys))
+	for i, k := range keys {
+		names[i] = k.Key
+	}
+	return "(" + strings.Join(names, ", ") + ")"
+}
+
+var (
+	orderbookFilePattern  = regexp.MustCompile(`^orderbook_.*?(\d{2}-\d{2}-\d{4}).*\.csv$`)
+	profitLossFilePattern = regexp.MustCompile(`^profitLoss_(\d{2}-\d{2}-\d{4})\.csv$`)
+)
+
+// CheckCSVDir reports CSV files whose names the date-based import would not pick up
+func CheckCSVDir(r *Report, dir string) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		r.Add(Fail, "csv-dir", fmt.Sprintf("cannot read %s: %v", dir, err), "pass an existing directory with -csv-dir")
+		return
+	}
+
+	var valid, malformed int
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.EqualFold(filepath.Ext(name), ".csv") {
+			continue
+		}
+
+		problem := filenameProblem(name)
+		if problem == "" {
+			valid++
+			continue
+		}
+		malformed++
+		r.Add(Warn, "csv-dir", fmt.Sprintf("%s: %s", name, problem),
+			"rename to orderbook_<name>_DD-MM-YYYY.csv or profitLoss_DD-MM-YYYY.csv, or import it explicitly by path")
+	}
+
+	if malformed == 0 {
+		r.Add(OK, "csv-dir", fmt.Sprintf("%d CSV files in %s follow the naming convention", valid, dir), "")
+	}
+}
+
+// filenameProblem explains why name does not match the import naming convention, or returns ""
+func filenameProblem(name string) string {
+	if filepath.Ext(name) != ".csv" {
+		return "extension must be lowercase .csv"
+	}
+
+	var match []string
+	switch {
+	case strings.HasPrefix(name, "profitLoss"):
+		match = profitLossFilePattern.FindStringSubmatch(name)
+		if match == nil {
+			return "expected profitLoss_DD-MM-YYYY.csv"
+		}
+	case strings.HasPrefix(name, "orderbook_"):
+		match = orderbookFilePattern.FindStringSubmatch(name)
+		if match == nil {
+			return "no DD-MM-YYYY date in the name"
+		}
+	default:
+		return "name starts with neither orderbook_ nor profitLoss_"
+	}
+
+	if _, err := time.Parse("02-01-2006", match[1]); err != nil {
+		return fmt.Sprintf("%s is not a valid date", match[1])
+	}
+	return ""
+}
@@ -0,0 +1,145 @@
+package events
+
+import (
+	"context"
+	"expvar"
+	"log"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Type identifies an import lifecycle event
+type Type string
+
+const (
+	FileStarted    Type = "file.started"
+	BatchInserted  Type = "batch.inserted"
+	FileCompleted  Type = "file.completed"
+	SummaryUpdated Type = "summary.updated"
+	ImportFailed   Type = "import.failed"
+	// DuplicatesResolved reports how a file's orders fared against the duplicate policy
+	DuplicatesResolved Type = "duplicates.resolved"
+	// RunPurged reports the rollback of an import run
+	RunPurged Type = "run.purged"
+	// FileSkipped reports a file not loaded because its content was already imported
+	FileSkipped Type = "file.skipped"
+	// Retrying reports a transient database error about to be retried; Kind
+	// names the operation and Count the attempts made
+	Retrying Type = "db.retrying"
+	// RowQuarantined reports a row that could not be parsed; Count is its CSV line
+	RowQuarantined Type = "row.quarantined"
+)
+
+// Event describes something that happened during an import
+type Event struct {
+	Type     Type      `bson:"type" json:"type"`
+	Time     time.Time `bson:"time" json:"time"`
+	Account  string    `bson:"account" json:"account"`
+	RunID    string    `bson:"run_id,omitempty" json:"run_id,omitempty"` // Import run the event belongs to
+	Source   string    `bson:"source,omitempty" json:"source,omitempty"` // File or URL being imported
+	Kind     string    `bson:"kind,omitempty" json:"kind,omitempty"`     // "orders" or "profitLoss"
+	Count    int       `bson:"count,omitempty" json:"count,omitempty"`   // Documents in the batch
+	Day      time.Time `bson:"day,omitempty" json:"day,omitempty"`       // Day of an updated summary
+	Error    string    `bson:"error,omitempty" json:"error,omitempty"`
+	Checksum string    `bson:"checksum,omitempty" json:"checksum,omitempty"` // SHA-256 of the file's content
+	// Outcomes counts orders per duplicate policy outcome, e.g. inserted or skipped
+	Outcomes map[string]int `bson:"outcomes,omitempty" json:"outcomes,omitempty"`
+}
+
+// Handler receives published events. Handlers run synchronously and must not
+// block for long; failures are theirs to log, they never fail an import.
+type Handler func(ctx context.Context, e Event)
+
+// Bus fans events out to subscribers. A nil *Bus discards everything, so
+// components can publish unconditionally.
+type Bus struct {
+	mu       sync.RWMutex
+	handlers map[Type][]Handler
+	all      []Handler
+}
+
+// NewBus creates a bus without subscribers
+func NewBus() *Bus {
+	return &Bus{handlers: map[Type][]Handler{}}
+}
+
+// Subscribe registers h for the given event types, or for every event when none are given
+func (b *Bus) Subscribe(h Handler, types ...Type) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if len(types) == 0 {
+		b.all = append(b.all, h)
+		return
+	}
+	for _, t := range types {
+		b.handlers[t] = append(b.handlers[t], h)
+	}
+}
+
+// Publish delivers e to its subscribers, stamping the time when unset
+func (b *Bus) Publish(ctx context.Context, e Event) {
+	if b == nil {
+		return
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+
+	b.mu.RLock()
+	handlers := append(append([]Handler(nil), b.handlers[e.Type]...), b.all...)
+	b.mu.RUnlock()
+
+	for _, h := range handlers {
+		deliver(ctx, h, e)
+	}
+}
+
+// deliver runs one handler, containing its panics
+func deliver(ctx context.Context, h Handler, e Event) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Event handler for %s panicked: %v", e.Type, r)
+		}
+	}()
+	h(ctx, e)
+}
+
+// LogHandler writes file progress to the standard logger
+func LogHandler(ctx context.Context, e Event) {
+	switch e.Type {
+	case FileStarted:
+		log.Printf("Processing %s (run %s)", e.Source, e.RunID)
+	case FileCompleted:
+		log.Printf("Completed processing: %s", e.Source)
+	case FileSkipped:
+		log.Printf("Skipped %s: its content was already imported (sha256 %s)", e.Source, e.Checksum)
+	case DuplicatesResolved:
+		log.Printf("Orders of %s by duplicate outcome: %v", e.Source, e.Outcomes)
+	case RowQuarantined:
+		log.Printf("Quarantined line %d of %s: %s", e.Count, e.Source, e.Error)
+	case Retrying:
+		log.Printf("Retrying %s after %d failed attempts: %s", e.Kind, e.Count, e.Error)
+	}
+}
+
+var counters = expvar.NewMap("import_events")
+
+// MetricsHandler counts events per type, exported through expvar as import_events
+func MetricsHandler(ctx context.Context, e Event) {
+	counters.Add(string(e.Type), 1)
+	if e.Type == BatchInserted {
+		counters.Add("documents."+e.Kind, int64(e.Count))
+	}
+}
+
+// AuditHandler stores every event in collection
+func AuditHandler(collection *mongo.Collection) Handler {
+	return func(ctx context.Context, e Event) {
+		if _, err := collection.InsertOne(ctx, e); err != nil {
+			log.Printf("Failed to write import audit event: %v", err)
+		}
+	}
+}
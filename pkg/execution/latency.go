@@ -0,0 +1,122 @@
+package execution
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	orderbook "profitLossAndTradeInfoToDB/orderbooks"
+	"profitLossAndTradeInfoToDB/pkg/market"
+)
+
+// Distribution summarises a set of placement-to-execution latencies
+type Distribution struct {
+	Key   string        `json:"key"`
+	Count int           `json:"count"`
+	Min   time.Duration `json:"min"`
+	P50   time.Duration `json:"p50"`
+	P90   time.Duration `json:"p90"`
+	P99   time.Duration `json:"p99"`
+	Max   time.Duration `json:"max"`
+	Mean  time.Duration `json:"mean"`
+}
+
+// LatencyReport groups latencies per symbol and per time-of-day bucket
+type LatencyReport struct {
+	Overall     Distribution   `json:"overall"`
+	BySymbol    []Distribution `json:"by_symbol"`
+	ByTimeOfDay []Distribution `json:"by_time_of_day"`
+}
+
+// Latency returns how long the order took from placement to execution, and
+// false when the order carries no execution time
+func Latency(order orderbook.Order) (time.Duration, bool) {
+	if order.ExecutionTime == nil {
+		return 0, false
+	}
+	latency := order.ExecutionTime.Sub(order.Timestamp)
+	if latency < 0 {
+		return 0, false
+	}
+	return latency, true
+}
+
+// BuildLatencyReport computes latency distributions; orders without an
+// execution time are ignored. Time-of-day buckets are in market time, with
+// special sessions such as muhurat trading bucketed apart.
+func BuildLatencyReport(orders []orderbook.Order, bucket time.Duration) LatencyReport {
+	var all []time.Duration
+	bySymbol := map[string][]time.Duration{}
+	byBucket := map[string][]time.Duration{}
+
+	for _, order := range orders {
+		latency, ok := Latency(order)
+		if !ok {
+			continue
+		}
+
+		all = append(all, latency)
+		bySymbol[order.Symbol] = append(bySymbol[order.Symbol], latency)
+
+		local := order.Timestamp.In(market.Location())
+		sinceMidnight := time.Duration(local.Hour())*time.Hour + time.Duration(local.Minute())*time.Minute
+		start := sinceMidnight - sinceMidnight%bucket
+		key := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC).Add(start).Format("15:04")
+		if session, ok := market.SessionAt(order.Timestamp); ok && !session.IsRegular() {
+			key = session.Name + " " + key // Special sessions are not mixed into regular hours
+		}
+		byBucket[key] = append(byBucket[key], latency)
+	}
+
+	return LatencyReport{
+		Overall:     distribution("all", all),
+		BySymbol:    distributions(bySymbol),
+		ByTimeOfDay: distributions(byBucket),
+	}
+}
+
+func distributions(groups map[string][]time.Duration) []Distribution {
+	keys := make([]string, 0, len(groups))
+	for key := range groups {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	result := make([]Distribution, len(keys))
+	for i, key := range keys {
+		result[i] = distribution(key, groups[key])
+	}
+	return result
+}
+
+func distribution(key string, latencies []time.Duration) Distribution {
+	d := Distribution{Key: key, Count: len(latencies)}
+	if len(latencies) == 0 {
+		return d
+	}
+
+	sorted := append([]time.Duration(nil), latencies...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	var total time.Duration
+	for _, latency := range sorted {
+		total += latency
+	}
+
+	d.Min = sorted[0]
+	d.Max = sorted[len(sorted)-1]
+	d.P50 = percentile(sorted, 0.50)
+	d.P90 = percentile(sorted, 0.90)
+	d.P99 = percentile(sorted, 0.99)
+	d.Mean = total / time.Duration(len(sorted))
+	return d
+}
+
+// percentile uses the nearest-rank method on sorted values
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	rank := int(math.Ceil(p*float64(len(sorted)))) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	return sorted[rank]
+}
@@ -0,0 +1,109 @@
+package export
+
+import (
+	"encoding/csv"
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"strconv"
+	"time"
+
+	orderbook "profitLossAndTradeInfoToDB/orderbooks"
+	"profitLossAndTradeInfoToDB/pkg/profitLossGraph"
+)
+
+// Anonymizer scales monetary amounts by a hidden factor and strips identifying
+// fields, so exported data keeps its shape and ratios but not its real size
+type Anonymizer struct {
+	factor float64
+}
+
+// NewAnonymizer creates an Anonymizer with the given factor, or a random one in [0.3, 3) when factor <= 0
+func NewAnonymizer(factor float64) *Anonymizer {
+	if factor <= 0 {
+		factor = 0.3 + rand.New(rand.NewSource(time.Now().UnixNano())).Float64()*2.7
+	}
+	return &Anonymizer{factor: factor}
+}
+
+// ProfitLoss returns scaled copies of the entries without account, source or run information
+func (a *Anonymizer) ProfitLoss(entries []profitLossGraph.ProfitLossEntry) []profitLossGraph.ProfitLossEntry {
+	anonymized := make([]profitLossGraph.ProfitLossEntry, len(entries))
+	for i, entry := range entries {
+		anonymized[i] = profitLossGraph.ProfitLossEntry{
+			Timestamp: entry.Timestamp,
+			Value:     math.Round(entry.Value*a.factor*100) / 100,
+		}
+	}
+	return anonymized
+}
+
+// Orders returns copies of the orders with quantities scaled (whole quantities
+// to at least 1, fractional ones to 8 decimals) and identifying fields
+// removed; prices are kept so per-unit moves stay intact
+func (a *Anonymizer) Orders(orders []orderbook.Order) []orderbook.Order {
+	anonymized := make([]orderbook.Order, len(orders))
+	for i, order := range orders {
+		quantity := math.Max(1, math.Round(order.Quantity*a.factor))
+		if order.Quantity != math.Trunc(order.Quantity) {
+			quantity = math.Max(1e-8, math.Round(order.Quantity*a.factor*1e8)/1e8)
+		}
+		anonymized[i] = orderbook.Order{
+			Timestamp:       order.Timestamp,
+			TransactionType: order.TransactionType,
+			Symbol:          order.Symbol,
+			InstrumentType:  order.InstrumentType,
+			Product:         order.Product,
+			Quantity:        quantity,
+			AveragePrice:    order.AveragePrice,
+			OrderStatus:     order.OrderStatus,
+			MetaData:        order.MetaData,
+		}
+	}
+	return anonymized
+}
+
+// WriteProfitLossCSV writes entries in the canonical profit/loss CSV layout
+func WriteProfitLossCSV(path string, entries []profitLossGraph.ProfitLossEntry) error {
+	rows := [][]string{profitLossGraph.CSVHeader}
+	for _, entry := range entries {
+		rows = append(rows, []string{
+			entry.Timestamp.Format(time.RFC3339),
+			strconv.FormatFloat(entry.Value, 'f', 2, 64),
+		})
+	}
+	return writeCSV(path, rows)
+}
+
+// WriteOrdersCSV writes orders in the canonical orderbook CSV layout
+func WriteOrdersCSV(path string, orders []orderbook.Order) error {
+	rows := [][]string{orderbook.CSVHeader}
+	for _, order := range orders {
+		rows = append(rows, []string{
+			order.Timestamp.Format(orderbook.CSVTimestampLayout),
+			order.TransactionType,
+			order.Symbol,
+			order.Product,
+			strconv.FormatFloat(order.Quantity, 'f', -1, 64),
+			strconv.FormatFloat(order.AveragePrice, 'f', 2, 64),
+			order.OrderStatus,
+		})
+	}
+	return writeCSV(path, rows)
+}
+
+func writeCSV(path string, rows [][]string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	if err := writer.WriteAll(rows); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,261 @@
+package instruments
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"profitLossAndTradeInfoToDB/constants"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Instrument is one contract of the exchange instrument master
+type Instrument struct {
+	Exchange       string     `bson:"exchange" json:"exchange"`
+	Symbol         string     `bson:"symbol" json:"symbol"` // Trading symbol as it appears in orderbooks
+	Token          string     `bson:"token" json:"token"`
+	Name           string     `bson:"name,omitempty" json:"name,omitempty"`
+	InstrumentType string     `bson:"instrument_type,omitempty" json:"instrument_type,omitempty"`
+	Expiry         *time.Time `bson:"expiry,omitempty" json:"expiry,omitempty"`
+	Strike         float64    `bson:"strike,omitempty" json:"strike,omitempty"`
+	LotSize        int32      `bson:"lot_size" json:"lot_size"`
+	TickSize       float64    `bson:"tick_size" json:"tick_size"`
+	LoadedAt       time.Time  `bson:"loaded_at" json:"loaded_at"`
+}
+
+// Header names recognised in NSE/BSE contract files and broker instrument dumps
+var (
+	symbolColumns   = []string{"tradingsymbol", "trading_symbol", "symbol", "scrip_id", "trdsym"}
+	tokenColumns    = []string{"instrument_token", "exchange_token", "token", "scrip_code", "sc_code", "fintoken"}
+	exchangeColumns = []string{"exchange", "exch", "exseg"}
+	nameColumns     = []string{"name", "scrip_name", "underlying"}
+	typeColumns     = []string{"instrument_type", "instrumenttype", "instrument", "opttype"}
+	expiryColumns   = []string{"expiry", "expiry_date", "expirydate"}
+	strikeColumns   = []string{"strike", "strike_price", "strikeprice"}
+	lotColumns      = []string{"lot_size", "lotsize", "market_lot", "boardlotqty", "mktlot"}
+	tickColumns     = []string{"tick_size", "ticksize", "tick"}
+)
+
+// expiryLayouts are the expiry date formats seen in contract files
+var expiryLayouts = []string{"2006-01-02", "02-Jan-2006", "02Jan2006", "02-01-2006", "02/01/2006"}
+
+// Parse reads a comma or pipe separated contract file. Columns are found by
+// header name; exchange is used for rows without an exchange column.
+func Parse(r io.Reader, exchange string) ([]Instrument, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read header: %w", err)
+	}
+	if len(header) == 1 && strings.Contains(header[0], "|") {
+		// NSE contract files are pipe separated; re-read the header cells
+		header = strings.Split(header[0], "|")
+		reader.Comma = '|'
+	}
+
+	symbolCol := findColumn(header, symbolColumns)
+	if symbolCol < 0 {
+		return nil, fmt.Errorf("no trading symbol column in header %v", header)
+	}
+	tokenCol := findColumn(header, tokenColumns)
+	exchangeCol := findColumn(header, exchangeColumns)
+	nameCol := findColumn(header, nameColumns)
+	typeCol := findColumn(header, typeColumns)
+	expiryCol := findColumn(header, expiryColumns)
+	strikeCol := findColumn(header, strikeColumns)
+	lotCol := findColumn(header, lotColumns)
+	tickCol := findColumn(header, tickColumns)
+
+	var list []Instrument
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		cell := func(col int) string {
+			if col < 0 || col >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[col])
+		}
+
+		instrument := Instrument{
+			Exchange:       strings.ToUpper(exchange),
+			Symbol:         strings.ToUpper(cell(symbolCol)),
+			Token:          cell(tokenCol),
+			Name:           cell(nameCol),
+			InstrumentType: cell(typeCol),
+			LotSize:        1,
+		}
+		if instrument.Symbol == "" {
+			continue
+		}
+		if e := cell(exchangeCol); e != "" {
+			instrument.Exchange = strings.ToUpper(e)
+		}
+		if instrument.Exchange == "" {
+			return nil, fmt.Errorf("line %d: no exchange for %s", line, instrument.Symbol)
+		}
+
+		if v := cell(lotCol); v != "" {
+			lot, err := strconv.Atoi(v)
+			if err != nil || lot <= 0 {
+				return nil, fmt.Errorf("line %d: invalid lot size %q", line, v)
+			}
+			instrument.LotSize = int32(lot)
+		}
+		if v := cell(tickCol); v != "" {
+			tick, err := strconv.ParseFloat(v, 64)
+			if err != nil || tick < 0 {
+				return nil, fmt.Errorf("line %d: invalid tick size %q", line, v)
+			}
+			instrument.TickSize = tick
+		}
+		if v := cell(strikeCol); v != "" {
+			instrument.Strike, _ = strconv.ParseFloat(v, 64)
+		}
+		if v := cell(expiryCol); v != "" {
+			instrument.Expiry = parseExpiry(v)
+		}
+
+		list = append(list, instrument)
+	}
+
+	return list, nil
+}
+
+// parseExpiry returns the expiry date, or nil when no known layout matches
+func parseExpiry(value string) *time.Time {
+	for _, layout := range expiryLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return &t
+		}
+	}
+	return nil
+}
+
+// findColumn returns the index of the first header matching one of the names (case-insensitive), or -1
+func findColumn(header []string, names []string) int {
+	for i, column := range header {
+		column = strings.ToLower(strings.TrimSpace(column))
+		for _, name := range names {
+			if column == name {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// Master is an in-memory index of the instrument master by trading symbol
+type Master struct {
+	bySymbol map[string]Instrument
+}
+
+// NewMaster indexes instruments by symbol; a later exchange's entry does not
+// replace an earlier one, so list NSE before BSE to prefer it
+func NewMaster(list []Instrument) *Master {
+	m := &Master{bySymbol: make(map[string]Instrument, len(list))}
+	for _, instrument := range list {
+		if _, ok := m.bySymbol[instrument.Symbol]; !ok {
+			m.bySymbol[instrument.Symbol] = instrument
+		}
+	}
+	return m
+}
+
+// Lookup returns the instrument traded as symbol
+func (m *Master) Lookup(symbol string) (Instrument, bool) {
+	instrument, ok := m.bySymbol[strings.ToUpper(strings.TrimSpace(symbol))]
+	return instrument, ok
+}
+
+// Len returns the number of symbols in the master
+func (m *Master) Len() int {
+	return len(m.bySymbol)
+}
+
+// Repository stores the instrument master in the reference collection. The
+// master is shared by all accounts.
+type Repository struct {
+	collection *mongo.Collection
+}
+
+// NewRepository creates an instrument repository
+func NewRepository(db *mongo.Database) (*Repository, error) {
+	if db == nil {
+		return nil, fmt.Errorf("database connection is nil")
+	}
+
+	return &Repository{collection: db.Collection(constants.INSTRUMENTS_SCHEMA)}, nil
+}
+
+// EnsureIndexes creates the unique (exchange, symbol) index the upserts rely on
+func (r *Repository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "exchange", Value: 1}, {Key: "symbol", Value: 1}},
+		Options: options.Index().SetUnique(true).SetName("exchange_symbol_unique"),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create instrument index: %w", err)
+	}
+	return nil
+}
+
+// Load upserts instruments keyed by (exchange, symbol). Contracts missing from
+// a newer file are kept, so orders in expired contracts still validate.
+func (r *Repository) Load(ctx context.Context, list []Instrument) (int, error) {
+	if len(list) == 0 {
+		return 0, nil
+	}
+
+	now := time.Now()
+	models := make([]mongo.WriteModel, len(list))
+	for i, instrument := range list {
+		instrument.LoadedAt = now
+		models[i] = mongo.NewReplaceOneModel().
+			SetFilter(bson.M{"exchange": instrument.Exchange, "symbol": instrument.Symbol}).
+			SetReplacement(instrument).
+			SetUpsert(true)
+	}
+
+	result, err := r.collection.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+	if err != nil {
+		return 0, fmt.Errorf("failed to load instruments: %w", err)
+	}
+
+	return int(result.UpsertedCount + result.ModifiedCount), nil
+}
+
+// Master reads the whole instrument master into memory, NSE contracts first
+func (r *Repository) Master(ctx context.Context) (*Master, error) {
+	cursor, err := r.collection.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{
+		{Key: "exchange", Value: -1}, // NSE sorts after BSE
+		{Key: "symbol", Value: 1},
+	}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query instruments: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var list []Instrument
+	if err := cursor.All(ctx, &list); err != nil {
+		return nil, fmt.Errorf("failed to decode instruments: %w", err)
+	}
+
+	return NewMaster(list), nil
+}
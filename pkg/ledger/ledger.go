@@ -0,0 +1,97 @@
+package ledger
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"profitLossAndTradeInfoToDB/constants"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Cashflow is a deposit (positive amount) or withdrawal (negative amount) of trading capital
+type Cashflow struct {
+	Account string    `bson:"account" json:"account"`
+	Date    time.Time `bson:"date" json:"date"`
+	Amount  float64   `bson:"amount" json:"amount"`
+	Note    string    `bson:"note,omitempty" json:"note,omitempty"`
+}
+
+// Repository stores an account's cashflows
+type Repository struct {
+	account    string
+	collection *mongo.Collection
+}
+
+// NewRepository creates a ledger repository scoped to account
+func NewRepository(db *mongo.Database, account string) (*Repository, error) {
+	if db == nil {
+		return nil, fmt.Errorf("database connection is nil")
+	}
+
+	if account == "" {
+		account = constants.DEFAULT_ACCOUNT
+	}
+
+	return &Repository{
+		account:    account,
+		collection: db.Collection(constants.LEDGER_SCHEMA),
+	}, nil
+}
+
+// Add records a cashflow for the repository's account
+func (r *Repository) Add(ctx context.Context, flow Cashflow) error {
+	if flow.Amount == 0 {
+		return fmt.Errorf("cashflow amount must not be zero")
+	}
+	if flow.Date.IsZero() {
+		return fmt.Errorf("cashflow date is required")
+	}
+
+	flow.Account = r.account
+	if _, err := r.collection.InsertOne(ctx, flow); err != nil {
+		return fmt.Errorf("failed to insert cashflow: %w", err)
+	}
+
+	return nil
+}
+
+// List returns cashflows dated in [from, to), oldest first; a zero from means since the beginning
+func (r *Repository) List(ctx context.Context, from, to time.Time) ([]Cashflow, error) {
+	dateFilter := bson.M{"$lt": to}
+	if !from.IsZero() {
+		dateFilter["$gte"] = from
+	}
+
+	cursor, err := r.collection.Find(ctx,
+		bson.M{"account": r.account, "date": dateFilter},
+		options.Find().SetSort(bson.D{{Key: "date", Value: 1}}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query cashflows: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var flows []Cashflow
+	if err := cursor.All(ctx, &flows); err != nil {
+		return nil, fmt.Errorf("failed to decode cashflows: %w", err)
+	}
+
+	return flows, nil
+}
+
+// NetDeposits returns deposits minus withdrawals dated before the given time
+func (r *Repository) NetDeposits(ctx context.Context, before time.Time) (float64, error) {
+	flows, err := r.List(ctx, time.Time{}, before)
+	if err != nil {
+		return 0, err
+	}
+
+	var total float64
+	for _, flow := range flows {
+		total += flow.Amount
+	}
+	return total, nil
+}
@@ -0,0 +1,117 @@
+package live
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Threshold is an intraday drawdown level, either an absolute amount or a
+// percentage of capital
+type Threshold struct {
+	Value   float64
+	Percent bool
+}
+
+func (t Threshold) String() string {
+	if t.Percent {
+		return strconv.FormatFloat(t.Value, 'f', -1, 64) + "%"
+	}
+	return strconv.FormatFloat(t.Value, 'f', -1, 64)
+}
+
+// ParseThresholds parses comma separated levels such as "5000,2%,10000"
+func ParseThresholds(spec string) ([]Threshold, error) {
+	var thresholds []Threshold
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		number, percent := strings.CutSuffix(part, "%")
+		value, err := strconv.ParseFloat(strings.TrimSpace(number), 64)
+		if err != nil || value <= 0 {
+			return nil, fmt.Errorf("invalid drawdown threshold %q", part)
+		}
+		thresholds = append(thresholds, Threshold{Value: value, Percent: percent})
+	}
+	return thresholds, nil
+}
+
+// Level is a threshold resolved to an amount, with its rank among the guard's
+// levels: 1 is the shallowest
+type Level struct {
+	Threshold Threshold
+	Amount    float64
+	Rank      int
+	Deepest   bool
+}
+
+// Guard escalates through drawdown levels as MTM falls from the day's high.
+// Each level fires once; a new high re-arms them all.
+type Guard struct {
+	levels  []Level
+	crossed int // Levels already fired
+	high    float64
+}
+
+// NewGuard resolves thresholds against capital, which percentage thresholds require
+func NewGuard(thresholds []Threshold, capital float64) (*Guard, error) {
+	g := &Guard{}
+	seen := map[float64]bool{}
+	for _, t := range thresholds {
+		amount := t.Value
+		if t.Percent {
+			if capital <= 0 {
+				return nil, fmt.Errorf("drawdown threshold %s needs the trading capital", t)
+			}
+			amount = capital * t.Value / 100
+		}
+		if !seen[amount] {
+			seen[amount] = true
+			g.levels = append(g.levels, Level{Threshold: t, Amount: amount})
+		}
+	}
+
+	sort.Slice(g.levels, func(i, j int) bool { return g.levels[i].Amount < g.levels[j].Amount })
+	for i := range g.levels {
+		g.levels[i].Rank = i + 1
+		g.levels[i].Deepest = i == len(g.levels)-1
+	}
+	return g, nil
+}
+
+// Levels returns the resolved levels, shallowest first
+func (g *Guard) Levels() []Level {
+	return g.levels
+}
+
+// Check returns the deepest level newly crossed by stats, if any. Levels
+// skipped over by a sudden fall fire together as that one level.
+func (g *Guard) Check(stats Stats) (Level, bool) {
+	if stats.Samples == 0 {
+		return Level{}, false
+	}
+	if stats.DayHigh > g.high || stats.Samples == 1 {
+		g.high = stats.DayHigh
+		g.crossed = 0
+	}
+
+	crossed := g.crossed
+	for crossed < len(g.levels) && stats.DistanceFromHigh >= g.levels[crossed].Amount {
+		crossed++
+	}
+	if crossed == g.crossed {
+		return Level{}, false
+	}
+	g.crossed = crossed
+	return g.levels[crossed-1], true
+}
+
+// Reset re-arms every level for a new session
+func (g *Guard) Reset() {
+	g.crossed = 0
+	g.high = 0
+}
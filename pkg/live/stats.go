@@ -0,0 +1,61 @@
+package live
+
+import (
+	"time"
+
+	"profitLossAndTradeInfoToDB/pkg/profitLossGraph"
+)
+
+// Stats are the rolling intraday figures after the latest MTM sample
+type Stats struct {
+	Time             time.Time `json:"time"`
+	MTM              float64   `json:"mtm"`
+	DayHigh          float64   `json:"day_high"`
+	DayLow           float64   `json:"day_low"`
+	DistanceFromHigh float64   `json:"distance_from_high"` // Current drawdown from the day's peak
+	MaxDrawdown      float64   `json:"max_drawdown"`       // Deepest peak-to-trough fall so far today
+	Samples          int       `json:"samples"`
+}
+
+// Tracker maintains Stats over a session's MTM samples
+type Tracker struct {
+	stats Stats
+}
+
+// Update folds a sample into the stats; samples older than the latest are ignored
+func (t *Tracker) Update(entry profitLossGraph.ProfitLossEntry) Stats {
+	s := &t.stats
+	if s.Samples > 0 && entry.Timestamp.Before(s.Time) {
+		return *s
+	}
+
+	if s.Samples == 0 {
+		s.DayHigh, s.DayLow = entry.Value, entry.Value
+	}
+	s.Time = entry.Timestamp
+	s.MTM = entry.Value
+	s.Samples++
+
+	if entry.Value > s.DayHigh {
+		s.DayHigh = entry.Value
+	}
+	if entry.Value < s.DayLow {
+		s.DayLow = entry.Value
+	}
+	s.DistanceFromHigh = s.DayHigh - entry.Value
+	if s.DistanceFromHigh > s.MaxDrawdown {
+		s.MaxDrawdown = s.DistanceFromHigh
+	}
+
+	return *s
+}
+
+// Stats returns the current figures
+func (t *Tracker) Stats() Stats {
+	return t.stats
+}
+
+// Reset starts a new session
+func (t *Tracker) Reset() {
+	t.stats = Stats{}
+}
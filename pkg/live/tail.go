@@ -0,0 +1,83 @@
+package live
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+
+	"profitLossAndTradeInfoToDB/pkg/profitLossGraph"
+)
+
+// Tailer reads the rows appended to a profit/loss CSV file since the last poll
+type Tailer struct {
+	path    string
+	offset  int64
+	partial []byte
+}
+
+// NewTailer tails path from its beginning
+func NewTailer(path string) *Tailer {
+	return &Tailer{path: path}
+}
+
+// Poll returns the complete rows written since the previous call. truncated
+// reports that the file shrank (e.g. replaced by the next session's file) and
+// was read again from the start. A missing file yields no rows.
+func (t *Tailer) Poll() (entries []profitLossGraph.ProfitLossEntry, truncated bool, err error) {
+	file, err := os.Open(t.path)
+	if os.IsNotExist(err) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, false, err
+	}
+	if info.Size() < t.offset {
+		t.offset, t.partial, truncated = 0, nil, true
+	}
+	if info.Size() == t.offset {
+		return nil, truncated, nil
+	}
+
+	if _, err := file.Seek(t.offset, io.SeekStart); err != nil {
+		return nil, truncated, err
+	}
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, truncated, err
+	}
+	t.offset += int64(len(data))
+
+	data = append(t.partial, data...)
+	last := bytes.LastIndexByte(data, '\n')
+	if last < 0 {
+		// No complete line yet
+		t.partial = data
+		return nil, truncated, nil
+	}
+	t.partial = append([]byte(nil), data[last+1:]...)
+
+	reader := csv.NewReader(bytes.NewReader(data[:last+1]))
+	reader.FieldsPerRecord = -1
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, truncated, fmt.Errorf("failed to parse %s: %w", t.path, err)
+	}
+
+	for _, record := range records {
+		entry, err := profitLossGraph.ParseRecord(record)
+		if err != nil {
+			// Header or a malformed row; the file is still being written by another process
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries, truncated, nil
+}
@@ -0,0 +1,214 @@
+package live
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// Timings of the client connections
+const (
+	writeWait  = 5 * time.Second
+	pongWait   = time.Minute
+	pingPeriod = pongWait * 9 / 10
+)
+
+// sendBuffer is the number of messages queued for a client; one that falls
+// further behind is disconnected rather than slowing the others
+const sendBuffer = 16
+
+// tokenProtocol is the WebSocket subprotocol a browser, which cannot set an
+// Authorization header on the handshake, names before its token, as in
+// new WebSocket(url, ["bearer", token])
+const tokenProtocol = "bearer"
+
+// Hub is a WebSocket endpoint that broadcasts JSON messages to every connected client
+type Hub struct {
+	upgrader websocket.Upgrader
+	token    string
+	origins  []string
+
+	mu      sync.Mutex
+	clients map[*client]bool
+	last    []byte
+}
+
+// client is one connection and the messages waiting to be written to it
+type client struct {
+	conn *websocket.Conn
+	send chan []byte
+}
+
+// NewHub creates an empty hub. Clients must send token, unless it is empty,
+// as "Authorization: Bearer <token>", as the subprotocols "bearer" and the
+// token, or as the token query parameter. Browser connections are accepted
+// from the hub's own origin and from origins, e.g. https://dash.example.com.
+func NewHub(token string, origins []string) *Hub {
+	h := &Hub{token: token, origins: origins, clients: map[*client]bool{}}
+	h.upgrader.Subprotocols = []string{tokenProtocol}
+	h.upgrader.CheckOrigin = h.checkOrigin
+	return h
+}
+
+// authorized reports whether the request sends the hub's token
+func (h *Hub) authorized(r *http.Request) bool {
+	if h.token == "" {
+		return true
+	}
+	sent, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		if protocols := websocket.Subprotocols(r); len(protocols) == 2 && protocols[0] == tokenProtocol {
+			sent, ok = protocols[1], true
+		}
+	}
+	if !ok {
+		sent, ok = r.URL.Query().Get("token"), r.URL.Query().Has("token")
+	}
+	return ok && subtle.ConstantTimeCompare([]byte(sent), []byte(h.token)) == 1
+}
+
+// checkOrigin accepts clients that send no origin, such as scripts, and
+// browsers on a page of the hub's host or of one of its allowed origins
+func (h *Hub) checkOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	if strings.EqualFold(u.Host, r.Host) {
+		return true
+	}
+	return slices.ContainsFunc(h.origins, func(allowed string) bool {
+		return strings.EqualFold(strings.TrimSuffix(allowed, "/"), origin)
+	})
+}
+
+// ServeHTTP upgrades the request to a WebSocket and sends the latest message right away
+func (h *Hub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !h.checkOrigin(r) {
+		http.Error(w, "origin not allowed", http.StatusForbidden)
+		return
+	}
+	if !h.authorized(r) {
+		http.Error(w, "missing or invalid bearer token", http.StatusUnauthorized)
+		return
+	}
+
+	// Upgrade checks the handshake and version and writes any error response
+	conn, err := h.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+
+	c := &client{conn: conn, send: make(chan []byte, sendBuffer)}
+	h.mu.Lock()
+	h.clients[c] = true
+	if h.last != nil {
+		c.send <- h.last
+	}
+	h.mu.Unlock()
+
+	go h.writePump(c)
+	go h.readPump(c)
+}
+
+// Broadcast queues v as a JSON text message for every client
+func (h *Hub) Broadcast(v any) error {
+	payload, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.last = payload
+	for c := range h.clients {
+		select {
+		case c.send <- payload:
+		default:
+			h.drop(c)
+		}
+	}
+	return nil
+}
+
+// Close disconnects every client
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for c := range h.clients {
+		h.drop(c)
+	}
+}
+
+// drop forgets a client and has its writer close the connection; h.mu must be held
+func (h *Hub) drop(c *client) {
+	if h.clients[c] {
+		delete(h.clients, c)
+		close(c.send)
+	}
+}
+
+// writePump writes the queued messages and keep-alive pings to the client,
+// closing the connection once the client is dropped or stops accepting writes
+func (h *Hub) writePump(c *client) {
+	ticker := time.NewTicker(pingPeriod)
+	defer func() {
+		ticker.Stop()
+		c.conn.Close()
+	}()
+
+	for {
+		select {
+		case payload, ok := <-c.send:
+			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if !ok {
+				c.conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseGoingAway, ""))
+				return
+			}
+			if err := c.conn.WriteMessage(websocket.TextMessage, payload); err != nil {
+				h.remove(c)
+				return
+			}
+		case <-ticker.C:
+			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				h.remove(c)
+				return
+			}
+		}
+	}
+}
+
+// readPump reads until the client closes or stops answering pings; clients
+// only listen, so their messages are discarded, while reading answers their
+// pings and close frames
+func (h *Hub) readPump(c *client) {
+	c.conn.SetReadLimit(512)
+	c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	c.conn.SetPongHandler(func(string) error {
+		return c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	})
+	for {
+		if _, _, err := c.conn.ReadMessage(); err != nil {
+			break
+		}
+	}
+	h.remove(c)
+}
+
+func (h *Hub) remove(c *client) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.drop(c)
+}
@@ -0,0 +1,68 @@
+package live
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestHubHandshake(t *testing.T) {
+	hub := NewHub("secret", []string{"https://dash.example.com"})
+	defer hub.Close()
+	server := httptest.NewServer(hub)
+	defer server.Close()
+	endpoint := "ws" + strings.TrimPrefix(server.URL, "http")
+
+	tests := []struct {
+		name      string
+		query     string
+		header    http.Header
+		protocols []string
+		want      int
+	}{
+		{name: "authorization header", header: http.Header{"Authorization": {"Bearer secret"}}, want: http.StatusSwitchingProtocols},
+		{name: "subprotocol", protocols: []string{"bearer", "secret"}, want: http.StatusSwitchingProtocols},
+		{name: "query parameter", query: "?token=secret", want: http.StatusSwitchingProtocols},
+		{name: "no token", want: http.StatusUnauthorized},
+		{name: "wrong token", query: "?token=guess", want: http.StatusUnauthorized},
+		{name: "wrong subprotocol token", protocols: []string{"bearer", "guess"}, want: http.StatusUnauthorized},
+		{
+			name:   "own origin",
+			header: http.Header{"Authorization": {"Bearer secret"}, "Origin": {server.URL}},
+			want:   http.StatusSwitchingProtocols,
+		},
+		{
+			name:   "allowed origin",
+			header: http.Header{"Authorization": {"Bearer secret"}, "Origin": {"https://dash.example.com"}},
+			want:   http.StatusSwitchingProtocols,
+		},
+		{
+			name:   "other origin",
+			header: http.Header{"Authorization": {"Bearer secret"}, "Origin": {"https://evil.example.com"}},
+			want:   http.StatusForbidden,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dialer := websocket.Dialer{Subprotocols: tt.protocols}
+			conn, resp, err := dialer.Dial(endpoint+tt.query, tt.header)
+			if resp == nil {
+				t.Fatalf("no handshake response: %v", err)
+			}
+			if resp.StatusCode != tt.want {
+				t.Fatalf("status = %d, want %d", resp.StatusCode, tt.want)
+			}
+			if conn == nil {
+				return
+			}
+			defer conn.Close()
+			// The token is never echoed back as the chosen subprotocol
+			if len(tt.protocols) > 0 && conn.Subprotocol() != "bearer" {
+				t.Errorf("subprotocol = %q, want bearer", conn.Subprotocol())
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package market
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"profitLossAndTradeInfoToDB/constants"
+)
+
+var (
+	locationMu sync.RWMutex
+	location   *time.Location
+	fixedZone  bool // location is the fixed IST offset, not a tz database zone
+)
+
+// Location returns the exchange timezone, set by SetTimezone; by default
+// MARKET_TIMEZONE, falling back to a fixed IST offset when the tz database
+// is unavailable
+func Location() *time.Location {
+	locationMu.RLock()
+	loc := location
+	locationMu.RUnlock()
+	if loc != nil {
+		return loc
+	}
+
+	locationMu.Lock()
+	defer locationMu.Unlock()
+	if location == nil {
+		loc, err := time.LoadLocation(constants.MARKET_TIMEZONE)
+		if err != nil {
+			loc = time.FixedZone("IST", 5*60*60+30*60)
+		}
+		location, fixedZone = loc, err != nil
+	}
+	return location
+}
+
+// SetTimezone sets the timezone naive timestamps are read in and days are
+// bounded by; "" restores the default
+func SetTimezone(name string) error {
+	var loc *time.Location
+	if name != "" {
+		var err error
+		if loc, err = time.LoadLocation(name); err != nil {
+			return fmt.Errorf("unknown timezone %q: %w", name, err)
+		}
+	}
+
+	locationMu.Lock()
+	defer locationMu.Unlock()
+	location, fixedZone = loc, false
+	return nil
+}
+
+// Timezone returns the exchange timezone as MongoDB date operators take it:
+// its tz database name, or its UTC offset, e.g. "+05:30", when Location has
+// fallen back to the fixed IST offset
+func Timezone() string {
+	loc := Location()
+	locationMu.RLock()
+	fixed := fixedZone
+	locationMu.RUnlock()
+	if !fixed {
+		return loc.String()
+	}
+
+	_, offset := time.Now().In(loc).Zone()
+	sign := "+"
+	if offset < 0 {
+		sign, offset = "-", -offset
+	}
+	return fmt.Sprintf("%s%02d:%02d", sign, offset/3600, offset%3600/60)
+}
+
+// DayStart returns midnight in the exchange timezone of the day containing t
+func DayStart(t time.Time) time.Time {
+	local := t.In(Location())
+	return time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, Location())
+}
+
+var (
+	holidaysMu sync.RWMutex
+	holidays   = map[string]bool{}
+)
+
+// SetHolidays replaces the exchange holiday list (YYYY-MM-DD dates)
+func SetHolidays(dates []string) {
+	holidaysMu.Lock()
+	defer holidaysMu.Unlock()
+
+	holidays = make(map[string]bool, len(dates))
+	for _, date := range dates {
+		holidays[date] = true
+	}
+}
+
+// IsTradingDay reports whether the exchange is open on the day containing t;
+// days in the session calendar always are
+func IsTradingDay(t time.Time) bool {
+	if _, ok := specialSessions(t); ok {
+		return true
+	}
+
+	local := t.In(Location())
+	if local.Weekday() == time.Saturday || local.Weekday() == time.Sunday {
+		return false
+	}
+
+	holidaysMu.RLock()
+	defer holidaysMu.RUnlock()
+	return !holidays[local.Format("2006-01-02")]
+}
+
+// Close returns the end of the last session on the day containing t, the
+// regular close on days without sessions
+func Close(t time.Time) time.Time {
+	last := Regular
+	if sessions := Sessions(t); len(sessions) > 0 {
+		last = sessions[len(sessions)-1]
+	}
+	_, closing := last.Bounds(t)
+	return closing
+}
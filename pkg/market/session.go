@@ -0,0 +1,153 @@
+package market
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Session is a trading window of a day, as offsets from midnight in market time
+type Session struct {
+	Name  string
+	Open  time.Duration
+	Close time.Duration
+}
+
+// Regular is the normal equity and F&O session
+var Regular = Session{Name: "regular", Open: 9*time.Hour + 15*time.Minute, Close: 15*time.Hour + 30*time.Minute}
+
+// IsRegular reports whether s is the regular session
+func (s Session) IsRegular() bool {
+	return s == Regular
+}
+
+// Bounds returns the open and close of the session on the day containing t
+func (s Session) Bounds(t time.Time) (time.Time, time.Time) {
+	day := DayStart(t)
+	return day.Add(s.Open), day.Add(s.Close)
+}
+
+func (s Session) String() string {
+	return fmt.Sprintf("%s@%s-%s", s.Name, clock(s.Open), clock(s.Close))
+}
+
+func clock(d time.Duration) string {
+	return fmt.Sprintf("%02d:%02d", int(d.Hours()), int(d.Minutes())%60)
+}
+
+var (
+	specialMu sync.RWMutex
+	special   = map[string][]Session{}
+)
+
+// SetSessions replaces the special session calendar: days, keyed YYYY-MM-DD,
+// traded in other windows than the regular session, e.g. muhurat trading or
+// shifted hours. A listed day is a trading day even on a weekend or holiday.
+func SetSessions(days map[string][]Session) {
+	specialMu.Lock()
+	defer specialMu.Unlock()
+
+	special = make(map[string][]Session, len(days))
+	for date, sessions := range days {
+		sorted := append([]Session(nil), sessions...)
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i].Open < sorted[j].Open })
+		special[date] = sorted
+	}
+}
+
+// ParseSessions parses a session calendar such as
+// "2024-11-01=muhurat@18:00-19:00;2025-02-01=09:15-15:30". Sessions of one
+// day are separated by commas; a session without a name is called special.
+func ParseSessions(spec string) (map[string][]Session, error) {
+	days := map[string][]Session{}
+	for _, entry := range strings.Split(spec, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		date, windows, ok := strings.Cut(entry, "=")
+		date = strings.TrimSpace(date)
+		if !ok {
+			return nil, fmt.Errorf("invalid session entry %q, expected DATE=[NAME@]HH:MM-HH:MM", entry)
+		}
+		if _, err := time.Parse("2006-01-02", date); err != nil {
+			return nil, fmt.Errorf("invalid session date %q, expected YYYY-MM-DD", date)
+		}
+
+		for _, window := range strings.Split(windows, ",") {
+			session, err := parseSession(strings.TrimSpace(window))
+			if err != nil {
+				return nil, fmt.Errorf("invalid session on %s: %w", date, err)
+			}
+			days[date] = append(days[date], session)
+		}
+	}
+	return days, nil
+}
+
+func parseSession(window string) (Session, error) {
+	session := Session{Name: "special"}
+	if name, rest, ok := strings.Cut(window, "@"); ok {
+		session.Name, window = strings.TrimSpace(name), rest
+	}
+
+	open, closing, ok := strings.Cut(window, "-")
+	if !ok {
+		return session, fmt.Errorf("%q is not HH:MM-HH:MM", window)
+	}
+	var err error
+	if session.Open, err = parseClock(open); err != nil {
+		return session, err
+	}
+	if session.Close, err = parseClock(closing); err != nil {
+		return session, err
+	}
+	if session.Close <= session.Open {
+		return session, fmt.Errorf("%q closes before it opens", window)
+	}
+	return session, nil
+}
+
+func parseClock(value string) (time.Duration, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(value))
+	if err != nil {
+		return 0, fmt.Errorf("%q is not HH:MM", value)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// specialSessions returns the calendar entry of the day containing t, if any
+func specialSessions(t time.Time) ([]Session, bool) {
+	specialMu.RLock()
+	defer specialMu.RUnlock()
+
+	sessions, ok := special[t.In(Location()).Format("2006-01-02")]
+	return sessions, ok
+}
+
+// Sessions returns the sessions traded on the day containing t in opening
+// order: the calendar's entry for special days, the regular session on other
+// trading days and none otherwise
+func Sessions(t time.Time) []Session {
+	if sessions, ok := specialSessions(t); ok {
+		return sessions
+	}
+	if !IsTradingDay(t) {
+		return nil
+	}
+	return []Session{Regular}
+}
+
+// SessionAt returns the session in progress at t
+func SessionAt(t time.Time) (Session, bool) {
+	for _, session := range Sessions(t) {
+		open, closing := session.Bounds(t)
+		if !t.Before(open) && t.Before(closing) {
+			return session, true
+		}
+	}
+	return Session{}, false
+}
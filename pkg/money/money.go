@@ -0,0 +1,155 @@
+package money
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+)
+
+// Paise is an amount in paise. Totals are summed as Paise so rupee figures
+// reconcile to the paisa however many orders they add up. Amounts are stored
+// as int64 paise and rendered in JSON as rupees.
+type Paise int64
+
+// Rounding decides how amounts finer than a paisa are rounded
+type Rounding string
+
+const (
+	HalfUp   Rounding = "half-up"   // Halves away from zero, as on contract notes
+	HalfEven Rounding = "half-even" // Halves to the even paisa, as MongoDB's $round
+	Down     Rounding = "down"      // Towards zero
+)
+
+// ParseRounding validates a policy name; empty selects HalfUp
+func ParseRounding(name string) (Rounding, error) {
+	switch rounding := Rounding(name); rounding {
+	case "":
+		return HalfUp, nil
+	case HalfUp, HalfEven, Down:
+		return rounding, nil
+	}
+	return "", fmt.Errorf("unknown rounding %q, expected half-up, half-even or down", name)
+}
+
+var (
+	roundingMu sync.RWMutex
+	rounding   = HalfUp
+)
+
+// SetRounding replaces the rounding policy used from now on
+func SetRounding(r Rounding) {
+	roundingMu.Lock()
+	defer roundingMu.Unlock()
+	rounding = r
+}
+
+// CurrentRounding returns the rounding policy in use
+func CurrentRounding() Rounding {
+	roundingMu.RLock()
+	defer roundingMu.RUnlock()
+	return rounding
+}
+
+// round rounds v to an integer under the current policy. v is first snapped
+// to a millionth, so float noise such as 0.49999999 does not decide the result.
+func round(v float64) float64 {
+	v = math.Round(v*1e6) / 1e6
+	switch CurrentRounding() {
+	case HalfEven:
+		return math.RoundToEven(v)
+	case Down:
+		return math.Trunc(v)
+	default:
+		return math.Round(v)
+	}
+}
+
+// FromRupees converts a rupee amount, rounding it to the paisa
+func FromRupees(rupees float64) Paise {
+	return Paise(round(rupees * 100))
+}
+
+// Value is the amount of quantity units at price rupees each
+func Value(price float64, quantity float64) Paise {
+	return FromRupees(price * quantity)
+}
+
+// Sum adds rupee amounts to the paisa
+func Sum(amounts ...float64) float64 {
+	var total Paise
+	for _, amount := range amounts {
+		total += FromRupees(amount)
+	}
+	return total.Rupees()
+}
+
+// Round rounds a rupee amount to the paisa
+func Round(rupees float64) float64 {
+	return FromRupees(rupees).Rupees()
+}
+
+// RoundRupee rounds a rupee amount to the whole rupee, as STT and stamp duty are levied
+func RoundRupee(rupees float64) float64 {
+	return round(rupees)
+}
+
+// Rupees returns the amount in rupees
+func (p Paise) Rupees() float64 {
+	return float64(p) / 100
+}
+
+// Div divides the amount n ways, rounding to the paisa
+func (p Paise) Div(n int64) Paise {
+	return Paise(round(float64(p) / float64(n)))
+}
+
+func (p Paise) String() string {
+	sign := ""
+	if p < 0 {
+		sign, p = "-", -p
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, p/100, p%100)
+}
+
+// MarshalBSONValue stores the amount as int64 paise
+func (p Paise) MarshalBSONValue() (bsontype.Type, []byte, error) {
+	return bson.MarshalValue(int64(p))
+}
+
+// UnmarshalBSONValue reads int64 paise, or a double in rupees as amounts were
+// stored before they were kept in paise
+func (p *Paise) UnmarshalBSONValue(t bsontype.Type, data []byte) error {
+	value := bson.RawValue{Type: t, Value: data}
+	switch t {
+	case bson.TypeInt64:
+		*p = Paise(value.Int64())
+	case bson.TypeInt32:
+		*p = Paise(value.Int32())
+	case bson.TypeDouble:
+		*p = FromRupees(value.Double())
+	case bson.TypeNull:
+		*p = 0
+	default:
+		return fmt.Errorf("cannot decode %s into an amount", t)
+	}
+	return nil
+}
+
+// MarshalJSON renders the amount as a rupee number with two decimals
+func (p Paise) MarshalJSON() ([]byte, error) {
+	return []byte(p.String()), nil
+}
+
+// UnmarshalJSON reads a rupee number, rounding it to the paisa
+func (p *Paise) UnmarshalJSON(data []byte) error {
+	var rupees float64
+	if err := json.Unmarshal(data, &rupees); err != nil {
+		return err
+	}
+	*p = FromRupees(rupees)
+	return nil
+}
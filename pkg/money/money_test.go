@@ -0,0 +1,102 @@
+package money
+
+import "testing"
+
+func TestFromRupees(t *testing.T) {
+	tests := []struct {
+		rupees                 float64
+		halfUp, halfEven, down Paise
+	}{
+		{1.23, 123, 123, 123},
+		// 1.005 and 2.675 are just under the half as floats and still round as halves
+		{1.005, 101, 100, 100},
+		{2.675, 268, 268, 267},
+		{0.125, 13, 12, 12},
+		{-1.005, -101, -100, -100},
+		{-0.125, -13, -12, -12},
+		{0.1 + 0.2, 30, 30, 30},
+		{0, 0, 0, 0},
+	}
+	for _, tt := range tests {
+		for _, policy := range []struct {
+			rounding Rounding
+			want     Paise
+		}{{HalfUp, tt.halfUp}, {HalfEven, tt.halfEven}, {Down, tt.down}} {
+			SetRounding(policy.rounding)
+			if got := FromRupees(tt.rupees); got != policy.want {
+				t.Errorf("FromRupees(%v) under %s = %d, want %d", tt.rupees, policy.rounding, got, policy.want)
+			}
+		}
+	}
+	SetRounding(HalfUp)
+}
+
+func TestRoundingOfDerivedAmounts(t *testing.T) {
+	tests := []struct {
+		name     string
+		rounding Rounding
+		got      func() float64
+		want     float64
+	}{
+		{"value half up", HalfUp, func() float64 { return Value(10.05, 0.5).Rupees() }, 5.03},
+		{"value half even", HalfEven, func() float64 { return Value(10.05, 0.5).Rupees() }, 5.02},
+		{"value down", Down, func() float64 { return Value(99.999, 1).Rupees() }, 99.99},
+		{"sum to the paisa", HalfUp, func() float64 { return Sum(0.1, 0.2, 0.3) }, 0.6},
+		{"round", HalfUp, func() float64 { return Round(12.345) }, 12.35},
+		{"rupee half up", HalfUp, func() float64 { return RoundRupee(2.5) }, 3},
+		{"rupee half even", HalfEven, func() float64 { return RoundRupee(2.5) }, 2},
+		{"rupee down", Down, func() float64 { return RoundRupee(-2.7) }, -2},
+		{"div half up", HalfUp, func() float64 { return Paise(5).Div(2).Rupees() }, 0.03},
+		{"div half even", HalfEven, func() float64 { return Paise(5).Div(2).Rupees() }, 0.02},
+		{"div thirds", HalfUp, func() float64 { return Paise(100).Div(3).Rupees() }, 0.33},
+		{"div negative", HalfUp, func() float64 { return Paise(-5).Div(2).Rupees() }, -0.03},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetRounding(tt.rounding)
+			t.Cleanup(func() { SetRounding(HalfUp) })
+
+			if got := tt.got(); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPaiseString(t *testing.T) {
+	tests := []struct {
+		paise Paise
+		want  string
+	}{
+		{0, "0.00"},
+		{5, "0.05"},
+		{-5, "-0.05"},
+		{12345, "123.45"},
+		{-100000, "-1000.00"},
+	}
+	for _, tt := range tests {
+		if got := tt.paise.String(); got != tt.want {
+			t.Errorf("Paise(%d).String() = %q, want %q", int64(tt.paise), got, tt.want)
+		}
+	}
+}
+
+func TestParseRounding(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    Rounding
+		wantErr bool
+	}{
+		{"", HalfUp, false},
+		{"half-up", HalfUp, false},
+		{"half-even", HalfEven, false},
+		{"down", Down, false},
+		{"bankers", "", true},
+	}
+	for _, tt := range tests {
+		got, err := ParseRounding(tt.name)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseRounding(%q) = %q, %v; want %q, error %v", tt.name, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+)
+
+// Severity levels of a notification
+const (
+	SeverityInfo    = "info"
+	SeverityWarning = "warning"
+	SeverityError   = "error"
+)
+
+// Kinds of notification, used to route messages to channels
+const (
+	KindImportFailed    = "import_failed"
+	KindImportAbandoned = "import_abandoned"
+	KindMissingPL       = "missing_pl"
+	KindDrawdown        = "drawdown"
+	KindDailyReport     = "daily_report"
+)
+
+// Message is a single notification
+type Message struct {
+	Kind     string    `json:"kind,omitempty"`
+	Severity string    `json:"severity"`
+	Title    string    `json:"title"`
+	Text     string    `json:"text"`
+	Time     time.Time `json:"time"`
+}
+
+// Notifier delivers messages to a channel
+type Notifier interface {
+	Notify(ctx context.Context, msg Message) error
+}
+
+// LogNotifier writes messages to the standard logger
+type LogNotifier struct{}
+
+func (LogNotifier) Notify(ctx context.Context, msg Message) error {
+	log.Printf("[%s] %s: %s", msg.Severity, msg.Title, msg.Text)
+	return nil
+}
+
+// WebhookNotifier posts messages as JSON to a URL. The body carries a "text"
+// field, so Slack and Mattermost incoming webhooks accept it as is.
+type WebhookNotifier struct {
+	URL    string
+	Client *http.Client
+}
+
+// NewWebhookNotifier creates a notifier posting to url
+func NewWebhookNotifier(url string) *WebhookNotifier {
+	return &WebhookNotifier{URL: url, Client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+func (w *WebhookNotifier) Notify(ctx context.Context, msg Message) error {
+	body, err := json.Marshal(struct {
+		Message
+		Text string `json:"text"`
+	}{
+		Message: msg,
+		Text:    fmt.Sprintf("[%s] %s\n%s", msg.Severity, msg.Title, msg.Text),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode notification: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("invalid notification URL: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := w.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send notification: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("notification endpoint returned %s", resp.Status)
+	}
+	return nil
+}
+
+// Multi sends every message to all notifiers, returning the first error
+type Multi []Notifier
+
+func (m Multi) Notify(ctx context.Context, msg Message) error {
+	var firstErr error
+	for _, n := range m {
+		if err := n.Notify(ctx, msg); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
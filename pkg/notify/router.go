@@ -0,0 +1,126 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// severityRank orders severities so rules can match a minimum
+var severityRank = map[string]int{SeverityInfo: 0, SeverityWarning: 1, SeverityError: 2}
+
+// Rule sends the messages it matches to its channels
+type Rule struct {
+	Kinds       []string // Empty matches every kind
+	MinSeverity string   // Empty matches every severity
+	Channels    []string
+}
+
+func (r Rule) matches(msg Message) bool {
+	if len(r.Kinds) > 0 && !slices.Contains(r.Kinds, msg.Kind) {
+		return false
+	}
+	return r.MinSeverity == "" || severityRank[msg.Severity] >= severityRank[r.MinSeverity]
+}
+
+// ParseRules parses routing rules such as
+// "severity=error->ops; kind=daily_report->mail; kind=drawdown|missing_pl->phone|ops; *->log".
+// A rule's conditions are comma separated; alternatives are separated by |.
+func ParseRules(spec string) ([]Rule, error) {
+	var rules []Rule
+	for _, entry := range strings.Split(spec, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		match, channels, ok := strings.Cut(entry, "->")
+		if !ok {
+			return nil, fmt.Errorf("invalid route %q, expected conditions->channels", entry)
+		}
+
+		var rule Rule
+		for _, channel := range strings.Split(channels, "|") {
+			if channel = strings.TrimSpace(channel); channel != "" {
+				rule.Channels = append(rule.Channels, channel)
+			}
+		}
+		if len(rule.Channels) == 0 {
+			return nil, fmt.Errorf("route %q has no channels", entry)
+		}
+
+		for _, condition := range strings.Split(match, ",") {
+			condition = strings.TrimSpace(condition)
+			if condition == "*" || condition == "" {
+				continue
+			}
+			key, value, _ := strings.Cut(condition, "=")
+			switch strings.TrimSpace(key) {
+			case "kind":
+				for _, kind := range strings.Split(value, "|") {
+					rule.Kinds = append(rule.Kinds, strings.TrimSpace(kind))
+				}
+			case "severity":
+				value = strings.TrimSpace(value)
+				if _, ok := severityRank[value]; !ok {
+					return nil, fmt.Errorf("route %q: unknown severity %q", entry, value)
+				}
+				rule.MinSeverity = value
+			default:
+				return nil, fmt.Errorf("route %q: unknown condition %q, expected kind= or severity=", entry, condition)
+			}
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// Router delivers each message to the channels of every rule it matches, once
+// per channel; messages no rule matches go to the fallback
+type Router struct {
+	channels map[string]Notifier
+	rules    []Rule
+	fallback Notifier
+}
+
+// NewRouter checks that every rule names a known channel
+func NewRouter(channels map[string]Notifier, rules []Rule, fallback Notifier) (*Router, error) {
+	for _, rule := range rules {
+		for _, name := range rule.Channels {
+			if _, ok := channels[name]; !ok {
+				return nil, fmt.Errorf("route to unknown channel %q", name)
+			}
+		}
+	}
+	return &Router{channels: channels, rules: rules, fallback: fallback}, nil
+}
+
+func (r *Router) Notify(ctx context.Context, msg Message) error {
+	var targets []string
+	for _, rule := range r.rules {
+		if !rule.matches(msg) {
+			continue
+		}
+		for _, name := range rule.Channels {
+			if !slices.Contains(targets, name) {
+				targets = append(targets, name)
+			}
+		}
+	}
+
+	if len(targets) == 0 {
+		if r.fallback == nil {
+			return nil
+		}
+		return r.fallback.Notify(ctx, msg)
+	}
+
+	var firstErr error
+	for _, name := range targets {
+		if err := r.channels[name].Notify(ctx, msg); err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("channel %s: %w", name, err)
+		}
+	}
+	return firstErr
+}
@@ -0,0 +1,58 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// TelegramNotifier sends messages through a Telegram bot to one chat
+type TelegramNotifier struct {
+	Token  string
+	ChatID string
+	Client *http.Client
+}
+
+func init() {
+	// Target is BOT_TOKEN@CHAT_ID
+	Register("telegram", func(target string) (Notifier, error) {
+		i := strings.LastIndex(target, "@")
+		if i <= 0 || i == len(target)-1 {
+			return nil, fmt.Errorf("telegram target must be BOT_TOKEN@CHAT_ID")
+		}
+		return &TelegramNotifier{Token: target[:i], ChatID: target[i+1:], Client: &http.Client{Timeout: 10 * time.Second}}, nil
+	})
+}
+
+func (t *TelegramNotifier) Notify(ctx context.Context, msg Message) error {
+	body, err := json.Marshal(map[string]string{
+		"chat_id": t.ChatID,
+		"text":    fmt.Sprintf("[%s] %s\n%s", msg.Severity, msg.Title, msg.Text),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode notification: %w", err)
+	}
+
+	url := "https://api.telegram.org/bot" + t.Token + "/sendMessage"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("invalid telegram request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := t.Client.Do(req)
+	if err != nil {
+		// The URL holds the bot token; keep it out of the error
+		return fmt.Errorf("failed to send telegram notification")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("telegram returned %s", resp.Status)
+	}
+	return nil
+}
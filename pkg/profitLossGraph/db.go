@@ -2,58 +2,284 @@ package profitLossGraph
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"profitLossAndTradeInfoToDB/constants"
+	"profitLossAndTradeInfoToDB/pkg/archive"
+	"profitLossAndTradeInfoToDB/pkg/dataversion"
+	"profitLossAndTradeInfoToDB/pkg/market"
+	"sort"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+// ErrReadOnly is returned by every write when the repository was opened read-only
+var ErrReadOnly = errors.New("profit loss repository is in read-only mode")
+
 type Repository struct {
+	account    string
+	readOnly   bool
 	collection *mongo.Collection
+	equity     *mongo.Collection // Cumulative daily closes, see EquityPoint
+	archive    *mongo.Collection
+	planner    *archive.Planner
+	version    *dataversion.Counter
 }
 
-func NewRepository(db *mongo.Database) (*Repository, error) {
+// NewRepository creates a repository scoped to account; a read-only repository refuses all writes
+func NewRepository(db *mongo.Database, account string, readOnly bool) (*Repository, error) {
 	if db == nil {
 		return nil, fmt.Errorf("database connection is nil")
 	}
 
+	if account == "" {
+		account = constants.DEFAULT_ACCOUNT
+	}
+
 	return &Repository{
+		account:    account,
+		readOnly:   readOnly,
 		collection: db.Collection(constants.PROFITLOSS_SCHEMA),
+		equity:     db.Collection(constants.EQUITY_CURVE_SCHEMA),
+		version:    dataversion.New(db, account),
 	}, nil
 }
 
+// Account returns the account the repository is scoped to
+func (r *Repository) Account() string {
+	return r.account
+}
+
+// AttachArchive makes range queries also read entries moved to the archive database
+func (r *Repository) AttachArchive(db *mongo.Database) {
+	if db != nil {
+		r.archive = db.Collection(constants.PROFITLOSS_SCHEMA)
+		r.planner = archive.NewPlanner(db, r.account)
+	}
+}
+
+// EnsureIndexes creates the unique (account, timestamp) index the upserts rely
+// on, and the equity curve's (account, date) one
+func (r *Repository) EnsureIndexes(ctx context.Context) error {
+	if r.readOnly {
+		return nil
+	}
+
+	if err := r.migrate(ctx); err != nil {
+		return err
+	}
+	_, err := r.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "account", Value: 1}, {Key: "timestamp", Value: 1}},
+		Options: options.Index().SetUnique(true).SetName(profitLossIndex),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create profit loss index: %w", err)
+	}
+
+	_, err = r.equity.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "account", Value: 1}, {Key: "date", Value: 1}},
+		Options: options.Index().SetUnique(true).SetName("account_date_unique"),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create equity curve index: %w", err)
+	}
+
+	return nil
+}
+
+// profitLossIndex is the unique (account, timestamp) index of the samples
+const profitLossIndex = "account_timestamp_unique"
+
+// migrate readies samples written before the unique index existed: samples
+// without an account are given the default one, and of samples sharing an
+// (account, timestamp) only the last written is kept. It does nothing once
+// the index exists.
+func (r *Repository) migrate(ctx context.Context) error {
+	specs, err := r.collection.Indexes().ListSpecifications(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list profit loss indexes: %w", err)
+	}
+	for _, spec := range specs {
+		if spec.Name == profitLossIndex {
+			return nil
+		}
+	}
+
+	_, err = r.collection.UpdateMany(ctx,
+		bson.M{"account": nil},
+		bson.M{"$set": bson.M{"account": constants.DEFAULT_ACCOUNT}})
+	if err != nil {
+		return fmt.Errorf("failed to set the account of legacy samples: %w", err)
+	}
+
+	pipeline := bson.A{
+		bson.M{"$sort": bson.M{"_id": 1}},
+		bson.M{"$group": bson.M{
+			"_id":   bson.M{"account": "$account", "timestamp": "$timestamp"},
+			"ids":   bson.M{"$push": "$_id"},
+			"count": bson.M{"$sum": 1},
+		}},
+		bson.M{"$match": bson.M{"count": bson.M{"$gt": 1}}},
+	}
+	cursor, err := r.collection.Aggregate(ctx, pipeline, options.Aggregate().SetAllowDiskUse(true))
+	if err != nil {
+		return fmt.Errorf("failed to find duplicate samples: %w", err)
+	}
+	var groups []struct {
+		IDs []interface{} `bson:"ids"`
+	}
+	if err := cursor.All(ctx, &groups); err != nil {
+		return fmt.Errorf("failed to decode duplicate samples: %w", err)
+	}
+
+	var stale []interface{}
+	for _, group := range groups {
+		stale = append(stale, group.IDs[:len(group.IDs)-1]...)
+	}
+	for len(stale) > 0 {
+		batch := stale[:min(len(stale), 1000)]
+		if _, err := r.collection.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": batch}}); err != nil {
+			return fmt.Errorf("failed to delete duplicate samples: %w", err)
+		}
+		stale = stale[len(batch):]
+	}
+	return nil
+}
+
+// SaveProfitLossEntries upserts entries keyed by (account, timestamp), so
+// re-processing the same day overwrites existing points instead of duplicating them.
+// Entries are always stored under the repository's account.
 func (r *Repository) SaveProfitLossEntries(ctx context.Context, entries []ProfitLossEntry) error {
+	if r.readOnly {
+		return ErrReadOnly
+	}
 	if len(entries) == 0 {
 		return nil
 	}
 
-	// Convert entries to interface{} for bulk write
-	documents := make([]interface{}, len(entries))
+	models := make([]mongo.WriteModel, len(entries))
 	for i, entry := range entries {
-		documents[i] = entry
+		models[i] = mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"account": r.account, "timestamp": entry.Timestamp}).
+			SetUpdate(bson.M{
+				"$set": bson.M{
+					"value":  entry.Value,
+					"source": entry.Source,
+				},
+				// A re-import keeps the run that first stored the sample, so
+				// purging it removes only samples that run created
+				"$setOnInsert": bson.M{"import_run_id": entry.ImportRunID},
+			}).
+			SetUpsert(true)
 	}
 
-	// Perform bulk insert
-	_, err := r.collection.InsertMany(ctx, documents)
+	_, err := r.collection.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
 	if err != nil {
-		return fmt.Errorf("failed to insert entries: %w", err)
+		return fmt.Errorf("failed to upsert entries: %w", err)
 	}
 
-	return nil
+	// Live MTM samples reach charts without a summary recompute
+	return r.version.Bump(ctx)
 }
 
-// GetProfitLossByDateRange retrieves profit/loss entries within a date range
+// GetProfitLossByDateRange retrieves profit/loss entries within a date range,
+// including entries moved to the archive
 func (r *Repository) GetProfitLossByDateRange(ctx context.Context, startDate, endDate time.Time) ([]ProfitLossEntry, error) {
 	filter := bson.M{
+		"account": r.account,
 		"timestamp": bson.M{
 			"$gte": startDate,
 			"$lte": endDate,
 		},
 	}
 
-	cursor, err := r.collection.Find(ctx, filter)
+	entries, err := findEntries(ctx, r.collection, filter)
+	if err != nil {
+		return nil, err
+	}
+	if r.archive == nil {
+		return entries, nil
+	}
+
+	// Only the part of the range before the archive boundary is read from the archive
+	archived, ok, err := r.planner.ArchiveFilter(ctx, startDate, filter["timestamp"].(bson.M))
+	if err != nil {
+		return nil, fmt.Errorf("failed to plan archive query: %w", err)
+	}
+	if !ok {
+		return entries, nil
+	}
+
+	older, err := findEntries(ctx, r.archive, bson.M{"account": r.account, "timestamp": archived})
+	if err != nil {
+		return nil, err
+	}
+
+	// Samples are unique per timestamp; one copied but not yet deleted is in both tiers
+	seen := make(map[time.Time]bool, len(entries))
+	for _, entry := range entries {
+		seen[entry.Timestamp] = true
+	}
+	for _, entry := range older {
+		if !seen[entry.Timestamp] {
+			entries = append(entries, entry)
+		}
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Timestamp.Before(entries[j].Timestamp)
+	})
+	return entries, nil
+}
+
+// PurgeRun deletes the entries an import run first stored, hot or archived,
+// and returns their timestamps; with dryRun nothing is deleted. Entries a later
+// run re-imported still belong to the first run.
+func (r *Repository) PurgeRun(ctx context.Context, runID string, dryRun bool) ([]time.Time, error) {
+	if runID == "" {
+		return nil, fmt.Errorf("run ID is required")
+	}
+	if r.readOnly && !dryRun {
+		return nil, ErrReadOnly
+	}
+
+	filter := bson.M{"account": r.account, "import_run_id": runID}
+	collections := []*mongo.Collection{r.collection}
+	if r.archive != nil {
+		collections = append(collections, r.archive)
+	}
+
+	var times []time.Time
+	for _, collection := range collections {
+		entries, err := findEntries(ctx, collection, filter)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			times = append(times, entry.Timestamp)
+		}
+
+		if dryRun || len(entries) == 0 {
+			continue
+		}
+		if _, err := collection.DeleteMany(ctx, filter); err != nil {
+			return nil, fmt.Errorf("failed to delete entries of run %s: %w", runID, err)
+		}
+	}
+
+	if !dryRun && len(times) > 0 {
+		if err := r.RefreshEquityCurve(ctx, earliest(times)); err != nil {
+			return nil, err
+		}
+	}
+	return times, nil
+}
+
+func findEntries(ctx context.Context, collection *mongo.Collection, filter bson.M) ([]ProfitLossEntry, error) {
+	cursor, err := collection.Find(ctx, filter)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query profit loss: %w", err)
 	}
@@ -63,6 +289,83 @@ func (r *Repository) GetProfitLossByDateRange(ctx context.Context, startDate, en
 	if err := cursor.All(ctx, &entries); err != nil {
 		return nil, fmt.Errorf("failed to decode entries: %w", err)
 	}
-
 	return entries, nil
 }
+
+// GetDailyCloses returns the last MTM sample of each market day in [startDate, endDate), oldest first
+func (r *Repository) GetDailyCloses(ctx context.Context, startDate, endDate time.Time) ([]DailyClose, error) {
+	timestamp := bson.M{"$gte": startDate, "$lt": endDate}
+	closes, err := aggregateCloses(ctx, r.collection, r.account, timestamp)
+	if err != nil {
+		return nil, err
+	}
+	if r.archive == nil {
+		return closes, nil
+	}
+
+	archived, ok, err := r.planner.ArchiveFilter(ctx, startDate, timestamp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to plan archive query: %w", err)
+	}
+	if !ok {
+		return closes, nil
+	}
+
+	older, err := aggregateCloses(ctx, r.archive, r.account, archived)
+	if err != nil {
+		return nil, err
+	}
+
+	// A day split across tiers closes with its newer, hot samples
+	seen := make(map[time.Time]bool, len(closes))
+	for _, c := range closes {
+		seen[c.Date.UTC()] = true
+	}
+	for _, c := range older {
+		if !seen[c.Date.UTC()] {
+			closes = append(closes, c)
+		}
+	}
+
+	sort.SliceStable(closes, func(i, j int) bool {
+		return closes[i].Date.Before(closes[j].Date)
+	})
+	return closes, nil
+}
+
+// aggregateCloses computes the last sample per market day in one collection
+func aggregateCloses(ctx context.Context, collection *mongo.Collection, account string, timestamp bson.M) ([]DailyClose, error) {
+	pipeline := bson.A{
+		bson.M{"$match": bson.M{
+			"account":   account,
+			"timestamp": timestamp,
+		}},
+		bson.M{"$sort": bson.M{"timestamp": 1}},
+		bson.M{"$group": bson.M{
+			"_id": bson.M{"$dateTrunc": bson.M{
+				"date":     "$timestamp",
+				"unit":     "day",
+				"timezone": market.Timezone(),
+			}},
+			"value": bson.M{"$last": "$value"},
+		}},
+		bson.M{"$project": bson.M{"_id": 0, "date": "$_id", "value": 1}},
+		bson.M{"$sort": bson.M{"date": 1}},
+	}
+
+	cursor, err := collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate daily closes: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var closes []DailyClose
+	if err := cursor.All(ctx, &closes); err != nil {
+		return nil, fmt.Errorf("failed to decode daily closes: %w", err)
+	}
+	for i := range closes {
+		closes[i].Date = closes[i].Date.In(market.Location())
+	}
+
+	return closes, nil
+}
@@ -0,0 +1,66 @@
+package profitLossGraph
+
+import "time"
+
+// DrawdownPeriod is a stretch of the equity curve spent below a peak, from
+// the first day under it until the day it was regained
+type DrawdownPeriod struct {
+	Peak      float64    `json:"peak"`
+	PeakDate  time.Time  `json:"peak_date"` // Zero when the peak is the curve's start
+	Start     time.Time  `json:"start"`     // First day below the peak in the range
+	Trough    time.Time  `json:"trough"`
+	Depth     float64    `json:"depth"`               // Deepest drawdown of the period
+	Recovered *time.Time `json:"recovered,omitempty"` // Nil while still below the peak
+	Days      int        `json:"days"`                // Days below the peak, including any before the range
+}
+
+// DrawdownAnalysis sums up the drawdowns of a range of the equity curve
+type DrawdownAnalysis struct {
+	MaxDrawdown   float64          `json:"max_drawdown"`
+	LongestDays   int              `json:"longest_days"`
+	Current       float64          `json:"current"`
+	CurrentDays   int              `json:"current_days"`
+	Periods       []DrawdownPeriod `json:"periods"`
+	MaxToDate     float64          `json:"max_to_date"` // Deepest drawdown since the curve began
+	LongestToDate int              `json:"longest_to_date"`
+}
+
+// AnalyzeDrawdowns splits points, oldest first, into drawdown periods. A
+// period already under way on the first point keeps the peak and length it
+// had before the range.
+func AnalyzeDrawdowns(points []EquityPoint) DrawdownAnalysis {
+	analysis := DrawdownAnalysis{Periods: []DrawdownPeriod{}}
+	var open *DrawdownPeriod
+	for _, point := range points {
+		if point.Drawdown <= 0 {
+			if open != nil {
+				recovered := point.Date
+				open.Recovered = &recovered
+				analysis.Periods = append(analysis.Periods, *open)
+				open = nil
+			}
+			continue
+		}
+
+		if open == nil {
+			open = &DrawdownPeriod{Peak: point.Peak, PeakDate: point.PeakDate, Start: point.Date, Trough: point.Date}
+		}
+		if point.Drawdown > open.Depth {
+			open.Depth, open.Trough = point.Drawdown, point.Date
+		}
+		open.Days = point.DrawdownDays
+
+		analysis.MaxDrawdown = max(analysis.MaxDrawdown, point.Drawdown)
+		analysis.LongestDays = max(analysis.LongestDays, point.DrawdownDays)
+	}
+	if open != nil {
+		analysis.Periods = append(analysis.Periods, *open)
+	}
+
+	if len(points) > 0 {
+		last := points[len(points)-1]
+		analysis.Current, analysis.CurrentDays = last.Drawdown, last.DrawdownDays
+		analysis.MaxToDate, analysis.LongestToDate = last.MaxDrawdown, last.MaxDrawdownDays
+	}
+	return analysis
+}
@@ -0,0 +1,164 @@
+package profitLossGraph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"profitLossAndTradeInfoToDB/pkg/market"
+	"profitLossAndTradeInfoToDB/pkg/money"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// EquityPoint is one day of the equity curve: the day's closing MTM, the
+// running total of closes since the first day with data, and how far that
+// total is below its highest point so far. Drawdown durations count days
+// with a close, i.e. trading days.
+type EquityPoint struct {
+	Account    string    `bson:"account" json:"account"`
+	Date       time.Time `bson:"date" json:"date"`
+	DailyPnL   float64   `bson:"daily_pnl" json:"daily_pnl"`
+	Cumulative float64   `bson:"cumulative" json:"cumulative"`
+	Peak       float64   `bson:"peak" json:"peak"`
+	PeakDate   time.Time `bson:"peak_date" json:"peak_date"` // Zero while the curve has not risen above its start
+	Drawdown   float64   `bson:"drawdown" json:"drawdown"`
+
+	DrawdownDays    int     `bson:"drawdown_days" json:"drawdown_days"`         // Days below the peak, 0 at a new high
+	MaxDrawdown     float64 `bson:"max_drawdown" json:"max_drawdown"`           // Deepest drawdown up to this day
+	MaxDrawdownDays int     `bson:"max_drawdown_days" json:"max_drawdown_days"` // Longest drawdown up to this day
+}
+
+// EquityStore is implemented by stores that keep an equity curve;
+// Repository is one
+type EquityStore interface {
+	RefreshEquityCurve(ctx context.Context, from time.Time) error
+	GetEquityCurve(ctx context.Context, from, to time.Time) ([]EquityPoint, error)
+}
+
+// BuildEquityCurve accumulates daily closes, oldest first, onto the point of
+// the day before them; a zero prev starts the curve at zero
+func BuildEquityCurve(account string, prev EquityPoint, closes []DailyClose) []EquityPoint {
+	points := make([]EquityPoint, len(closes))
+	for i, c := range closes {
+		point := EquityPoint{
+			Account:         account,
+			Date:            c.Date,
+			DailyPnL:        c.Value,
+			Cumulative:      money.Sum(prev.Cumulative, c.Value),
+			Peak:            prev.Peak,
+			PeakDate:        prev.PeakDate,
+			MaxDrawdown:     prev.MaxDrawdown,
+			MaxDrawdownDays: prev.MaxDrawdownDays,
+		}
+		if point.Cumulative > point.Peak {
+			point.Peak, point.PeakDate = point.Cumulative, c.Date
+		}
+		point.Drawdown = money.Sum(point.Peak, -point.Cumulative)
+		if point.Drawdown > 0 {
+			point.DrawdownDays = prev.DrawdownDays + 1
+		}
+		point.MaxDrawdown = math.Max(point.MaxDrawdown, point.Drawdown)
+		point.MaxDrawdownDays = max(point.MaxDrawdownDays, point.DrawdownDays)
+
+		points[i] = point
+		prev = point
+	}
+	return points
+}
+
+// RefreshEquityCurve rebuilds the stored equity curve from the market day
+// containing from onwards, continuing from the stored point before it
+func (r *Repository) RefreshEquityCurve(ctx context.Context, from time.Time) error {
+	if r.readOnly {
+		return ErrReadOnly
+	}
+	from = market.DayStart(from)
+
+	var prev EquityPoint
+	err := r.equity.FindOne(ctx,
+		bson.M{"account": r.account, "date": bson.M{"$lt": from}},
+		options.FindOne().SetSort(bson.D{{Key: "date", Value: -1}}),
+	).Decode(&prev)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		return fmt.Errorf("failed to read equity curve: %w", err)
+	}
+
+	// Closes are read up to a day ahead, so a late evening sample is kept
+	closes, err := r.GetDailyCloses(ctx, from, market.DayStart(time.Now()).AddDate(0, 0, 2))
+	if err != nil {
+		return err
+	}
+	points := BuildEquityCurve(r.account, prev, closes)
+
+	if _, err := r.equity.DeleteMany(ctx, bson.M{"account": r.account, "date": bson.M{"$gte": from}}); err != nil {
+		return fmt.Errorf("failed to clear equity curve: %w", err)
+	}
+	if len(points) > 0 {
+		docs := make([]interface{}, len(points))
+		for i, point := range points {
+			docs[i] = point
+		}
+		if _, err := r.equity.InsertMany(ctx, docs); err != nil {
+			return fmt.Errorf("failed to store equity curve: %w", err)
+		}
+	}
+	return r.version.Bump(ctx)
+}
+
+// GetEquityCurve returns the stored equity curve of the days in [from, to), oldest first
+func (r *Repository) GetEquityCurve(ctx context.Context, from, to time.Time) ([]EquityPoint, error) {
+	filter := bson.M{"account": r.account, "date": bson.M{"$gte": from, "$lt": to}}
+	cursor, err := r.equity.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "date", Value: 1}}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query equity curve: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var points []EquityPoint
+	if err := cursor.All(ctx, &points); err != nil {
+		return nil, fmt.Errorf("failed to decode equity curve: %w", err)
+	}
+	for i := range points {
+		points[i].Date = points[i].Date.In(market.Location())
+		if !points[i].PeakDate.IsZero() {
+			points[i].PeakDate = points[i].PeakDate.In(market.Location())
+		}
+	}
+	return points, nil
+}
+
+// EquityCurve returns the equity curve of the days in [from, to) when the
+// store keeps one
+func (s *Service) EquityCurve(ctx context.Context, from, to time.Time) ([]EquityPoint, error) {
+	store, ok := s.repo.(EquityStore)
+	if !ok {
+		return nil, fmt.Errorf("the profit/loss store of account %s keeps no equity curve", s.repo.Account())
+	}
+	return store.GetEquityCurve(ctx, from, to)
+}
+
+// refreshEquity rebuilds the equity curve from the earliest of times, when the
+// store keeps one
+func (s *Service) refreshEquity(ctx context.Context, times []time.Time) error {
+	store, ok := s.repo.(EquityStore)
+	if !ok || len(times) == 0 {
+		return nil
+	}
+	return store.RefreshEquityCurve(ctx, earliest(times))
+}
+
+// earliest returns the first of a non-empty list of times
+func earliest(times []time.Time) time.Time {
+	first := times[0]
+	for _, t := range times[1:] {
+		if t.Before(first) {
+			first = t
+		}
+	}
+	return first
+}
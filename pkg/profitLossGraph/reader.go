@@ -3,11 +3,15 @@ package profitLossGraph
 import (
 	"encoding/csv"
 	"fmt"
+	"io"
 	"os"
 	"strconv"
 	"time"
 )
 
+// CSVHeader is the canonical column layout of a profit/loss CSV file
+var CSVHeader = []string{"timestamp", "value"}
+
 func ReadProfitLossFile(filename string) ([]ProfitLossEntry, error) {
 	file, err := os.Open(filename)
 	if err != nil {
@@ -15,7 +19,12 @@ func ReadProfitLossFile(filename string) ([]ProfitLossEntry, error) {
 	}
 	defer file.Close()
 
-	reader := csv.NewReader(file)
+	return ReadProfitLoss(file)
+}
+
+// ReadProfitLoss parses profit/loss CSV data from any reader
+func ReadProfitLoss(r io.Reader) ([]ProfitLossEntry, error) {
+	reader := csv.NewReader(r)
 	// Read the header
 	if _, err := reader.Read(); err != nil {
 		return nil, err
@@ -28,23 +37,36 @@ func ReadProfitLossFile(filename string) ([]ProfitLossEntry, error) {
 
 	entries := make([]ProfitLossEntry, 0, len(records))
 	for _, record := range records {
-		timestamp, err := time.Parse(time.RFC3339, record[0])
+		entry, err := ParseRecord(record)
 		if err != nil {
 			return nil, err
 		}
+		entries = append(entries, entry)
+	}
 
-		value, err := strconv.ParseFloat(record[1], 64)
-		if err != nil {
-			return nil, err
-		}
+	return entries, nil
+}
 
-		entries = append(entries, ProfitLossEntry{
-			Timestamp: timestamp,
-			Value:     value,
-		})
+// ParseRecord parses one timestamp,value row
+func ParseRecord(record []string) (ProfitLossEntry, error) {
+	if len(record) < 2 {
+		return ProfitLossEntry{}, fmt.Errorf("expected 2 columns, got %d", len(record))
 	}
 
-	return entries, nil
+	timestamp, err := time.Parse(time.RFC3339, record[0])
+	if err != nil {
+		return ProfitLossEntry{}, err
+	}
+
+	value, err := strconv.ParseFloat(record[1], 64)
+	if err != nil {
+		return ProfitLossEntry{}, err
+	}
+
+	return ProfitLossEntry{
+		Timestamp: timestamp,
+		Value:     value,
+	}, nil
 }
 
 // GetFileNameForDate generates the filename for a specific date
@@ -3,19 +3,50 @@ package profitLossGraph
 import (
 	"context"
 	"fmt"
+	"io"
 	"time"
+
+	"profitLossAndTradeInfoToDB/pkg/events"
 )
 
+// Store persists profit/loss entries for one account; Repository is the
+// MongoDB implementation
+type Store interface {
+	Account() string
+	SaveProfitLossEntries(ctx context.Context, entries []ProfitLossEntry) error
+}
+
 type Service struct {
-	repo *Repository
+	repo      Store
+	runID     string
+	afterSave func(ctx context.Context, dates []time.Time) error
+	events    *events.Bus
 }
 
-func NewService(repo *Repository) *Service {
+// NewService creates a Service that tags every stored entry with the given import run ID
+func NewService(repo Store, runID string) *Service {
 	return &Service{
-		repo: repo,
+		repo:  repo,
+		runID: runID,
 	}
 }
 
+// SetRunID tags entries saved from now on with a new import run ID
+func (s *Service) SetRunID(runID string) {
+	s.runID = runID
+}
+
+// OnSaved registers a callback run with the timestamps of every batch of saved
+// entries, e.g. to refresh the daily summaries that show the broker MTM
+func (s *Service) OnSaved(fn func(ctx context.Context, dates []time.Time) error) {
+	s.afterSave = fn
+}
+
+// PublishTo makes the service announce every saved batch on bus
+func (s *Service) PublishTo(bus *events.Bus) {
+	s.events = bus
+}
+
 // ProcessDailyProfitLoss reads the profit/loss file for a given date and stores it in the database
 func (s *Service) ProcessDailyProfitLoss(ctx context.Context, date time.Time) error {
 	filename := GetFileNameForDate(date)
@@ -25,13 +56,54 @@ func (s *Service) ProcessDailyProfitLoss(ctx context.Context, date time.Time) er
 		return fmt.Errorf("failed to read profit loss file: %w", err)
 	}
 
+	return s.saveEntries(ctx, entries, filename)
+}
+
+// ProcessProfitLoss reads profit/loss CSV data from r and stores it; source
+// names the file or URL it came from
+func (s *Service) ProcessProfitLoss(ctx context.Context, r io.Reader, source string) error {
+	entries, err := ReadProfitLoss(r)
+	if err != nil {
+		return fmt.Errorf("failed to read profit loss data from %s: %w", source, err)
+	}
+
+	return s.saveEntries(ctx, entries, source)
+}
+
+// ProcessEntries stores already parsed entries, e.g. rows tailed from a growing file
+func (s *Service) ProcessEntries(ctx context.Context, entries []ProfitLossEntry, source string) error {
+	return s.saveEntries(ctx, entries, source)
+}
+
+func (s *Service) saveEntries(ctx context.Context, entries []ProfitLossEntry, source string) error {
 	if len(entries) == 0 {
-		return fmt.Errorf("no entries found in file %s", filename)
+		return fmt.Errorf("no entries found in file %s", source)
+	}
+
+	for i := range entries {
+		entries[i].Source = source
+		entries[i].ImportRunID = s.runID
 	}
 
 	if err := s.repo.SaveProfitLossEntries(ctx, entries); err != nil {
 		return fmt.Errorf("failed to save profit loss entries: %w", err)
 	}
+	dates := make([]time.Time, len(entries))
+	for i, entry := range entries {
+		dates[i] = entry.Timestamp
+	}
+	if err := s.refreshEquity(ctx, dates); err != nil {
+		return fmt.Errorf("failed to refresh equity curve: %w", err)
+	}
+	s.events.Publish(ctx, events.Event{
+		Type: events.BatchInserted, Account: s.repo.Account(), Source: source, Kind: "profitLoss", Count: len(entries),
+	})
+
+	if s.afterSave != nil {
+		if err := s.afterSave(ctx, dates); err != nil {
+			return fmt.Errorf("failed to run post-save hook: %w", err)
+		}
+	}
 
 	return nil
 }
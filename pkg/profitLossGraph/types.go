@@ -2,12 +2,22 @@ package profitLossGraph
 
 import "time"
 
+// ProfitLossEntry is a single MTM sample, traceable to the file and import run that produced it
 type ProfitLossEntry struct {
-	Timestamp time.Time
-	Value     float64
+	Account     string    `bson:"account" json:"account"`
+	Timestamp   time.Time `bson:"timestamp" json:"timestamp"`
+	Value       float64   `bson:"value" json:"value"`
+	Source      string    `bson:"source,omitempty" json:"source,omitempty"`
+	ImportRunID string    `bson:"import_run_id,omitempty" json:"import_run_id,omitempty"`
 }
 
 type DailyProfitLoss struct {
 	Date    time.Time
 	Entries []ProfitLossEntry
 }
+
+// DailyClose is the final MTM value of one trading day
+type DailyClose struct {
+	Date  time.Time `bson:"date" json:"date"`
+	Value float64   `bson:"value" json:"value"`
+}
@@ -0,0 +1,87 @@
+package reconcile
+
+import (
+	"context"
+	"math"
+	"time"
+
+	orderbook "profitLossAndTradeInfoToDB/orderbooks"
+	"profitLossAndTradeInfoToDB/pkg/charges"
+	"profitLossAndTradeInfoToDB/pkg/money"
+	"profitLossAndTradeInfoToDB/pkg/profitLossGraph"
+	"profitLossAndTradeInfoToDB/pkg/trades"
+)
+
+// Options controls how computed and broker P&L are compared
+type Options struct {
+	Charges   charges.Schedule // Rates by date, so each day is charged at the rates then in force
+	Tolerance float64          // absolute rupee difference allowed before a day is flagged
+	// BrokerNetOfCharges compares the broker MTM with realized P&L minus
+	// charges; otherwise it is compared with the gross realized P&L
+	BrokerNetOfCharges bool
+}
+
+// DayCheck is the comparison of one trading day
+type DayCheck struct {
+	Date        time.Time         `json:"date"`
+	Orders      int               `json:"orders"`
+	RealizedPnL money.Paise       `json:"realized_pnl"`
+	Charges     charges.Breakdown `json:"charges"`
+	Computed    money.Paise       `json:"computed"`
+	BrokerMTM   *money.Paise      `json:"broker_mtm,omitempty"`
+	Difference  money.Paise       `json:"difference"`
+	OpenLots    int               `json:"open_lots"`
+	Diverges    bool              `json:"diverges"`
+}
+
+// CrossCheck compares FIFO-matched P&L against the broker's final MTM for
+// every day in [from, to). Days with orders but no MTM (or MTM but no orders)
+// are always flagged, as they usually mean a missing file.
+func CrossCheck(ctx context.Context, ob *orderbook.OrderBook, pl *profitLossGraph.Repository, from, to time.Time, opts Options) ([]DayCheck, error) {
+	closes, err := pl.GetDailyCloses(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+	mtmByDay := make(map[string]money.Paise, len(closes))
+	for _, c := range closes {
+		mtmByDay[c.Date.Format("2006-01-02")] = money.FromRupees(c.Value)
+	}
+
+	var checks []DayCheck
+	for day := from; day.Before(to); day = day.AddDate(0, 0, 1) {
+		orders, err := ob.GetOrdersByDateRange(ctx, day, day.AddDate(0, 0, 1))
+		if err != nil {
+			return nil, err
+		}
+
+		mtm, hasMTM := mtmByDay[day.Format("2006-01-02")]
+		if len(orders) == 0 && !hasMTM {
+			continue
+		}
+
+		fills := orderbook.Fills(orders)
+		roundTrips, openLots := trades.MatchFIFO(fills)
+
+		check := DayCheck{
+			Date:        day,
+			Orders:      len(orders),
+			RealizedPnL: trades.RealizedPnL(roundTrips),
+			Charges:     opts.Charges.Compute(fills),
+			OpenLots:    len(openLots),
+		}
+		check.Computed = check.RealizedPnL
+		if opts.BrokerNetOfCharges {
+			check.Computed -= check.Charges.Total
+		}
+
+		if hasMTM {
+			check.BrokerMTM = &mtm
+			check.Difference = mtm - check.Computed
+		}
+		check.Diverges = !hasMTM || len(orders) == 0 || math.Abs(check.Difference.Rupees()) > opts.Tolerance
+
+		checks = append(checks, check)
+	}
+
+	return checks, nil
+}
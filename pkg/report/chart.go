@@ -0,0 +1,197 @@
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"image/color"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/plotter"
+	"gonum.org/v1/plot/vg"
+	"gonum.org/v1/plot/vg/draw"
+
+	"profitLossAndTradeInfoToDB/pkg/display"
+	"profitLossAndTradeInfoToDB/pkg/market"
+)
+
+var (
+	chartLine     = color.RGBA{0x1f, 0x77, 0xb4, 0xff}
+	chartBaseline = color.RGBA{0x99, 0x99, 0x99, 0xff}
+	chartGrid     = color.RGBA{0xe0, 0xe0, 0xe0, 0xff}
+)
+
+// WriteChart renders points as a line chart to path, as SVG or PNG by its
+// extension, with the title, a time axis and an axis of amounts
+func WriteChart(path, title string, points []Point, width, height int) error {
+	if len(points) == 0 {
+		return fmt.Errorf("no data to chart for %s", title)
+	}
+	format := strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
+	if format != "svg" && format != "png" {
+		return fmt.Errorf("unsupported chart format %q, expected .svg or .png", filepath.Ext(path))
+	}
+
+	out, err := renderPlot(newChart(title, points), width, height, format)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, out, 0o644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// renderPlot draws p as width x height pixels in format: svg, png or pdf
+func renderPlot(p *plot.Plot, width, height int, format string) ([]byte, error) {
+	if width < 120 || height < 80 {
+		return nil, fmt.Errorf("chart size %dx%d is too small", width, height)
+	}
+	w, err := p.WriterTo(pixels(width), pixels(height), format)
+	if err != nil {
+		return nil, fmt.Errorf("failed to draw chart: %w", err)
+	}
+	var b bytes.Buffer
+	if _, err := w.WriteTo(&b); err != nil {
+		return nil, fmt.Errorf("failed to encode chart as %s: %w", format, err)
+	}
+	return b.Bytes(), nil
+}
+
+// svgChart is the chart of points as an SVG element to inline in a page
+func svgChart(title string, points []Point, width, height int) (string, error) {
+	if len(points) == 0 {
+		return "", nil
+	}
+	out, err := renderPlot(newChart(title, points), width, height, "svg")
+	if err != nil {
+		return "", err
+	}
+	// Drop the XML prolog, which is not allowed inside HTML
+	svg := string(out)
+	if i := strings.Index(svg, "<svg"); i > 0 {
+		svg = svg[i:]
+	}
+	return svg, nil
+}
+
+// pixels is the length of n pixels at the 96 DPI images are rendered at
+func pixels(n int) vg.Length {
+	return vg.Length(n) * vg.Inch / 96
+}
+
+// newChart plots the values of points against their time, with a dashed zero
+// line when the values cross it
+func newChart(title string, points []Point) *plot.Plot {
+	p := plot.New()
+	p.Title.Text = title
+	p.Y.Label.Text = "Rupees"
+	p.Y.Tick.Marker = amountTicks{}
+	p.Add(&plotter.Grid{
+		Vertical:   draw.LineStyle{Color: chartGrid, Width: vg.Points(0.5)},
+		Horizontal: draw.LineStyle{Color: chartGrid, Width: vg.Points(0.5)},
+	})
+
+	xys := make(plotter.XYs, len(points))
+	low, high := bounds(values(points))
+	for i, point := range points {
+		xys[i] = plotter.XY{X: float64(point.Date.Unix()), Y: point.Value}
+	}
+	first, last := xys[0].X, xys[len(xys)-1].X
+
+	if low < 0 && high > 0 {
+		zero := plotter.XYs{{X: first, Y: 0}, {X: last, Y: 0}}
+		baseline, _ := plotter.NewLine(zero)
+		baseline.Color = chartBaseline
+		baseline.Dashes = []vg.Length{vg.Points(4), vg.Points(4)}
+		p.Add(baseline)
+	}
+	line, _ := plotter.NewLine(xys)
+	line.Color = chartLine
+	line.Width = vg.Points(1.5)
+	p.Add(line)
+	if len(xys) == 1 {
+		// A single sample has no line to draw; mark it instead
+		dot, _ := plotter.NewScatter(xys)
+		dot.Color = chartLine
+		p.Add(dot)
+		p.X.Min, p.X.Max = first-12*3600, last+12*3600
+	}
+
+	// Samples within a session are read by time of day; daily points, which
+	// fall on the start of their market day, by date
+	daily := last-first >= 24*3600 || points[0].Date.Equal(market.DayStart(points[0].Date))
+	p.X.Tick.Marker = timeTicks{daily: daily}
+	p.X.Label.Text = "Time"
+	if daily {
+		p.X.Label.Text = "Date"
+	}
+	return p
+}
+
+// amountTicks labels the default ticks of an axis of rupees with the display
+// locale's grouping
+type amountTicks struct{}
+
+func (amountTicks) Ticks(min, max float64) []plot.Tick {
+	ticks := plot.DefaultTicks{}.Ticks(min, max)
+	for i := range ticks {
+		if !ticks[i].IsMinor() {
+			ticks[i].Label = display.Number(ticks[i].Value, 0)
+		}
+	}
+	return ticks
+}
+
+// timeTickSteps are the spacings a time axis may be marked at
+var timeTickSteps = []time.Duration{
+	5 * time.Minute, 15 * time.Minute, 30 * time.Minute, time.Hour, 2 * time.Hour, 6 * time.Hour,
+	24 * time.Hour, 2 * 24 * time.Hour, 7 * 24 * time.Hour, 14 * 24 * time.Hour, 28 * 24 * time.Hour,
+	91 * 24 * time.Hour, 364 * 24 * time.Hour,
+}
+
+// timeTicks marks an axis of Unix seconds at whole steps of time, the widest
+// step that still gives a handful of labels: times of day in the display
+// timezone, or whole market days when daily
+type timeTicks struct {
+	daily bool
+}
+
+func (t timeTicks) Ticks(min, max float64) []plot.Tick {
+	location, format, steps := display.Location(), "15:04", timeTickSteps
+	if t.daily {
+		location, format = market.Location(), "02 Jan"
+		for len(steps) > 1 && steps[0] < 24*time.Hour {
+			steps = steps[1:]
+		}
+	}
+	from := time.Unix(int64(min), 0).In(location)
+	to := time.Unix(int64(max), 0).In(location)
+	span := to.Sub(from)
+
+	step := steps[len(steps)-1]
+	for _, s := range steps {
+		if span/s <= 8 {
+			step = s
+			break
+		}
+	}
+
+	var ticks []plot.Tick
+	y, m, d := from.Date()
+	tick := time.Date(y, m, d, 0, 0, 0, 0, location)
+	for !tick.After(to) {
+		if !tick.Before(from) {
+			ticks = append(ticks, plot.Tick{Value: float64(tick.Unix()), Label: tick.Format(format)})
+		}
+		if step >= 24*time.Hour {
+			tick = tick.AddDate(0, 0, int(step/(24*time.Hour)))
+		} else {
+			tick = tick.Add(step)
+		}
+	}
+	return ticks
+}
@@ -0,0 +1,202 @@
+package report
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"profitLossAndTradeInfoToDB/pkg/money"
+	"profitLossAndTradeInfoToDB/pkg/trades"
+)
+
+// Untagged groups trades without a strategy
+const Untagged = "untagged"
+
+// AccountResult is one side of a comparison
+type AccountResult struct {
+	Account     string
+	Stats       Stats
+	MaxDrawdown float64 // Deepest peak-to-trough fall of the cumulative daily P&L
+}
+
+// DayDiff is the realized P&L of both accounts on a day either traded
+type DayDiff struct {
+	Date time.Time
+	A    float64
+	B    float64
+	Diff float64 // A - B
+}
+
+// StrategyDiff compares the closed trades of one strategy
+type StrategyDiff struct {
+	Strategy string
+	TradesA  int
+	TradesB  int
+	PnLA     money.Paise
+	PnLB     money.Paise
+	WinRateA float64 // Percent
+	WinRateB float64
+	Diff     money.Paise // PnLA - PnLB
+}
+
+// Comparison sets two accounts against each other over the same period, e.g.
+// live execution against the paper account it follows
+type Comparison struct {
+	A, B       AccountResult
+	From, To   time.Time
+	CommonDays int // Days both accounts traded
+	// Correlation is the Pearson correlation of daily P&L over the common
+	// days; nil with fewer than two common days or a flat series
+	Correlation *float64
+	// RelativeDrawdown is the deepest fall of cumulative A - B, i.e. how far
+	// A fell behind B at worst
+	RelativeDrawdown float64
+	Days             []DayDiff
+	Strategies       []StrategyDiff
+}
+
+// Compare compares the reports of two accounts; strategyA and strategyB name
+// the strategy of a closed trade, empty for Untagged
+func Compare(a, b *Data, strategyA, strategyB func(trades.RoundTrip) string) *Comparison {
+	c := &Comparison{
+		A:    AccountResult{Account: a.Account, Stats: a.Stats, MaxDrawdown: maxDrawdown(a.DailyPnL)},
+		B:    AccountResult{Account: b.Account, Stats: b.Stats, MaxDrawdown: maxDrawdown(b.DailyPnL)},
+		From: a.From,
+		To:   a.To,
+	}
+
+	days := map[time.Time]*DayDiff{}
+	var commonA, commonB []float64
+	for _, p := range a.DailyPnL {
+		days[p.Date.UTC()] = &DayDiff{Date: p.Date, A: p.Value}
+	}
+	for _, p := range b.DailyPnL {
+		if day, ok := days[p.Date.UTC()]; ok {
+			day.B = p.Value
+			commonA = append(commonA, day.A)
+			commonB = append(commonB, p.Value)
+			continue
+		}
+		days[p.Date.UTC()] = &DayDiff{Date: p.Date, B: p.Value}
+	}
+	c.CommonDays = len(commonA)
+	c.Correlation = correlation(commonA, commonB)
+
+	for _, day := range days {
+		day.Diff = day.A - day.B
+		c.Days = append(c.Days, *day)
+	}
+	sort.Slice(c.Days, func(i, j int) bool { return c.Days[i].Date.Before(c.Days[j].Date) })
+
+	relative := make([]Point, len(c.Days))
+	for i, day := range c.Days {
+		relative[i] = Point{Date: day.Date, Value: day.Diff}
+	}
+	c.RelativeDrawdown = maxDrawdown(relative)
+
+	c.Strategies = compareStrategies(a.Trades, b.Trades, strategyA, strategyB)
+	return c
+}
+
+// maxDrawdown returns the deepest fall of the running sum of daily from its
+// peak, the start counting as a peak of zero
+func maxDrawdown(daily []Point) float64 {
+	var equity, peak, deepest float64
+	for _, p := range daily {
+		equity += p.Value
+		if equity > peak {
+			peak = equity
+		}
+		if peak-equity > deepest {
+			deepest = peak - equity
+		}
+	}
+	return deepest
+}
+
+// correlation returns the Pearson correlation of two equal length series
+func correlation(x, y []float64) *float64 {
+	n := float64(len(x))
+	if len(x) < 2 {
+		return nil
+	}
+
+	var sumX, sumY float64
+	for i := range x {
+		sumX += x[i]
+		sumY += y[i]
+	}
+	meanX, meanY := sumX/n, sumY/n
+
+	var cov, varX, varY float64
+	for i := range x {
+		dx, dy := x[i]-meanX, y[i]-meanY
+		cov += dx * dy
+		varX += dx * dx
+		varY += dy * dy
+	}
+	if varX == 0 || varY == 0 {
+		return nil
+	}
+
+	r := cov / math.Sqrt(varX*varY)
+	return &r
+}
+
+// compareStrategies totals closed trades per strategy on both sides, strategies sorted by name
+func compareStrategies(a, b []trades.RoundTrip, strategyA, strategyB func(trades.RoundTrip) string) []StrategyDiff {
+	type tally struct {
+		trades, wins int
+		pnl          money.Paise
+	}
+	count := func(trips []trades.RoundTrip, strategy func(trades.RoundTrip) string) map[string]*tally {
+		tallies := map[string]*tally{}
+		for _, trip := range trips {
+			name := strategy(trip)
+			if name == "" {
+				name = Untagged
+			}
+			t, ok := tallies[name]
+			if !ok {
+				t = &tally{}
+				tallies[name] = t
+			}
+			t.trades++
+			t.pnl += trip.RealizedPnL
+			if trip.RealizedPnL > 0 {
+				t.wins++
+			}
+		}
+		return tallies
+	}
+	winRate := func(t *tally) float64 {
+		if t == nil || t.trades == 0 {
+			return 0
+		}
+		return float64(t.wins) / float64(t.trades) * 100
+	}
+
+	talliesA, talliesB := count(a, strategyA), count(b, strategyB)
+	names := map[string]bool{}
+	for name := range talliesA {
+		names[name] = true
+	}
+	for name := range talliesB {
+		names[name] = true
+	}
+
+	var diffs []StrategyDiff
+	for name := range names {
+		d := StrategyDiff{Strategy: name, WinRateA: winRate(talliesA[name]), WinRateB: winRate(talliesB[name])}
+		if t := talliesA[name]; t != nil {
+			d.TradesA, d.PnLA = t.trades, t.pnl
+		}
+		if t := talliesB[name]; t != nil {
+			d.TradesB, d.PnLB = t.trades, t.pnl
+		}
+		d.Diff = d.PnLA - d.PnLB
+		diffs = append(diffs, d)
+	}
+	sort.Slice(diffs, func(i, j int) bool { return diffs[i].Strategy < diffs[j].Strategy })
+	return diffs
+}
@@ -0,0 +1,133 @@
+package report
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/font"
+	"gonum.org/v1/plot/vg"
+	"gonum.org/v1/plot/vg/draw"
+
+	"profitLossAndTradeInfoToDB/pkg/display"
+)
+
+// Heatmap is a grid of amounts to shade, Cells[row][column]; nil cells have
+// no data
+type Heatmap struct {
+	Rows    []string
+	Columns []string
+	Cells   [][]*float64
+}
+
+var heatmapEmpty = color.RGBA{0xee, 0xee, 0xee, 0xff}
+
+// WriteHeatmap renders the grid to path, as SVG or PNG by its extension, with
+// the title, row and column labels and each cell's amount, shading profits
+// green and losses red by their size against the largest
+func WriteHeatmap(path, title string, grid Heatmap, width, height int) error {
+	if len(grid.Rows) == 0 || len(grid.Columns) == 0 {
+		return fmt.Errorf("no data to chart for %s", title)
+	}
+	format := strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
+	if format != "svg" && format != "png" {
+		return fmt.Errorf("unsupported chart format %q, expected .svg or .png", filepath.Ext(path))
+	}
+
+	p := plot.New()
+	p.Title.Text = title
+	p.Add(heatmapCells{grid: grid, largest: grid.largest()})
+	p.NominalX(grid.Columns...)
+	p.X.Tick.Label.Rotation = math.Pi / 3
+	p.X.Tick.Label.XAlign = draw.XRight
+	p.X.Tick.Label.YAlign = draw.YCenter
+	// Nominal rows count up from the bottom; keep the first row on top
+	rows := make([]string, len(grid.Rows))
+	for i, row := range grid.Rows {
+		rows[len(rows)-1-i] = row
+	}
+	p.NominalY(rows...)
+
+	out, err := renderPlot(p, width, height, format)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, out, 0o644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// heatmapColor shades v against the largest absolute amount of the grid
+func heatmapColor(v *float64, largest float64) color.RGBA {
+	if v == nil {
+		return heatmapEmpty
+	}
+	strength := 0.0
+	if largest > 0 {
+		strength = math.Min(1, math.Abs(*v)/largest)
+	}
+	// Blend from white, keeping cells with data visibly tinted
+	blend := func(full uint8) uint8 {
+		return uint8(255 - (255-float64(full))*(0.15+0.85*strength))
+	}
+	if *v < 0 {
+		return color.RGBA{blend(0xb0), blend(0x00), blend(0x20), 0xff}
+	}
+	return color.RGBA{blend(0x1b), blend(0x7f), blend(0x3b), 0xff}
+}
+
+func (g Heatmap) largest() float64 {
+	var largest float64
+	for _, row := range g.Cells {
+		for _, v := range row {
+			if v != nil {
+				largest = math.Max(largest, math.Abs(*v))
+			}
+		}
+	}
+	return largest
+}
+
+// heatmapCells draws the cells of a grid at the integer positions of a plot's
+// nominal axes, the first row at the top
+type heatmapCells struct {
+	grid    Heatmap
+	largest float64
+}
+
+func (h heatmapCells) Plot(c draw.Canvas, p *plot.Plot) {
+	x, y := p.Transforms(&c)
+	label := draw.TextStyle{
+		Color:   color.Black,
+		Font:    font.From(plot.DefaultFont, 7),
+		XAlign:  draw.XCenter,
+		YAlign:  draw.YCenter,
+		Handler: p.TextHandler,
+	}
+	// Leave a gap of background between cells
+	gap := vg.Points(0.5)
+	for r, row := range h.grid.Cells {
+		mid := float64(len(h.grid.Rows) - 1 - r)
+		y0, y1 := y(mid-0.5)+gap, y(mid+0.5)-gap
+		for col, v := range row {
+			x0, x1 := x(float64(col)-0.5)+gap, x(float64(col)+0.5)-gap
+			c.FillPolygon(heatmapColor(v, h.largest), []vg.Point{{X: x0, Y: y0}, {X: x1, Y: y0}, {X: x1, Y: y1}, {X: x0, Y: y1}})
+			if v == nil {
+				continue
+			}
+			if amount := display.Number(*v, 0); label.Width(amount) < x1-x0 {
+				c.FillText(label, vg.Point{X: (x0 + x1) / 2, Y: (y0 + y1) / 2}, amount)
+			}
+		}
+	}
+}
+
+// DataRange spans the cells, each a unit wide around its position
+func (h heatmapCells) DataRange() (xmin, xmax, ymin, ymax float64) {
+	return -0.5, float64(len(h.grid.Columns)) - 0.5, -0.5, float64(len(h.grid.Rows)) - 0.5
+}
@@ -0,0 +1,169 @@
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"github.com/go-fonts/liberation/liberationmonoregular"
+	"github.com/go-fonts/liberation/liberationsansbold"
+	"github.com/go-fonts/liberation/liberationsansregular"
+	"github.com/go-pdf/fpdf"
+
+	"profitLossAndTradeInfoToDB/pkg/display"
+)
+
+// A4 page size and margins in points
+const (
+	pdfWidth  = 595.0
+	pdfHeight = 842.0
+	pdfMargin = 50.0
+)
+
+// pdfFonts are embedded as UTF-8 fonts so symbols and account names outside
+// ASCII print as they are
+var pdfFonts = []struct {
+	family, style string
+	ttf           []byte
+}{
+	{"sans", "", liberationsansregular.TTF},
+	{"sans", "B", liberationsansbold.TTF},
+	{"mono", "", liberationmonoregular.TTF},
+}
+
+const (
+	fontText = iota
+	fontBold
+	fontMono
+)
+
+// pdfDoc lays out lines of text and charts top to bottom on A4 pages
+type pdfDoc struct {
+	pdf    *fpdf.Fpdf
+	charts int
+}
+
+func newPDFDoc() *pdfDoc {
+	pdf := fpdf.New("P", "pt", "A4", "")
+	pdf.SetMargins(pdfMargin, pdfMargin, pdfMargin)
+	pdf.SetAutoPageBreak(true, pdfMargin)
+	for _, font := range pdfFonts {
+		pdf.AddUTF8FontFromBytes(font.family, font.style, font.ttf)
+	}
+	pdf.AddPage()
+	return &pdfDoc{pdf: pdf}
+}
+
+// space moves down by height
+func (d *pdfDoc) space(height float64) {
+	d.pdf.Ln(height)
+}
+
+// line writes one line of text, starting a new page when it does not fit
+func (d *pdfDoc) line(font int, size float64, text string) {
+	d.pdf.SetFont(pdfFonts[font].family, pdfFonts[font].style, size)
+	d.pdf.CellFormat(0, size*1.4, text, "", 1, "L", false, 0, "")
+}
+
+// chart draws points as a titled line chart spanning the page width
+func (d *pdfDoc) chart(title string, points []Point, height float64) error {
+	width := pdfWidth - 2*pdfMargin
+	// A point is 4/3 of a pixel at the 96 DPI charts are rendered at
+	image, err := renderPlot(newChart(title, points), int(width*4/3), int(height*4/3), "png")
+	if err != nil {
+		return err
+	}
+
+	if d.pdf.GetY()+height > pdfHeight-pdfMargin {
+		d.pdf.AddPage()
+	}
+	d.charts++
+	name := fmt.Sprintf("chart%d", d.charts)
+	options := fpdf.ImageOptions{ImageType: "PNG"}
+	d.pdf.RegisterImageOptionsReader(name, options, bytes.NewReader(image))
+	y := d.pdf.GetY()
+	d.pdf.ImageOptions(name, pdfMargin, y, width, height, false, options, 0, "")
+	d.pdf.SetY(y + height + 10)
+	return d.pdf.Error()
+}
+
+// write serializes the document
+func (d *pdfDoc) write(w io.Writer) error {
+	if err := d.pdf.Output(w); err != nil {
+		return fmt.Errorf("failed to write PDF: %w", err)
+	}
+	return nil
+}
+
+// RenderPDF writes a printable report: headline figures, the intraday MTM of
+// a single day or the equity curve of a range, the daily summaries and every
+// closed trade
+func RenderPDF(w io.Writer, data *Data) error {
+	doc := newPDFDoc()
+	period := display.Day(data.From)
+	if !data.To.Equal(data.From) {
+		period += " to " + display.Day(data.To)
+	}
+	doc.line(fontBold, 16, fmt.Sprintf("Trading report for %s: %s", data.Account, period))
+	doc.line(fontText, 9, "Generated "+display.Time(data.Generated))
+	doc.space(6)
+
+	s := data.Stats
+	doc.line(fontText, 11, fmt.Sprintf("Closed trades: %d (%d wins, %d losses, win rate %s%%)", s.Trades, s.Wins, s.Losses, display.Number(s.WinRate, 1)))
+	doc.line(fontText, 11, fmt.Sprintf("Realized P&L: %s   Average win: %s   Average loss: %s   Profit factor: %s",
+		display.Amount(s.RealizedPnL), display.Amount(s.AvgWin), display.Amount(s.AvgLoss), display.Number(s.ProfitFactor, 2)))
+	ratios := fmt.Sprintf("Sharpe: %s   Sortino: %s   over %d trading days",
+		display.Number(s.Ratios.Sharpe, 2), display.Number(s.Ratios.Sortino, 2), s.Ratios.Days)
+	if latest := s.LatestRolling(); latest != nil {
+		ratios += fmt.Sprintf("   Rolling %d-day: %s / %s", s.RollingWindow, display.Number(latest.Sharpe, 2), display.Number(latest.Sortino, 2))
+	}
+	doc.line(fontText, 11, ratios)
+	if len(data.Summaries) == 1 {
+		summary := data.Summaries[0]
+		doc.line(fontText, 11, fmt.Sprintf("Charges: %s   Net P&L: %s   Turnover: %s",
+			display.Amount(summary.Charges), display.Amount(summary.NetPnL), display.Amount(summary.BuyTurnover+summary.SellTurnover)))
+	}
+
+	chart, title := data.Intraday, "Intraday MTM"
+	if len(chart) == 0 {
+		chart, title = data.Equity, "Cumulative realized P&L"
+	}
+	if len(chart) > 0 {
+		doc.space(8)
+		if err := doc.chart(title, chart, 240); err != nil {
+			return err
+		}
+	}
+
+	if len(data.Summaries) > 1 {
+		doc.space(8)
+		doc.line(fontBold, 12, "Daily summaries")
+		doc.line(fontMono, 8, fmt.Sprintf("%-12s %7s %14s %12s %14s %14s", "Date", "Trades", "Realized", "Charges", "Net", "Broker MTM"))
+		for _, summary := range data.Summaries {
+			mtm := "-"
+			if summary.BrokerMTM != nil {
+				mtm = display.Amount(*summary.BrokerMTM)
+			}
+			doc.line(fontMono, 8, fmt.Sprintf("%-12s %7d %14s %12s %14s %14s", display.Day(summary.Date), summary.TotalTrades,
+				display.Amount(summary.RealizedPnL), display.Amount(summary.Charges), display.Amount(summary.NetPnL), mtm))
+		}
+	}
+
+	doc.space(8)
+	doc.line(fontBold, 12, fmt.Sprintf("Closed trades (%d)", len(data.Trades)))
+	doc.line(fontMono, 8, fmt.Sprintf("%-24s %-22s %-5s %8s %10s %10s %12s", "Entry", "Symbol", "Side", "Qty", "Entry", "Exit", "P&L"))
+	for _, trip := range data.Trades {
+		doc.line(fontMono, 8, fmt.Sprintf("%-24s %-22s %-5s %8s %10s %10s %12s", display.Time(trip.EntryTime), trip.Symbol, trip.Side,
+			display.Quantity(trip.Quantity), display.Number(trip.EntryPrice, 2), display.Number(trip.ExitPrice, 2), display.Amount(trip.RealizedPnL)))
+	}
+
+	if len(data.OpenLots) > 0 {
+		doc.space(8)
+		doc.line(fontBold, 12, "Open positions")
+		for _, lot := range data.OpenLots {
+			doc.line(fontMono, 8, fmt.Sprintf("%-24s %-5s %8s @ %10s", lot.Symbol, lot.Side, display.Quantity(lot.Quantity), display.Money(lot.Price)))
+		}
+	}
+
+	return doc.write(w)
+}
@@ -0,0 +1,135 @@
+package report
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"profitLossAndTradeInfoToDB/pkg/market"
+)
+
+// TradingDaysPerYear annualizes the daily ratios
+const TradingDaysPerYear = 252
+
+// RatioSettings are what the Sharpe and Sortino ratios are computed against.
+// Daily returns are net P&L over Capital; without a capital base the ratios
+// are computed over rupee P&L and the risk-free rate cannot apply.
+type RatioSettings struct {
+	Capital      float64 // Rupees the returns are relative to
+	RiskFreeRate float64 // Annual percent, e.g. 6.5
+	Window       int     // Trading days of the rolling ratios
+}
+
+var (
+	ratioMu       sync.RWMutex
+	ratioSettings = RatioSettings{Window: 20}
+)
+
+// SetRatioSettings replaces the capital base, risk-free rate and rolling window
+func SetRatioSettings(settings RatioSettings) error {
+	if settings.Capital < 0 {
+		return fmt.Errorf("capital must not be negative")
+	}
+	if settings.Window < 2 {
+		return fmt.Errorf("rolling window must be at least 2 days")
+	}
+	ratioMu.Lock()
+	defer ratioMu.Unlock()
+	ratioSettings = settings
+	return nil
+}
+
+// CurrentRatioSettings returns the settings in effect
+func CurrentRatioSettings() RatioSettings {
+	ratioMu.RLock()
+	defer ratioMu.RUnlock()
+	return ratioSettings
+}
+
+// Ratios are the annualized Sharpe and Sortino ratios of a run of days; each
+// is 0 when it is undefined, i.e. with fewer than two days or no spread
+type Ratios struct {
+	Days    int
+	Sharpe  float64
+	Sortino float64
+}
+
+// RollingRatio is the ratios of the window of days ending on Date
+type RollingRatio struct {
+	Date time.Time
+	Ratios
+}
+
+// ComputeRatios works out the ratios of daily net P&L under settings
+func ComputeRatios(daily []float64, settings RatioSettings) Ratios {
+	ratios := Ratios{Days: len(daily)}
+	if len(daily) < 2 {
+		return ratios
+	}
+
+	excess := make([]float64, len(daily))
+	riskFree := 0.0
+	if settings.Capital > 0 {
+		riskFree = settings.RiskFreeRate / 100 / TradingDaysPerYear
+	}
+	var mean float64
+	for i, pnl := range daily {
+		ret := pnl
+		if settings.Capital > 0 {
+			ret = pnl / settings.Capital
+		}
+		excess[i] = ret - riskFree
+		mean += excess[i]
+	}
+	mean /= float64(len(excess))
+
+	var variance, downside float64
+	for _, r := range excess {
+		variance += (r - mean) * (r - mean)
+		if r < 0 {
+			downside += r * r
+		}
+	}
+	annualize := math.Sqrt(TradingDaysPerYear)
+	if std := math.Sqrt(variance / float64(len(excess)-1)); std > 0 {
+		ratios.Sharpe = mean / std * annualize
+	}
+	if deviation := math.Sqrt(downside / float64(len(excess))); deviation > 0 {
+		ratios.Sortino = mean / deviation * annualize
+	}
+	return ratios
+}
+
+// RollingRatios computes the ratios of every window of settings.Window days,
+// one per day from the first full window on
+func RollingRatios(daily []Point, settings RatioSettings) []RollingRatio {
+	var rolling []RollingRatio
+	for end := settings.Window; end <= len(daily); end++ {
+		rolling = append(rolling, RollingRatio{
+			Date:   daily[end-1].Date,
+			Ratios: ComputeRatios(values(daily[end-settings.Window:end]), settings),
+		})
+	}
+	return rolling
+}
+
+// tradingDayPnL returns the net P&L of every trading day in [from, to) up to
+// today, 0 on days without trades, so idle days count towards the spread
+func tradingDayPnL(netPnL []Point, from, to time.Time) []Point {
+	byDay := make(map[string]float64, len(netPnL))
+	for _, p := range netPnL {
+		byDay[p.Date.In(market.Location()).Format("2006-01-02")] += p.Value
+	}
+
+	if today := market.DayStart(time.Now()).AddDate(0, 0, 1); to.After(today) {
+		to = today
+	}
+	var daily []Point
+	for day := market.DayStart(from); day.Before(to); day = day.AddDate(0, 0, 1) {
+		if market.IsTradingDay(day) {
+			daily = append(daily, Point{Date: day, Value: byDay[day.Format("2006-01-02")]})
+		}
+	}
+	return daily
+}
@@ -0,0 +1,63 @@
+package report
+
+import (
+	"math"
+	"testing"
+)
+
+func TestComputeRatios(t *testing.T) {
+	annualize := math.Sqrt(TradingDaysPerYear)
+
+	tests := []struct {
+		name            string
+		daily           []float64
+		settings        RatioSettings
+		sharpe, sortino float64
+	}{
+		{name: "no days"},
+		{name: "a single day", daily: []float64{500}},
+		{name: "no spread", daily: []float64{100, 100, 100}},
+		{name: "no mean", daily: []float64{100, -100}},
+		{
+			// Mean 100, sample deviation 200√2, downside deviation 100/√2
+			name:    "rupee P&L",
+			daily:   []float64{300, -100},
+			sharpe:  100 / (200 * math.Sqrt2) * annualize,
+			sortino: math.Sqrt2 * annualize,
+		},
+		{
+			name:     "risk-free rate needs a capital base",
+			daily:    []float64{300, -100},
+			settings: RatioSettings{RiskFreeRate: 6.3},
+			sharpe:   100 / (200 * math.Sqrt2) * annualize,
+			sortino:  math.Sqrt2 * annualize,
+		},
+		{
+			// Returns of 0.3% and -0.1% less 0.025% a day risk free
+			name:     "returns on capital",
+			daily:    []float64{300, -100},
+			settings: RatioSettings{Capital: 100000, RiskFreeRate: 6.3},
+			sharpe:   0.00075 / (0.002 * math.Sqrt2) * annualize,
+			sortino:  0.00075 / (0.00125 / math.Sqrt2) * annualize,
+		},
+		{
+			name:   "no losing days",
+			daily:  []float64{100, 200, 300},
+			sharpe: 200 / 100.0 * annualize,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ComputeRatios(tt.daily, tt.settings)
+			if got.Days != len(tt.daily) {
+				t.Errorf("days = %d, want %d", got.Days, len(tt.daily))
+			}
+			if math.Abs(got.Sharpe-tt.sharpe) > 1e-9 {
+				t.Errorf("sharpe = %v, want %v", got.Sharpe, tt.sharpe)
+			}
+			if math.Abs(got.Sortino-tt.sortino) > 1e-9 {
+				t.Errorf("sortino = %v, want %v", got.Sortino, tt.sortino)
+			}
+		})
+	}
+}
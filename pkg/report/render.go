@@ -0,0 +1,138 @@
+package report
+
+import (
+	_ "embed"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	texttemplate "text/template"
+	"time"
+
+	"profitLossAndTradeInfoToDB/pkg/display"
+	"profitLossAndTradeInfoToDB/pkg/money"
+)
+
+//go:embed default.tmpl
+var defaultTemplate string
+
+//go:embed default.html.tmpl
+var defaultHTMLTemplate string
+
+// Render executes the template at path against data; .html and .htm files are
+// rendered with html/template, everything else with text/template. An empty
+// path uses the built-in text report.
+func Render(w io.Writer, path string, data *Data) error {
+	name, source := "default.tmpl", defaultTemplate
+	if path != "" {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read template: %w", err)
+		}
+		name, source = filepath.Base(path), string(content)
+	}
+
+	ext := strings.ToLower(filepath.Ext(name))
+	if ext == ".html" || ext == ".htm" {
+		return renderHTML(w, name, source, data)
+	}
+
+	tmpl, err := texttemplate.New(name).Funcs(funcs(false)).Parse(source)
+	if err != nil {
+		return fmt.Errorf("failed to parse template: %w", err)
+	}
+	return tmpl.Execute(w, data)
+}
+
+// RenderHTML writes the built-in HTML report: a self-contained page with
+// charts inlined as SVG and styles in the page, so it can be archived or mailed
+func RenderHTML(w io.Writer, data *Data) error {
+	return renderHTML(w, "default.html.tmpl", defaultHTMLTemplate, data)
+}
+
+func renderHTML(w io.Writer, name, source string, data *Data) error {
+	tmpl, err := htmltemplate.New(name).Funcs(htmltemplate.FuncMap(funcs(true))).Parse(source)
+	if err != nil {
+		return fmt.Errorf("failed to parse template: %w", err)
+	}
+	return tmpl.Execute(w, data)
+}
+
+// funcs are the helpers available to every template
+func funcs(html bool) texttemplate.FuncMap {
+	return texttemplate.FuncMap{
+		// money takes rupees, or paise as the stored amounts are kept
+		"money": func(v any) (string, error) {
+			switch v := v.(type) {
+			case float64:
+				return display.Money(v), nil
+			case money.Paise:
+				return display.Amount(v), nil
+			}
+			return "", fmt.Errorf("money: unexpected %T", v)
+		},
+		"quantity": display.Quantity,
+		"number":   display.Number,
+		"day":      display.Day,
+		"time":     display.Time,
+		"pct":      func(v float64) string { return display.Number(v, 1) + "%" },
+		"values":   values,
+		"deref": func(v *money.Paise) money.Paise {
+			if v == nil {
+				return 0
+			}
+			return *v
+		},
+		"sparkline": func(points []Point) string {
+			return sparkline(values(points))
+		},
+		"chart": func(title string, points []Point, width, height int) (any, error) {
+			svg, err := svgChart(title, points, width, height)
+			if err != nil || !html {
+				return svg, err
+			}
+			return htmltemplate.HTML(svg), nil
+		},
+		"duration": func(d time.Duration) string { return d.Round(time.Second).String() },
+	}
+}
+
+func values(points []Point) []float64 {
+	out := make([]float64, len(points))
+	for i, p := range points {
+		out[i] = p.Value
+	}
+	return out
+}
+
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+
+// sparkline draws values as a line of block characters
+func sparkline(vals []float64) string {
+	if len(vals) == 0 {
+		return ""
+	}
+	low, high := bounds(vals)
+
+	var b strings.Builder
+	for _, v := range vals {
+		i := 0
+		if high > low {
+			i = int((v - low) / (high - low) * float64(len(sparkBlocks)-1))
+		}
+		b.WriteRune(sparkBlocks[i])
+	}
+	return b.String()
+}
+
+func bounds(vals []float64) (float64, float64) {
+	low, high := math.Inf(1), math.Inf(-1)
+	for _, v := range vals {
+		low = math.Min(low, v)
+		high = math.Max(high, v)
+	}
+	return low, high
+}
@@ -0,0 +1,191 @@
+package report
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	orderbook "profitLossAndTradeInfoToDB/orderbooks"
+	"profitLossAndTradeInfoToDB/pkg/money"
+	"profitLossAndTradeInfoToDB/pkg/profitLossGraph"
+	"profitLossAndTradeInfoToDB/pkg/trades"
+)
+
+// Point is one value of a daily series
+type Point struct {
+	Date  time.Time
+	Value float64
+}
+
+// Stats are headline figures over the report's closed trades and days
+type Stats struct {
+	Days         int
+	Trades       int
+	Wins         int
+	Losses       int
+	WinRate      float64 // Percent of closed trades with a profit
+	RealizedPnL  money.Paise
+	AvgWin       money.Paise
+	AvgLoss      money.Paise
+	ProfitFactor float64 // Gross profit / gross loss; 0 without losses
+	BestDay      Point
+	WorstDay     Point
+
+	// Sharpe and Sortino of the daily net P&L, idle trading days counting as
+	// flat ones, over the whole range and over each trailing window
+	Ratios        Ratios
+	RollingWindow int
+	Rolling       []RollingRatio
+}
+
+// LatestRolling returns the ratios of the last full window, nil when the
+// range is shorter than one
+func (s Stats) LatestRolling() *RollingRatio {
+	if len(s.Rolling) == 0 {
+		return nil
+	}
+	return &s.Rolling[len(s.Rolling)-1]
+}
+
+// Data is everything a report template can use
+type Data struct {
+	Account    string
+	From       time.Time
+	To         time.Time // Last day included
+	Generated  time.Time
+	Summaries  []orderbook.DailySummary
+	Trades     []trades.RoundTrip
+	OpenLots   []trades.Lot
+	Stats      Stats
+	DailyPnL   []Point // Matched realized P&L per day
+	Equity     []Point // Cumulative matched realized P&L
+	BrokerMTM  []Point // Broker closing MTM per day
+	Intraday   []Point // Broker MTM samples, for single-day reports only
+	Symbols    []SymbolTotal
+	Parameters map[string]string
+}
+
+// SymbolTotal adds up the symbol summaries of one symbol over the report's days
+type SymbolTotal struct {
+	Symbol       string
+	Trades       int
+	BuyQuantity  float64
+	SellQuantity float64
+	Turnover     money.Paise
+	RealizedPnL  money.Paise
+}
+
+// Build loads the data of the account's days in [from, to)
+func Build(ctx context.Context, ob *orderbook.OrderBook, pl *profitLossGraph.Repository, from, to time.Time) (*Data, error) {
+	summaries, err := ob.GetDailySummaries(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	orders, err := ob.GetOrdersByDateRange(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+	closed, open := trades.MatchFIFO(orderbook.Fills(orders))
+
+	closes, err := pl.GetDailyCloses(ctx, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load broker MTM: %w", err)
+	}
+
+	symbolSummaries, err := ob.GetSymbolSummaries(ctx, from, to, "")
+	if err != nil {
+		return nil, err
+	}
+
+	data := &Data{
+		Account:    ob.Account(),
+		From:       from,
+		To:         to.AddDate(0, 0, -1),
+		Generated:  time.Now(),
+		Summaries:  summaries,
+		Trades:     closed,
+		OpenLots:   open,
+		Parameters: map[string]string{},
+	}
+
+	var cumulative money.Paise
+	var netPnL []Point
+	for _, summary := range summaries {
+		cumulative += summary.RealizedPnL
+		data.DailyPnL = append(data.DailyPnL, Point{Date: summary.Date, Value: summary.RealizedPnL.Rupees()})
+		data.Equity = append(data.Equity, Point{Date: summary.Date, Value: cumulative.Rupees()})
+		netPnL = append(netPnL, Point{Date: summary.Date, Value: summary.NetPnL.Rupees()})
+	}
+	for _, c := range closes {
+		data.BrokerMTM = append(data.BrokerMTM, Point{Date: c.Date, Value: c.Value})
+	}
+	data.Symbols = symbolTotals(symbolSummaries)
+
+	if to.Sub(from) <= 24*time.Hour {
+		entries, err := pl.GetProfitLossByDateRange(ctx, from, to)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load intraday MTM: %w", err)
+		}
+		for _, entry := range entries {
+			data.Intraday = append(data.Intraday, Point{Date: entry.Timestamp, Value: entry.Value})
+		}
+	}
+
+	data.Stats = computeStats(closed, data.DailyPnL)
+	settings := CurrentRatioSettings()
+	daily := tradingDayPnL(netPnL, from, to)
+	data.Stats.Ratios = ComputeRatios(values(daily), settings)
+	data.Stats.RollingWindow = settings.Window
+	data.Stats.Rolling = RollingRatios(daily, settings)
+	return data, nil
+}
+
+// symbolTotals adds up symbol summaries per symbol, sorted by symbol
+func symbolTotals(summaries []orderbook.SymbolSummary) []SymbolTotal {
+	index := make(map[string]int)
+	var totals []SymbolTotal
+	for _, s := range summaries {
+		i, ok := index[s.Symbol]
+		if !ok {
+			i = len(totals)
+			index[s.Symbol] = i
+			totals = append(totals, SymbolTotal{Symbol: s.Symbol})
+		}
+		t := &totals[i]
+		t.Trades += int(s.Trades)
+		t.BuyQuantity += s.BuyQuantity
+		t.SellQuantity += s.SellQuantity
+		t.Turnover += s.BuyTurnover + s.SellTurnover
+		t.RealizedPnL += s.RealizedPnL
+	}
+	sort.Slice(totals, func(i, j int) bool { return totals[i].Symbol < totals[j].Symbol })
+	return totals
+}
+
+func computeStats(closed []trades.RoundTrip, daily []Point) Stats {
+	performance := orderbook.ComputePerformance(closed)
+	stats := Stats{
+		Days:         len(daily),
+		Trades:       len(closed),
+		Wins:         int(performance.Wins),
+		Losses:       int(performance.Losses),
+		WinRate:      performance.WinRate,
+		RealizedPnL:  performance.RealizedPnL,
+		AvgWin:       performance.AvgWin,
+		AvgLoss:      performance.AvgLoss,
+		ProfitFactor: performance.ProfitFactor,
+	}
+
+	for i, day := range daily {
+		if i == 0 || day.Value > stats.BestDay.Value {
+			stats.BestDay = day
+		}
+		if i == 0 || day.Value < stats.WorstDay.Value {
+			stats.WorstDay = day
+		}
+	}
+
+	return stats
+}
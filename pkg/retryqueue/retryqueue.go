@@ -0,0 +1,195 @@
+package retryqueue
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Policy controls how failed files are retried
+type Policy struct {
+	BaseDelay   time.Duration // Delay before the first retry; doubled after every failure
+	MaxDelay    time.Duration
+	MaxAttempts int // Attempts, including the first, before a file is given up on
+}
+
+// DefaultPolicy retries after 1m, 2m, 4m, ... up to an hour apart, 8 attempts in total
+var DefaultPolicy = Policy{BaseDelay: time.Minute, MaxDelay: time.Hour, MaxAttempts: 8}
+
+// Entry is a failed file waiting to be retried
+type Entry struct {
+	Location    string    `json:"location"`
+	Attempts    int       `json:"attempts"`
+	FirstFailed time.Time `json:"first_failed"`
+	NextAttempt time.Time `json:"next_attempt"`
+	LastError   string    `json:"last_error"`
+	// Exhausted is set once MaxAttempts failed; the entry stays until reset or
+	// the file is imported successfully
+	Exhausted bool `json:"exhausted"`
+}
+
+// Queue is a retry queue persisted to a local JSON file, so it survives
+// restarts and keeps working while the database is unreachable
+type Queue struct {
+	mu      sync.Mutex
+	path    string
+	policy  Policy
+	entries map[string]*Entry
+}
+
+// Open loads the queue stored at path; a missing file is an empty queue
+func Open(path string, policy Policy) (*Queue, error) {
+	if policy.BaseDelay <= 0 || policy.MaxAttempts <= 0 {
+		return nil, fmt.Errorf("retry policy needs a positive base delay and max attempts")
+	}
+	if policy.MaxDelay < policy.BaseDelay {
+		policy.MaxDelay = policy.BaseDelay
+	}
+
+	q := &Queue{path: path, policy: policy, entries: map[string]*Entry{}}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return q, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read retry queue: %w", err)
+	}
+
+	var entries []*Entry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("failed to decode retry queue %s: %w", path, err)
+	}
+	for _, entry := range entries {
+		q.entries[entry.Location] = entry
+	}
+	return q, nil
+}
+
+// Fail records a failed attempt at location and schedules the next one
+func (q *Queue) Fail(location string, cause error, now time.Time) (Entry, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	entry, ok := q.entries[location]
+	if !ok {
+		entry = &Entry{Location: location, FirstFailed: now}
+		q.entries[location] = entry
+	}
+	entry.Attempts++
+	entry.LastError = cause.Error()
+	entry.NextAttempt = now.Add(q.delay(entry.Attempts))
+	entry.Exhausted = entry.Attempts >= q.policy.MaxAttempts
+
+	return *entry, q.save()
+}
+
+// delay returns the backoff after the given number of failed attempts
+func (q *Queue) delay(attempts int) time.Duration {
+	delay := q.policy.BaseDelay
+	for i := 1; i < attempts && delay < q.policy.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > q.policy.MaxDelay {
+		delay = q.policy.MaxDelay
+	}
+	return delay
+}
+
+// Succeed removes location from the queue
+func (q *Queue) Succeed(location string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if _, ok := q.entries[location]; !ok {
+		return nil
+	}
+	delete(q.entries, location)
+	return q.save()
+}
+
+// Reset re-arms exhausted entries for an immediate retry and returns how many there were
+func (q *Queue) Reset(now time.Time) (int, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var reset int
+	for _, entry := range q.entries {
+		if entry.Exhausted {
+			entry.Attempts = 0
+			entry.Exhausted = false
+			entry.NextAttempt = now
+			reset++
+		}
+	}
+	if reset == 0 {
+		return 0, nil
+	}
+	return reset, q.save()
+}
+
+// Due returns the entries whose next attempt is at or before now, oldest first
+func (q *Queue) Due(now time.Time) []Entry {
+	var due []Entry
+	for _, entry := range q.Entries() {
+		if !entry.Exhausted && !entry.NextAttempt.After(now) {
+			due = append(due, entry)
+		}
+	}
+	return due
+}
+
+// Entries returns every queued entry ordered by next attempt
+func (q *Queue) Entries() []Entry {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	entries := make([]Entry, 0, len(q.entries))
+	for _, entry := range q.entries {
+		entries = append(entries, *entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].NextAttempt.Before(entries[j].NextAttempt)
+	})
+	return entries
+}
+
+// save writes the queue atomically through a temporary file
+func (q *Queue) save() error {
+	entries := make([]*Entry, 0, len(q.entries))
+	for _, entry := range q.entries {
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Location < entries[j].Location
+	})
+
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode retry queue: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(q.path), ".retry-queue-*")
+	if err != nil {
+		return fmt.Errorf("failed to write retry queue: %w", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write retry queue: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write retry queue: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), q.path); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write retry queue: %w", err)
+	}
+	return nil
+}
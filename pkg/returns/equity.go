@@ -0,0 +1,70 @@
+package returns
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"profitLossAndTradeInfoToDB/pkg/ledger"
+	"profitLossAndTradeInfoToDB/pkg/profitLossGraph"
+)
+
+// Period is the result of a money-weighted return computation
+type Period struct {
+	From          time.Time
+	To            time.Time
+	StartEquity   float64
+	EndEquity     float64
+	NetDeposits   float64 // deposits minus withdrawals within the period
+	ProfitLoss    float64
+	XIRR          float64
+	Flows         []Flow
+	ClosesCovered int // trading days with a final MTM inside the period
+}
+
+// ComputePeriod builds equity as net deposits plus cumulative daily P&L and
+// returns the XIRR of [from, to): the starting equity and every deposit count
+// as money in, withdrawals and the ending equity as money out
+func ComputePeriod(ctx context.Context, cash *ledger.Repository, pl *profitLossGraph.Repository, from, to time.Time) (*Period, error) {
+	depositsBefore, err := cash.NetDeposits(ctx, from)
+	if err != nil {
+		return nil, err
+	}
+	flows, err := cash.List(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	closes, err := pl.GetDailyCloses(ctx, time.Time{}, to)
+	if err != nil {
+		return nil, err
+	}
+
+	period := &Period{From: from, To: to}
+	var pnlBefore float64
+	for _, c := range closes {
+		if c.Date.Before(from) {
+			pnlBefore += c.Value
+			continue
+		}
+		period.ProfitLoss += c.Value
+		period.ClosesCovered++
+	}
+
+	period.StartEquity = depositsBefore + pnlBefore
+	period.Flows = append(period.Flows, Flow{Date: from, Amount: -period.StartEquity})
+	for _, flow := range flows {
+		period.NetDeposits += flow.Amount
+		period.Flows = append(period.Flows, Flow{Date: flow.Date, Amount: -flow.Amount})
+	}
+	period.EndEquity = period.StartEquity + period.NetDeposits + period.ProfitLoss
+	period.Flows = append(period.Flows, Flow{Date: to, Amount: period.EndEquity})
+
+	rate, err := XIRR(period.Flows)
+	if err != nil {
+		return period, fmt.Errorf("failed to compute XIRR: %w", err)
+	}
+	period.XIRR = rate
+
+	return period, nil
+}
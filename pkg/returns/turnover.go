@@ -0,0 +1,142 @@
+package returns
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	orderbook "profitLossAndTradeInfoToDB/orderbooks"
+	"profitLossAndTradeInfoToDB/pkg/ledger"
+	"profitLossAndTradeInfoToDB/pkg/money"
+)
+
+// TurnoverRow is the traded value of one month or financial year relative to capital
+type TurnoverRow struct {
+	Period   string
+	Start    time.Time
+	Turnover money.Paise // buy value + sell value of all executed orders
+	Capital  float64     // average capital deployed over the period
+	Ratio    float64     // Turnover / Capital
+}
+
+// ComputeTurnover reports turnover per month and per Indian financial year
+// (April to March) for [from, to). Whole months are read from the monthly
+// rollup; partial months at either end, and months the rollup has no row for,
+// e.g. before it was first refreshed, are summed from the raw orders. Capital
+// comes from the ledger, averaged between the start and end of each month's
+// part of the range; defaultCapital is used where the ledger holds no money.
+func ComputeTurnover(ctx context.Context, ob *orderbook.OrderBook, cash *ledger.Repository, defaultCapital float64, from, to time.Time) ([]TurnoverRow, []TurnoverRow, error) {
+	rolledUp := map[int64]money.Paise{}
+	if ob.MaintainsRollup(orderbook.MonthlyRollup) {
+		rollups, err := ob.GetRollups(ctx, orderbook.MonthlyRollup, from, to)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, rollup := range rollups {
+			rolledUp[rollup.PeriodStart.Unix()] = rollup.Turnover
+		}
+	}
+
+	var monthly []TurnoverRow
+	yearly := map[string]*TurnoverRow{}
+	var yearOrder []string
+	yearMonths := map[string]int{}
+
+	for month := orderbook.PeriodMonth.Start(from); month.Before(to); month = month.AddDate(0, 1, 0) {
+		start, end := month, month.AddDate(0, 1, 0)
+		if start.Before(from) {
+			start = from
+		}
+		if end.After(to) {
+			end = to
+		}
+
+		turnover, ok := rolledUp[month.Unix()]
+		if !ok || !start.Equal(month) || !end.Equal(month.AddDate(0, 1, 0)) {
+			var traded bool
+			var err error
+			turnover, traded, err = rawTurnover(ctx, ob, start, end)
+			if err != nil {
+				return nil, nil, err
+			}
+			if !traded {
+				continue
+			}
+		}
+
+		opening, err := cash.NetDeposits(ctx, start)
+		if err != nil {
+			return nil, nil, err
+		}
+		closing, err := cash.NetDeposits(ctx, end)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		capital := (opening + closing) / 2
+		if capital <= 0 {
+			capital = defaultCapital
+		}
+
+		row := TurnoverRow{
+			Period:   month.Format("Jan 2006"),
+			Start:    start,
+			Turnover: turnover,
+			Capital:  capital,
+		}
+		if capital > 0 {
+			row.Ratio = row.Turnover.Rupees() / capital
+		}
+		monthly = append(monthly, row)
+
+		fy := financialYear(month)
+		if yearly[fy] == nil {
+			yearly[fy] = &TurnoverRow{Period: fy, Start: financialYearStart(month)}
+			yearOrder = append(yearOrder, fy)
+		}
+		yearly[fy].Turnover += row.Turnover
+		yearly[fy].Capital += capital
+		yearMonths[fy]++
+	}
+
+	years := make([]TurnoverRow, 0, len(yearOrder))
+	for _, fy := range yearOrder {
+		row := *yearly[fy]
+		row.Capital /= float64(yearMonths[fy])
+		if row.Capital > 0 {
+			row.Ratio = row.Turnover.Rupees() / row.Capital
+		}
+		years = append(years, row)
+	}
+
+	return monthly, years, nil
+}
+
+// rawTurnover sums the value of the non-voided, filled orders in [from, to)
+// and reports whether there were any
+func rawTurnover(ctx context.Context, ob *orderbook.OrderBook, from, to time.Time) (money.Paise, bool, error) {
+	orders, err := ob.GetOrdersByDateRange(ctx, from, to)
+	if err != nil {
+		return 0, false, err
+	}
+	var turnover money.Paise
+	for _, order := range orders {
+		turnover += money.Value(order.AveragePrice, order.Quantity)
+	}
+	return turnover, len(orders) > 0, nil
+}
+
+// financialYearStart returns 1 April of the financial year containing t
+func financialYearStart(t time.Time) time.Time {
+	year := t.Year()
+	if t.Month() < time.April {
+		year--
+	}
+	return time.Date(year, time.April, 1, 0, 0, 0, 0, t.Location())
+}
+
+// financialYear labels the financial year containing t, e.g. "FY2023-24"
+func financialYear(t time.Time) string {
+	start := financialYearStart(t).Year()
+	return fmt.Sprintf("FY%d-%02d", start, (start+1)%100)
+}
@@ -0,0 +1,98 @@
+package returns
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"time"
+)
+
+// Flow is a dated cashflow from the investor's point of view:
+// money put in is negative, money taken out (or final value) is positive
+type Flow struct {
+	Date   time.Time
+	Amount float64
+}
+
+// ErrNoSolution is returned when the flows do not admit an internal rate of return
+var ErrNoSolution = errors.New("xirr: no solution for these cashflows")
+
+const daysPerYear = 365.0
+
+// XIRR returns the annualised money-weighted return of irregularly dated flows
+func XIRR(flows []Flow) (float64, error) {
+	if len(flows) < 2 {
+		return 0, ErrNoSolution
+	}
+
+	sorted := append([]Flow(nil), flows...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Date.Before(sorted[j].Date) })
+
+	var hasIn, hasOut bool
+	for _, f := range sorted {
+		hasIn = hasIn || f.Amount < 0
+		hasOut = hasOut || f.Amount > 0
+	}
+	if !hasIn || !hasOut {
+		return 0, ErrNoSolution
+	}
+
+	start := sorted[0].Date
+	years := make([]float64, len(sorted))
+	for i, f := range sorted {
+		years[i] = f.Date.Sub(start).Hours() / 24 / daysPerYear
+	}
+
+	npv := func(rate float64) float64 {
+		var sum float64
+		for i, f := range sorted {
+			sum += f.Amount / math.Pow(1+rate, years[i])
+		}
+		return sum
+	}
+	derivative := func(rate float64) float64 {
+		var sum float64
+		for i, f := range sorted {
+			sum -= years[i] * f.Amount / math.Pow(1+rate, years[i]+1)
+		}
+		return sum
+	}
+
+	// Newton's method converges quickly for typical inputs
+	rate := 0.1
+	for i := 0; i < 100; i++ {
+		d := derivative(rate)
+		if d == 0 {
+			break
+		}
+		next := rate - npv(rate)/d
+		if next <= -1 || math.IsNaN(next) || math.IsInf(next, 0) {
+			break
+		}
+		if math.Abs(next-rate) < 1e-10 {
+			return next, nil
+		}
+		rate = next
+	}
+
+	// Fall back to bisection over a wide bracket
+	low, high := -0.9999, 100.0
+	fLow, fHigh := npv(low), npv(high)
+	if fLow*fHigh > 0 {
+		return 0, ErrNoSolution
+	}
+	for i := 0; i < 300; i++ {
+		mid := (low + high) / 2
+		fMid := npv(mid)
+		if math.Abs(fMid) < 1e-9 || high-low < 1e-12 {
+			return mid, nil
+		}
+		if fLow*fMid < 0 {
+			high = mid
+		} else {
+			low, fLow = mid, fMid
+		}
+	}
+
+	return (low + high) / 2, nil
+}
@@ -0,0 +1,81 @@
+package returns
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func TestXIRR(t *testing.T) {
+	tests := []struct {
+		name    string
+		flows   []Flow
+		want    float64
+		wantErr error
+	}{
+		{
+			name:  "gain over a year",
+			flows: []Flow{{date(2023, 1, 1), -1000}, {date(2024, 1, 1), 1100}},
+			want:  0.1,
+		},
+		{
+			name:  "loss over a year",
+			flows: []Flow{{date(2023, 1, 1), -1000}, {date(2024, 1, 1), 900}},
+			want:  -0.1,
+		},
+		{
+			// Years are 365 days, so a leap year's 366 are a little more than one
+			name:  "gain over a leap year",
+			flows: []Flow{{date(2024, 1, 1), -1000}, {date(2025, 1, 1), 1100}},
+			want:  math.Pow(1.1, 365.0/366) - 1,
+		},
+		{
+			// 1000 compounded for two years and 1000 for one at 10%
+			name:  "two deposits",
+			flows: []Flow{{date(2021, 1, 1), -1000}, {date(2022, 1, 1), -1000}, {date(2023, 1, 1), 2310}},
+			want:  0.1,
+		},
+		{
+			name:  "flows in any order",
+			flows: []Flow{{date(2023, 1, 1), 2310}, {date(2021, 1, 1), -1000}, {date(2022, 1, 1), -1000}},
+			want:  0.1,
+		},
+		{
+			// The root of 2000x² - 1000x - 1210 for x = 1 + rate
+			name:  "withdrawal along the way",
+			flows: []Flow{{date(2021, 1, 1), -2000}, {date(2022, 1, 1), 1000}, {date(2023, 1, 1), 1210}},
+			want:  (1000+math.Sqrt(1000*1000+4*2000*1210))/4000 - 1,
+		},
+		{
+			name:    "a single flow",
+			flows:   []Flow{{date(2023, 1, 1), -1000}},
+			wantErr: ErrNoSolution,
+		},
+		{
+			name:    "only deposits",
+			flows:   []Flow{{date(2023, 1, 1), -1000}, {date(2024, 1, 1), -1000}},
+			wantErr: ErrNoSolution,
+		},
+		{
+			name:    "only withdrawals",
+			flows:   []Flow{{date(2023, 1, 1), 1000}, {date(2024, 1, 1), 1000}},
+			wantErr: ErrNoSolution,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := XIRR(tt.flows)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			if math.Abs(got-tt.want) > 1e-6 {
+				t.Errorf("rate = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,209 @@
+package risk
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"profitLossAndTradeInfoToDB/constants"
+	"profitLossAndTradeInfoToDB/pkg/trades"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Untagged groups trades whose plan has no strategy
+const Untagged = "untagged"
+
+// Plan is the risk planned for an entry order: either a rupee amount for the
+// whole order or a stop distance in price per unit
+type Plan struct {
+	Account       string    `bson:"account" json:"account"`
+	OrderID       string    `bson:"order_id" json:"order_id"`
+	Symbol        string    `bson:"symbol" json:"symbol"`
+	EntryTime     time.Time `bson:"entry_time" json:"entry_time"`
+	OrderQuantity float64   `bson:"order_quantity" json:"order_quantity"`
+	RiskAmount    float64   `bson:"risk_amount,omitempty" json:"risk_amount,omitempty"`
+	StopDistance  float64   `bson:"stop_distance,omitempty" json:"stop_distance,omitempty"`
+	Strategy      string    `bson:"strategy,omitempty" json:"strategy,omitempty"`
+	Note          string    `bson:"note,omitempty" json:"note,omitempty"`
+	RecordedAt    time.Time `bson:"recorded_at" json:"recorded_at"`
+}
+
+// RiskFor returns the rupees at risk on quantity units of the order
+func (p Plan) RiskFor(quantity float64) float64 {
+	if p.StopDistance > 0 {
+		return p.StopDistance * quantity
+	}
+	if p.OrderQuantity <= 0 {
+		return p.RiskAmount
+	}
+	return p.RiskAmount * quantity / p.OrderQuantity
+}
+
+// Repository stores an account's risk plans
+type Repository struct {
+	account    string
+	collection *mongo.Collection
+}
+
+// NewRepository creates a risk repository scoped to account
+func NewRepository(db *mongo.Database, account string) (*Repository, error) {
+	if db == nil {
+		return nil, fmt.Errorf("database connection is nil")
+	}
+
+	if account == "" {
+		account = constants.DEFAULT_ACCOUNT
+	}
+
+	return &Repository{
+		account:    account,
+		collection: db.Collection(constants.TRADE_RISK_SCHEMA),
+	}, nil
+}
+
+// Record stores the plan for its order, replacing an earlier one
+func (r *Repository) Record(ctx context.Context, plan Plan) error {
+	if plan.OrderID == "" {
+		return fmt.Errorf("order ID is required")
+	}
+	if (plan.RiskAmount > 0) == (plan.StopDistance > 0) {
+		return fmt.Errorf("exactly one of risk amount and stop distance must be positive")
+	}
+
+	plan.Account = r.account
+	plan.RecordedAt = time.Now()
+
+	_, err := r.collection.ReplaceOne(ctx,
+		bson.M{"account": r.account, "order_id": plan.OrderID},
+		plan,
+		options.Replace().SetUpsert(true),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record risk plan: %w", err)
+	}
+
+	return nil
+}
+
+// ForOrders returns the plans of the given orders keyed by order ID
+func (r *Repository) ForOrders(ctx context.Context, orderIDs []string) (map[string]Plan, error) {
+	plans := make(map[string]Plan)
+	if len(orderIDs) == 0 {
+		return plans, nil
+	}
+
+	cursor, err := r.collection.Find(ctx, bson.M{"account": r.account, "order_id": bson.M{"$in": orderIDs}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query risk plans: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var list []Plan
+	if err := cursor.All(ctx, &list); err != nil {
+		return nil, fmt.Errorf("failed to decode risk plans: %w", err)
+	}
+	for _, plan := range list {
+		plans[plan.OrderID] = plan
+	}
+
+	return plans, nil
+}
+
+// RMultiple is a closed trade measured in units of its planned risk
+type RMultiple struct {
+	Trip     trades.RoundTrip
+	Risk     float64
+	R        float64
+	Strategy string
+}
+
+// Expectancy summarises R-multiples of one strategy (or all trades)
+type Expectancy struct {
+	Strategy   string
+	Trades     int
+	Wins       int
+	WinRate    float64 // Percent
+	AvgWinR    float64
+	AvgLossR   float64
+	TotalR     float64
+	Expectancy float64 // Average R per trade
+}
+
+// Analyze converts trades with a plan into R-multiples and returns them with the
+// per-strategy expectancy, an "all" row last, and the number of trades without a plan
+func Analyze(trips []trades.RoundTrip, plans map[string]Plan) ([]RMultiple, []Expectancy, int) {
+	var multiples []RMultiple
+	unplanned := 0
+	for _, trip := range trips {
+		plan, ok := plans[trip.EntryID]
+		if !ok {
+			unplanned++
+			continue
+		}
+		risk := plan.RiskFor(trip.Quantity)
+		if risk <= 0 {
+			unplanned++
+			continue
+		}
+
+		strategy := plan.Strategy
+		if strategy == "" {
+			strategy = Untagged
+		}
+		multiples = append(multiples, RMultiple{Trip: trip, Risk: risk, R: trip.RealizedPnL.Rupees() / risk, Strategy: strategy})
+	}
+
+	groups := map[string][]RMultiple{}
+	var names []string
+	for _, m := range multiples {
+		if _, ok := groups[m.Strategy]; !ok {
+			names = append(names, m.Strategy)
+		}
+		groups[m.Strategy] = append(groups[m.Strategy], m)
+	}
+	sort.Strings(names)
+
+	var expectancies []Expectancy
+	for _, name := range names {
+		expectancies = append(expectancies, expectancy(name, groups[name]))
+	}
+	if len(names) > 1 {
+		expectancies = append(expectancies, expectancy("all", multiples))
+	}
+
+	return multiples, expectancies, unplanned
+}
+
+func expectancy(strategy string, multiples []RMultiple) Expectancy {
+	e := Expectancy{Strategy: strategy, Trades: len(multiples)}
+
+	var winR, lossR float64
+	var losses int
+	for _, m := range multiples {
+		e.TotalR += m.R
+		if m.R > 0 {
+			e.Wins++
+			winR += m.R
+		} else if m.R < 0 {
+			losses++
+			lossR += m.R
+		}
+	}
+
+	if e.Trades > 0 {
+		e.WinRate = float64(e.Wins) / float64(e.Trades) * 100
+		e.Expectancy = e.TotalR / float64(e.Trades)
+	}
+	if e.Wins > 0 {
+		e.AvgWinR = winR / float64(e.Wins)
+	}
+	if losses > 0 {
+		e.AvgLossR = lossR / float64(losses)
+	}
+
+	return e
+}
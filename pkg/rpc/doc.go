@@ -0,0 +1,9 @@
+// Package rpc serves the OrderBookService and ProfitLossService defined in
+// proto/orderbook/v1 over gRPC. The orderbookv1 package is generated from the
+// proto file and committed; after changing the proto, regenerate it with
+// protoc, protoc-gen-go and protoc-gen-go-grpc on the PATH:
+//
+//	go generate ./pkg/rpc
+package rpc
+
+//go:generate protoc -I ../../proto --go_out=../.. --go_opt=module=profitLossAndTradeInfoToDB --go-grpc_out=../.. --go-grpc_opt=module=profitLossAndTradeInfoToDB orderbook/v1/orderbook.proto
@@ -0,0 +1,1029 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        (unknown)
+// source: orderbook/v1/orderbook.proto
+
+package orderbookv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Days are YYYY-MM-DD in market time; ranges include both ends.
+type DayRange struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	From          string                 `protobuf:"bytes,1,opt,name=from,proto3" json:"from,omitempty"`
+	To            string                 `protobuf:"bytes,2,opt,name=to,proto3" json:"to,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DayRange) Reset() {
+	*x = DayRange{}
+	mi := &file_orderbook_v1_orderbook_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DayRange) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DayRange) ProtoMessage() {}
+
+func (x *DayRange) ProtoReflect() protoreflect.Message {
+	mi := &file_orderbook_v1_orderbook_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DayRange.ProtoReflect.Descriptor instead.
+func (*DayRange) Descriptor() ([]byte, []int) {
+	return file_orderbook_v1_orderbook_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *DayRange) GetFrom() string {
+	if x != nil {
+		return x.From
+	}
+	return ""
+}
+
+func (x *DayRange) GetTo() string {
+	if x != nil {
+		return x.To
+	}
+	return ""
+}
+
+type Order struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Id              string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Account         string                 `protobuf:"bytes,2,opt,name=account,proto3" json:"account,omitempty"`
+	Timestamp       *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	TransactionType string                 `protobuf:"bytes,4,opt,name=transaction_type,json=transactionType,proto3" json:"transaction_type,omitempty"` // B or S
+	Symbol          string                 `protobuf:"bytes,5,opt,name=symbol,proto3" json:"symbol,omitempty"`
+	Product         string                 `protobuf:"bytes,6,opt,name=product,proto3" json:"product,omitempty"`
+	Quantity        float64                `protobuf:"fixed64,13,opt,name=quantity,proto3" json:"quantity,omitempty"`
+	AveragePrice    float64                `protobuf:"fixed64,8,opt,name=average_price,json=averagePrice,proto3" json:"average_price,omitempty"`
+	OrderStatus     string                 `protobuf:"bytes,9,opt,name=order_status,json=orderStatus,proto3" json:"order_status,omitempty"`
+	Source          string                 `protobuf:"bytes,10,opt,name=source,proto3" json:"source,omitempty"`
+	ImportRunId     string                 `protobuf:"bytes,11,opt,name=import_run_id,json=importRunId,proto3" json:"import_run_id,omitempty"`
+	ExecutionTime   *timestamppb.Timestamp `protobuf:"bytes,12,opt,name=execution_time,json=executionTime,proto3" json:"execution_time,omitempty"` // Unset when the export has none
+	OrderId         string                 `protobuf:"bytes,14,opt,name=order_id,json=orderId,proto3" json:"order_id,omitempty"`                   // Broker's order ID, empty when the export has none
+	ExchangeOrderId string                 `protobuf:"bytes,15,opt,name=exchange_order_id,json=exchangeOrderId,proto3" json:"exchange_order_id,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *Order) Reset() {
+	*x = Order{}
+	mi := &file_orderbook_v1_orderbook_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Order) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Order) ProtoMessage() {}
+
+func (x *Order) ProtoReflect() protoreflect.Message {
+	mi := &file_orderbook_v1_orderbook_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Order.ProtoReflect.Descriptor instead.
+func (*Order) Descriptor() ([]byte, []int) {
+	return file_orderbook_v1_orderbook_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Order) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Order) GetAccount() string {
+	if x != nil {
+		return x.Account
+	}
+	return ""
+}
+
+func (x *Order) GetTimestamp() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Timestamp
+	}
+	return nil
+}
+
+func (x *Order) GetTransactionType() string {
+	if x != nil {
+		return x.TransactionType
+	}
+	return ""
+}
+
+func (x *Order) GetSymbol() string {
+	if x != nil {
+		return x.Symbol
+	}
+	return ""
+}
+
+func (x *Order) GetProduct() string {
+	if x != nil {
+		return x.Product
+	}
+	return ""
+}
+
+func (x *Order) GetQuantity() float64 {
+	if x != nil {
+		return x.Quantity
+	}
+	return 0
+}
+
+func (x *Order) GetAveragePrice() float64 {
+	if x != nil {
+		return x.AveragePrice
+	}
+	return 0
+}
+
+func (x *Order) GetOrderStatus() string {
+	if x != nil {
+		return x.OrderStatus
+	}
+	return ""
+}
+
+func (x *Order) GetSource() string {
+	if x != nil {
+		return x.Source
+	}
+	return ""
+}
+
+func (x *Order) GetImportRunId() string {
+	if x != nil {
+		return x.ImportRunId
+	}
+	return ""
+}
+
+func (x *Order) GetExecutionTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ExecutionTime
+	}
+	return nil
+}
+
+func (x *Order) GetOrderId() string {
+	if x != nil {
+		return x.OrderId
+	}
+	return ""
+}
+
+func (x *Order) GetExchangeOrderId() string {
+	if x != nil {
+		return x.ExchangeOrderId
+	}
+	return ""
+}
+
+type DailySummary struct {
+	state             protoimpl.MessageState `protogen:"open.v1"`
+	Account           string                 `protobuf:"bytes,1,opt,name=account,proto3" json:"account,omitempty"`
+	Date              string                 `protobuf:"bytes,2,opt,name=date,proto3" json:"date,omitempty"`
+	TotalTrades       int32                  `protobuf:"varint,3,opt,name=total_trades,json=totalTrades,proto3" json:"total_trades,omitempty"`
+	TotalBuyQuantity  float64                `protobuf:"fixed64,13,opt,name=total_buy_quantity,json=totalBuyQuantity,proto3" json:"total_buy_quantity,omitempty"`
+	TotalSellQuantity float64                `protobuf:"fixed64,14,opt,name=total_sell_quantity,json=totalSellQuantity,proto3" json:"total_sell_quantity,omitempty"`
+	UniqueSymbols     int32                  `protobuf:"varint,6,opt,name=unique_symbols,json=uniqueSymbols,proto3" json:"unique_symbols,omitempty"`
+	RealizedPnl       float64                `protobuf:"fixed64,7,opt,name=realized_pnl,json=realizedPnl,proto3" json:"realized_pnl,omitempty"`
+	BrokerMtm         *float64               `protobuf:"fixed64,8,opt,name=broker_mtm,json=brokerMtm,proto3,oneof" json:"broker_mtm,omitempty"`
+	BuyTurnover       float64                `protobuf:"fixed64,9,opt,name=buy_turnover,json=buyTurnover,proto3" json:"buy_turnover,omitempty"`
+	SellTurnover      float64                `protobuf:"fixed64,10,opt,name=sell_turnover,json=sellTurnover,proto3" json:"sell_turnover,omitempty"`
+	NetPremium        float64                `protobuf:"fixed64,11,opt,name=net_premium,json=netPremium,proto3" json:"net_premium,omitempty"`
+	LastUpdated       *timestamppb.Timestamp `protobuf:"bytes,12,opt,name=last_updated,json=lastUpdated,proto3" json:"last_updated,omitempty"`
+	GrossPnl          float64                `protobuf:"fixed64,15,opt,name=gross_pnl,json=grossPnl,proto3" json:"gross_pnl,omitempty"`
+	Charges           float64                `protobuf:"fixed64,16,opt,name=charges,proto3" json:"charges,omitempty"`
+	NetPnl            float64                `protobuf:"fixed64,17,opt,name=net_pnl,json=netPnl,proto3" json:"net_pnl,omitempty"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *DailySummary) Reset() {
+	*x = DailySummary{}
+	mi := &file_orderbook_v1_orderbook_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DailySummary) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DailySummary) ProtoMessage() {}
+
+func (x *DailySummary) ProtoReflect() protoreflect.Message {
+	mi := &file_orderbook_v1_orderbook_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DailySummary.ProtoReflect.Descriptor instead.
+func (*DailySummary) Descriptor() ([]byte, []int) {
+	return file_orderbook_v1_orderbook_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *DailySummary) GetAccount() string {
+	if x != nil {
+		return x.Account
+	}
+	return ""
+}
+
+func (x *DailySummary) GetDate() string {
+	if x != nil {
+		return x.Date
+	}
+	return ""
+}
+
+func (x *DailySummary) GetTotalTrades() int32 {
+	if x != nil {
+		return x.TotalTrades
+	}
+	return 0
+}
+
+func (x *DailySummary) GetTotalBuyQuantity() float64 {
+	if x != nil {
+		return x.TotalBuyQuantity
+	}
+	return 0
+}
+
+func (x *DailySummary) GetTotalSellQuantity() float64 {
+	if x != nil {
+		return x.TotalSellQuantity
+	}
+	return 0
+}
+
+func (x *DailySummary) GetUniqueSymbols() int32 {
+	if x != nil {
+		return x.UniqueSymbols
+	}
+	return 0
+}
+
+func (x *DailySummary) GetRealizedPnl() float64 {
+	if x != nil {
+		return x.RealizedPnl
+	}
+	return 0
+}
+
+func (x *DailySummary) GetBrokerMtm() float64 {
+	if x != nil && x.BrokerMtm != nil {
+		return *x.BrokerMtm
+	}
+	return 0
+}
+
+func (x *DailySummary) GetBuyTurnover() float64 {
+	if x != nil {
+		return x.BuyTurnover
+	}
+	return 0
+}
+
+func (x *DailySummary) GetSellTurnover() float64 {
+	if x != nil {
+		return x.SellTurnover
+	}
+	return 0
+}
+
+func (x *DailySummary) GetNetPremium() float64 {
+	if x != nil {
+		return x.NetPremium
+	}
+	return 0
+}
+
+func (x *DailySummary) GetLastUpdated() *timestamppb.Timestamp {
+	if x != nil {
+		return x.LastUpdated
+	}
+	return nil
+}
+
+func (x *DailySummary) GetGrossPnl() float64 {
+	if x != nil {
+		return x.GrossPnl
+	}
+	return 0
+}
+
+func (x *DailySummary) GetCharges() float64 {
+	if x != nil {
+		return x.Charges
+	}
+	return 0
+}
+
+func (x *DailySummary) GetNetPnl() float64 {
+	if x != nil {
+		return x.NetPnl
+	}
+	return 0
+}
+
+type ProfitLossEntry struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Timestamp     *timestamppb.Timestamp `protobuf:"bytes,1,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	Value         float64                `protobuf:"fixed64,2,opt,name=value,proto3" json:"value,omitempty"`
+	Source        string                 `protobuf:"bytes,3,opt,name=source,proto3" json:"source,omitempty"`
+	ImportRunId   string                 `protobuf:"bytes,4,opt,name=import_run_id,json=importRunId,proto3" json:"import_run_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ProfitLossEntry) Reset() {
+	*x = ProfitLossEntry{}
+	mi := &file_orderbook_v1_orderbook_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ProfitLossEntry) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ProfitLossEntry) ProtoMessage() {}
+
+func (x *ProfitLossEntry) ProtoReflect() protoreflect.Message {
+	mi := &file_orderbook_v1_orderbook_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ProfitLossEntry.ProtoReflect.Descriptor instead.
+func (*ProfitLossEntry) Descriptor() ([]byte, []int) {
+	return file_orderbook_v1_orderbook_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ProfitLossEntry) GetTimestamp() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Timestamp
+	}
+	return nil
+}
+
+func (x *ProfitLossEntry) GetValue() float64 {
+	if x != nil {
+		return x.Value
+	}
+	return 0
+}
+
+func (x *ProfitLossEntry) GetSource() string {
+	if x != nil {
+		return x.Source
+	}
+	return ""
+}
+
+func (x *ProfitLossEntry) GetImportRunId() string {
+	if x != nil {
+		return x.ImportRunId
+	}
+	return ""
+}
+
+type DailyClose struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Date          string                 `protobuf:"bytes,1,opt,name=date,proto3" json:"date,omitempty"`
+	Value         float64                `protobuf:"fixed64,2,opt,name=value,proto3" json:"value,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DailyClose) Reset() {
+	*x = DailyClose{}
+	mi := &file_orderbook_v1_orderbook_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DailyClose) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DailyClose) ProtoMessage() {}
+
+func (x *DailyClose) ProtoReflect() protoreflect.Message {
+	mi := &file_orderbook_v1_orderbook_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DailyClose.ProtoReflect.Descriptor instead.
+func (*DailyClose) Descriptor() ([]byte, []int) {
+	return file_orderbook_v1_orderbook_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *DailyClose) GetDate() string {
+	if x != nil {
+		return x.Date
+	}
+	return ""
+}
+
+func (x *DailyClose) GetValue() float64 {
+	if x != nil {
+		return x.Value
+	}
+	return 0
+}
+
+type GetOrdersRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Range         *DayRange              `protobuf:"bytes,1,opt,name=range,proto3" json:"range,omitempty"`
+	Symbol        string                 `protobuf:"bytes,2,opt,name=symbol,proto3" json:"symbol,omitempty"` // Optional filter
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetOrdersRequest) Reset() {
+	*x = GetOrdersRequest{}
+	mi := &file_orderbook_v1_orderbook_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetOrdersRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetOrdersRequest) ProtoMessage() {}
+
+func (x *GetOrdersRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_orderbook_v1_orderbook_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetOrdersRequest.ProtoReflect.Descriptor instead.
+func (*GetOrdersRequest) Descriptor() ([]byte, []int) {
+	return file_orderbook_v1_orderbook_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *GetOrdersRequest) GetRange() *DayRange {
+	if x != nil {
+		return x.Range
+	}
+	return nil
+}
+
+func (x *GetOrdersRequest) GetSymbol() string {
+	if x != nil {
+		return x.Symbol
+	}
+	return ""
+}
+
+type GetOrdersResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Orders        []*Order               `protobuf:"bytes,1,rep,name=orders,proto3" json:"orders,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetOrdersResponse) Reset() {
+	*x = GetOrdersResponse{}
+	mi := &file_orderbook_v1_orderbook_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetOrdersResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetOrdersResponse) ProtoMessage() {}
+
+func (x *GetOrdersResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_orderbook_v1_orderbook_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetOrdersResponse.ProtoReflect.Descriptor instead.
+func (*GetOrdersResponse) Descriptor() ([]byte, []int) {
+	return file_orderbook_v1_orderbook_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *GetOrdersResponse) GetOrders() []*Order {
+	if x != nil {
+		return x.Orders
+	}
+	return nil
+}
+
+type GetDailySummariesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Range         *DayRange              `protobuf:"bytes,1,opt,name=range,proto3" json:"range,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetDailySummariesRequest) Reset() {
+	*x = GetDailySummariesRequest{}
+	mi := &file_orderbook_v1_orderbook_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetDailySummariesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetDailySummariesRequest) ProtoMessage() {}
+
+func (x *GetDailySummariesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_orderbook_v1_orderbook_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetDailySummariesRequest.ProtoReflect.Descriptor instead.
+func (*GetDailySummariesRequest) Descriptor() ([]byte, []int) {
+	return file_orderbook_v1_orderbook_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *GetDailySummariesRequest) GetRange() *DayRange {
+	if x != nil {
+		return x.Range
+	}
+	return nil
+}
+
+type GetDailySummariesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Summaries     []*DailySummary        `protobuf:"bytes,1,rep,name=summaries,proto3" json:"summaries,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetDailySummariesResponse) Reset() {
+	*x = GetDailySummariesResponse{}
+	mi := &file_orderbook_v1_orderbook_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetDailySummariesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetDailySummariesResponse) ProtoMessage() {}
+
+func (x *GetDailySummariesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_orderbook_v1_orderbook_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetDailySummariesResponse.ProtoReflect.Descriptor instead.
+func (*GetDailySummariesResponse) Descriptor() ([]byte, []int) {
+	return file_orderbook_v1_orderbook_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *GetDailySummariesResponse) GetSummaries() []*DailySummary {
+	if x != nil {
+		return x.Summaries
+	}
+	return nil
+}
+
+type GetProfitLossRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	From          *timestamppb.Timestamp `protobuf:"bytes,1,opt,name=from,proto3" json:"from,omitempty"`
+	To            *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=to,proto3" json:"to,omitempty"` // Inclusive
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetProfitLossRequest) Reset() {
+	*x = GetProfitLossRequest{}
+	mi := &file_orderbook_v1_orderbook_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetProfitLossRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetProfitLossRequest) ProtoMessage() {}
+
+func (x *GetProfitLossRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_orderbook_v1_orderbook_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetProfitLossRequest.ProtoReflect.Descriptor instead.
+func (*GetProfitLossRequest) Descriptor() ([]byte, []int) {
+	return file_orderbook_v1_orderbook_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *GetProfitLossRequest) GetFrom() *timestamppb.Timestamp {
+	if x != nil {
+		return x.From
+	}
+	return nil
+}
+
+func (x *GetProfitLossRequest) GetTo() *timestamppb.Timestamp {
+	if x != nil {
+		return x.To
+	}
+	return nil
+}
+
+type GetProfitLossResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Entries       []*ProfitLossEntry     `protobuf:"bytes,1,rep,name=entries,proto3" json:"entries,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetProfitLossResponse) Reset() {
+	*x = GetProfitLossResponse{}
+	mi := &file_orderbook_v1_orderbook_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetProfitLossResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetProfitLossResponse) ProtoMessage() {}
+
+func (x *GetProfitLossResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_orderbook_v1_orderbook_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetProfitLossResponse.ProtoReflect.Descriptor instead.
+func (*GetProfitLossResponse) Descriptor() ([]byte, []int) {
+	return file_orderbook_v1_orderbook_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *GetProfitLossResponse) GetEntries() []*ProfitLossEntry {
+	if x != nil {
+		return x.Entries
+	}
+	return nil
+}
+
+type GetDailyClosesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Range         *DayRange              `protobuf:"bytes,1,opt,name=range,proto3" json:"range,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetDailyClosesRequest) Reset() {
+	*x = GetDailyClosesRequest{}
+	mi := &file_orderbook_v1_orderbook_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetDailyClosesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetDailyClosesRequest) ProtoMessage() {}
+
+func (x *GetDailyClosesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_orderbook_v1_orderbook_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetDailyClosesRequest.ProtoReflect.Descriptor instead.
+func (*GetDailyClosesRequest) Descriptor() ([]byte, []int) {
+	return file_orderbook_v1_orderbook_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *GetDailyClosesRequest) GetRange() *DayRange {
+	if x != nil {
+		return x.Range
+	}
+	return nil
+}
+
+type GetDailyClosesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Closes        []*DailyClose          `protobuf:"bytes,1,rep,name=closes,proto3" json:"closes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetDailyClosesResponse) Reset() {
+	*x = GetDailyClosesResponse{}
+	mi := &file_orderbook_v1_orderbook_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetDailyClosesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetDailyClosesResponse) ProtoMessage() {}
+
+func (x *GetDailyClosesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_orderbook_v1_orderbook_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetDailyClosesResponse.ProtoReflect.Descriptor instead.
+func (*GetDailyClosesResponse) Descriptor() ([]byte, []int) {
+	return file_orderbook_v1_orderbook_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *GetDailyClosesResponse) GetCloses() []*DailyClose {
+	if x != nil {
+		return x.Closes
+	}
+	return nil
+}
+
+var File_orderbook_v1_orderbook_proto protoreflect.FileDescriptor
+
+const file_orderbook_v1_orderbook_proto_rawDesc = "" +
+	"\n" +
+	"\x1corderbook/v1/orderbook.proto\x12\forderbook.v1\x1a\x1fgoogle/protobuf/timestamp.proto\".\n" +
+	"\bDayRange\x12\x12\n" +
+	"\x04from\x18\x01 \x01(\tR\x04from\x12\x0e\n" +
+	"\x02to\x18\x02 \x01(\tR\x02to\"\xf8\x03\n" +
+	"\x05Order\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x18\n" +
+	"\aaccount\x18\x02 \x01(\tR\aaccount\x128\n" +
+	"\ttimestamp\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\ttimestamp\x12)\n" +
+	"\x10transaction_type\x18\x04 \x01(\tR\x0ftransactionType\x12\x16\n" +
+	"\x06symbol\x18\x05 \x01(\tR\x06symbol\x12\x18\n" +
+	"\aproduct\x18\x06 \x01(\tR\aproduct\x12\x1a\n" +
+	"\bquantity\x18\r \x01(\x01R\bquantity\x12#\n" +
+	"\raverage_price\x18\b \x01(\x01R\faveragePrice\x12!\n" +
+	"\forder_status\x18\t \x01(\tR\vorderStatus\x12\x16\n" +
+	"\x06source\x18\n" +
+	" \x01(\tR\x06source\x12\"\n" +
+	"\rimport_run_id\x18\v \x01(\tR\vimportRunId\x12A\n" +
+	"\x0eexecution_time\x18\f \x01(\v2\x1a.google.protobuf.TimestampR\rexecutionTime\x12\x19\n" +
+	"\border_id\x18\x0e \x01(\tR\aorderId\x12*\n" +
+	"\x11exchange_order_id\x18\x0f \x01(\tR\x0fexchangeOrderIdJ\x04\b\a\x10\b\"\xbe\x04\n" +
+	"\fDailySummary\x12\x18\n" +
+	"\aaccount\x18\x01 \x01(\tR\aaccount\x12\x12\n" +
+	"\x04date\x18\x02 \x01(\tR\x04date\x12!\n" +
+	"\ftotal_trades\x18\x03 \x01(\x05R\vtotalTrades\x12,\n" +
+	"\x12total_buy_quantity\x18\r \x01(\x01R\x10totalBuyQuantity\x12.\n" +
+	"\x13total_sell_quantity\x18\x0e \x01(\x01R\x11totalSellQuantity\x12%\n" +
+	"\x0eunique_symbols\x18\x06 \x01(\x05R\runiqueSymbols\x12!\n" +
+	"\frealized_pnl\x18\a \x01(\x01R\vrealizedPnl\x12\"\n" +
+	"\n" +
+	"broker_mtm\x18\b \x01(\x01H\x00R\tbrokerMtm\x88\x01\x01\x12!\n" +
+	"\fbuy_turnover\x18\t \x01(\x01R\vbuyTurnover\x12#\n" +
+	"\rsell_turnover\x18\n" +
+	" \x01(\x01R\fsellTurnover\x12\x1f\n" +
+	"\vnet_premium\x18\v \x01(\x01R\n" +
+	"netPremium\x12=\n" +
+	"\flast_updated\x18\f \x01(\v2\x1a.google.protobuf.TimestampR\vlastUpdated\x12\x1b\n" +
+	"\tgross_pnl\x18\x0f \x01(\x01R\bgrossPnl\x12\x18\n" +
+	"\acharges\x18\x10 \x01(\x01R\acharges\x12\x17\n" +
+	"\anet_pnl\x18\x11 \x01(\x01R\x06netPnlB\r\n" +
+	"\v_broker_mtmJ\x04\b\x04\x10\x05J\x04\b\x05\x10\x06\"\x9d\x01\n" +
+	"\x0fProfitLossEntry\x128\n" +
+	"\ttimestamp\x18\x01 \x01(\v2\x1a.google.protobuf.TimestampR\ttimestamp\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x01R\x05value\x12\x16\n" +
+	"\x06source\x18\x03 \x01(\tR\x06source\x12\"\n" +
+	"\rimport_run_id\x18\x04 \x01(\tR\vimportRunId\"6\n" +
+	"\n" +
+	"DailyClose\x12\x12\n" +
+	"\x04date\x18\x01 \x01(\tR\x04date\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x01R\x05value\"X\n" +
+	"\x10GetOrdersRequest\x12,\n" +
+	"\x05range\x18\x01 \x01(\v2\x16.orderbook.v1.DayRangeR\x05range\x12\x16\n" +
+	"\x06symbol\x18\x02 \x01(\tR\x06symbol\"@\n" +
+	"\x11GetOrdersResponse\x12+\n" +
+	"\x06orders\x18\x01 \x03(\v2\x13.orderbook.v1.OrderR\x06orders\"H\n" +
+	"\x18GetDailySummariesRequest\x12,\n" +
+	"\x05range\x18\x01 \x01(\v2\x16.orderbook.v1.DayRangeR\x05range\"U\n" +
+	"\x19GetDailySummariesResponse\x128\n" +
+	"\tsummaries\x18\x01 \x03(\v2\x1a.orderbook.v1.DailySummaryR\tsummaries\"r\n" +
+	"\x14GetProfitLossRequest\x12.\n" +
+	"\x04from\x18\x01 \x01(\v2\x1a.google.protobuf.TimestampR\x04from\x12*\n" +
+	"\x02to\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\x02to\"P\n" +
+	"\x15GetProfitLossResponse\x127\n" +
+	"\aentries\x18\x01 \x03(\v2\x1d.orderbook.v1.ProfitLossEntryR\aentries\"E\n" +
+	"\x15GetDailyClosesRequest\x12,\n" +
+	"\x05range\x18\x01 \x01(\v2\x16.orderbook.v1.DayRangeR\x05range\"J\n" +
+	"\x16GetDailyClosesResponse\x120\n" +
+	"\x06closes\x18\x01 \x03(\v2\x18.orderbook.v1.DailyCloseR\x06closes2\xc6\x01\n" +
+	"\x10OrderBookService\x12L\n" +
+	"\tGetOrders\x12\x1e.orderbook.v1.GetOrdersRequest\x1a\x1f.orderbook.v1.GetOrdersResponse\x12d\n" +
+	"\x11GetDailySummaries\x12&.orderbook.v1.GetDailySummariesRequest\x1a'.orderbook.v1.GetDailySummariesResponse2\xca\x01\n" +
+	"\x11ProfitLossService\x12X\n" +
+	"\rGetProfitLoss\x12\".orderbook.v1.GetProfitLossRequest\x1a#.orderbook.v1.GetProfitLossResponse\x12[\n" +
+	"\x0eGetDailyCloses\x12#.orderbook.v1.GetDailyClosesRequest\x1a$.orderbook.v1.GetDailyClosesResponseB0Z.profitLossAndTradeInfoToDB/pkg/rpc/orderbookv1b\x06proto3"
+
+var (
+	file_orderbook_v1_orderbook_proto_rawDescOnce sync.Once
+	file_orderbook_v1_orderbook_proto_rawDescData []byte
+)
+
+func file_orderbook_v1_orderbook_proto_rawDescGZIP() []byte {
+	file_orderbook_v1_orderbook_proto_rawDescOnce.Do(func() {
+		file_orderbook_v1_orderbook_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_orderbook_v1_orderbook_proto_rawDesc), len(file_orderbook_v1_orderbook_proto_rawDesc)))
+	})
+	return file_orderbook_v1_orderbook_proto_rawDescData
+}
+
+var file_orderbook_v1_orderbook_proto_msgTypes = make([]protoimpl.MessageInfo, 13)
+var file_orderbook_v1_orderbook_proto_goTypes = []any{
+	(*DayRange)(nil),                  // 0: orderbook.v1.DayRange
+	(*Order)(nil),                     // 1: orderbook.v1.Order
+	(*DailySummary)(nil),              // 2: orderbook.v1.DailySummary
+	(*ProfitLossEntry)(nil),           // 3: orderbook.v1.ProfitLossEntry
+	(*DailyClose)(nil),                // 4: orderbook.v1.DailyClose
+	(*GetOrdersRequest)(nil),          // 5: orderbook.v1.GetOrdersRequest
+	(*GetOrdersResponse)(nil),         // 6: orderbook.v1.GetOrdersResponse
+	(*GetDailySummariesRequest)(nil),  // 7: orderbook.v1.GetDailySummariesRequest
+	(*GetDailySummariesResponse)(nil), // 8: orderbook.v1.GetDailySummariesResponse
+	(*GetProfitLossRequest)(nil),      // 9: orderbook.v1.GetProfitLossRequest
+	(*GetProfitLossResponse)(nil),     // 10: orderbook.v1.GetProfitLossResponse
+	(*GetDailyClosesRequest)(nil),     // 11: orderbook.v1.GetDailyClosesRequest
+	(*GetDailyClosesResponse)(nil),    // 12: orderbook.v1.GetDailyClosesResponse
+	(*timestamppb.Timestamp)(nil),     // 13: google.protobuf.Timestamp
+}
+var file_orderbook_v1_orderbook_proto_depIdxs = []int32{
+	13, // 0: orderbook.v1.Order.timestamp:type_name -> google.protobuf.Timestamp
+	13, // 1: orderbook.v1.Order.execution_time:type_name -> google.protobuf.Timestamp
+	13, // 2: orderbook.v1.DailySummary.last_updated:type_name -> google.protobuf.Timestamp
+	13, // 3: orderbook.v1.ProfitLossEntry.timestamp:type_name -> google.protobuf.Timestamp
+	0,  // 4: orderbook.v1.GetOrdersRequest.range:type_name -> orderbook.v1.DayRange
+	1,  // 5: orderbook.v1.GetOrdersResponse.orders:type_name -> orderbook.v1.Order
+	0,  // 6: orderbook.v1.GetDailySummariesRequest.range:type_name -> orderbook.v1.DayRange
+	2,  // 7: orderbook.v1.GetDailySummariesResponse.summaries:type_name -> orderbook.v1.DailySummary
+	13, // 8: orderbook.v1.GetProfitLossRequest.from:type_name -> google.protobuf.Timestamp
+	13, // 9: orderbook.v1.GetProfitLossRequest.to:type_name -> google.protobuf.Timestamp
+	3,  // 10: orderbook.v1.GetProfitLossResponse.entries:type_name -> orderbook.v1.ProfitLossEntry
+	0,  // 11: orderbook.v1.GetDailyClosesRequest.range:type_name -> orderbook.v1.DayRange
+	4,  // 12: orderbook.v1.GetDailyClosesResponse.closes:type_name -> orderbook.v1.DailyClose
+	5,  // 13: orderbook.v1.OrderBookService.GetOrders:input_type -> orderbook.v1.GetOrdersRequest
+	7,  // 14: orderbook.v1.OrderBookService.GetDailySummaries:input_type -> orderbook.v1.GetDailySummariesRequest
+	9,  // 15: orderbook.v1.ProfitLossService.GetProfitLoss:input_type -> orderbook.v1.GetProfitLossRequest
+	11, // 16: orderbook.v1.ProfitLossService.GetDailyCloses:input_type -> orderbook.v1.GetDailyClosesRequest
+	6,  // 17: orderbook.v1.OrderBookService.GetOrders:output_type -> orderbook.v1.GetOrdersResponse
+	8,  // 18: orderbook.v1.OrderBookService.GetDailySummaries:output_type -> orderbook.v1.GetDailySummariesResponse
+	10, // 19: orderbook.v1.ProfitLossService.GetProfitLoss:output_type -> orderbook.v1.GetProfitLossResponse
+	12, // 20: orderbook.v1.ProfitLossService.GetDailyCloses:output_type -> orderbook.v1.GetDailyClosesResponse
+	17, // [17:21] is the sub-list for method output_type
+	13, // [13:17] is the sub-list for method input_type
+	13, // [13:13] is the sub-list for extension type_name
+	13, // [13:13] is the sub-list for extension extendee
+	0,  // [0:13] is the sub-list for field type_name
+}
+
+func init() { file_orderbook_v1_orderbook_proto_init() }
+func file_orderbook_v1_orderbook_proto_init() {
+	if File_orderbook_v1_orderbook_proto != nil {
+		return
+	}
+	file_orderbook_v1_orderbook_proto_msgTypes[2].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_orderbook_v1_orderbook_proto_rawDesc), len(file_orderbook_v1_orderbook_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   13,
+			NumExtensions: 0,
+			NumServices:   2,
+		},
+		GoTypes:           file_orderbook_v1_orderbook_proto_goTypes,
+		DependencyIndexes: file_orderbook_v1_orderbook_proto_depIdxs,
+		MessageInfos:      file_orderbook_v1_orderbook_proto_msgTypes,
+	}.Build()
+	File_orderbook_v1_orderbook_proto = out.File
+	file_orderbook_v1_orderbook_proto_goTypes = nil
+	file_orderbook_v1_orderbook_proto_depIdxs = nil
+}
@@ -0,0 +1,307 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: orderbook/v1/orderbook.proto
+
+package orderbookv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	OrderBookService_GetOrders_FullMethodName         = "/orderbook.v1.OrderBookService/GetOrders"
+	OrderBookService_GetDailySummaries_FullMethodName = "/orderbook.v1.OrderBookService/GetDailySummaries"
+)
+
+// OrderBookServiceClient is the client API for OrderBookService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// OrderBookService reads the orders and daily summaries of the server's account.
+type OrderBookServiceClient interface {
+	GetOrders(ctx context.Context, in *GetOrdersRequest, opts ...grpc.CallOption) (*GetOrdersResponse, error)
+	GetDailySummaries(ctx context.Context, in *GetDailySummariesRequest, opts ...grpc.CallOption) (*GetDailySummariesResponse, error)
+}
+
+type orderBookServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewOrderBookServiceClient(cc grpc.ClientConnInterface) OrderBookServiceClient {
+	return &orderBookServiceClient{cc}
+}
+
+func (c *orderBookServiceClient) GetOrders(ctx context.Context, in *GetOrdersRequest, opts ...grpc.CallOption) (*GetOrdersResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetOrdersResponse)
+	err := c.cc.Invoke(ctx, OrderBookService_GetOrders_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *orderBookServiceClient) GetDailySummaries(ctx context.Context, in *GetDailySummariesRequest, opts ...grpc.CallOption) (*GetDailySummariesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetDailySummariesResponse)
+	err := c.cc.Invoke(ctx, OrderBookService_GetDailySummaries_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// OrderBookServiceServer is the server API for OrderBookService service.
+// All implementations must embed UnimplementedOrderBookServiceServer
+// for forward compatibility.
+//
+// OrderBookService reads the orders and daily summaries of the server's account.
+type OrderBookServiceServer interface {
+	GetOrders(context.Context, *GetOrdersRequest) (*GetOrdersResponse, error)
+	GetDailySummaries(context.Context, *GetDailySummariesRequest) (*GetDailySummariesResponse, error)
+	mustEmbedUnimplementedOrderBookServiceServer()
+}
+
+// UnimplementedOrderBookServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedOrderBookServiceServer struct{}
+
+func (UnimplementedOrderBookServiceServer) GetOrders(context.Context, *GetOrdersRequest) (*GetOrdersResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetOrders not implemented")
+}
+func (UnimplementedOrderBookServiceServer) GetDailySummaries(context.Context, *GetDailySummariesRequest) (*GetDailySummariesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetDailySummaries not implemented")
+}
+func (UnimplementedOrderBookServiceServer) mustEmbedUnimplementedOrderBookServiceServer() {}
+func (UnimplementedOrderBookServiceServer) testEmbeddedByValue()                          {}
+
+// UnsafeOrderBookServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to OrderBookServiceServer will
+// result in compilation errors.
+type UnsafeOrderBookServiceServer interface {
+	mustEmbedUnimplementedOrderBookServiceServer()
+}
+
+func RegisterOrderBookServiceServer(s grpc.ServiceRegistrar, srv OrderBookServiceServer) {
+	// If the following call pancis, it indicates UnimplementedOrderBookServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&OrderBookService_ServiceDesc, srv)
+}
+
+func _OrderBookService_GetOrders_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetOrdersRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OrderBookServiceServer).GetOrders(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: OrderBookService_GetOrders_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OrderBookServiceServer).GetOrders(ctx, req.(*GetOrdersRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _OrderBookService_GetDailySummaries_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetDailySummariesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OrderBookServiceServer).GetDailySummaries(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: OrderBookService_GetDailySummaries_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OrderBookServiceServer).GetDailySummaries(ctx, req.(*GetDailySummariesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// OrderBookService_ServiceDesc is the grpc.ServiceDesc for OrderBookService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var OrderBookService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "orderbook.v1.OrderBookService",
+	HandlerType: (*OrderBookServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetOrders",
+			Handler:    _OrderBookService_GetOrders_Handler,
+		},
+		{
+			MethodName: "GetDailySummaries",
+			Handler:    _OrderBookService_GetDailySummaries_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "orderbook/v1/orderbook.proto",
+}
+
+const (
+	ProfitLossService_GetProfitLoss_FullMethodName  = "/orderbook.v1.ProfitLossService/GetProfitLoss"
+	ProfitLossService_GetDailyCloses_FullMethodName = "/orderbook.v1.ProfitLossService/GetDailyCloses"
+)
+
+// ProfitLossServiceClient is the client API for ProfitLossService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// ProfitLossService reads the broker MTM samples of the server's account.
+type ProfitLossServiceClient interface {
+	GetProfitLoss(ctx context.Context, in *GetProfitLossRequest, opts ...grpc.CallOption) (*GetProfitLossResponse, error)
+	GetDailyCloses(ctx context.Context, in *GetDailyClosesRequest, opts ...grpc.CallOption) (*GetDailyClosesResponse, error)
+}
+
+type profitLossServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewProfitLossServiceClient(cc grpc.ClientConnInterface) ProfitLossServiceClient {
+	return &profitLossServiceClient{cc}
+}
+
+func (c *profitLossServiceClient) GetProfitLoss(ctx context.Context, in *GetProfitLossRequest, opts ...grpc.CallOption) (*GetProfitLossResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetProfitLossResponse)
+	err := c.cc.Invoke(ctx, ProfitLossService_GetProfitLoss_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *profitLossServiceClient) GetDailyCloses(ctx context.Context, in *GetDailyClosesRequest, opts ...grpc.CallOption) (*GetDailyClosesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetDailyClosesResponse)
+	err := c.cc.Invoke(ctx, ProfitLossService_GetDailyCloses_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ProfitLossServiceServer is the server API for ProfitLossService service.
+// All implementations must embed UnimplementedProfitLossServiceServer
+// for forward compatibility.
+//
+// ProfitLossService reads the broker MTM samples of the server's account.
+type ProfitLossServiceServer interface {
+	GetProfitLoss(context.Context, *GetProfitLossRequest) (*GetProfitLossResponse, error)
+	GetDailyCloses(context.Context, *GetDailyClosesRequest) (*GetDailyClosesResponse, error)
+	mustEmbedUnimplementedProfitLossServiceServer()
+}
+
+// UnimplementedProfitLossServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedProfitLossServiceServer struct{}
+
+func (UnimplementedProfitLossServiceServer) GetProfitLoss(context.Context, *GetProfitLossRequest) (*GetProfitLossResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetProfitLoss not implemented")
+}
+func (UnimplementedProfitLossServiceServer) GetDailyCloses(context.Context, *GetDailyClosesRequest) (*GetDailyClosesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetDailyCloses not implemented")
+}
+func (UnimplementedProfitLossServiceServer) mustEmbedUnimplementedProfitLossServiceServer() {}
+func (UnimplementedProfitLossServiceServer) testEmbeddedByValue()                           {}
+
+// UnsafeProfitLossServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ProfitLossServiceServer will
+// result in compilation errors.
+type UnsafeProfitLossServiceServer interface {
+	mustEmbedUnimplementedProfitLossServiceServer()
+}
+
+func RegisterProfitLossServiceServer(s grpc.ServiceRegistrar, srv ProfitLossServiceServer) {
+	// If the following call pancis, it indicates UnimplementedProfitLossServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&ProfitLossService_ServiceDesc, srv)
+}
+
+func _ProfitLossService_GetProfitLoss_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetProfitLossRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProfitLossServiceServer).GetProfitLoss(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ProfitLossService_GetProfitLoss_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProfitLossServiceServer).GetProfitLoss(ctx, req.(*GetProfitLossRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ProfitLossService_GetDailyCloses_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetDailyClosesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProfitLossServiceServer).GetDailyCloses(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ProfitLossService_GetDailyCloses_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProfitLossServiceServer).GetDailyCloses(ctx, req.(*GetDailyClosesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// ProfitLossService_ServiceDesc is the grpc.ServiceDesc for ProfitLossService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var ProfitLossService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "orderbook.v1.ProfitLossService",
+	HandlerType: (*ProfitLossServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetProfitLoss",
+			Handler:    _ProfitLossService_GetProfitLoss_Handler,
+		},
+		{
+			MethodName: "GetDailyCloses",
+			Handler:    _ProfitLossService_GetDailyCloses_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "orderbook/v1/orderbook.proto",
+}
@@ -0,0 +1,207 @@
+package rpc
+
+import (
+	"context"
+	"crypto/subtle"
+	"time"
+
+	orderbook "profitLossAndTradeInfoToDB/orderbooks"
+	"profitLossAndTradeInfoToDB/pkg/market"
+	"profitLossAndTradeInfoToDB/pkg/money"
+	"profitLossAndTradeInfoToDB/pkg/profitLossGraph"
+	"profitLossAndTradeInfoToDB/pkg/rpc/orderbookv1"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// dayLayout is the format of the days in a DayRange
+const dayLayout = "2006-01-02"
+
+// NewServer creates a gRPC server with both services registered. When token is
+// set every call must send it as "authorization: Bearer <token>" metadata.
+func NewServer(ob *orderbook.OrderBook, pl *profitLossGraph.Repository, token string) *grpc.Server {
+	var opts []grpc.ServerOption
+	if token != "" {
+		opts = append(opts, grpc.UnaryInterceptor(authenticate(token)))
+	}
+
+	server := grpc.NewServer(opts...)
+	orderbookv1.RegisterOrderBookServiceServer(server, &orderBookService{ob: ob})
+	orderbookv1.RegisterProfitLossServiceServer(server, &profitLossService{pl: pl})
+	return server
+}
+
+// authenticate rejects calls without the bearer token
+func authenticate(token string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		for _, value := range md.Get("authorization") {
+			if subtle.ConstantTimeCompare([]byte(value), []byte("Bearer "+token)) == 1 {
+				return handler(ctx, req)
+			}
+		}
+		return nil, status.Error(codes.Unauthenticated, "missing or invalid bearer token")
+	}
+}
+
+type orderBookService struct {
+	orderbookv1.UnimplementedOrderBookServiceServer
+	ob *orderbook.OrderBook
+}
+
+func (s *orderBookService) GetOrders(ctx context.Context, req *orderbookv1.GetOrdersRequest) (*orderbookv1.GetOrdersResponse, error) {
+	from, to, err := dayRange(req.GetRange())
+	if err != nil {
+		return nil, err
+	}
+
+	orders, err := s.ob.GetOrdersByDateRange(ctx, from, to)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	resp := &orderbookv1.GetOrdersResponse{}
+	for _, order := range orders {
+		if req.GetSymbol() != "" && order.Symbol != req.GetSymbol() {
+			continue
+		}
+		msg := &orderbookv1.Order{
+			Id:              order.ID.Hex(),
+			Account:         order.Account,
+			Timestamp:       timestamppb.New(order.Timestamp),
+			TransactionType: order.TransactionType,
+			Symbol:          order.Symbol,
+			Product:         order.Product,
+			Quantity:        order.Quantity,
+			AveragePrice:    order.AveragePrice,
+			OrderStatus:     order.OrderStatus,
+			Source:          order.Source,
+			ImportRunId:     order.ImportRunID,
+			OrderId:         order.OrderID,
+			ExchangeOrderId: order.ExchangeOrderID,
+		}
+		if order.ExecutionTime != nil {
+			msg.ExecutionTime = timestamppb.New(*order.ExecutionTime)
+		}
+		resp.Orders = append(resp.Orders, msg)
+	}
+	return resp, nil
+}
+
+func (s *orderBookService) GetDailySummaries(ctx context.Context, req *orderbookv1.GetDailySummariesRequest) (*orderbookv1.GetDailySummariesResponse, error) {
+	from, to, err := dayRange(req.GetRange())
+	if err != nil {
+		return nil, err
+	}
+
+	summaries, err := s.ob.GetDailySummaries(ctx, from, to)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	resp := &orderbookv1.GetDailySummariesResponse{}
+	for _, summary := range summaries {
+		resp.Summaries = append(resp.Summaries, &orderbookv1.DailySummary{
+			Account:           summary.Account,
+			Date:              summary.Date.In(market.Location()).Format(dayLayout),
+			TotalTrades:       summary.TotalTrades,
+			TotalBuyQuantity:  summary.TotalBuyQuantity,
+			TotalSellQuantity: summary.TotalSellQuantity,
+			UniqueSymbols:     summary.UniqueSymbols,
+			RealizedPnl:       summary.RealizedPnL.Rupees(),
+			BrokerMtm:         brokerMTM(summary.BrokerMTM),
+			GrossPnl:          summary.GrossPnL.Rupees(),
+			Charges:           summary.Charges.Rupees(),
+			NetPnl:            summary.NetPnL.Rupees(),
+			BuyTurnover:       summary.BuyTurnover.Rupees(),
+			SellTurnover:      summary.SellTurnover.Rupees(),
+			NetPremium:        summary.NetPremium.Rupees(),
+			LastUpdated:       timestamppb.New(summary.LastUpdated),
+		})
+	}
+	return resp, nil
+}
+
+// brokerMTM converts an optional amount to the rupees the messages carry
+func brokerMTM(mtm *money.Paise) *float64 {
+	if mtm == nil {
+		return nil
+	}
+	rupees := mtm.Rupees()
+	return &rupees
+}
+
+type profitLossService struct {
+	orderbookv1.UnimplementedProfitLossServiceServer
+	pl *profitLossGraph.Repository
+}
+
+func (s *profitLossService) GetProfitLoss(ctx context.Context, req *orderbookv1.GetProfitLossRequest) (*orderbookv1.GetProfitLossResponse, error) {
+	if req.GetFrom() == nil || req.GetTo() == nil {
+		return nil, status.Error(codes.InvalidArgument, "from and to are required")
+	}
+	from, to := req.GetFrom().AsTime(), req.GetTo().AsTime()
+	if to.Before(from) {
+		return nil, status.Error(codes.InvalidArgument, "to is before from")
+	}
+
+	entries, err := s.pl.GetProfitLossByDateRange(ctx, from, to)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	resp := &orderbookv1.GetProfitLossResponse{}
+	for _, entry := range entries {
+		resp.Entries = append(resp.Entries, &orderbookv1.ProfitLossEntry{
+			Timestamp:   timestamppb.New(entry.Timestamp),
+			Value:       entry.Value,
+			Source:      entry.Source,
+			ImportRunId: entry.ImportRunID,
+		})
+	}
+	return resp, nil
+}
+
+func (s *profitLossService) GetDailyCloses(ctx context.Context, req *orderbookv1.GetDailyClosesRequest) (*orderbookv1.GetDailyClosesResponse, error) {
+	from, to, err := dayRange(req.GetRange())
+	if err != nil {
+		return nil, err
+	}
+
+	closes, err := s.pl.GetDailyCloses(ctx, from, to)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	resp := &orderbookv1.GetDailyClosesResponse{}
+	for _, c := range closes {
+		resp.Closes = append(resp.Closes, &orderbookv1.DailyClose{
+			Date:  c.Date.Format(dayLayout),
+			Value: c.Value,
+		})
+	}
+	return resp, nil
+}
+
+// dayRange converts an inclusive range of days to the half-open range [from, to)
+func dayRange(r *orderbookv1.DayRange) (time.Time, time.Time, error) {
+	if r == nil {
+		return time.Time{}, time.Time{}, status.Error(codes.InvalidArgument, "range is required")
+	}
+	from, err := time.ParseInLocation(dayLayout, r.GetFrom(), market.Location())
+	if err != nil {
+		return time.Time{}, time.Time{}, status.Errorf(codes.InvalidArgument, "invalid from: %v", err)
+	}
+	to, err := time.ParseInLocation(dayLayout, r.GetTo(), market.Location())
+	if err != nil {
+		return time.Time{}, time.Time{}, status.Errorf(codes.InvalidArgument, "invalid to: %v", err)
+	}
+	if to.Before(from) {
+		return time.Time{}, time.Time{}, status.Error(codes.InvalidArgument, "to is before from")
+	}
+	return from, to.AddDate(0, 0, 1), nil
+}
@@ -0,0 +1,213 @@
+package sampledata
+
+import (
+	"encoding/csv"
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	orderbook "profitLossAndTradeInfoToDB/orderbooks"
+	"profitLossAndTradeInfoToDB/pkg/market"
+	"profitLossAndTradeInfoToDB/pkg/profitLossGraph"
+)
+
+// underlying describes an index the generator trades options on
+type underlying struct {
+	name       string
+	spot       float64
+	strikeStep int
+	lotSize    int
+}
+
+var underlyings = []underlying{
+	{name: "NIFTY", spot: 22000, strikeStep: 50, lotSize: 25},
+	{name: "BANKNIFTY", spot: 48000, strikeStep: 100, lotSize: 15},
+	{name: "FINNIFTY", spot: 21500, strikeStep: 50, lotSize: 40},
+}
+
+var ist = time.FixedZone("IST", 5*60*60+30*60)
+
+// Generator produces synthetic orderbook and profit/loss files
+type Generator struct {
+	Dir string
+	rnd *rand.Rand
+}
+
+// NewGenerator creates a Generator writing into dir; the same seed produces the same files
+func NewGenerator(dir string, seed int64) *Generator {
+	return &Generator{
+		Dir: dir,
+		rnd: rand.New(rand.NewSource(seed)),
+	}
+}
+
+// WriteTemplates writes header-only orderbook and profit/loss CSV templates
+func (g *Generator) WriteTemplates() ([]string, error) {
+	files := map[string][]string{
+		"orderbook_template.csv":  orderbook.CSVHeader,
+		"profitLoss_template.csv": profitLossGraph.CSVHeader,
+	}
+
+	var written []string
+	for name, header := range files {
+		path := filepath.Join(g.Dir, name)
+		if err := writeCSV(path, [][]string{header}); err != nil {
+			return nil, err
+		}
+		written = append(written, path)
+	}
+
+	return written, nil
+}
+
+// GenerateDays writes orderbook and profit/loss files for the given number of
+// trading days ending on end, skipping weekends and holidays unless the
+// session calendar lists them
+func (g *Generator) GenerateDays(end time.Time, days int) ([]string, error) {
+	var written []string
+
+	date := time.Date(end.Year(), end.Month(), end.Day(), 0, 0, 0, 0, ist)
+	for generated := 0; generated < days; date = date.AddDate(0, 0, -1) {
+		if !market.IsTradingDay(date) {
+			continue
+		}
+
+		files, err := g.generateDay(date)
+		if err != nil {
+			return nil, err
+		}
+		written = append(written, files...)
+		generated++
+	}
+
+	return written, nil
+}
+
+// generateDay writes one day's files; the MTM curve ends at the realized P&L of the day's trades
+func (g *Generator) generateDay(date time.Time) ([]string, error) {
+	// Trade the day's first session: the regular one, or e.g. muhurat trading
+	first := market.Regular
+	if sessions := market.Sessions(date); len(sessions) > 0 {
+		first = sessions[0]
+	}
+	marketOpen, marketClose := first.Bounds(date)
+	session := marketClose.Sub(marketOpen)
+
+	orders := [][]string{orderbook.CSVHeader}
+	realized := 0.0
+
+	trades := 2 + g.rnd.Intn(8)
+	for i := 0; i < trades; i++ {
+		u := underlyings[g.rnd.Intn(len(underlyings))]
+		symbol := g.optionSymbol(u, date)
+		quantity := u.lotSize * (1 + g.rnd.Intn(4))
+
+		entryTime := marketOpen.Add(time.Duration(g.rnd.Int63n(int64(session * 3 / 4))))
+		exitTime := entryTime.Add(time.Duration(1+g.rnd.Intn(90)) * time.Minute)
+		if exitTime.After(marketClose) {
+			exitTime = marketClose.Add(-time.Minute)
+		}
+
+		entryPrice := roundTick(50 + g.rnd.Float64()*250)
+		exitPrice := roundTick(math.Max(0.05, entryPrice*(1+g.rnd.NormFloat64()*0.15)))
+
+		// Option sellers sell first and buy back; buyers do the opposite
+		entrySide, exitSide := "B", "S"
+		if g.rnd.Intn(2) == 0 {
+			entrySide, exitSide = "S", "B"
+			realized += (entryPrice - exitPrice) * float64(quantity)
+		} else {
+			realized += (exitPrice - entryPrice) * float64(quantity)
+		}
+
+		orders = append(orders,
+			orderRow(entryTime, entrySide, symbol, quantity, entryPrice),
+			orderRow(exitTime, exitSide, symbol, quantity, exitPrice),
+		)
+	}
+
+	// Brownian bridge from zero to the realized P&L, one sample per minute
+	samples := [][]string{profitLossGraph.CSVHeader}
+	minutes := int(session / time.Minute)
+	volatility := math.Max(200, math.Abs(realized)/4)
+	walk := 0.0
+	for m := 0; m <= minutes; m++ {
+		progress := float64(m) / float64(minutes)
+		value := walk*(1-progress) + realized*progress
+		if m == minutes {
+			value = realized
+		}
+		samples = append(samples, []string{
+			marketOpen.Add(time.Duration(m) * time.Minute).Format(time.RFC3339),
+			strconv.FormatFloat(math.Round(value*100)/100, 'f', 2, 64),
+		})
+		walk += g.rnd.NormFloat64() * volatility / math.Sqrt(float64(minutes))
+	}
+
+	orderFile := filepath.Join(g.Dir, fmt.Sprintf("orderbook_%s.csv", date.Format("02-01-2006")))
+	plFile := filepath.Join(g.Dir, profitLossGraph.GetFileNameForDate(date))
+
+	if err := writeCSV(orderFile, orders); err != nil {
+		return nil, err
+	}
+	if err := writeCSV(plFile, samples); err != nil {
+		return nil, err
+	}
+
+	return []string{orderFile, plFile}, nil
+}
+
+// optionSymbol builds a weekly option symbol near the money, e.g. NIFTY25JAN24C22000
+func (g *Generator) optionSymbol(u underlying, date time.Time) string {
+	expiry := date
+	for expiry.Weekday() != time.Thursday {
+		expiry = expiry.AddDate(0, 0, 1)
+	}
+
+	offset := (g.rnd.Intn(11) - 5) * u.strikeStep
+	strike := int(u.spot)/u.strikeStep*u.strikeStep + offset
+
+	optionType := "C"
+	if g.rnd.Intn(2) == 0 {
+		optionType = "P"
+	}
+
+	return fmt.Sprintf("%s%s%s%d", u.name, strings.ToUpper(expiry.Format("02Jan06")), optionType, strike)
+}
+
+func orderRow(t time.Time, side, symbol string, quantity int, price float64) []string {
+	return []string{
+		t.Format(orderbook.CSVTimestampLayout),
+		side,
+		symbol,
+		"MIS",
+		strconv.Itoa(quantity),
+		strconv.FormatFloat(price, 'f', 2, 64),
+		"COMPLETE",
+	}
+}
+
+// roundTick rounds a price to the 0.05 exchange tick
+func roundTick(price float64) float64 {
+	return math.Round(price*20) / 20
+}
+
+func writeCSV(path string, rows [][]string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	if err := writer.WriteAll(rows); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+
+	return nil
+}
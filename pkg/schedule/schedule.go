@@ -0,0 +1,217 @@
+package schedule
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/bits"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"profitLossAndTradeInfoToDB/pkg/market"
+)
+
+// Schedule is a five-field cron expression (minute hour day-of-month month
+// day-of-week) evaluated in the market timezone
+type Schedule struct {
+	spec                     string
+	minute, hour, dom, month uint64
+	dow                      uint64
+	domWildcard, dowWildcard bool
+}
+
+// field bounds of the cron expression, in order
+var fields = []struct {
+	name     string
+	min, max int
+}{
+	{"minute", 0, 59},
+	{"hour", 0, 23},
+	{"day of month", 1, 31},
+	{"month", 1, 12},
+	{"day of week", 0, 7}, // 0 and 7 are both Sunday
+}
+
+// Parse parses a cron expression such as "0 18 * * 1-5". Fields accept *,
+// single values, ranges, comma separated lists and /step.
+func Parse(spec string) (*Schedule, error) {
+	parts := strings.Fields(spec)
+	if len(parts) != len(fields) {
+		return nil, fmt.Errorf("schedule %q needs 5 fields, got %d", spec, len(parts))
+	}
+
+	sets := make([]uint64, len(fields))
+	for i, part := range parts {
+		set, err := parseField(part, fields[i].min, fields[i].max)
+		if err != nil {
+			return nil, fmt.Errorf("schedule %q: %s: %w", spec, fields[i].name, err)
+		}
+		sets[i] = set
+	}
+	if sets[4]&(1<<7) != 0 {
+		sets[4] |= 1 // Sunday
+	}
+
+	return &Schedule{
+		spec:        spec,
+		minute:      sets[0],
+		hour:        sets[1],
+		dom:         sets[2],
+		month:       sets[3],
+		dow:         sets[4],
+		domWildcard: strings.HasPrefix(parts[2], "*"),
+		dowWildcard: strings.HasPrefix(parts[4], "*"),
+	}, nil
+}
+
+// parseField returns the values a field matches as a bit set
+func parseField(field string, min, max int) (uint64, error) {
+	var set uint64
+	for _, item := range strings.Split(field, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(item, "/")
+		step := 1
+		if hasStep {
+			var err error
+			if step, err = strconv.Atoi(stepPart); err != nil || step <= 0 {
+				return 0, fmt.Errorf("invalid step %q", stepPart)
+			}
+		}
+
+		lo, hi := min, max
+		if rangePart != "*" {
+			first, last, isRange := strings.Cut(rangePart, "-")
+			var err error
+			if lo, err = strconv.Atoi(first); err != nil {
+				return 0, fmt.Errorf("invalid value %q", first)
+			}
+			hi = lo
+			if isRange {
+				if hi, err = strconv.Atoi(last); err != nil {
+					return 0, fmt.Errorf("invalid value %q", last)
+				}
+			} else if hasStep {
+				hi = max
+			}
+		}
+		if lo < min || hi > max || lo > hi {
+			return 0, fmt.Errorf("%q is outside %d-%d", item, min, max)
+		}
+
+		for v := lo; v <= hi; v += step {
+			set |= 1 << uint(v)
+		}
+	}
+	return set, nil
+}
+
+// String returns the expression the schedule was parsed from
+func (s *Schedule) String() string {
+	return s.spec
+}
+
+// matchesDay applies cron's rule that a restricted day of month and day of
+// week match when either does
+func (s *Schedule) matchesDay(t time.Time) bool {
+	domMatch := s.dom&(1<<uint(t.Day())) != 0
+	dowMatch := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.domWildcard || s.dowWildcard {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
+
+// Next returns the first time the schedule fires strictly after t, or the
+// zero time when it never fires within five years
+func (s *Schedule) Next(t time.Time) time.Time {
+	loc := market.Location()
+	t = t.In(loc).Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		switch {
+		case s.month&(1<<uint(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+		case !s.matchesDay(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+		case s.hour&(1<<uint(t.Hour())) == 0:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+		case s.minute&(1<<uint(t.Minute())) == 0:
+			// Jump to the next matching minute of this hour, if any
+			later := s.minute >> uint(t.Minute())
+			if later == 0 {
+				t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+			} else {
+				t = t.Add(time.Duration(bits.TrailingZeros64(later)) * time.Minute)
+			}
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// Missed returns the times the schedule fired in (since, until], oldest first
+func (s *Schedule) Missed(since, until time.Time) []time.Time {
+	var times []time.Time
+	for next := s.Next(since); !next.IsZero() && !next.After(until); next = s.Next(next) {
+		times = append(times, next)
+	}
+	return times
+}
+
+// Checkpoint remembers the last scheduled run that completed, so runs missed
+// while the process was down can be caught up on the next start
+type Checkpoint struct {
+	path    string
+	LastRun time.Time `json:"last_run"`
+}
+
+// OpenCheckpoint loads the checkpoint stored at path; a missing file has a zero LastRun
+func OpenCheckpoint(path string) (*Checkpoint, error) {
+	c := &Checkpoint{path: path}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schedule checkpoint: %w", err)
+	}
+	if err := json.Unmarshal(data, c); err != nil {
+		return nil, fmt.Errorf("failed to decode schedule checkpoint %s: %w", path, err)
+	}
+	return c, nil
+}
+
+// Advance records a completed run scheduled at t
+func (c *Checkpoint) Advance(t time.Time) error {
+	c.LastRun = t
+
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode schedule checkpoint: %w", err)
+	}
+
+	// Write then rename, so a crash never leaves a truncated checkpoint
+	tmp, err := os.CreateTemp(filepath.Dir(c.path), ".schedule-*")
+	if err != nil {
+		return fmt.Errorf("failed to write schedule checkpoint: %w", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write schedule checkpoint: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write schedule checkpoint: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), c.path); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write schedule checkpoint: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package source
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Opener opens CSV inputs given either as local paths or as HTTP(S) URLs
+type Opener struct {
+	Headers http.Header // sent with every HTTP request, e.g. Authorization
+	Client  *http.Client
+}
+
+// NewOpener creates an Opener sending the given headers with remote requests
+func NewOpener(headers http.Header) *Opener {
+	return &Opener{
+		Headers: headers,
+		Client:  &http.Client{Timeout: 5 * time.Minute},
+	}
+}
+
+// IsURL reports whether location is an HTTP(S) URL rather than a local path
+func IsURL(location string) bool {
+	return strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://")
+}
+
+// BaseName returns the file name of a local path or URL, used to classify inputs
+func BaseName(location string) string {
+	if IsURL(location) {
+		if u, err := url.Parse(location); err == nil {
+			return path.Base(u.Path)
+		}
+	}
+	return filepath.Base(location)
+}
+
+// Open returns a reader for the location; the caller must close it
+func (o *Opener) Open(ctx context.Context, location string) (io.ReadCloser, error) {
+	if !IsURL(location) {
+		file, err := os.Open(location)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open file: %w", err)
+		}
+		return file, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, location, nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL %s: %w", location, err)
+	}
+	for key, values := range o.Headers {
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
+
+	resp, err := o.Client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", location, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("failed to fetch %s: %s", location, resp.Status)
+	}
+
+	return resp.Body, nil
+}
+
+// ParseHeaders parses "Name: value" pairs separated by newlines or semicolons
+func ParseHeaders(spec string) (http.Header, error) {
+	headers := http.Header{}
+	for _, line := range strings.FieldsFunc(spec, func(r rune) bool { return r == '\n' || r == ';' }) {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(line, ":")
+		if !ok || strings.TrimSpace(name) == "" {
+			return nil, fmt.Errorf("invalid header %q, expected \"Name: value\"", line)
+		}
+		headers.Add(strings.TrimSpace(name), strings.TrimSpace(value))
+	}
+	return headers, nil
+}
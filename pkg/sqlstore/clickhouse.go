@@ -0,0 +1,133 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// ClickHouse stores multi-year order histories for analytics. Orders, keyed
+// by their dedup key, and profit/loss samples go to ReplacingMergeTrees
+// partitioned by month, summaries to one keyed by day, so re-imports replace
+// rows on merge and reads use FINAL. Each file's orders are sent as one block and the
+// server buffers small inserts asynchronously.
+var ClickHouse = Dialect{
+	Name:       "clickhouse",
+	Driver:     "clickhouse",
+	Replacing:  true,
+	PrepareDSN: clickHouseDSN,
+	Setup:      setupClickHouse,
+	Schema: []string{
+		ordersTable,
+		`CREATE TABLE IF NOT EXISTS profit_loss (
+			account LowCardinality(String),
+			timestamp DateTime64(3, 'UTC'),
+			value Float64,
+			source String,
+			import_run_id String
+		) ENGINE = ReplacingMergeTree
+		PARTITION BY toYYYYMM(timestamp)
+		ORDER BY (account, timestamp)`,
+		`CREATE TABLE IF NOT EXISTS daily_summary (
+			account LowCardinality(String),
+			date DateTime64(3, 'UTC'),
+			total_trades Int32,
+			total_buy_quantity Float64,
+			total_sell_quantity Float64,
+			unique_symbols Int32,
+			realized_pnl Float64,
+			broker_mtm Nullable(Float64),
+			gross_pnl Float64 DEFAULT 0,
+			charges Float64 DEFAULT 0,
+			net_pnl Float64 DEFAULT 0,
+			buy_turnover Float64,
+			sell_turnover Float64,
+			premium_collected Float64,
+			premium_bought_back Float64,
+			net_premium Float64,
+			premium_retained_pct Nullable(Float64),
+			last_updated DateTime64(3, 'UTC')
+		) ENGINE = ReplacingMergeTree(last_updated)
+		ORDER BY (account, date)`,
+		// Tables created when quantities and strikes were whole numbers
+		`ALTER TABLE orders MODIFY COLUMN quantity Float64, MODIFY COLUMN strike_price Float64`,
+		`ALTER TABLE daily_summary MODIFY COLUMN total_buy_quantity Float64, MODIFY COLUMN total_sell_quantity Float64`,
+		// Tables created before summaries carried charges
+		`ALTER TABLE daily_summary ADD COLUMN IF NOT EXISTS gross_pnl Float64 DEFAULT 0 AFTER broker_mtm,
+			ADD COLUMN IF NOT EXISTS charges Float64 DEFAULT 0 AFTER gross_pnl,
+			ADD COLUMN IF NOT EXISTS net_pnl Float64 DEFAULT 0 AFTER charges`,
+		// Tables created before orders carried dedup keys
+		`ALTER TABLE orders ADD COLUMN IF NOT EXISTS dedup_key String DEFAULT ''`,
+	},
+}
+
+// ordersTable keeps one row per dedup key of an account
+const ordersTable = `CREATE TABLE IF NOT EXISTS orders (
+			account LowCardinality(String),
+			timestamp DateTime64(3, 'UTC'),
+			transaction_type LowCardinality(String),
+			symbol LowCardinality(String),
+			product LowCardinality(String),
+			quantity Float64,
+			average_price Float64,
+			order_status LowCardinality(String),
+			execution_time Nullable(DateTime64(3, 'UTC')),
+			source String,
+			voided Bool DEFAULT false,
+			strike_price Float64,
+			option_type LowCardinality(String),
+			dedup_key String DEFAULT ''
+		) ENGINE = ReplacingMergeTree
+		PARTITION BY toYYYYMM(timestamp)
+		ORDER BY (account, dedup_key)`
+
+func init() {
+	Register(ClickHouse)
+}
+
+// setupClickHouse moves the orders of a table created as a plain MergeTree,
+// before orders were deduplicated, into a ReplacingMergeTree. Orders stored
+// without a dedup key are keyed by their content, so copies of the same
+// order collapse into one.
+func setupClickHouse(ctx context.Context, db *sql.DB) error {
+	var engine string
+	err := db.QueryRowContext(ctx, `SELECT engine FROM system.tables
+		WHERE database = currentDatabase() AND name = 'orders'`).Scan(&engine)
+	if err != nil {
+		return fmt.Errorf("failed to read the engine of orders: %w", err)
+	}
+	if engine != "MergeTree" {
+		return nil
+	}
+
+	const columns = `account, timestamp, transaction_type, symbol, product, quantity, average_price,
+		order_status, execution_time, source, voided, strike_price, option_type`
+	for _, statement := range []string{
+		`DROP TABLE IF EXISTS orders_replacing`,
+		strings.Replace(ordersTable, "orders (", "orders_replacing (", 1),
+		`INSERT INTO orders_replacing (` + columns + `, dedup_key) SELECT ` + columns + `,
+			if(dedup_key = '', lower(hex(SHA1(concat(account, '|', toString(timestamp), '|', symbol, '|',
+				transaction_type, '|', toString(quantity), '|', toString(average_price))))), dedup_key)
+			FROM orders`,
+		`EXCHANGE TABLES orders AND orders_replacing`,
+		`DROP TABLE orders_replacing`,
+	} {
+		if _, err := db.ExecContext(ctx, statement); err != nil {
+			return fmt.Errorf("failed to move orders to a ReplacingMergeTree: %w", err)
+		}
+	}
+	return nil
+}
+
+// clickHouseDSN enables asynchronous inserts unless the DSN configures them
+func clickHouseDSN(dsn string) string {
+	if strings.Contains(dsn, "async_insert") {
+		return dsn
+	}
+	separator := "?"
+	if strings.Contains(dsn, "?") {
+		separator = "&"
+	}
+	return dsn + separator + "async_insert=1&wait_for_async_insert=1"
+}
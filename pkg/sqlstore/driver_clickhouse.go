@@ -0,0 +1,4 @@
+package sqlstore
+
+// The clickhouse-go driver serves the clickhouse dialect
+import _ "github.com/ClickHouse/clickhouse-go/v2"
@@ -0,0 +1,4 @@
+package sqlstore
+
+// The pgx driver serves the postgres and timescale dialects
+import _ "github.com/jackc/pgx/v5/stdlib"
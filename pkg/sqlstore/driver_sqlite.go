@@ -0,0 +1,4 @@
+package sqlstore
+
+// The pure-Go SQLite driver serves the sqlite dialect without cgo
+import _ "modernc.org/sqlite"
@@ -0,0 +1,73 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+)
+
+// Postgres stores data in PostgreSQL; when the TimescaleDB extension is
+// installed the orders and profit/loss tables become hypertables.
+var Postgres = Dialect{
+	Name:      "postgres",
+	Driver:    "pgx",
+	Numbered:  true,
+	TimeType:  "TIMESTAMPTZ",
+	FloatType: "DOUBLE PRECISION",
+	BoolType:  "BOOLEAN",
+	Setup:     setupPostgres,
+}
+
+func init() {
+	Register(Postgres)
+}
+
+// setupPostgres widens quantity and strike columns of tables created when
+// they were whole numbers, then sets up TimescaleDB
+func setupPostgres(ctx context.Context, db *sql.DB) error {
+	for _, column := range [][2]string{
+		{"orders", "quantity"},
+		{"orders", "strike_price"},
+		{"daily_summary", "total_buy_quantity"},
+		{"daily_summary", "total_sell_quantity"},
+	} {
+		var dataType string
+		err := db.QueryRowContext(ctx, `SELECT data_type FROM information_schema.columns
+			WHERE table_schema = current_schema() AND table_name = $1 AND column_name = $2`, column[0], column[1]).Scan(&dataType)
+		if err != nil {
+			return fmt.Errorf("failed to read the type of %s.%s: %w", column[0], column[1], err)
+		}
+		if dataType != "integer" {
+			continue
+		}
+		if _, err := db.ExecContext(ctx, fmt.Sprintf(`ALTER TABLE %s ALTER COLUMN %s TYPE DOUBLE PRECISION`, column[0], column[1])); err != nil {
+			return fmt.Errorf("failed to widen %s.%s: %w", column[0], column[1], err)
+		}
+	}
+	return setupTimescale(ctx, db)
+}
+
+// hypertables are partitioned by their timestamp column under TimescaleDB
+var hypertables = []string{"orders", "profit_loss"}
+
+// setupTimescale converts the time-series tables to hypertables when TimescaleDB is available
+func setupTimescale(ctx context.Context, db *sql.DB) error {
+	var installed bool
+	err := db.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM pg_extension WHERE extname = 'timescaledb')`).Scan(&installed)
+	if err != nil {
+		return fmt.Errorf("failed to check for TimescaleDB: %w", err)
+	}
+	if !installed {
+		log.Printf("TimescaleDB extension not installed; using plain PostgreSQL tables")
+		return nil
+	}
+
+	for _, table := range hypertables {
+		_, err := db.ExecContext(ctx, `SELECT create_hypertable($1, 'timestamp', if_not_exists => TRUE, migrate_data => TRUE)`, table)
+		if err != nil {
+			return fmt.Errorf("failed to create hypertable %s: %w", table, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// SQLite stores everything in a single local file, for running offline
+// without a database server, through the pure Go modernc.org/sqlite driver.
+// Orders already in the file are left out of re-imports by the unique dedup
+// key index.
+var SQLite = Dialect{
+	Name:           "sqlite",
+	Driver:         "sqlite",
+	TimeType:       "TIMESTAMP",
+	FloatType:      "REAL",
+	BoolType:       "BOOLEAN",
+	InsertOrIgnore: true,
+	Setup:          setupSQLite,
+}
+
+func init() {
+	Register(SQLite)
+}
+
+// setupSQLite lets readers run alongside an import and waits out short locks
+func setupSQLite(ctx context.Context, db *sql.DB) error {
+	for _, pragma := range []string{"PRAGMA journal_mode = WAL", "PRAGMA busy_timeout = 5000"} {
+		if _, err := db.ExecContext(ctx, pragma); err != nil {
+			return fmt.Errorf("failed to run %s: %w", pragma, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"profitLossAndTradeInfoToDB/pkg/market"
+)
+
+func TestSQLiteLegacyOrders(t *testing.T) {
+	// A file written before orders carried dedup keys keeps its orders, and
+	// imports into it skip the orders they stored before
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "orders.db")
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	legacy := time.Date(2024, 3, 13, 10, 0, 0, 0, time.UTC)
+	for _, statement := range []string{
+		`CREATE TABLE orders (
+			account TEXT NOT NULL, timestamp TIMESTAMP NOT NULL, transaction_type TEXT NOT NULL,
+			symbol TEXT NOT NULL, product TEXT NOT NULL, quantity REAL NOT NULL, average_price REAL NOT NULL,
+			order_status TEXT NOT NULL, execution_time TIMESTAMP, source TEXT NOT NULL,
+			voided BOOLEAN NOT NULL DEFAULT FALSE, strike_price INTEGER NOT NULL, option_type TEXT NOT NULL
+		)`,
+		`INSERT INTO orders VALUES ('acct', ?, 'B', 'NIFTY24MAR22000CE', 'NRML', 50, 100, 'COMPLETE', NULL, 'old.csv', FALSE, 22000, 'C')`,
+		`INSERT INTO orders VALUES ('acct', ?, 'B', 'NIFTY24MAR22000CE', 'NRML', 50, 100, 'COMPLETE', NULL, 'old.csv', FALSE, 22000, 'C')`,
+	} {
+		var args []interface{}
+		if strings.Contains(statement, "?") {
+			args = append(args, legacy)
+		}
+		if _, err := db.ExecContext(ctx, statement, args...); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	store, err := Open(ctx, "sqlite", path, "acct")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	for i := 0; i < 2; i++ {
+		if err := store.InsertOrders(ctx, parseTestOrders(t)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	from := time.Date(2024, 3, 13, 0, 0, 0, 0, market.Location())
+	orders, err := store.GetOrdersByDateRange(ctx, from, from.AddDate(0, 0, 2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(orders) != 7 {
+		t.Errorf("stored %d orders, want the 2 legacy ones and 5 imported", len(orders))
+	}
+}
@@ -0,0 +1,508 @@
+// Package sqlstore stores orders, profit/loss samples and daily summaries in a
+// SQL database through database/sql. Each supported database registers a
+// Dialect, and every dialect's driver is linked into the binary.
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"profitLossAndTradeInfoToDB/constants"
+	orderbook "profitLossAndTradeInfoToDB/orderbooks"
+	"profitLossAndTradeInfoToDB/pkg/charges"
+	"profitLossAndTradeInfoToDB/pkg/market"
+	"profitLossAndTradeInfoToDB/pkg/money"
+	"profitLossAndTradeInfoToDB/pkg/profitLossGraph"
+	"profitLossAndTradeInfoToDB/pkg/symbol"
+)
+
+// Dialect adapts the store to one database
+type Dialect struct {
+	Name   string
+	Driver string // database/sql driver name
+	// Numbered uses $1, $2, ... placeholders instead of ?
+	Numbered bool
+	// Column types substituted into the schema
+	TimeType, FloatType, BoolType string
+	// Setup runs after the tables are created, e.g. to turn them into hypertables
+	Setup func(ctx context.Context, db *sql.DB) error
+	// Schema replaces the default schema for databases whose DDL differs
+	Schema []string
+	// Replacing marks tables that keep the newest row per key on their own:
+	// upserts become plain inserts and reads of upserted tables use FINAL
+	Replacing bool
+	// InsertOrIgnore skips rows already stored with INSERT OR IGNORE rather
+	// than ON CONFLICT DO NOTHING
+	InsertOrIgnore bool
+	// PrepareDSN adds the dialect's default settings to the connection string
+	PrepareDSN func(dsn string) string
+}
+
+var dialects = map[string]Dialect{}
+
+// Register makes a dialect available to Open
+func Register(d Dialect) {
+	dialects[d.Name] = d
+}
+
+// Dialects returns the registered dialect names
+func Dialects() []string {
+	names := make([]string, 0, len(dialects))
+	for name := range dialects {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// schema creates the tables; {time}, {float} and {bool} are replaced by the dialect's types
+var schema = []string{
+	`CREATE TABLE IF NOT EXISTS orders (
+		account TEXT NOT NULL,
+		timestamp {time} NOT NULL,
+		transaction_type TEXT NOT NULL,
+		symbol TEXT NOT NULL,
+		product TEXT NOT NULL,
+		quantity {float} NOT NULL,
+		average_price {float} NOT NULL,
+		order_status TEXT NOT NULL,
+		execution_time {time},
+		source TEXT NOT NULL,
+		voided {bool} NOT NULL DEFAULT FALSE,
+		strike_price {float} NOT NULL,
+		option_type TEXT NOT NULL,
+		dedup_key TEXT
+	)`,
+	`CREATE INDEX IF NOT EXISTS orders_account_timestamp ON orders (account, timestamp)`,
+	`CREATE TABLE IF NOT EXISTS profit_loss (
+		account TEXT NOT NULL,
+		timestamp {time} NOT NULL,
+		value {float} NOT NULL,
+		source TEXT NOT NULL,
+		import_run_id TEXT NOT NULL,
+		PRIMARY KEY (account, timestamp)
+	)`,
+	`CREATE TABLE IF NOT EXISTS daily_summary (
+		account TEXT NOT NULL,
+		date {time} NOT NULL,
+		total_trades INTEGER NOT NULL,
+		total_buy_quantity {float} NOT NULL,
+		total_sell_quantity {float} NOT NULL,
+		unique_symbols INTEGER NOT NULL,
+		realized_pnl {float} NOT NULL,
+		broker_mtm {float},
+		gross_pnl {float} NOT NULL DEFAULT 0,
+		charges {float} NOT NULL DEFAULT 0,
+		net_pnl {float} NOT NULL DEFAULT 0,
+		buy_turnover {float} NOT NULL,
+		sell_turnover {float} NOT NULL,
+		premium_collected {float} NOT NULL,
+		premium_bought_back {float} NOT NULL,
+		net_premium {float} NOT NULL,
+		premium_retained_pct {float},
+		last_updated {time} NOT NULL,
+		PRIMARY KEY (account, date)
+	)`,
+}
+
+// addedColumns are columns of the default schema added after its tables were
+// first released; migrate adds them to tables created without them
+var addedColumns = []struct{ table, column, definition string }{
+	{"daily_summary", "gross_pnl", "{float} NOT NULL DEFAULT 0"},
+	{"daily_summary", "charges", "{float} NOT NULL DEFAULT 0"},
+	{"daily_summary", "net_pnl", "{float} NOT NULL DEFAULT 0"},
+	{"orders", "dedup_key", "TEXT"},
+}
+
+// addedIndexes are created after addedColumns, as they may cover added columns.
+// An order is stored once per dedup key; the key covers the order's time, which
+// is in the unique index as TimescaleDB requires of hypertables. Orders stored
+// before dedup keys were recorded have none and are not deduplicated.
+var addedIndexes = []string{
+	`CREATE UNIQUE INDEX IF NOT EXISTS orders_account_dedup_key ON orders (account, timestamp, dedup_key)`,
+}
+
+// Store is a SQL backed store scoped to one account
+type Store struct {
+	db      *sql.DB
+	dialect Dialect
+	account string
+	charges charges.Schedule
+}
+
+// Open connects to the database named by dsn, creates missing tables and
+// returns a store scoped to account
+func Open(ctx context.Context, dialectName, dsn, account string) (*Store, error) {
+	dialect, ok := dialects[dialectName]
+	if !ok {
+		return nil, fmt.Errorf("unknown database backend %q, expected one of %s", dialectName, strings.Join(Dialects(), ", "))
+	}
+	if dsn == "" {
+		return nil, fmt.Errorf("a DSN is required for the %s backend", dialectName)
+	}
+	if account == "" {
+		account = constants.DEFAULT_ACCOUNT
+	}
+
+	if dialect.PrepareDSN != nil {
+		dsn = dialect.PrepareDSN(dsn)
+	}
+
+	db, err := sql.Open(dialect.Driver, dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s database: %w", dialectName, err)
+	}
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to ping %s database: %w", dialectName, err)
+	}
+
+	s := &Store{db: db, dialect: dialect, account: account, charges: charges.DefaultSchedule}
+	if err := s.migrate(ctx); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// migrate creates the tables and runs the dialect's setup
+func (s *Store) migrate(ctx context.Context) error {
+	statements := schema
+	if s.dialect.Schema != nil {
+		statements = s.dialect.Schema
+	}
+
+	types := strings.NewReplacer("{time}", s.dialect.TimeType, "{float}", s.dialect.FloatType, "{bool}", s.dialect.BoolType)
+	for _, statement := range statements {
+		if _, err := s.db.ExecContext(ctx, types.Replace(statement)); err != nil {
+			return fmt.Errorf("failed to create schema: %w", err)
+		}
+	}
+	if s.dialect.Schema == nil {
+		for _, added := range addedColumns {
+			// Selecting the column fails when the table predates it
+			if _, err := s.db.ExecContext(ctx, fmt.Sprintf(`SELECT %s FROM %s WHERE 1 = 0`, added.column, added.table)); err == nil {
+				continue
+			}
+			statement := fmt.Sprintf(`ALTER TABLE %s ADD COLUMN %s %s`, added.table, added.column, types.Replace(added.definition))
+			if _, err := s.db.ExecContext(ctx, statement); err != nil {
+				return fmt.Errorf("failed to add %s.%s: %w", added.table, added.column, err)
+			}
+		}
+		for _, index := range addedIndexes {
+			if _, err := s.db.ExecContext(ctx, index); err != nil {
+				return fmt.Errorf("failed to create index: %w", err)
+			}
+		}
+	}
+	if s.dialect.Setup != nil {
+		if err := s.dialect.Setup(ctx, s.db); err != nil {
+			return fmt.Errorf("failed to set up %s schema: %w", s.dialect.Name, err)
+		}
+	}
+	return nil
+}
+
+// SetCharges sets the rates the net P&L of summaries is computed at,
+// charges.DefaultSchedule unless set
+func (s *Store) SetCharges(schedule charges.Schedule) {
+	s.charges = schedule
+}
+
+// Close closes the database connection
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// Account returns the account the store is scoped to
+func (s *Store) Account() string {
+	return s.account
+}
+
+// rebind rewrites ? placeholders for dialects with numbered placeholders
+func (s *Store) rebind(query string) string {
+	if !s.dialect.Numbered {
+		return query
+	}
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// upsert turns an insert into an upsert on the key columns, unless the dialect's tables replace rows themselves
+func (s *Store) upsert(insert string, key string, columns ...string) string {
+	if s.dialect.Replacing {
+		return s.rebind(insert)
+	}
+	sets := make([]string, len(columns))
+	for i, column := range columns {
+		sets[i] = column + " = excluded." + column
+	}
+	return s.rebind(insert + " ON CONFLICT (" + key + ") DO UPDATE SET " + strings.Join(sets, ", "))
+}
+
+// insertNew turns an insert into one that skips rows whose unique key is
+// already stored, unless the dialect's tables replace rows themselves
+func (s *Store) insertNew(insert string) string {
+	if s.dialect.Replacing {
+		return s.rebind(insert)
+	}
+	if s.dialect.InsertOrIgnore {
+		return s.rebind(strings.Replace(insert, "INSERT INTO", "INSERT OR IGNORE INTO", 1))
+	}
+	return s.rebind(insert + " ON CONFLICT DO NOTHING")
+}
+
+// final returns the table name to read upserted rows from
+func (s *Store) final(table string) string {
+	if s.dialect.Replacing {
+		return table + " FINAL"
+	}
+	return table
+}
+
+// InsertOrders stores orders in one transaction and recomputes the summaries
+// of their days. Orders already stored under the same dedup key are skipped,
+// so importing a file again leaves the data as it was.
+func (s *Store) InsertOrders(ctx context.Context, orders []orderbook.Order) error {
+	if len(orders) == 0 {
+		return nil
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, s.insertNew(`INSERT INTO orders
+		(account, timestamp, transaction_type, symbol, product, quantity, average_price,
+		 order_status, execution_time, source, voided, strike_price, option_type, dedup_key)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`))
+	if err != nil {
+		return fmt.Errorf("failed to prepare order insert: %w", err)
+	}
+	defer stmt.Close()
+
+	dates := make([]time.Time, len(orders))
+	for i, order := range orders {
+		var executed interface{}
+		if order.ExecutionTime != nil {
+			executed = order.ExecutionTime.UTC()
+		}
+		_, err := stmt.ExecContext(ctx,
+			s.account, order.Timestamp.UTC(), order.TransactionType, order.Symbol, order.Product,
+			order.Quantity, order.AveragePrice, order.OrderStatus, executed, order.Source, order.Voided,
+			order.MetaData.StrikePrice, order.MetaData.OptionType, order.DedupKey,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to insert order: %w", err)
+		}
+		dates[i] = order.Timestamp
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit orders: %w", err)
+	}
+
+	return s.RefreshDays(ctx, dates)
+}
+
+// GetOrdersByDateRange returns the account's orders in [from, to), oldest first
+func (s *Store) GetOrdersByDateRange(ctx context.Context, from, to time.Time) ([]orderbook.Order, error) {
+	rows, err := s.db.QueryContext(ctx, s.rebind(`SELECT
+		timestamp, transaction_type, symbol, product, quantity, average_price,
+		order_status, execution_time, source, voided, strike_price, option_type, dedup_key
+		FROM `+s.final("orders")+` WHERE account = ? AND timestamp >= ? AND timestamp < ?
+		ORDER BY timestamp`), s.account, from.UTC(), to.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("failed to query orders: %w", err)
+	}
+	defer rows.Close()
+
+	var orders []orderbook.Order
+	for rows.Next() {
+		order := orderbook.Order{Account: s.account}
+		var executed sql.NullTime
+		var key sql.NullString
+		err := rows.Scan(&order.Timestamp, &order.TransactionType, &order.Symbol, &order.Product,
+			&order.Quantity, &order.AveragePrice, &order.OrderStatus, &executed, &order.Source,
+			&order.Voided, &order.MetaData.StrikePrice, &order.MetaData.OptionType, &key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode order: %w", err)
+		}
+		if executed.Valid {
+			order.ExecutionTime = &executed.Time
+		}
+		order.DedupKey = key.String
+		order.InstrumentType = string(symbol.Parse(order.Symbol).Type)
+		orders = append(orders, order)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read orders: %w", err)
+	}
+
+	return orders, nil
+}
+
+// SaveProfitLossEntries upserts entries keyed by (account, timestamp)
+func (s *Store) SaveProfitLossEntries(ctx context.Context, entries []profitLossGraph.ProfitLossEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, s.upsert(`INSERT INTO profit_loss
+		(account, timestamp, value, source, import_run_id) VALUES (?, ?, ?, ?, ?)`,
+		"account, timestamp", "value", "source"))
+	if err != nil {
+		return fmt.Errorf("failed to prepare entry upsert: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, entry := range entries {
+		if _, err := stmt.ExecContext(ctx, s.account, entry.Timestamp.UTC(), entry.Value, entry.Source, entry.ImportRunID); err != nil {
+			return fmt.Errorf("failed to upsert entry: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit entries: %w", err)
+	}
+	return nil
+}
+
+// GetProfitLossByDateRange returns the account's samples in [start, end], oldest first
+func (s *Store) GetProfitLossByDateRange(ctx context.Context, start, end time.Time) ([]profitLossGraph.ProfitLossEntry, error) {
+	rows, err := s.db.QueryContext(ctx, s.rebind(`SELECT timestamp, value, source, import_run_id
+		FROM `+s.final("profit_loss")+` WHERE account = ? AND timestamp >= ? AND timestamp <= ?
+		ORDER BY timestamp`), s.account, start.UTC(), end.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("failed to query profit loss: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []profitLossGraph.ProfitLossEntry
+	for rows.Next() {
+		entry := profitLossGraph.ProfitLossEntry{Account: s.account}
+		if err := rows.Scan(&entry.Timestamp, &entry.Value, &entry.Source, &entry.ImportRunID); err != nil {
+			return nil, fmt.Errorf("failed to decode entry: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read profit loss: %w", err)
+	}
+
+	return entries, nil
+}
+
+// RefreshDays recomputes the summaries of the market days containing dates
+func (s *Store) RefreshDays(ctx context.Context, dates []time.Time) error {
+	seen := map[time.Time]bool{}
+	for _, date := range dates {
+		day := market.DayStart(date)
+		if seen[day] {
+			continue
+		}
+		seen[day] = true
+
+		if err := s.refreshDay(ctx, day); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Store) refreshDay(ctx context.Context, day time.Time) error {
+	end := day.AddDate(0, 0, 1)
+	orders, err := s.GetOrdersByDateRange(ctx, day, end)
+	if err != nil {
+		return err
+	}
+	summary := orderbook.SummarizeDay(s.account, day, orders, s.charges)
+
+	var mtm sql.NullFloat64
+	err = s.db.QueryRowContext(ctx, s.rebind(`SELECT value FROM `+s.final("profit_loss")+`
+		WHERE account = ? AND timestamp >= ? AND timestamp < ?
+		ORDER BY timestamp DESC LIMIT 1`), s.account, day.UTC(), end.UTC()).Scan(&mtm)
+	if err != nil && err != sql.ErrNoRows {
+		return fmt.Errorf("failed to read broker MTM: %w", err)
+	}
+	if mtm.Valid {
+		brokerMTM := money.FromRupees(mtm.Float64)
+		summary.BrokerMTM = &brokerMTM
+	}
+
+	// The summary columns hold rupees; the amounts are rounded to the paisa
+	_, err = s.db.ExecContext(ctx, s.upsert(`INSERT INTO daily_summary
+		(account, date, total_trades, total_buy_quantity, total_sell_quantity,
+		 unique_symbols, realized_pnl, broker_mtm, gross_pnl, charges, net_pnl, buy_turnover, sell_turnover,
+		 premium_collected, premium_bought_back, net_premium, premium_retained_pct, last_updated)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		"account, date", "total_trades", "total_buy_quantity", "total_sell_quantity",
+		"unique_symbols", "realized_pnl", "broker_mtm", "gross_pnl", "charges", "net_pnl", "buy_turnover", "sell_turnover",
+		"premium_collected", "premium_bought_back", "net_premium", "premium_retained_pct", "last_updated"),
+		s.account, day.UTC(), summary.TotalTrades, summary.TotalBuyQuantity, summary.TotalSellQuantity,
+		summary.UniqueSymbols, summary.RealizedPnL.Rupees(), mtm, summary.GrossPnL.Rupees(), summary.Charges.Rupees(), summary.NetPnL.Rupees(),
+		summary.BuyTurnover.Rupees(), summary.SellTurnover.Rupees(),
+		summary.PremiumCollected.Rupees(), summary.PremiumBoughtBack.Rupees(), summary.NetPremium.Rupees(), summary.PremiumRetainedPct,
+		summary.LastUpdated.UTC())
+	if err != nil {
+		return fmt.Errorf("failed to upsert daily summary: %w", err)
+	}
+	return nil
+}
+
+// GetDailySummary returns the summary of the market day containing date
+func (s *Store) GetDailySummary(ctx context.Context, date time.Time) (*orderbook.DailySummary, error) {
+	day := market.DayStart(date)
+	summary := orderbook.DailySummary{Account: s.account}
+	var mtm, retained sql.NullFloat64
+	var realized, gross, charged, net, buy, sell, collected, boughtBack, premium float64
+
+	err := s.db.QueryRowContext(ctx, s.rebind(`SELECT date, total_trades, total_buy_quantity,
+		total_sell_quantity, unique_symbols, realized_pnl, broker_mtm, gross_pnl, charges, net_pnl,
+		buy_turnover, sell_turnover, premium_collected, premium_bought_back, net_premium, premium_retained_pct, last_updated
+		FROM `+s.final("daily_summary")+` WHERE account = ? AND date = ?`), s.account, day.UTC()).
+		Scan(&summary.Date, &summary.TotalTrades, &summary.TotalBuyQuantity, &summary.TotalSellQuantity,
+			&summary.UniqueSymbols, &realized, &mtm, &gross, &charged, &net,
+			&buy, &sell, &collected, &boughtBack, &premium, &retained,
+			&summary.LastUpdated)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get daily summary: %w", err)
+	}
+	summary.RealizedPnL, summary.GrossPnL = money.FromRupees(realized), money.FromRupees(gross)
+	summary.Charges, summary.NetPnL = money.FromRupees(charged), money.FromRupees(net)
+	summary.BuyTurnover, summary.SellTurnover = money.FromRupees(buy), money.FromRupees(sell)
+	summary.PremiumCollected, summary.PremiumBoughtBack = money.FromRupees(collected), money.FromRupees(boughtBack)
+	summary.NetPremium = money.FromRupees(premium)
+	if mtm.Valid {
+		brokerMTM := money.FromRupees(mtm.Float64)
+		summary.BrokerMTM = &brokerMTM
+	}
+	if retained.Valid {
+		summary.PremiumRetainedPct = &retained.Float64
+	}
+	summary.Date = summary.Date.In(market.Location())
+
+	return &summary, nil
+}
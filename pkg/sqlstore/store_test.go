@@ -0,0 +1,104 @@
+package sqlstore
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	orderbook "profitLossAndTradeInfoToDB/orderbooks"
+	"profitLossAndTradeInfoToDB/pkg/market"
+	"profitLossAndTradeInfoToDB/pkg/money"
+)
+
+const testOrders = `timestamp,transaction_type,symbol,product,quantity,average_price,order_status
+2024-03-14 09:20:00,B,NIFTY24MAR22000CE,NRML,50,120.5,COMPLETE
+2024-03-14 09:45:00,S,NIFTY24MAR22000CE,NRML,50,131.25,COMPLETE
+2024-03-14 10:05:00,B,BANKNIFTY24MAR47000PE,NRML,15,210,COMPLETE
+2024-03-14 10:30:00,S,BANKNIFTY24MAR47000PE,NRML,15,198.4,COMPLETE
+2024-03-14 11:00:00,B,NIFTY24MAR22000CE,NRML,50,99,CANCELLED
+`
+
+// openTestStore opens a store on a fresh SQLite file
+func openTestStore(t *testing.T) *Store {
+	t.Helper()
+	store, err := Open(context.Background(), "sqlite", filepath.Join(t.TempDir(), "orders.db"), "acct")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+// parseTestOrders reads testOrders as an import reads an export
+func parseTestOrders(t *testing.T) []orderbook.Order {
+	t.Helper()
+	orders, _, err := orderbook.ParseOrders(strings.NewReader(testOrders), "orderbook_14-03-2024.csv", "acct", orderbook.ParseStrict, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return orders
+}
+
+func TestInsertOrdersSummary(t *testing.T) {
+	ctx := context.Background()
+	store := openTestStore(t)
+	if err := store.InsertOrders(ctx, parseTestOrders(t)); err != nil {
+		t.Fatal(err)
+	}
+
+	day := time.Date(2024, 3, 14, 0, 0, 0, 0, market.Location())
+	summary, err := store.GetDailySummary(ctx, day)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The cancelled order is stored but not summarized
+	if summary.TotalTrades != 4 || summary.UniqueSymbols != 2 {
+		t.Errorf("trades = %d in %d symbols, want 4 in 2", summary.TotalTrades, summary.UniqueSymbols)
+	}
+	if want := money.Value(120.5, 50) + money.Value(210, 15); summary.BuyTurnover != want {
+		t.Errorf("buy turnover = %v, want %v", summary.BuyTurnover, want)
+	}
+	// 50 x 10.75 won on the call, 15 x 11.60 lost on the put
+	if want := money.FromRupees(537.5 - 174); summary.RealizedPnL != want {
+		t.Errorf("realized P&L = %v, want %v", summary.RealizedPnL, want)
+	}
+	if summary.NetPnL != summary.GrossPnL-summary.Charges {
+		t.Errorf("net P&L = %v, want %v less %v charges", summary.NetPnL, summary.GrossPnL, summary.Charges)
+	}
+}
+
+func TestInsertOrdersReimport(t *testing.T) {
+	ctx := context.Background()
+	store := openTestStore(t)
+	day := time.Date(2024, 3, 14, 0, 0, 0, 0, market.Location())
+
+	if err := store.InsertOrders(ctx, parseTestOrders(t)); err != nil {
+		t.Fatal(err)
+	}
+	first, err := store.GetDailySummary(ctx, day)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The same export imported again stores nothing new
+	if err := store.InsertOrders(ctx, parseTestOrders(t)); err != nil {
+		t.Fatal(err)
+	}
+	orders, err := store.GetOrdersByDateRange(ctx, day, day.AddDate(0, 0, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(orders) != 5 {
+		t.Errorf("stored %d orders after reimport, want 5", len(orders))
+	}
+	second, err := store.GetDailySummary(ctx, day)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.TotalTrades != first.TotalTrades || second.BuyTurnover != first.BuyTurnover ||
+		second.SellTurnover != first.SellTurnover || second.RealizedPnL != first.RealizedPnL {
+		t.Errorf("summary after reimport = %+v, want %+v", second, first)
+	}
+}
@@ -0,0 +1,140 @@
+package symbol
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"profitLossAndTradeInfoToDB/pkg/market"
+)
+
+// Type is the instrument type of a trading symbol
+type Type string
+
+const (
+	Call   Type = "CE"
+	Put    Type = "PE"
+	Future Type = "FUT"
+	Equity Type = "EQ"
+	// Crypto is set from the export format of crypto exchanges; Parse never returns it
+	Crypto Type = "CRYPTO"
+)
+
+// Contract is what a trading symbol says about the instrument
+type Contract struct {
+	Underlying string
+	Type       Type
+	Expiry     *time.Time // Expiry day in market time; nil for equity
+	Strike     float64    // Zero unless an option
+}
+
+// IsOption reports whether the contract is a call or a put
+func (c Contract) IsOption() bool {
+	return c.Type == Call || c.Type == Put
+}
+
+var months = map[string]time.Month{
+	"JAN": time.January, "FEB": time.February, "MAR": time.March, "APR": time.April,
+	"MAY": time.May, "JUN": time.June, "JUL": time.July, "AUG": time.August,
+	"SEP": time.September, "OCT": time.October, "NOV": time.November, "DEC": time.December,
+}
+
+// Weekly contracts encode the month as 1-9, O, N or D
+var weeklyMonths = map[string]time.Month{
+	"1": time.January, "2": time.February, "3": time.March, "4": time.April,
+	"5": time.May, "6": time.June, "7": time.July, "8": time.August,
+	"9": time.September, "O": time.October, "N": time.November, "D": time.December,
+}
+
+const monthNames = `JAN|FEB|MAR|APR|MAY|JUN|JUL|AUG|SEP|OCT|NOV|DEC`
+
+var (
+	// NIFTY25JAN24C22000 and NIFTY25JAN24F: expiry day, month and year
+	datedOptionPattern = regexp.MustCompile(`^([A-Z][A-Z0-9&_-]*?)(\d{2})(` + monthNames + `)(\d{2})([CP])(\d+(?:\.\d+)?)$`)
+	datedFuturePattern = regexp.MustCompile(`^([A-Z][A-Z0-9&_-]*?)(\d{2})(` + monthNames + `)(\d{2})F$`)
+	// NIFTY24JANFUT
+	futurePattern = regexp.MustCompile(`^([A-Z][A-Z0-9&_-]*?)(\d{2})(` + monthNames + `)FUT$`)
+	// NIFTY24JAN21000CE
+	monthlyPattern = regexp.MustCompile(`^([A-Z][A-Z0-9&_-]*?)(\d{2})(` + monthNames + `)(\d+(?:\.\d+)?)(CE|PE)$`)
+	// NIFTY2411821000CE: year, month code, day, strike
+	weeklyPattern = regexp.MustCompile(`^([A-Z][A-Z0-9&_-]*?)(\d{2})([1-9OND])(\d{2})(\d+(?:\.\d+)?)(CE|PE)$`)
+	// Series suffixes of cash-segment symbols, e.g. RELIANCE-EQ
+	equitySeries = regexp.MustCompile(`-(EQ|BE|BZ|BL|SM|ST)$`)
+)
+
+// Parse reads an NSE or BSE trading symbol: broker symbols carrying the
+// expiry date, exchange monthly and weekly options, futures, and anything
+// else as equity
+func Parse(tradingSymbol string) Contract {
+	s := strings.ToUpper(strings.TrimSpace(tradingSymbol))
+
+	if m := datedOptionPattern.FindStringSubmatch(s); m != nil {
+		if expiry, ok := date(m[4], months[m[3]], m[2]); ok {
+			strike, _ := strconv.ParseFloat(m[6], 64)
+			optionType := Call
+			if m[5] == "P" {
+				optionType = Put
+			}
+			return Contract{Underlying: m[1], Type: optionType, Expiry: &expiry, Strike: strike}
+		}
+	}
+
+	if m := datedFuturePattern.FindStringSubmatch(s); m != nil {
+		if expiry, ok := date(m[4], months[m[3]], m[2]); ok {
+			return Contract{Underlying: m[1], Type: Future, Expiry: &expiry}
+		}
+	}
+
+	if m := futurePattern.FindStringSubmatch(s); m != nil {
+		expiry := MonthlyExpiry(year(m[2]), months[m[3]])
+		return Contract{Underlying: m[1], Type: Future, Expiry: &expiry}
+	}
+
+	if m := monthlyPattern.FindStringSubmatch(s); m != nil {
+		expiry := MonthlyExpiry(year(m[2]), months[m[3]])
+		strike, _ := strconv.ParseFloat(m[4], 64)
+		return Contract{Underlying: m[1], Type: Type(m[5]), Expiry: &expiry, Strike: strike}
+	}
+
+	if m := weeklyPattern.FindStringSubmatch(s); m != nil {
+		if expiry, ok := date(m[2], weeklyMonths[m[3]], m[4]); ok {
+			strike, _ := strconv.ParseFloat(m[5], 64)
+			return Contract{Underlying: m[1], Type: Type(m[6]), Expiry: &expiry, Strike: strike}
+		}
+	}
+
+	return Contract{Underlying: equitySeries.ReplaceAllString(s, ""), Type: Equity}
+}
+
+func year(yy string) int {
+	n, _ := strconv.Atoi(yy)
+	return 2000 + n
+}
+
+// date builds an expiry from two-digit year and day, rejecting days the month does not have
+func date(yy string, month time.Month, dd string) (time.Time, bool) {
+	day, _ := strconv.Atoi(dd)
+	t := time.Date(year(yy), month, day, 0, 0, 0, 0, market.Location())
+	return t, t.Day() == day
+}
+
+// MonthlyExpiry returns the monthly contract expiry of a month: its last
+// Thursday, or last Tuesday from September 2025, moved back over holidays
+func MonthlyExpiry(year int, month time.Month) time.Time {
+	// NSE moved monthly expiries from Thursday to Tuesday in September 2025
+	weekday := time.Thursday
+	if year > 2025 || year == 2025 && month >= time.September {
+		weekday = time.Tuesday
+	}
+	first := time.Date(year, month, 1, 0, 0, 0, 0, market.Location())
+
+	day := first.AddDate(0, 1, -1)
+	for day.Weekday() != weekday {
+		day = day.AddDate(0, 0, -1)
+	}
+	for !market.IsTradingDay(day) && day.Month() == month {
+		day = day.AddDate(0, 0, -1)
+	}
+	return day
+}
@@ -0,0 +1,77 @@
+package symbol
+
+import (
+	"testing"
+	"time"
+
+	"profitLossAndTradeInfoToDB/pkg/market"
+)
+
+func day(year int, month time.Month, d int) *time.Time {
+	t := time.Date(year, month, d, 0, 0, 0, 0, market.Location())
+	return &t
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		symbol string
+		want   Contract
+	}{
+		{"NIFTY25JAN24C22000", Contract{Underlying: "NIFTY", Type: Call, Expiry: day(2024, time.January, 25), Strike: 22000}},
+		{"BANKNIFTY28FEB24P45000.5", Contract{Underlying: "BANKNIFTY", Type: Put, Expiry: day(2024, time.February, 28), Strike: 45000.5}},
+		{"NIFTY25JAN24F", Contract{Underlying: "NIFTY", Type: Future, Expiry: day(2024, time.January, 25)}},
+		{"NIFTY24JANFUT", Contract{Underlying: "NIFTY", Type: Future, Expiry: day(2024, time.January, 25)}},
+		{"M&M24MARFUT", Contract{Underlying: "M&M", Type: Future, Expiry: day(2024, time.March, 28)}},
+		{"BANKNIFTY24FEB45000PE", Contract{Underlying: "BANKNIFTY", Type: Put, Expiry: day(2024, time.February, 29), Strike: 45000}},
+		{"NIFTY25SEP24000CE", Contract{Underlying: "NIFTY", Type: Call, Expiry: day(2025, time.September, 30), Strike: 24000}},
+		{"NIFTY2411821000CE", Contract{Underlying: "NIFTY", Type: Call, Expiry: day(2024, time.January, 18), Strike: 21000}},
+		{"NIFTY24O0325000PE", Contract{Underlying: "NIFTY", Type: Put, Expiry: day(2024, time.October, 3), Strike: 25000}},
+		{" nifty24d1924000ce ", Contract{Underlying: "NIFTY", Type: Call, Expiry: day(2024, time.December, 19), Strike: 24000}},
+		{"RELIANCE-EQ", Contract{Underlying: "RELIANCE", Type: Equity}},
+		{"IDEA-BE", Contract{Underlying: "IDEA", Type: Equity}},
+		{"infy", Contract{Underlying: "INFY", Type: Equity}},
+		// 31 February is no expiry, so the symbol is not read as an option
+		{"NIFTY31FEB24C22000", Contract{Underlying: "NIFTY31FEB24C22000", Type: Equity}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.symbol, func(t *testing.T) {
+			got := Parse(tt.symbol)
+			if got.Underlying != tt.want.Underlying || got.Type != tt.want.Type || got.Strike != tt.want.Strike {
+				t.Errorf("Parse(%q) = %+v, want %+v", tt.symbol, got, tt.want)
+			}
+			switch {
+			case got.Expiry == nil && tt.want.Expiry == nil:
+			case got.Expiry == nil || tt.want.Expiry == nil || !got.Expiry.Equal(*tt.want.Expiry):
+				t.Errorf("Parse(%q) expiry = %v, want %v", tt.symbol, got.Expiry, tt.want.Expiry)
+			}
+		})
+	}
+}
+
+func TestMonthlyExpiry(t *testing.T) {
+	tests := []struct {
+		name     string
+		year     int
+		month    time.Month
+		holidays []string
+		want     *time.Time
+	}{
+		{"last Thursday", 2024, time.January, nil, day(2024, time.January, 25)},
+		{"last Thursday of a leap February", 2024, time.February, nil, day(2024, time.February, 29)},
+		{"last Thursday before the move to Tuesday", 2025, time.August, nil, day(2025, time.August, 28)},
+		{"last Tuesday from September 2025", 2025, time.September, nil, day(2025, time.September, 30)},
+		{"last Tuesday", 2026, time.March, nil, day(2026, time.March, 31)},
+		{"moved back over a holiday", 2026, time.March, []string{"2026-03-31"}, day(2026, time.March, 30)},
+		{"moved back over a holiday and the weekend", 2024, time.March, []string{"2024-03-28", "2024-03-27"}, day(2024, time.March, 26)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			market.SetHolidays(tt.holidays)
+			t.Cleanup(func() { market.SetHolidays(nil) })
+
+			if got := MonthlyExpiry(tt.year, tt.month); !got.Equal(*tt.want) {
+				t.Errorf("MonthlyExpiry(%d, %s) = %s, want %s", tt.year, tt.month, got.Format("2006-01-02"), tt.want.Format("2006-01-02"))
+			}
+		})
+	}
+}
@@ -0,0 +1,140 @@
+package trades
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"profitLossAndTradeInfoToDB/pkg/money"
+)
+
+// Position sides
+const (
+	Long  = "LONG"
+	Short = "SHORT"
+)
+
+// Fill is an executed buy or sell to be matched
+type Fill struct {
+	ID              string // Order the fill came from, carried to the trades it opens
+	Symbol          string
+	TransactionType string // B or S
+	Quantity        float64
+	Price           float64
+	Time            time.Time
+}
+
+// RoundTrip is a closed trade: an entry matched FIFO against an opposite exit
+type RoundTrip struct {
+	EntryID     string        `bson:"entry_id,omitempty" json:"entry_id,omitempty"`
+	Symbol      string        `bson:"symbol" json:"symbol"`
+	Side        string        `bson:"side" json:"side"`
+	Quantity    float64       `bson:"quantity" json:"quantity"`
+	EntryTime   time.Time     `bson:"entry_time" json:"entry_time"`
+	ExitTime    time.Time     `bson:"exit_time" json:"exit_time"`
+	EntryPrice  float64       `bson:"entry_price" json:"entry_price"`
+	ExitPrice   float64       `bson:"exit_price" json:"exit_price"`
+	HoldingTime time.Duration `bson:"holding_time" json:"holding_time"`
+	RealizedPnL money.Paise   `bson:"realized_pnl" json:"realized_pnl"`
+}
+
+// Lot is an open quantity waiting to be matched
+type Lot struct {
+	ID       string    `bson:"id,omitempty" json:"id,omitempty"`
+	Symbol   string    `bson:"symbol" json:"symbol"`
+	Side     string    `bson:"side" json:"side"`
+	Quantity float64   `bson:"quantity" json:"quantity"`
+	Price    float64   `bson:"price" json:"price"`
+	Time     time.Time `bson:"time" json:"time"`
+}
+
+// MatchFIFO pairs buys and sells per symbol in time order, first in first out.
+// It returns the closed round trips and the lots still open at the end.
+func MatchFIFO(fills []Fill) ([]RoundTrip, []Lot) {
+	sorted := append([]Fill(nil), fills...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Time.Before(sorted[j].Time) })
+
+	open := make(map[string][]Lot)
+	var symbols []string
+	var closed []RoundTrip
+
+	for _, fill := range sorted {
+		side := Long
+		if fill.TransactionType == "S" {
+			side = Short
+		}
+
+		queue, seen := open[fill.Symbol]
+		if !seen {
+			symbols = append(symbols, fill.Symbol)
+		}
+
+		remaining := fill.Quantity
+		for remaining > 0 && len(queue) > 0 && queue[0].Side != side {
+			lot := &queue[0]
+			matched := min(remaining, lot.Quantity)
+
+			closed = append(closed, newRoundTrip(*lot, matched, fill.Time, fill.Price))
+
+			lot.Quantity = roundQuantity(lot.Quantity - matched)
+			remaining = roundQuantity(remaining - matched)
+			if lot.Quantity == 0 {
+				queue = queue[1:]
+			}
+		}
+
+		if remaining > 0 {
+			queue = append(queue, Lot{
+				ID:       fill.ID,
+				Symbol:   fill.Symbol,
+				Side:     side,
+				Quantity: remaining,
+				Price:    fill.Price,
+				Time:     fill.Time,
+			})
+		}
+		open[fill.Symbol] = queue
+	}
+
+	var lots []Lot
+	for _, symbol := range symbols {
+		lots = append(lots, open[symbol]...)
+	}
+
+	return closed, lots
+}
+
+func newRoundTrip(lot Lot, quantity float64, exitTime time.Time, exitPrice float64) RoundTrip {
+	pnl := (money.Value(exitPrice, quantity) - money.Value(lot.Price, quantity))
+	if lot.Side == Short {
+		pnl = -pnl
+	}
+
+	return RoundTrip{
+		EntryID:     lot.ID,
+		Symbol:      lot.Symbol,
+		Side:        lot.Side,
+		Quantity:    quantity,
+		EntryTime:   lot.Time,
+		ExitTime:    exitTime,
+		EntryPrice:  lot.Price,
+		ExitPrice:   exitPrice,
+		HoldingTime: exitTime.Sub(lot.Time),
+		RealizedPnL: pnl,
+	}
+}
+
+// roundQuantity drops the float error left when fractional quantities are
+// matched, keeping the 8 decimals crypto exchanges trade in
+func roundQuantity(quantity float64) float64 {
+	return math.Round(quantity*1e8) / 1e8
+}
+
+// RealizedPnL sums the realized P&L of the round trips
+func RealizedPnL(roundTrips []RoundTrip) money.Paise {
+	var total money.Paise
+	for _, trip := range roundTrips {
+		total += trip.RealizedPnL
+	}
+	return total
+}
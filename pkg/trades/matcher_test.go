@@ -0,0 +1,103 @@
+package trades
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"profitLossAndTradeInfoToDB/pkg/money"
+)
+
+// trip and lot are the fields of round trips and open lots the cases check
+type trip struct {
+	Symbol, Side          string
+	Quantity              float64
+	EntryPrice, ExitPrice float64
+	RealizedPnL           money.Paise
+}
+
+type lot struct {
+	Symbol, Side string
+	Quantity     float64
+	Price        float64
+}
+
+func TestMatchFIFO(t *testing.T) {
+	start := time.Date(2024, time.January, 1, 9, 15, 0, 0, time.UTC)
+	fill := func(minute int, symbol, transaction string, quantity, price float64) Fill {
+		return Fill{Symbol: symbol, TransactionType: transaction, Quantity: quantity, Price: price, Time: start.Add(time.Duration(minute) * time.Minute)}
+	}
+
+	tests := []struct {
+		name  string
+		fills []Fill
+		trips []trip
+		lots  []lot
+	}{
+		{
+			name:  "long round trip",
+			fills: []Fill{fill(0, "INFY", "B", 10, 100), fill(5, "INFY", "S", 10, 110)},
+			trips: []trip{{"INFY", Long, 10, 100, 110, 10000}},
+		},
+		{
+			name:  "short round trip",
+			fills: []Fill{fill(0, "INFY", "S", 5, 200), fill(5, "INFY", "B", 5, 190.5)},
+			trips: []trip{{"INFY", Short, 5, 200, 190.5, 4750}},
+		},
+		{
+			name:  "exit matched against the oldest entries first",
+			fills: []Fill{fill(0, "INFY", "B", 10, 100), fill(1, "INFY", "B", 10, 105), fill(2, "INFY", "S", 15, 110)},
+			trips: []trip{{"INFY", Long, 10, 100, 110, 10000}, {"INFY", Long, 5, 105, 110, 2500}},
+			lots:  []lot{{"INFY", Long, 5, 105}},
+		},
+		{
+			name:  "exit larger than the position opens the other side",
+			fills: []Fill{fill(0, "INFY", "B", 10, 100), fill(1, "INFY", "S", 15, 90)},
+			trips: []trip{{"INFY", Long, 10, 100, 90, -10000}},
+			lots:  []lot{{"INFY", Short, 5, 90}},
+		},
+		{
+			name:  "fills matched in time order",
+			fills: []Fill{fill(5, "INFY", "S", 10, 110), fill(0, "INFY", "B", 10, 100)},
+			trips: []trip{{"INFY", Long, 10, 100, 110, 10000}},
+		},
+		{
+			name:  "symbols matched apart, lots in first seen order",
+			fills: []Fill{fill(0, "TCS", "B", 1, 4000), fill(1, "INFY", "S", 2, 1500), fill(2, "TCS", "B", 1, 4010), fill(3, "TCS", "S", 1, 4020)},
+			trips: []trip{{"TCS", Long, 1, 4000, 4020, 2000}},
+			lots:  []lot{{"TCS", Long, 1, 4010}, {"INFY", Short, 2, 1500}},
+		},
+		{
+			name:  "fractional quantities close without float residue",
+			fills: []Fill{fill(0, "BTCUSDT", "B", 0.1, 100), fill(1, "BTCUSDT", "B", 0.2, 100), fill(2, "BTCUSDT", "S", 0.3, 101)},
+			trips: []trip{{"BTCUSDT", Long, 0.1, 100, 101, 10}, {"BTCUSDT", Long, 0.2, 100, 101, 20}},
+		},
+		{
+			name: "no fills",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			closed, open := MatchFIFO(tt.fills)
+
+			var trips []trip
+			for _, rt := range closed {
+				trips = append(trips, trip{rt.Symbol, rt.Side, rt.Quantity, rt.EntryPrice, rt.ExitPrice, rt.RealizedPnL})
+				if rt.HoldingTime != rt.ExitTime.Sub(rt.EntryTime) {
+					t.Errorf("holding time %s, want %s", rt.HoldingTime, rt.ExitTime.Sub(rt.EntryTime))
+				}
+			}
+			var lots []lot
+			for _, l := range open {
+				lots = append(lots, lot{l.Symbol, l.Side, l.Quantity, l.Price})
+			}
+
+			if !reflect.DeepEqual(trips, tt.trips) {
+				t.Errorf("round trips = %+v, want %+v", trips, tt.trips)
+			}
+			if !reflect.DeepEqual(lots, tt.lots) {
+				t.Errorf("open lots = %+v, want %+v", lots, tt.lots)
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"path/filepath"
+	"strings"
+	"time"
+
+	orderbook "profitLossAndTradeInfoToDB/orderbooks"
+	"profitLossAndTradeInfoToDB/pkg/charges"
+	"profitLossAndTradeInfoToDB/pkg/display"
+	"profitLossAndTradeInfoToDB/pkg/profitLossGraph"
+	"profitLossAndTradeInfoToDB/pkg/source"
+	"profitLossAndTradeInfoToDB/pkg/sqlstore"
+)
+
+// runSQLImport is the default import run against a SQL backend: it loads the
+// explicit inputs, or each day's orderbook and profit/loss files, and prints
+// the days' summaries
+func runSQLImport(ctx context.Context, config Config) error {
+	if config.Merge {
+		return fmt.Errorf("-merge is only supported with the mongo backend")
+	}
+
+	days, err := processDays(config)
+	if err != nil {
+		return err
+	}
+	mode, err := orderbook.ParseParseMode(config.ParseMode)
+	if err != nil {
+		return err
+	}
+	parser, err := csvParser(config)
+	if err != nil {
+		return err
+	}
+	unfilled, err := orderbook.ParseUnfilledPolicy(config.Unfilled)
+	if err != nil {
+		return err
+	}
+	schedule, err := charges.LoadSchedule(config.ChargesSchedule)
+	if err != nil {
+		return err
+	}
+
+	store, err := sqlstore.Open(ctx, config.Backend, config.DSN, config.Account)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+	store.SetCharges(schedule)
+
+	plService := profitLossGraph.NewService(store, newRunID())
+	plService.OnSaved(store.RefreshDays)
+	opener := source.NewOpener(config.HTTPHeaders)
+
+	if len(config.Inputs) > 0 {
+		return sqlImportInputs(ctx, opener, store, plService, mode, parser, unfilled, config.Inputs, days[0])
+	}
+
+	var failedDays int
+	for _, processDate := range days {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		pattern := fmt.Sprintf("orderbook_*%s*.csv", processDate.Format("02-01-2006"))
+		inputs, err := filepath.Glob(filepath.Join(config.CSVDir, pattern))
+		if err != nil {
+			return fmt.Errorf("failed to find CSV files: %v", err)
+		}
+		inputs = append(inputs, profitLossFile(config, processDate))
+
+		if err := sqlImportInputs(ctx, opener, store, plService, mode, parser, unfilled, inputs, processDate); err != nil {
+			log.Printf("%s: %v", processDate.Format("2006-01-02"), err)
+			failedDays++
+		}
+	}
+
+	if failedDays > 0 {
+		return fmt.Errorf("%d of %d days had failed inputs", failedDays, len(days))
+	}
+	return nil
+}
+
+// sqlImportInputs loads inputs and prints the summary of processDate
+func sqlImportInputs(ctx context.Context, opener *source.Opener, store *sqlstore.Store, plService *profitLossGraph.Service, mode orderbook.ParseMode, parser orderbook.Parser, unfilled orderbook.UnfilledPolicy, inputs []string, processDate time.Time) error {
+	var failed int
+	for _, location := range inputs {
+		if err := sqlImportInput(ctx, opener, store, plService, mode, parser, unfilled, location); err != nil {
+			log.Printf("Failed to import %s: %v", location, err)
+			failed++
+			continue
+		}
+		log.Printf("Imported %s", location)
+	}
+
+	if summary, err := store.GetDailySummary(ctx, processDate); err == nil {
+		fmt.Printf("Summary for %s: %d trades, %d symbols, realized %s\n", display.Day(summary.Date),
+			summary.TotalTrades, summary.UniqueSymbols, display.Amount(summary.RealizedPnL))
+		if summary.BrokerMTM != nil {
+			fmt.Printf("Broker MTM: %s\n", display.Amount(*summary.BrokerMTM))
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d inputs failed", failed, len(inputs))
+	}
+	return nil
+}
+
+// sqlImportInput loads one orderbook or profit/loss file into the SQL store
+func sqlImportInput(ctx context.Context, opener *source.Opener, store *sqlstore.Store, plService *profitLossGraph.Service, mode orderbook.ParseMode, parser orderbook.Parser, unfilled orderbook.UnfilledPolicy, location string) error {
+	r, err := opener.Open(ctx, location)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	if strings.HasPrefix(source.BaseName(location), "profitLoss") {
+		return plService.ProcessProfitLoss(ctx, r, location)
+	}
+
+	orders, skipped, err := orderbook.ParseOrders(r, location, store.Account(), mode, parser)
+	if err != nil {
+		return err
+	}
+	for _, row := range skipped {
+		log.Printf("Skipped line %d of %s: %s", row.Row, location, row.Message)
+	}
+	if unfilled == orderbook.UnfilledSkip {
+		orders = orderbook.DropUnfilled(orders)
+	}
+	return store.InsertOrders(ctx, orders)
+}